@@ -30,6 +30,8 @@ class DataSource(BaseModel):
     type: str
     plugin: str
     config: dict[str, Any] = Field(default_factory=dict)
+    environments: dict[str, dict[str, Any]] = Field(default_factory=dict)
+    environment: str | None = None
     capabilities: list[str] = Field(default_factory=list)
     status: str = "inactive"
 
@@ -89,6 +91,7 @@ class Pipeline(BaseModel):
     trigger: PipelineTrigger = Field(default_factory=PipelineTrigger)
     parameters: list[dict[str, Any]] = Field(default_factory=list)
     steps: list[PipelineStep] = Field(default_factory=list)
+    tags: list[str] = Field(default_factory=list)
     status: str = "draft"
 
 
@@ -109,6 +112,8 @@ class Schedule(BaseModel):
     cron_expr: str
     timezone: str = "Asia/Shanghai"
     enabled: bool = False
+    active_from: datetime | None = None
+    active_until: datetime | None = None
     dag: list[DAGNode] = Field(default_factory=list)
     last_run_at: datetime | None = None
     next_run_at: datetime | None = None
@@ -18,6 +18,35 @@ from ..executor import DAGExecutor
 logger = structlog.get_logger()
 
 
+def cron_trigger(expr: str, timezone: Any) -> CronTrigger:
+    """Build the trigger of a cron expression.
+
+    Expressions have five fields, or six with a leading second; both use
+    the field semantics of CronTrigger.from_crontab.
+    """
+    fields = expr.split()
+    if len(fields) != 6:
+        return CronTrigger.from_crontab(expr, timezone=timezone)
+    second, minute, hour, day, month, day_of_week = fields
+    return CronTrigger(
+        second=second,
+        minute=minute,
+        hour=hour,
+        day=day,
+        month=month,
+        day_of_week=day_of_week,
+        timezone=timezone,
+    )
+
+
+def croniter_expr(expr: str) -> str:
+    """Move the leading second of a six-field expression last, where croniter reads it."""
+    fields = expr.split()
+    if len(fields) != 6:
+        return expr
+    return " ".join(fields[1:] + fields[:1])
+
+
 class CronScheduler:
     """Manages scheduled ETL execution using APScheduler.
 
@@ -108,17 +137,18 @@ class CronScheduler:
         self._update_next_run_times()
 
     def _load_schedules_from_db(self) -> list[Schedule]:
-        """Load all enabled schedules from database."""
+        """Load enabled schedules whose active window has not ended."""
         schedules: list[Schedule] = []
 
         with get_db() as conn:
             with conn.cursor() as cur:
                 cur.execute(
                     """
-                    SELECT id, name, description, cron_expr, timezone, enabled, 
-                           dag, last_run_at, next_run_at
+                    SELECT id, name, description, cron_expr, timezone, enabled,
+                           active_from, active_until, dag, last_run_at, next_run_at
                     FROM etl_schedules
                     WHERE enabled = true
+                      AND (active_until IS NULL OR active_until > NOW())
                     """
                 )
                 rows = cur.fetchall()
@@ -134,6 +164,8 @@ class CronScheduler:
                 cron_expr=row["cron_expr"],
                 timezone=row.get("timezone", "Asia/Shanghai"),
                 enabled=row["enabled"],
+                active_from=row.get("active_from"),
+                active_until=row.get("active_until"),
                 dag=dag_nodes,
                 last_run_at=row.get("last_run_at"),
                 next_run_at=row.get("next_run_at"),
@@ -144,15 +176,26 @@ class CronScheduler:
 
     def _schedule_changed(self, old: Schedule, new: Schedule) -> bool:
         """Check if schedule configuration has changed."""
-        return old.cron_expr != new.cron_expr or old.timezone != new.timezone or old.dag != new.dag
+        return (
+            old.cron_expr != new.cron_expr
+            or old.timezone != new.timezone
+            or old.active_from != new.active_from
+            or old.active_until != new.active_until
+            or old.dag != new.dag
+        )
 
     def _add_job(self, schedule: Schedule) -> None:
-        """Add APScheduler job for a schedule."""
+        """Add APScheduler job for a schedule.
+
+        The trigger never fires outside the schedule's active window.
+        """
         try:
-            trigger = CronTrigger.from_crontab(
+            trigger = cron_trigger(
                 schedule.cron_expr,
                 timezone=pytz.timezone(schedule.timezone),
             )
+            trigger.start_date = schedule.active_from
+            trigger.end_date = schedule.active_until
 
             self.scheduler.add_job(
                 self._execute_schedule,
@@ -241,14 +284,23 @@ class CronScheduler:
                 )
 
     def _update_next_run_times(self) -> None:
-        """Update next_run_at for all active schedules."""
+        """Update next_run_at for all active schedules.
+
+        Runs before a schedule's active window opens are skipped; a schedule
+        whose window closes before its next run has none.
+        """
         now = datetime.now()
 
         for schedule_id, schedule in self._active_schedules.items():
             try:
                 tz = pytz.timezone(schedule.timezone)
-                cron = croniter(schedule.cron_expr, now.astimezone(tz))
+                start = now.astimezone(tz)
+                if schedule.active_from and schedule.active_from > start:
+                    start = schedule.active_from.astimezone(tz)
+                cron = croniter(croniter_expr(schedule.cron_expr), start)
                 next_run = cron.get_next(datetime)
+                if schedule.active_until and next_run >= schedule.active_until:
+                    next_run = None
 
                 with get_db() as conn:
                     with conn.cursor() as cur:
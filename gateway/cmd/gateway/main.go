@@ -9,10 +9,10 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/mellivora-mind/mellivora-mind-studio/gateway/internal/config"
-	"github.com/mellivora-mind/mellivora-mind-studio/gateway/internal/handler"
-	"github.com/mellivora-mind/mellivora-mind-studio/gateway/internal/middleware"
-	"github.com/mellivora-mind/mellivora-mind-studio/gateway/internal/router"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/handler"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/middleware"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/router"
 	"go.uber.org/zap"
 )
 
@@ -40,18 +40,25 @@ func main() {
 
 	// Initialize middleware
 	mw := middleware.New(cfg, logger)
+	defer mw.Stop()
 
 	// Setup router
-	r := router.New(h, mw, logger)
+	r := router.New(cfg, h, mw, logger)
 
-	// Create HTTP server
+	// Create HTTP server. The write timeout leaves room for the longest
+	// request deadline so slow routes get their 504 rather than a reset.
+	writeTimeout := 15 * time.Second
+	if d := cfg.Timeout.Max() + 5*time.Second; d > writeTimeout {
+		writeTimeout = d
+	}
 	srv := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Port),
 		Handler:      r,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: writeTimeout,
 		IdleTimeout:  60 * time.Second,
 	}
+	srv.RegisterOnShutdown(h.StopStreams)
 
 	// Start server in goroutine
 	go func() {
@@ -1,12 +1,19 @@
-module github.com/mellivora-mind/mellivora-mind-studio/gateway
+module github.com/mellivora-tech/mellivora-mind-studio/gateway
 
 go 1.22
 
 require (
 	github.com/gin-gonic/gin v1.9.1
+	github.com/mellivora-tech/mellivora-mind-studio/gen/go v0.0.0
+	github.com/mellivora-tech/mellivora-mind-studio/pkg v0.0.0
 	github.com/nats-io/nats.go v1.31.0
 	github.com/redis/go-redis/v9 v9.4.0
 	go.uber.org/zap v1.26.0
 	google.golang.org/grpc v1.60.1
 	google.golang.org/protobuf v1.32.0
 )
+
+replace (
+	github.com/mellivora-tech/mellivora-mind-studio/gen/go => ../gen/go
+	github.com/mellivora-tech/mellivora-mind-studio/pkg => ../pkg
+)
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds gateway configuration
@@ -11,9 +17,18 @@ type Config struct {
 	Port int    `json:"port"`
 	Env  string `json:"env"` // dev, test, prod
 
+	// TrustedProxies lists the proxy CIDRs whose X-Forwarded-For is honored
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	// Cross-origin request settings
+	CORS CORSConfig `json:"cors"`
+
 	// Service endpoints (gRPC)
 	Services ServiceEndpoints `json:"services"`
 
+	// gRPC client settings
+	GRPC GRPCClientConfig `json:"grpc"`
+
 	// Redis settings
 	Redis RedisConfig `json:"redis"`
 
@@ -25,6 +40,73 @@ type Config struct {
 
 	// Rate limiting
 	RateLimit RateLimitConfig `json:"rate_limit"`
+
+	// Request logging
+	Log LogConfig `json:"log"`
+
+	// Maintenance mode
+	Maintenance MaintenanceConfig `json:"maintenance"`
+
+	// Client IP allow/deny lists
+	IPFilter IPFilterConfig `json:"ip_filter"`
+
+	// Batch endpoint limits
+	Batch BatchConfig `json:"batch"`
+
+	// API versioning
+	API APIConfig `json:"api"`
+
+	// Portfolio target and rebalancing rules
+	Portfolio PortfolioConfig `json:"portfolio"`
+
+	// Risk endpoint settings
+	Risk RiskConfig `json:"risk"`
+
+	// Signal endpoint settings
+	Signal SignalConfig `json:"signal"`
+
+	// Request deadlines
+	Timeout TimeoutConfig `json:"timeout"`
+
+	// Feature flags by name
+	Features map[string]FeatureFlagConfig `json:"features"`
+
+	// Instrument code formats, tried in order for bare codes
+	Markets []MarketConfig `json:"markets"`
+
+	// Instrument directory settings
+	Universe UniverseConfig `json:"universe"`
+}
+
+// CORSConfig holds cross-origin request settings
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to call the API, e.g.
+	// "https://studio.example.com". "*" allows any origin.
+	AllowedOrigins []string `json:"allowed_origins"`
+
+	// AllowCredentials lets browsers send cookies and auth headers
+	// cross-origin. It requires an explicit origin list.
+	AllowCredentials bool `json:"allow_credentials"`
+}
+
+// Allows reports whether requests from origin are allowed
+func (c CORSConfig) Allows(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAny reports whether every origin is allowed
+func (c CORSConfig) AllowsAny() bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
 }
 
 // ServiceEndpoints holds gRPC service addresses
@@ -42,6 +124,24 @@ type ServiceEndpoints struct {
 	Optimize string `json:"optimize"`
 }
 
+// GRPCClientConfig holds the message size limits and keepalive of backend
+// connections. KeepaliveTimeMs must not be below the servers'
+// GRPC_KEEPALIVE_MIN_TIME_MS, or they close the connection.
+type GRPCClientConfig struct {
+	MaxRecvMsgBytes    int `json:"max_recv_msg_bytes"`
+	MaxSendMsgBytes    int `json:"max_send_msg_bytes"`
+	KeepaliveTimeMs    int `json:"keepalive_time_ms"`    // ping after this long without activity
+	KeepaliveTimeoutMs int `json:"keepalive_timeout_ms"` // close if a ping is not answered in time
+	DialTimeoutMs      int `json:"dial_timeout_ms"`      // per connection attempt; bounds startup when Block is set
+
+	// TLS dials backends over TLS instead of plaintext
+	TLS bool `json:"tls"`
+
+	// Block makes startup wait until every backend is connected, failing
+	// once DialTimeoutMs passes. Otherwise connections are made lazily.
+	Block bool `json:"block"`
+}
+
 // RedisConfig holds Redis connection settings
 type RedisConfig struct {
 	Addr     string `json:"addr"`
@@ -56,16 +156,173 @@ type NATSConfig struct {
 
 // AuthConfig holds authentication settings
 type AuthConfig struct {
-	JWTSecret     string `json:"jwt_secret"`
+	JWTSecret     string `json:"jwt_secret"`   // verifies tokens without a kid header
 	TokenExpiry   int    `json:"token_expiry"` // seconds
 	RefreshExpiry int    `json:"refresh_expiry"`
+
+	// SigningKeys maps a kid to its HMAC secret. Tokens with a kid header
+	// are verified with that key; new tokens are signed with CurrentKeyID.
+	SigningKeys  map[string]string `json:"signing_keys"`
+	CurrentKeyID string            `json:"current_key_id"`
 }
 
 // RateLimitConfig holds rate limiting settings
 type RateLimitConfig struct {
-	Enabled         bool `json:"enabled"`
-	RequestsPerSec  int  `json:"requests_per_sec"`
-	BurstSize       int  `json:"burst_size"`
+	Enabled        bool `json:"enabled"`
+	RequestsPerSec int  `json:"requests_per_sec"`
+	BurstSize      int  `json:"burst_size"`
+	IdleTTL        int  `json:"idle_ttl"` // seconds; limiters unused this long are dropped
+
+	// RouteLimits overrides the default for requests matching a
+	// "METHOD /path-prefix" key, e.g. "GET /api/v1/data/quotes". The prefix
+	// matches whole path segments, the longest matching prefix wins, and
+	// RouteLimits wins over Routes.
+	RouteLimits map[string]RateLimitRule `json:"route_limits"`
+
+	// Routes overrides the default per route group (e.g. "risk") or route
+	// pattern without the API version (e.g. "/risk/decomposition/:account_id").
+	// A pattern wins over its group.
+	Routes map[string]RateLimitRule `json:"routes"`
+}
+
+// RateLimitRule is the rate limit of one route group or pattern
+type RateLimitRule struct {
+	RequestsPerSec int `json:"requests_per_sec"`
+	BurstSize      int `json:"burst_size"`
+}
+
+// LogConfig holds request logging settings
+type LogConfig struct {
+	SlowRequestMs int `json:"slow_request_ms"` // requests slower than this log at warn
+	SampleRate    int `json:"sample_rate"`     // log 1 in N successful requests; <= 1 logs all
+}
+
+// MaintenanceConfig holds maintenance mode settings
+type MaintenanceConfig struct {
+	Enabled    bool `json:"enabled"`
+	BlockReads bool `json:"block_reads"` // reject all methods, not just mutating ones
+	RetryAfter int  `json:"retry_after"` // seconds
+}
+
+// IPFilterConfig holds client IP allow/deny lists per route group
+type IPFilterConfig struct {
+	Global IPRules `json:"global"` // applied to every request
+	Admin  IPRules `json:"admin"`  // applied to /admin in addition to Global
+}
+
+// IPRules is a pair of CIDR lists. Deny wins over allow; an empty allow list
+// allows every address that is not denied. Bare IPs are treated as /32 or /128.
+type IPRules struct {
+	Allow []string `json:"allow"`
+	Deny  []string `json:"deny"`
+}
+
+// BatchConfig holds limits for the batch endpoint
+type BatchConfig struct {
+	MaxRequests int `json:"max_requests"` // sub-requests per batch
+	Concurrency int `json:"concurrency"`  // sub-requests executed in parallel
+	TimeoutMs   int `json:"timeout_ms"`   // deadline for the whole batch
+}
+
+// APIConfig holds API versioning settings
+type APIConfig struct {
+	V1Sunset string `json:"v1_sunset"` // YYYY-MM-DD; when set, v1 responses carry deprecation headers
+}
+
+// PortfolioConfig holds target portfolio rules
+type PortfolioConfig struct {
+	RequireFullWeight bool    `json:"require_full_weight"` // target weights must sum to exactly 1
+	MinTradeAmount    float64 `json:"min_trade_amount"`    // trades below this notional are dropped from trade lists
+	LotSize           int     `json:"lot_size"`            // trade quantities are rounded down to whole lots
+	MaxPositions      int     `json:"max_positions"`       // positions read per account before giving up
+	FetchTimeoutMs    int     `json:"fetch_timeout_ms"`    // deadline for reading all of an account's positions
+}
+
+// RiskConfig holds risk endpoint settings
+type RiskConfig struct {
+	CacheTTL           int `json:"cache_ttl"`            // seconds; risk as of today
+	HistoricalCacheTTL int `json:"historical_cache_ttl"` // seconds; risk as of a past date
+	CacheSize          int `json:"cache_size"`           // max cached responses
+}
+
+// SignalConfig holds signal endpoint settings
+type SignalConfig struct {
+	TimeoutMs    int `json:"timeout_ms"`    // deadline for the backend calls of one request
+	DefaultLimit int `json:"default_limit"` // signals returned when ?limit= is absent
+	MaxLimit     int `json:"max_limit"`
+}
+
+// TimeoutConfig holds request deadlines. A route group is the first path
+// segment after the API version, e.g. "risk" for /api/v1/risk/...
+type TimeoutConfig struct {
+	DefaultMs int            `json:"default_ms"`
+	Groups    map[string]int `json:"groups"` // route group -> ms, overriding DefaultMs
+}
+
+// For returns the deadline for requests in a route group
+func (t TimeoutConfig) For(group string) time.Duration {
+	if ms, ok := t.Groups[group]; ok {
+		return time.Duration(ms) * time.Millisecond
+	}
+	return time.Duration(t.DefaultMs) * time.Millisecond
+}
+
+// Max returns the longest configured deadline
+func (t TimeoutConfig) Max() time.Duration {
+	max := t.DefaultMs
+	for _, ms := range t.Groups {
+		if ms > max {
+			max = ms
+		}
+	}
+	return time.Duration(max) * time.Millisecond
+}
+
+// FeatureFlagConfig is the initial state of a feature flag. A flag is on for
+// every tenant when Enabled, otherwise only for the listed tenants.
+type FeatureFlagConfig struct {
+	Enabled bool     `json:"enabled"`
+	Tenants []string `json:"tenants"`
+}
+
+// DefaultFeatureFlags are the flags enabled when FEATURE_FLAGS is unset
+var DefaultFeatureFlags = []string{"batch"}
+
+// MarketConfig is the code format of one market. Codes are written either
+// bare ("600000") or with the market suffix ("600000.SH").
+type MarketConfig struct {
+	Suffix   string `json:"suffix"`   // e.g. "SH"
+	Exchange string `json:"exchange"` // common.Exchange name without prefix, e.g. "SSE"
+	Pattern  string `json:"pattern"`  // regexp the uppercased bare code must match
+}
+
+// DefaultMarkets covers the mainland and Hong Kong equity markets
+const DefaultMarkets = `SH:SSE:^6\d{5}$;SZ:SZSE:^[03]\d{5}$;BJ:BSE:^[48]\d{5}$;HK:HKEX:^\d{5}$`
+
+// UniverseConfig holds instrument directory settings
+type UniverseConfig struct {
+	CacheTTL  int `json:"cache_ttl"`  // seconds
+	CacheSize int `json:"cache_size"` // max cached pages
+}
+
+// DevJWTSecret is the JWT secret used when JWT_SECRET is unset. It is
+// public, so Validate rejects it in prod.
+const DevJWTSecret = "dev-secret-change-in-production"
+
+// DefaultRouteLimits are the route limits RATE_LIMIT_ROUTE_LIMITS is merged
+// into. Quotes are polled far more often than anything else is called.
+var DefaultRouteLimits = map[string]RateLimitRule{
+	"GET /api/v1/data/quotes": {RequestsPerSec: 20, BurstSize: 40},
+}
+
+// DefaultTrustedProxies covers loopback and private network ranges
+var DefaultTrustedProxies = []string{
+	"127.0.0.0/8",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::1/128",
+	"fc00::/7",
 }
 
 // Load loads configuration from environment variables
@@ -74,6 +331,13 @@ func Load() (*Config, error) {
 		Port: getEnvInt("GATEWAY_PORT", 8080),
 		Env:  getEnv("GATEWAY_ENV", "dev"),
 
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", DefaultTrustedProxies),
+
+		CORS: CORSConfig{
+			AllowedOrigins:   getEnvList("CORS_ALLOWED_ORIGINS", []string{"*"}),
+			AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", false),
+		},
+
 		Services: ServiceEndpoints{
 			Account:  getEnv("SERVICE_ACCOUNT", "localhost:9001"),
 			Order:    getEnv("SERVICE_ORDER", "localhost:9002"),
@@ -88,6 +352,16 @@ func Load() (*Config, error) {
 			Optimize: getEnv("SERVICE_OPTIMIZE", "localhost:9103"),
 		},
 
+		GRPC: GRPCClientConfig{
+			MaxRecvMsgBytes:    getEnvInt("GRPC_MAX_RECV_MSG_BYTES", 16<<20),
+			MaxSendMsgBytes:    getEnvInt("GRPC_MAX_SEND_MSG_BYTES", 16<<20),
+			KeepaliveTimeMs:    getEnvInt("GRPC_KEEPALIVE_TIME_MS", 30000),
+			KeepaliveTimeoutMs: getEnvInt("GRPC_KEEPALIVE_TIMEOUT_MS", 10000),
+			DialTimeoutMs:      getEnvInt("GRPC_DIAL_TIMEOUT_MS", 5000),
+			TLS:                getEnvBool("GRPC_TLS", false),
+			Block:              getEnvBool("STARTUP_BLOCK", false),
+		},
+
 		Redis: RedisConfig{
 			Addr:     getEnv("REDIS_ADDR", "localhost:6379"),
 			Password: getEnv("REDIS_PASSWORD", ""),
@@ -99,7 +373,7 @@ func Load() (*Config, error) {
 		},
 
 		Auth: AuthConfig{
-			JWTSecret:     getEnv("JWT_SECRET", "dev-secret-change-in-production"),
+			JWTSecret:     getEnv("JWT_SECRET", DevJWTSecret),
 			TokenExpiry:   getEnvInt("JWT_TOKEN_EXPIRY", 3600),
 			RefreshExpiry: getEnvInt("JWT_REFRESH_EXPIRY", 86400),
 		},
@@ -108,12 +382,380 @@ func Load() (*Config, error) {
 			Enabled:        getEnvBool("RATE_LIMIT_ENABLED", true),
 			RequestsPerSec: getEnvInt("RATE_LIMIT_RPS", 100),
 			BurstSize:      getEnvInt("RATE_LIMIT_BURST", 200),
+			IdleTTL:        getEnvInt("RATE_LIMIT_IDLE_TTL", 600),
+		},
+
+		Log: LogConfig{
+			SlowRequestMs: getEnvInt("LOG_SLOW_REQUEST_MS", 1000),
+			SampleRate:    getEnvInt("LOG_SAMPLE_RATE", 1),
+		},
+
+		Maintenance: MaintenanceConfig{
+			Enabled:    getEnvBool("MAINTENANCE_ENABLED", false),
+			BlockReads: getEnvBool("MAINTENANCE_BLOCK_READS", false),
+			RetryAfter: getEnvInt("MAINTENANCE_RETRY_AFTER", 120),
+		},
+
+		IPFilter: IPFilterConfig{
+			Global: IPRules{
+				Allow: getEnvList("IP_ALLOW", nil),
+				Deny:  getEnvList("IP_DENY", nil),
+			},
+			Admin: IPRules{
+				Allow: getEnvList("ADMIN_IP_ALLOW", nil),
+				Deny:  getEnvList("ADMIN_IP_DENY", nil),
+			},
+		},
+
+		Batch: BatchConfig{
+			MaxRequests: getEnvInt("BATCH_MAX_REQUESTS", 20),
+			Concurrency: getEnvInt("BATCH_CONCURRENCY", 4),
+			TimeoutMs:   getEnvInt("BATCH_TIMEOUT_MS", 10000),
+		},
+
+		API: APIConfig{
+			V1Sunset: getEnv("API_V1_SUNSET", ""),
 		},
+
+		Portfolio: PortfolioConfig{
+			RequireFullWeight: getEnvBool("PORTFOLIO_REQUIRE_FULL_WEIGHT", false),
+			MinTradeAmount:    getEnvFloat("PORTFOLIO_MIN_TRADE_AMOUNT", 0),
+			LotSize:           getEnvInt("PORTFOLIO_LOT_SIZE", 100),
+			MaxPositions:      getEnvInt("PORTFOLIO_MAX_POSITIONS", 10000),
+			FetchTimeoutMs:    getEnvInt("PORTFOLIO_FETCH_TIMEOUT_MS", 5000),
+		},
+
+		Risk: RiskConfig{
+			CacheTTL:           getEnvInt("RISK_CACHE_TTL", 60),
+			HistoricalCacheTTL: getEnvInt("RISK_HISTORICAL_CACHE_TTL", 3600),
+			CacheSize:          getEnvInt("RISK_CACHE_SIZE", 1000),
+		},
+
+		Signal: SignalConfig{
+			TimeoutMs:    getEnvInt("SIGNAL_TIMEOUT_MS", 5000),
+			DefaultLimit: getEnvInt("SIGNAL_DEFAULT_LIMIT", 50),
+			MaxLimit:     getEnvInt("SIGNAL_MAX_LIMIT", 1000),
+		},
+
+		Timeout: TimeoutConfig{
+			DefaultMs: getEnvInt("REQUEST_TIMEOUT_MS", 10000),
+		},
+
+		Universe: UniverseConfig{
+			CacheTTL:  getEnvInt("UNIVERSE_CACHE_TTL", 3600),
+			CacheSize: getEnvInt("UNIVERSE_CACHE_SIZE", 500),
+		},
+	}
+
+	groups, err := parseGroupValues(getEnvList("REQUEST_TIMEOUT_GROUPS", nil))
+	if err != nil {
+		return nil, fmt.Errorf("invalid REQUEST_TIMEOUT_GROUPS: %w", err)
 	}
+	cfg.Timeout.Groups = groups
 
+	features, err := parseFeatureFlags(getEnvList("FEATURE_FLAGS", DefaultFeatureFlags))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FEATURE_FLAGS: %w", err)
+	}
+	cfg.Features = features
+
+	routes, err := parseRateLimitRoutes(getEnvList("RATE_LIMIT_ROUTES", nil))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTES: %w", err)
+	}
+	cfg.RateLimit.Routes = routes
+
+	routeLimits, err := parseRouteLimits(getEnv("RATE_LIMIT_ROUTE_LIMITS", ""), DefaultRouteLimits)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_ROUTE_LIMITS: %w", err)
+	}
+	cfg.RateLimit.RouteLimits = routeLimits
+
+	keys, err := parseSigningKeys(getEnvList("JWT_KEYS", nil))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_KEYS: %w", err)
+	}
+	for _, kid := range getEnvList("JWT_RETIRED_KIDS", nil) {
+		delete(keys, kid)
+	}
+	cfg.Auth.SigningKeys = keys
+	cfg.Auth.CurrentKeyID = getEnv("JWT_CURRENT_KID", "")
+	if cfg.Auth.CurrentKeyID != "" {
+		if _, ok := keys[cfg.Auth.CurrentKeyID]; !ok {
+			return nil, fmt.Errorf("JWT_CURRENT_KID %q is not an active key in JWT_KEYS", cfg.Auth.CurrentKeyID)
+		}
+	}
+
+	markets, err := parseMarkets(getEnv("MARKET_CODE_FORMATS", DefaultMarkets))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MARKET_CODE_FORMATS: %w", err)
+	}
+	cfg.Markets = markets
+
+	if cfg.API.V1Sunset != "" {
+		if _, err := time.Parse(time.DateOnly, cfg.API.V1Sunset); err != nil {
+			return nil, fmt.Errorf("invalid API_V1_SUNSET %q: %w", cfg.API.V1Sunset, err)
+		}
+	}
+
+	if len(cfg.CORS.AllowedOrigins) == 0 {
+		return nil, fmt.Errorf("CORS_ALLOWED_ORIGINS lists no origins")
+	}
+	if cfg.CORS.AllowCredentials && cfg.CORS.AllowsAny() {
+		return nil, fmt.Errorf(`CORS_ALLOW_CREDENTIALS requires CORS_ALLOWED_ORIGINS to list origins instead of "*"`)
+	}
+
+	if cfg.RateLimit.IdleTTL < 1 {
+		return nil, fmt.Errorf("invalid RATE_LIMIT_IDLE_TTL %d: must be at least 1", cfg.RateLimit.IdleTTL)
+	}
+
+	if cfg.Auth.TokenExpiry < 1 {
+		return nil, fmt.Errorf("invalid JWT_TOKEN_EXPIRY %d: must be at least 1", cfg.Auth.TokenExpiry)
+	}
+
+	if cfg.Portfolio.LotSize < 1 {
+		return nil, fmt.Errorf("invalid PORTFOLIO_LOT_SIZE %d: must be at least 1", cfg.Portfolio.LotSize)
+	}
+	if cfg.Portfolio.MaxPositions < 1 {
+		return nil, fmt.Errorf("invalid PORTFOLIO_MAX_POSITIONS %d: must be at least 1", cfg.Portfolio.MaxPositions)
+	}
+	if cfg.Portfolio.FetchTimeoutMs < 1 {
+		return nil, fmt.Errorf("invalid PORTFOLIO_FETCH_TIMEOUT_MS %d: must be at least 1", cfg.Portfolio.FetchTimeoutMs)
+	}
+	if cfg.Portfolio.MinTradeAmount < 0 {
+		return nil, fmt.Errorf("invalid PORTFOLIO_MIN_TRADE_AMOUNT %v: must not be negative", cfg.Portfolio.MinTradeAmount)
+	}
+
+	for name, value := range map[string]int{
+		"GRPC_MAX_RECV_MSG_BYTES":   cfg.GRPC.MaxRecvMsgBytes,
+		"GRPC_MAX_SEND_MSG_BYTES":   cfg.GRPC.MaxSendMsgBytes,
+		"GRPC_KEEPALIVE_TIME_MS":    cfg.GRPC.KeepaliveTimeMs,
+		"GRPC_KEEPALIVE_TIMEOUT_MS": cfg.GRPC.KeepaliveTimeoutMs,
+		"GRPC_DIAL_TIMEOUT_MS":      cfg.GRPC.DialTimeoutMs,
+	} {
+		if value < 1 {
+			return nil, fmt.Errorf("invalid %s %d: must be at least 1", name, value)
+		}
+	}
+
+	for _, rules := range []IPRules{cfg.IPFilter.Global, cfg.IPFilter.Admin} {
+		if _, err := ParseCIDRs(append(rules.Allow, rules.Deny...)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
+// Validate rejects settings that are tolerable in dev and test but unsafe
+// in prod: the public dev JWT secret, a rate limit that is enabled without
+// positive limits, and unset service endpoints
+func (cfg *Config) Validate() error {
+	if cfg.Env != "prod" {
+		return nil
+	}
+
+	if cfg.Auth.JWTSecret == "" || cfg.Auth.JWTSecret == DevJWTSecret {
+		return fmt.Errorf("JWT_SECRET must be set to a private secret in prod")
+	}
+	if cfg.RateLimit.Enabled && (cfg.RateLimit.RequestsPerSec <= 0 || cfg.RateLimit.BurstSize <= 0) {
+		return fmt.Errorf("RATE_LIMIT_RPS and RATE_LIMIT_BURST must be positive in prod when RATE_LIMIT_ENABLED")
+	}
+
+	s := cfg.Services
+	for _, endpoint := range []struct{ env, addr string }{
+		{"SERVICE_ACCOUNT", s.Account},
+		{"SERVICE_ORDER", s.Order},
+		{"SERVICE_POSITION", s.Position},
+		{"SERVICE_TRADE", s.Trade},
+		{"SERVICE_DATA", s.Data},
+		{"SERVICE_SCHEDULE", s.Schedule},
+		{"SERVICE_CONFIG", s.Config},
+		{"SERVICE_ALERT", s.Alert},
+		{"SERVICE_RISK", s.Risk},
+		{"SERVICE_SIGNAL", s.Signal},
+		{"SERVICE_OPTIMIZE", s.Optimize},
+	} {
+		if strings.TrimSpace(endpoint.addr) == "" {
+			return fmt.Errorf("%s must not be blank in prod", endpoint.env)
+		}
+	}
+	return nil
+}
+
+// parseGroupValues parses "group=value" entries, e.g. "risk=30000", into a
+// map of positive integers
+func parseGroupValues(list []string) (map[string]int, error) {
+	values := make(map[string]int, len(list))
+	for _, entry := range list {
+		group, value, ok := strings.Cut(entry, "=")
+		if !ok || strings.TrimSpace(group) == "" {
+			return nil, fmt.Errorf("entry %q is not group=value", entry)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("entry %q: value must be a positive integer", entry)
+		}
+		values[strings.TrimSpace(group)] = n
+	}
+	return values, nil
+}
+
+// parseRateLimitRoutes parses "route=rps:burst" entries, e.g. "risk=5:10"
+func parseRateLimitRoutes(list []string) (map[string]RateLimitRule, error) {
+	routes := make(map[string]RateLimitRule, len(list))
+	for _, entry := range list {
+		route, limits, ok := strings.Cut(entry, "=")
+		route = strings.TrimSpace(route)
+		if !ok || route == "" {
+			return nil, fmt.Errorf("entry %q is not route=rps:burst", entry)
+		}
+		rps, burst, ok := strings.Cut(limits, ":")
+		if !ok {
+			return nil, fmt.Errorf("entry %q is not route=rps:burst", entry)
+		}
+		var rule RateLimitRule
+		var err error
+		if rule.RequestsPerSec, err = strconv.Atoi(strings.TrimSpace(rps)); err != nil || rule.RequestsPerSec <= 0 {
+			return nil, fmt.Errorf("entry %q: rps must be a positive integer", entry)
+		}
+		if rule.BurstSize, err = strconv.Atoi(strings.TrimSpace(burst)); err != nil || rule.BurstSize <= 0 {
+			return nil, fmt.Errorf("entry %q: burst must be a positive integer", entry)
+		}
+		routes[route] = rule
+	}
+	return routes, nil
+}
+
+// parseRouteLimits parses a JSON object mapping "METHOD /path-prefix" to a
+// rule, e.g. {"GET /api/v1/data/quotes": {"requests_per_sec": 5,
+// "burst_size": 10}}, over a copy of defaults: a key of defaults that the
+// object repeats takes the object's rule.
+func parseRouteLimits(value string, defaults map[string]RateLimitRule) (map[string]RateLimitRule, error) {
+	limits := make(map[string]RateLimitRule, len(defaults))
+	for key, rule := range defaults {
+		limits[key] = rule
+	}
+	if strings.TrimSpace(value) == "" {
+		return limits, nil
+	}
+
+	var overrides map[string]RateLimitRule
+	if err := json.Unmarshal([]byte(value), &overrides); err != nil {
+		return nil, err
+	}
+	for key, rule := range overrides {
+		method, prefix, ok := strings.Cut(key, " ")
+		if !ok || !httpMethods[method] || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("key %q is not METHOD /path-prefix", key)
+		}
+		if rule.RequestsPerSec <= 0 || rule.BurstSize <= 0 {
+			return nil, fmt.Errorf("key %q: requests_per_sec and burst_size must be positive", key)
+		}
+		if prefix != "/" {
+			prefix = strings.TrimSuffix(prefix, "/")
+		}
+		limits[method+" "+prefix] = rule
+	}
+	return limits, nil
+}
+
+// httpMethods are the methods a route limit may be scoped to
+var httpMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true, "OPTIONS": true,
+}
+
+// parseFeatureFlags parses flag entries: "name" enables a flag for every
+// tenant, "name=tenant1|tenant2" for the listed tenants only
+func parseFeatureFlags(list []string) (map[string]FeatureFlagConfig, error) {
+	flags := make(map[string]FeatureFlagConfig, len(list))
+	for _, entry := range list {
+		name, tenants, scoped := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("entry %q has no flag name", entry)
+		}
+		if !scoped {
+			flags[name] = FeatureFlagConfig{Enabled: true}
+			continue
+		}
+		var flag FeatureFlagConfig
+		for _, tenant := range strings.Split(tenants, "|") {
+			if tenant = strings.TrimSpace(tenant); tenant != "" {
+				flag.Tenants = append(flag.Tenants, tenant)
+			}
+		}
+		if len(flag.Tenants) == 0 {
+			return nil, fmt.Errorf("entry %q lists no tenants", entry)
+		}
+		flags[name] = flag
+	}
+	return flags, nil
+}
+
+// parseSigningKeys parses "kid=secret" entries
+func parseSigningKeys(list []string) (map[string]string, error) {
+	keys := make(map[string]string, len(list))
+	for _, entry := range list {
+		kid, secret, ok := strings.Cut(entry, "=")
+		kid = strings.TrimSpace(kid)
+		if !ok || kid == "" || secret == "" {
+			return nil, fmt.Errorf("an entry is not kid=secret")
+		}
+		keys[kid] = secret
+	}
+	return keys, nil
+}
+
+// parseMarkets parses ";"-separated "SUFFIX:EXCHANGE:pattern" entries. The
+// pattern is last so it may itself contain ":" or ",".
+func parseMarkets(value string) ([]MarketConfig, error) {
+	var markets []MarketConfig
+	for _, entry := range strings.Split(value, ";") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("entry %q is not SUFFIX:EXCHANGE:pattern", entry)
+		}
+		if _, err := regexp.Compile(parts[2]); err != nil {
+			return nil, fmt.Errorf("entry %q: %w", entry, err)
+		}
+		markets = append(markets, MarketConfig{
+			Suffix:   strings.ToUpper(parts[0]),
+			Exchange: strings.ToUpper(parts[1]),
+			Pattern:  parts[2],
+		})
+	}
+	return markets, nil
+}
+
+// ParseCIDRs parses a list of CIDRs or bare IP addresses into prefixes
+func ParseCIDRs(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, entry := range list {
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q: %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -130,6 +772,29 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if b, err := strconv.ParseBool(value); err == nil {
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRouteLimits(t *testing.T) {
+	defaults := map[string]RateLimitRule{
+		"GET /api/v1/data/quotes": {RequestsPerSec: 20, BurstSize: 40},
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]RateLimitRule
+		wantErr bool
+	}{
+		{"unset keeps the defaults", "", defaults, false},
+		{
+			"overrides a default and adds a route",
+			`{"GET /api/v1/data/quotes": {"requests_per_sec": 5, "burst_size": 10}, "POST /api/v1/orders/": {"requests_per_sec": 2, "burst_size": 4}}`,
+			map[string]RateLimitRule{
+				"GET /api/v1/data/quotes": {RequestsPerSec: 5, BurstSize: 10},
+				"POST /api/v1/orders":     {RequestsPerSec: 2, BurstSize: 4},
+			},
+			false,
+		},
+		{"not JSON", "GET /x=1:2", nil, true},
+		{"no method", `{"/api/v1/data": {"requests_per_sec": 1, "burst_size": 1}}`, nil, true},
+		{"unknown method", `{"FETCH /api/v1/data": {"requests_per_sec": 1, "burst_size": 1}}`, nil, true},
+		{"relative path", `{"GET api/v1/data": {"requests_per_sec": 1, "burst_size": 1}}`, nil, true},
+		{"zero burst", `{"GET /api/v1/data": {"requests_per_sec": 1}}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseRouteLimits(tt.value, defaults)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseRouteLimits = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseRouteLimits: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseRouteLimits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if len(defaults) != 1 || defaults["GET /api/v1/data/quotes"].RequestsPerSec != 20 {
+		t.Errorf("parseRouteLimits changed the defaults: %v", defaults)
+	}
+}
+
+// prodConfig returns a config that passes Validate in prod
+func prodConfig() *Config {
+	return &Config{
+		Env: "prod",
+		Services: ServiceEndpoints{
+			Account: "account:9001", Order: "order:9002", Position: "position:9003",
+			Trade: "trade:9004", Data: "data:9005", Schedule: "schedule:9006",
+			Config: "config:9007", Alert: "alert:9008", Risk: "risk:9101",
+			Signal: "signal:9102", Optimize: "optimize:9103",
+		},
+		Auth:      AuthConfig{JWTSecret: "a-private-secret"},
+		RateLimit: RateLimitConfig{Enabled: true, RequestsPerSec: 100, BurstSize: 200},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"prod with a private secret", "prod", func(*Config) {}, false},
+		{"prod rejects the dev secret", "prod", func(c *Config) { c.Auth.JWTSecret = DevJWTSecret }, true},
+		{"prod rejects an empty secret", "prod", func(c *Config) { c.Auth.JWTSecret = "" }, true},
+		{"prod rejects a zero rate", "prod", func(c *Config) { c.RateLimit.RequestsPerSec = 0 }, true},
+		{"prod rejects a zero burst", "prod", func(c *Config) { c.RateLimit.BurstSize = -1 }, true},
+		{"prod ignores limits when disabled", "prod", func(c *Config) { c.RateLimit = RateLimitConfig{} }, false},
+		{"prod rejects a blank endpoint", "prod", func(c *Config) { c.Services.Risk = " " }, true},
+		{"dev tolerates the dev secret", "dev", func(c *Config) { c.Auth.JWTSecret = DevJWTSecret }, false},
+		{"dev tolerates blank endpoints", "dev", func(c *Config) { c.Services = ServiceEndpoints{} }, false},
+		{"test tolerates the dev secret", "test", func(c *Config) { c.Auth.JWTSecret = DevJWTSecret }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := prodConfig()
+			cfg.Env = tt.env
+			tt.modify(cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadDefaultSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+
+	t.Setenv("GATEWAY_ENV", "dev")
+	if _, err := Load(); err != nil {
+		t.Errorf("Load in dev with the default secret: %v", err)
+	}
+
+	t.Setenv("GATEWAY_ENV", "prod")
+	if _, err := Load(); err == nil {
+		t.Error("Load in prod with the default secret succeeded, want an error")
+	}
+}
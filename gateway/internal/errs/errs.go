@@ -0,0 +1,106 @@
+// Package errs writes the gateway's error responses. Every error, from a
+// handler, a middleware or a backend, is an api.ErrorResponse with a stable
+// code and a message written for the client; internal error text, which
+// may hold SQL or gRPC detail, only goes to the server log.
+package errs
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// New returns the error envelope of code and message for the request
+func New(c *gin.Context, code, message string) api.ErrorResponse {
+	return api.ErrorResponse{Code: code, Error: message, RequestID: c.GetString("request_id")}
+}
+
+// Respond writes an error response
+func Respond(c *gin.Context, status int, code, message string) {
+	c.JSON(status, New(c, code, message))
+}
+
+// Abort writes an error response and stops the handler chain, for
+// middleware
+func Abort(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, New(c, code, message))
+}
+
+// BadRequest writes a 400 for a request that failed validation. The
+// message of an *api.InvalidError is relayed; any other error, such as a
+// JSON decoding error naming Go types, gets a generic message.
+func BadRequest(c *gin.Context, err error) {
+	message := "the request is invalid"
+	var invalid *api.InvalidError
+	if errors.As(err, &invalid) {
+		message = invalid.Message
+	}
+	Respond(c, http.StatusBadRequest, "invalid_argument", message)
+}
+
+// rpcError is the HTTP status, code and client message of a gRPC code
+type rpcError struct {
+	status  int
+	code    string
+	message string
+}
+
+// rpcErrors maps the gRPC codes backends return; any other code is
+// reported as backendError
+var rpcErrors = map[codes.Code]rpcError{
+	codes.InvalidArgument:    {http.StatusBadRequest, "invalid_argument", "the request is invalid"},
+	codes.OutOfRange:         {http.StatusBadRequest, "out_of_range", "a request value is out of range"},
+	codes.NotFound:           {http.StatusNotFound, "not_found", "resource not found"},
+	codes.AlreadyExists:      {http.StatusConflict, "already_exists", "resource already exists"},
+	codes.Aborted:            {http.StatusConflict, "aborted", "the request conflicted with another change; try again"},
+	codes.FailedPrecondition: {http.StatusUnprocessableEntity, "failed_precondition", "the resource is not in a state that allows this request"},
+	codes.PermissionDenied:   {http.StatusForbidden, "permission_denied", "permission denied"},
+	codes.Unauthenticated:    {http.StatusUnauthorized, "unauthenticated", "authentication required"},
+	codes.ResourceExhausted:  {http.StatusTooManyRequests, "resource_exhausted", "too many requests"},
+	codes.DeadlineExceeded:   {http.StatusGatewayTimeout, "deadline_exceeded", "the backend did not respond in time"},
+	codes.Canceled:           {http.StatusGatewayTimeout, "canceled", "the request was canceled"},
+	codes.Unavailable:        {http.StatusServiceUnavailable, "unavailable", "the service is unavailable"},
+	codes.Unimplemented:      {http.StatusNotImplemented, "unimplemented", "not implemented"},
+}
+
+// backendError covers Internal, Unknown, DataLoss and errors that carry no
+// gRPC status
+var backendError = rpcError{http.StatusBadGateway, "backend_error", "backend error"}
+
+// FromRPC returns the HTTP status and client-safe error envelope of a
+// backend gRPC error
+func FromRPC(err error) (int, api.ErrorResponse) {
+	st, _ := status.FromError(err)
+	e, ok := rpcErrors[st.Code()]
+	if !ok {
+		e = backendError
+	}
+	return e.status, api.ErrorResponse{Code: e.code, Error: e.message}
+}
+
+// RespondRPC writes a backend gRPC error as an error envelope carrying the
+// request ID, and logs the backend detail: at error for server errors, at
+// warn for the rest
+func RespondRPC(c *gin.Context, logger *zap.Logger, err error) {
+	code, resp := FromRPC(err)
+	resp.RequestID = c.GetString("request_id")
+
+	log := logger.Warn
+	if code >= http.StatusInternalServerError {
+		log = logger.Error
+	}
+	log("backend call failed",
+		zap.String("path", c.Request.URL.Path),
+		zap.Int("status", code),
+		zap.String("grpc_code", status.Code(err).String()),
+		zap.Error(err),
+		zap.String("request_id", resp.RequestID),
+	)
+
+	c.JSON(code, resp)
+}
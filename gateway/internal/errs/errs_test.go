@@ -0,0 +1,109 @@
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromRPC(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{status.Error(codes.NotFound, "account 42 not found"), http.StatusNotFound, "not_found"},
+		{status.Error(codes.InvalidArgument, "quantity must be positive"), http.StatusBadRequest, "invalid_argument"},
+		{status.Error(codes.AlreadyExists, "duplicate key"), http.StatusConflict, "already_exists"},
+		{status.Error(codes.PermissionDenied, "user 7 lacks orders:write"), http.StatusForbidden, "permission_denied"},
+		{status.Error(codes.Unauthenticated, "no token"), http.StatusUnauthorized, "unauthenticated"},
+		{status.Error(codes.FailedPrecondition, "market closed"), http.StatusUnprocessableEntity, "failed_precondition"},
+		{status.Error(codes.ResourceExhausted, "quota"), http.StatusTooManyRequests, "resource_exhausted"},
+		{status.Error(codes.DeadlineExceeded, "slow"), http.StatusGatewayTimeout, "deadline_exceeded"},
+		{status.Error(codes.Unavailable, "connection refused"), http.StatusServiceUnavailable, "unavailable"},
+		{status.Error(codes.Unimplemented, "unknown method"), http.StatusNotImplemented, "unimplemented"},
+		{status.Error(codes.Internal, `pq: relation "orders" does not exist`), http.StatusBadGateway, "backend_error"},
+		{errors.New("dial tcp 10.0.0.5:50051: connect: connection refused"), http.StatusBadGateway, "backend_error"},
+	}
+	for _, tt := range tests {
+		gotStatus, got := FromRPC(tt.err)
+		if gotStatus != tt.wantStatus || got.Code != tt.wantCode {
+			t.Errorf("FromRPC(%v) = %d %q, want %d %q", tt.err, gotStatus, got.Code, tt.wantStatus, tt.wantCode)
+		}
+		if detail := status.Convert(tt.err).Message(); strings.Contains(got.Error, detail) {
+			t.Errorf("FromRPC(%v) message %q relays the backend message", tt.err, got.Error)
+		}
+	}
+}
+
+func TestRespondRPC(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zap.DebugLevel)
+	backendErr := status.Error(codes.Internal, `pq: password authentication failed for user "trader"`)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/orders", nil)
+	c.Set("request_id", "req-1")
+	RespondRPC(c, zap.New(core), backendErr)
+
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "password") {
+		t.Errorf("response %s leaks the backend error", w.Body.String())
+	}
+	var got api.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Code != "backend_error" || got.Error == "" || got.RequestID != "req-1" {
+		t.Errorf("body = %+v, want code backend_error, a message and request ID req-1", got)
+	}
+
+	entries := logs.FilterMessage("backend call failed").All()
+	if len(entries) != 1 || entries[0].Level != zap.ErrorLevel {
+		t.Fatalf("logged %v, want one error entry", entries)
+	}
+	if logged := entries[0].ContextMap()["error"]; !strings.Contains(logged.(string), "password authentication failed") {
+		t.Errorf("logged error %v, want the backend detail", logged)
+	}
+}
+
+func TestBadRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{api.Invalidf("page must be a positive integer"), "page must be a positive integer"},
+		{fmt.Errorf("request 2: %w", api.Invalidf("path is required")), "path is required"},
+		{errors.New("json: cannot unmarshal string into Go struct field req.weights of type []handler.weight"), "the request is invalid"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Set("request_id", "req-1")
+		BadRequest(c, tt.err)
+
+		var got api.ErrorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		want := api.ErrorResponse{Code: "invalid_argument", Error: tt.want, RequestID: "req-1"}
+		if w.Code != http.StatusBadRequest || got != want {
+			t.Errorf("BadRequest(%v) = %d %+v, want 400 %+v", tt.err, w.Code, got, want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/middleware"
+)
+
+// authorizeAccount checks that the caller may act on an account: admins may
+// act on any account, other users only on the accounts listed in their
+// token. It writes a 403 and returns false otherwise.
+func authorizeAccount(c *gin.Context, accountID string) bool {
+	claims := middleware.ClaimsFrom(c)
+	if claims != nil && (claims.IsAdmin() || claims.OwnsAccount(accountID)) {
+		return true
+	}
+	errs.Respond(c, http.StatusForbidden, "permission_denied", "access to account denied")
+	return false
+}
+
+// scopeAccount resolves the account filter of a list request. Admins may
+// omit it to list across accounts; other users must name one of their own
+// accounts, or may omit it when their token lists exactly one.
+func scopeAccount(c *gin.Context, accountID string) (string, bool) {
+	claims := middleware.ClaimsFrom(c)
+	if claims != nil && claims.IsAdmin() {
+		return accountID, true
+	}
+	if accountID == "" && claims != nil && len(claims.AccountIDs) == 1 {
+		return claims.AccountIDs[0], true
+	}
+	if accountID == "" {
+		errs.Respond(c, http.StatusBadRequest, "invalid_argument", "account_id is required")
+		return "", false
+	}
+	if !authorizeAccount(c, accountID) {
+		return "", false
+	}
+	return accountID, true
+}
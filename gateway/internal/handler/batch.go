@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"go.uber.org/zap"
+)
+
+// batchPathPrefixes are the only path prefixes sub-requests may target
+var batchPathPrefixes = []string{"/api/v1/", "/api/v2/"}
+
+// batchContextKey marks requests dispatched from inside a batch
+type batchContextKey struct{}
+
+// BatchRequest is a single sub-request of a batch
+type BatchRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+}
+
+// BatchResult is the outcome of a single sub-request
+type BatchResult struct {
+	Status int         `json:"status"`
+	Body   interface{} `json:"body,omitempty"`
+}
+
+// SetDispatcher sets the handler used to execute batch sub-requests,
+// normally the router itself
+func (h *Handler) SetDispatcher(d http.Handler) {
+	h.dispatcher = d
+}
+
+// Batch handles POST /api/{v1,v2}/batch
+// Sub-requests run concurrently through the full router, so they are
+// authenticated, rate limited and logged like any other request. Only GET
+// is accepted because the execution order is not defined.
+func (h *Handler) Batch(c *gin.Context) {
+	if c.Request.Context().Value(batchContextKey{}) != nil {
+		errs.Respond(c, http.StatusBadRequest, "invalid_argument", "nested batch requests are not allowed")
+		return
+	}
+	if h.dispatcher == nil {
+		errs.Respond(c, http.StatusServiceUnavailable, "unavailable", "batch dispatcher not configured")
+		return
+	}
+
+	var reqs []BatchRequest
+	if err := c.ShouldBindJSON(&reqs); err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	if len(reqs) == 0 {
+		errs.Respond(c, http.StatusBadRequest, "invalid_argument", "at least one sub-request is required")
+		return
+	}
+	if max := h.cfg.Batch.MaxRequests; max > 0 && len(reqs) > max {
+		errs.Respond(c, http.StatusBadRequest, "invalid_argument", fmt.Sprintf("at most %d sub-requests are allowed", max))
+		return
+	}
+	for i, req := range reqs {
+		if err := validateBatchRequest(req); err != nil {
+			errs.BadRequest(c, api.Invalidf("request %d: %v", i, err))
+			return
+		}
+	}
+
+	timeout := time.Duration(h.cfg.Batch.TimeoutMs) * time.Millisecond
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.WithValue(c.Request.Context(), batchContextKey{}, true), timeout)
+	defer cancel()
+
+	workers := h.cfg.Batch.Concurrency
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(reqs) {
+		workers = len(reqs)
+	}
+
+	caller := newBatchCaller(c)
+	var (
+		mu       sync.Mutex
+		results  = make([]*BatchResult, len(reqs))
+		finished bool
+		wg       sync.WaitGroup
+	)
+
+	jobs := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				// Past the deadline the result would be dropped anyway
+				if ctx.Err() != nil {
+					continue
+				}
+				result := h.dispatch(ctx, caller, i, reqs[i])
+				mu.Lock()
+				if !finished {
+					results[i] = result
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(jobs)
+		for i := range reqs {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		h.logger.Warn("batch deadline exceeded",
+			zap.Int("requests", len(reqs)),
+			zap.Duration("timeout", timeout),
+			zap.String("request_id", c.GetString("request_id")),
+		)
+	}
+
+	mu.Lock()
+	finished = true
+	out := make([]BatchResult, len(reqs))
+	for i, result := range results {
+		if result == nil {
+			out[i] = BatchResult{
+				Status: http.StatusGatewayTimeout,
+				Body:   api.ErrorResponse{Code: "deadline_exceeded", Error: "batch deadline exceeded"},
+			}
+			continue
+		}
+		out[i] = *result
+	}
+	mu.Unlock()
+
+	c.JSON(http.StatusOK, out)
+}
+
+// batchForwardHeaders are the batch request headers sub-requests carry
+var batchForwardHeaders = []string{"Authorization", "X-Forwarded-For", "X-Real-IP", "Accept-Language"}
+
+// batchCaller is what sub-requests copy from the batch request. It is
+// captured before the workers start: a worker still running when the
+// deadline passes outlives the handler, and gin reuses the context once the
+// handler returns.
+type batchCaller struct {
+	header     http.Header
+	remoteAddr string
+	requestID  string
+}
+
+func newBatchCaller(c *gin.Context) batchCaller {
+	header := make(http.Header)
+	for _, name := range batchForwardHeaders {
+		if value := c.GetHeader(name); value != "" {
+			header.Set(name, value)
+		}
+	}
+	return batchCaller{
+		header:     header,
+		remoteAddr: c.Request.RemoteAddr,
+		requestID:  c.GetString("request_id"),
+	}
+}
+
+// dispatch executes one sub-request against the router with the caller's
+// credentials and client address
+func (h *Handler) dispatch(ctx context.Context, caller batchCaller, index int, req BatchRequest) *BatchResult {
+	sub, err := http.NewRequestWithContext(ctx, http.MethodGet, req.Path, nil)
+	if err != nil {
+		return &BatchResult{Status: http.StatusBadRequest, Body: api.ErrorResponse{Code: "invalid_argument", Error: "path is not a valid URL"}}
+	}
+	sub.RemoteAddr = caller.remoteAddr
+	sub.Header = caller.header.Clone()
+	if caller.requestID != "" {
+		sub.Header.Set("X-Request-ID", fmt.Sprintf("%s-%d", caller.requestID, index))
+	}
+
+	rec := newResponseRecorder()
+	h.dispatcher.ServeHTTP(rec, sub)
+
+	result := &BatchResult{Status: rec.status}
+	if rec.body.Len() > 0 {
+		if json.Valid(rec.body.Bytes()) {
+			result.Body = json.RawMessage(rec.body.Bytes())
+		} else {
+			result.Body = rec.body.String()
+		}
+	}
+	return result
+}
+
+// validateBatchRequest checks a sub-request before it is dispatched
+func validateBatchRequest(req BatchRequest) error {
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if method != http.MethodGet {
+		return api.Invalidf("method %s is not allowed, only GET", req.Method)
+	}
+	for _, prefix := range batchPathPrefixes {
+		if !strings.HasPrefix(req.Path, prefix) {
+			continue
+		}
+		if path := strings.SplitN(req.Path, "?", 2)[0]; strings.TrimSuffix(path, "/") == prefix+"batch" {
+			return api.Invalidf("nested batch requests are not allowed")
+		}
+		return nil
+	}
+	return api.Invalidf("path must start with one of %s", strings.Join(batchPathPrefixes, ", "))
+}
+
+// responseRecorder captures a sub-request response in memory
+type responseRecorder struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
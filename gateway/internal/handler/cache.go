@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"sync"
+	"time"
+)
+
+// responseCache is a small in-process cache of computed responses with a
+// per-entry TTL. When full, expired entries are dropped first and then the
+// entry closest to expiry.
+type responseCache struct {
+	mu         sync.Mutex
+	entries    map[string]cacheEntry
+	maxEntries int
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// newResponseCache creates a cache holding at most maxEntries responses;
+// maxEntries <= 0 disables caching
+func newResponseCache(maxEntries int) *responseCache {
+	return &responseCache{
+		entries:    make(map[string]cacheEntry),
+		maxEntries: maxEntries,
+	}
+}
+
+// get returns the cached value for key if it has not expired
+func (rc *responseCache) get(key string) (interface{}, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(rc.entries, key)
+		return nil, false
+	}
+	return entry.value, true
+}
+
+// set caches value under key for ttl
+func (rc *responseCache) set(key string, value interface{}, ttl time.Duration) {
+	if rc.maxEntries <= 0 || ttl <= 0 {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := rc.entries[key]; !ok && len(rc.entries) >= rc.maxEntries {
+		var oldest string
+		var oldestExpires time.Time
+		for k, e := range rc.entries {
+			if now.After(e.expires) {
+				delete(rc.entries, k)
+				continue
+			}
+			if oldest == "" || e.expires.Before(oldestExpires) {
+				oldest, oldestExpires = k, e.expires
+			}
+		}
+		if len(rc.entries) >= rc.maxEntries {
+			delete(rc.entries, oldest)
+		}
+	}
+	rc.entries[key] = cacheEntry{value: value, expires: now.Add(ttl)}
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+)
+
+// codeRule is the compiled code format of one market
+type codeRule struct {
+	suffix   string
+	exchange commonpb.Exchange
+	pattern  *regexp.Regexp
+}
+
+// compileCodeRules compiles the configured market code formats
+func compileCodeRules(markets []config.MarketConfig) ([]codeRule, error) {
+	rules := make([]codeRule, 0, len(markets))
+	for _, m := range markets {
+		exchange, ok := commonpb.Exchange_value["EXCHANGE_"+m.Exchange]
+		if !ok {
+			return nil, fmt.Errorf("market %s: unknown exchange %q", m.Suffix, m.Exchange)
+		}
+		pattern, err := regexp.Compile(m.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("market %s: %w", m.Suffix, err)
+		}
+		rules = append(rules, codeRule{
+			suffix:   m.Suffix,
+			exchange: commonpb.Exchange(exchange),
+			pattern:  pattern,
+		})
+	}
+	return rules, nil
+}
+
+// normalizeCode validates an instrument code and returns its security ID and
+// canonical "CODE.SUFFIX" form. Codes are trimmed and uppercased; a bare
+// code is assigned to the first market whose format it matches, so
+// "600000", "600000.sh" and " 600000.SH" all normalize to "600000.SH".
+func (h *Handler) normalizeCode(raw string) (*commonpb.SecurityId, string, error) {
+	code := strings.ToUpper(strings.TrimSpace(raw))
+	if code == "" {
+		return nil, "", api.Invalidf("code is required")
+	}
+
+	if base, suffix, ok := strings.Cut(code, "."); ok {
+		for _, rule := range h.codeRules {
+			if rule.suffix != suffix {
+				continue
+			}
+			if !rule.pattern.MatchString(base) {
+				return nil, "", api.Invalidf("code %q is not a valid %s code", raw, suffix)
+			}
+			return &commonpb.SecurityId{Code: base, Exchange: rule.exchange}, base + "." + suffix, nil
+		}
+		return nil, "", api.Invalidf("code %q has unknown market suffix %q", raw, suffix)
+	}
+
+	for _, rule := range h.codeRules {
+		if rule.pattern.MatchString(code) {
+			return &commonpb.SecurityId{Code: code, Exchange: rule.exchange}, code + "." + rule.suffix, nil
+		}
+	}
+	return nil, "", api.Invalidf("code %q does not match any market format", raw)
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/middleware"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dial opens a client connection to a backend service. The connection is
+// established lazily, so an unreachable service does not fail startup,
+// unless GRPC.Block asks to wait for it. Keepalive pings detect connections
+// silently dropped by the network.
+func (h *Handler) dial(name, addr string) (*grpc.ClientConn, error) {
+	opts := h.cfg.GRPC
+	dialTimeout := time.Duration(opts.DialTimeoutMs) * time.Millisecond
+
+	creds := insecure.NewCredentials()
+	if opts.TLS {
+		creds = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: dialTimeout,
+		}),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(opts.MaxRecvMsgBytes),
+			grpc.MaxCallSendMsgSize(opts.MaxSendMsgBytes),
+		),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                time.Duration(opts.KeepaliveTimeMs) * time.Millisecond,
+			Timeout:             time.Duration(opts.KeepaliveTimeoutMs) * time.Millisecond,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithChainUnaryInterceptor(recordBackendTiming),
+	}
+	if h.dialer != nil {
+		dialOpts = append(dialOpts, grpc.WithContextDialer(h.dialer))
+	}
+
+	ctx := context.Background()
+	if opts.Block {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dialTimeout)
+		defer cancel()
+		dialOpts = append(dialOpts, grpc.WithBlock())
+	}
+	conn, err := grpc.DialContext(ctx, addr, dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("dial %s service at %s: %w", name, addr, err)
+	}
+	h.conns = append(h.conns, backendConn{name: name, conn: conn})
+	return conn, nil
+}
+
+// recordBackendTiming is a unary client interceptor that records how long
+// each backend call took against the request it serves, for the slow
+// request log
+func recordBackendTiming(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	middleware.RecordBackendTiming(ctx, method, time.Since(start))
+	return err
+}
+
+// backendConn is the client connection to one backend service
+type backendConn struct {
+	name string
+	conn *grpc.ClientConn
+}
+
+// BackendStatus is the connectivity of one backend service
+type BackendStatus struct {
+	Service   string `json:"service"`
+	State     string `json:"state"` // e.g. "ready", "transient_failure"
+	Ready     bool   `json:"ready"`
+	LatencyMs int64  `json:"latency_ms"` // time taken to settle on State
+}
+
+// probeBackends checks every backend connection in parallel, connecting
+// idle ones, and waits up to timeout for each to become ready or fail
+func (h *Handler) probeBackends(ctx context.Context, timeout time.Duration) []BackendStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	statuses := make([]BackendStatus, len(h.conns))
+	var wg sync.WaitGroup
+	for i, b := range h.conns {
+		wg.Add(1)
+		go func(i int, b backendConn) {
+			defer wg.Done()
+			start := time.Now()
+			state := settledState(ctx, b.conn)
+			statuses[i] = BackendStatus{
+				Service:   b.name,
+				State:     strings.ToLower(state.String()),
+				Ready:     state == connectivity.Ready,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+		}(i, b)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// settledState waits until a connection is ready or has failed, returning
+// its state when ctx ends first
+func settledState(ctx context.Context, conn *grpc.ClientConn) connectivity.State {
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready, connectivity.TransientFailure, connectivity.Shutdown:
+			return state
+		case connectivity.Idle:
+			conn.Connect()
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return conn.GetState()
+		}
+	}
+}
+
+// respondRPCError maps a backend gRPC error onto an HTTP error response
+func (h *Handler) respondRPCError(c *gin.Context, err error) {
+	errs.RespondRPC(c, h.logger, err)
+}
+
+// parseDateQuery reads an optional YYYY-MM-DD query parameter as a
+// common.Date; it returns nil when the parameter is absent
+func parseDateQuery(c *gin.Context, key string) (*commonpb.Date, time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, time.Time{}, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, time.Time{}, api.Invalidf("%s must be a date in YYYY-MM-DD format", key)
+	}
+	return toDate(t), t, nil
+}
+
+// toDate converts a time to a common.Date
+func toDate(t time.Time) *commonpb.Date {
+	return &commonpb.Date{Year: int32(t.Year()), Month: int32(t.Month()), Day: int32(t.Day())}
+}
+
+// formatDate renders a common.Date as YYYY-MM-DD, or "" if unset
+func formatDate(d *commonpb.Date) string {
+	if d == nil {
+		return ""
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", d.GetYear(), d.GetMonth(), d.GetDay())
+}
+
+// decimalValue returns the string form of a common.Decimal, or "0"
+func decimalValue(d *commonpb.Decimal) string {
+	if d.GetValue() == "" {
+		return "0"
+	}
+	return d.GetValue()
+}
+
+// formatTimestamp renders a protobuf timestamp as RFC 3339, or "" if unset
+func formatTimestamp(ts *timestamppb.Timestamp) string {
+	if ts == nil {
+		return ""
+	}
+	return ts.AsTime().UTC().Format(time.RFC3339Nano)
+}
+
+// sideName renders an order side as its lowercase name, e.g. "buy"
+func sideName(side commonpb.Side) string {
+	return enumName(side.String(), "SIDE_")
+}
+
+// enumName strips the type prefix of a protobuf enum value name and
+// lowercases it; unspecified values render as ""
+func enumName(name, prefix string) string {
+	name = strings.ToLower(strings.TrimPrefix(name, prefix))
+	if name == "unspecified" {
+		return ""
+	}
+	return name
+}
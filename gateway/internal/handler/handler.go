@@ -1,44 +1,152 @@
 package handler
 
 import (
+	"context"
+	"net"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mellivora-mind/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/middleware"
+	accountpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/account"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	datapb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/data"
+	orderpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/order"
+	positionpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/position"
+	riskpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/risk"
+	signalpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/signal"
+	tradepb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/trade"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/version"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Handler holds all HTTP handlers
 type Handler struct {
 	cfg    *config.Config
 	logger *zap.Logger
-	// TODO: Add gRPC clients for backend services
-	// accountClient  accountpb.AccountServiceClient
-	// orderClient    orderpb.OrderServiceClient
-	// positionClient positionpb.PositionServiceClient
-	// etc.
+
+	// dispatcher executes batch sub-requests
+	dispatcher http.Handler
+
+	// gRPC clients for backend services
+	conns          []backendConn
+	accountClient  accountpb.AccountServiceClient
+	orderClient    orderpb.OrderServiceClient
+	positionClient positionpb.PositionServiceClient
+	tradeClient    tradepb.TradeServiceClient
+	dataClient     datapb.DataServiceClient
+	riskClient     riskpb.RiskServiceClient
+	signalClient   signalpb.SignalServiceClient
+
+	// dialer, when set, replaces the network dialer of backend
+	// connections; tests use it to serve backends in memory
+	dialer func(ctx context.Context, addr string) (net.Conn, error)
+
+	// riskCache holds computed risk responses
+	riskCache *responseCache
+
+	// universeCache holds instrument directory pages
+	universeCache *responseCache
+
+	// codeRules are the instrument code formats by market
+	codeRules []codeRule
+
+	// streams tracks active streaming responses; stopping is closed to
+	// tell them to finish
+	streams  sync.WaitGroup
+	stopping chan struct{}
+	stopOnce sync.Once
 }
 
 // New creates a new Handler instance
 func New(cfg *config.Config, logger *zap.Logger) (*Handler, error) {
 	h := &Handler{
-		cfg:    cfg,
-		logger: logger,
+		cfg:           cfg,
+		logger:        logger,
+		riskCache:     newResponseCache(cfg.Risk.CacheSize),
+		universeCache: newResponseCache(cfg.Universe.CacheSize),
+		stopping:      make(chan struct{}),
+	}
+
+	rules, err := compileCodeRules(cfg.Markets)
+	if err != nil {
+		return nil, err
+	}
+	h.codeRules = rules
+
+	accountConn, err := h.dial("account", cfg.Services.Account)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.accountClient = accountpb.NewAccountServiceClient(accountConn)
+
+	orderConn, err := h.dial("order", cfg.Services.Order)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.orderClient = orderpb.NewOrderServiceClient(orderConn)
+
+	positionConn, err := h.dial("position", cfg.Services.Position)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.positionClient = positionpb.NewPositionServiceClient(positionConn)
+
+	tradeConn, err := h.dial("trade", cfg.Services.Trade)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.tradeClient = tradepb.NewTradeServiceClient(tradeConn)
+
+	dataConn, err := h.dial("data", cfg.Services.Data)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.dataClient = datapb.NewDataServiceClient(dataConn)
+
+	riskConn, err := h.dial("risk", cfg.Services.Risk)
+	if err != nil {
+		h.Close()
+		return nil, err
 	}
+	h.riskClient = riskpb.NewRiskServiceClient(riskConn)
 
-	// TODO: Initialize gRPC connections to backend services
-	// conn, err := grpc.Dial(cfg.Services.Account, grpc.WithInsecure())
-	// if err != nil {
-	//     return nil, err
-	// }
-	// h.accountClient = accountpb.NewAccountServiceClient(conn)
+	signalConn, err := h.dial("signal", cfg.Services.Signal)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.signalClient = signalpb.NewSignalServiceClient(signalConn)
 
 	return h, nil
 }
 
-// Close closes all connections
+// Close stops active streams, waiting a bounded time for them to finish,
+// and then closes all backend connections
 func (h *Handler) Close() {
-	// TODO: Close gRPC connections
+	h.drainStreams()
+
+	for _, b := range h.conns {
+		if err := b.conn.Close(); err != nil {
+			h.logger.Warn("failed to close grpc connection",
+				zap.String("service", b.name),
+				zap.String("target", b.conn.Target()),
+				zap.Error(err),
+			)
+		}
+	}
+	h.conns = nil
 }
 
 // ============================================================================
@@ -47,41 +155,170 @@ func (h *Handler) Close() {
 
 // HealthCheck returns the health status of the gateway
 func (h *Handler) HealthCheck(c *gin.Context) {
+	info := version.Get()
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"service": "gateway",
-		"version": "0.1.0",
+		"status":    "healthy",
+		"service":   "gateway",
+		"version":   info.Version,
+		"commit":    info.Commit,
+		"buildTime": info.BuildTime,
 	})
 }
 
-// ReadyCheck returns the readiness status
+// Version returns the build metadata of the gateway binary
+func (h *Handler) Version(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get())
+}
+
+// readyProbeTimeout bounds how long ReadyCheck waits for backends to connect
+const readyProbeTimeout = 2 * time.Second
+
+// ReadyCheck returns the readiness status: 503 naming the unavailable
+// backend services unless every one is connected. ?verbose=true adds the
+// state and probe latency of each service.
 func (h *Handler) ReadyCheck(c *gin.Context) {
-	// TODO: Check backend service connectivity
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ready",
-	})
+	statuses := h.probeBackends(c.Request.Context(), readyProbeTimeout)
+
+	code := http.StatusOK
+	resp := gin.H{"status": "ready"}
+	var unavailable []string
+	for _, s := range statuses {
+		if !s.Ready {
+			unavailable = append(unavailable, s.Service)
+		}
+	}
+	if len(unavailable) > 0 {
+		code = http.StatusServiceUnavailable
+		resp["status"] = "not_ready"
+		resp["unavailable"] = unavailable
+	}
+	if verbose, _ := strconv.ParseBool(c.Query("verbose")); verbose {
+		resp["services"] = statuses
+	}
+	c.JSON(code, resp)
 }
 
 // ============================================================================
 // Account Endpoints
 // ============================================================================
 
-// ListAccounts handles GET /api/v1/accounts
+// Account is the JSON shape of a trading account
+type Account struct {
+	AccountID     string `json:"account_id"`
+	AccountName   string `json:"account_name"`
+	AccountType   string `json:"account_type"`
+	Broker        string `json:"broker"`
+	Channel       string `json:"channel"`
+	Status        string `json:"status"`
+	TotalAsset    string `json:"total_asset"`
+	CashBalance   string `json:"cash_balance"`
+	AvailableCash string `json:"available_cash"`
+	FrozenCash    string `json:"frozen_cash"`
+	MarketValue   string `json:"market_value"`
+	TotalPnL      string `json:"total_pnl"`
+	TodayPnL      string `json:"today_pnl"`
+	RiskLevel     string `json:"risk_level"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// ListAccounts handles GET /api/{v1,v2}/accounts
+// v1 returns {"accounts", "total"}; v2 uses the PageResponse envelope.
+// Admins page through every account; other users get the accounts listed
+// in their token.
 func (h *Handler) ListAccounts(c *gin.Context) {
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"accounts": []gin.H{},
-		"total":    0,
+	p, err := parsePagination(c)
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+
+	claims := middleware.ClaimsFrom(c)
+	if claims == nil || !claims.IsAdmin() {
+		var owned []string
+		if claims != nil {
+			owned = claims.AccountIDs
+		}
+		h.listOwnAccounts(c, owned, p)
+		return
+	}
+
+	resp, err := h.accountClient.ListAccounts(c.Request.Context(), &accountpb.ListAccountsRequest{
+		Page: &commonpb.PageRequest{Page: int32(p.Page), PageSize: int32(p.PageSize)},
 	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	accounts := make([]Account, 0, len(resp.GetAccounts()))
+	for _, a := range resp.GetAccounts() {
+		accounts = append(accounts, accountJSON(a))
+	}
+	respondPage(c, "accounts", accounts, int(resp.GetPage().GetTotal()), p)
+}
+
+// listOwnAccounts writes one page of the given accounts, read one by one
+func (h *Handler) listOwnAccounts(c *gin.Context, ids []string, p Pagination) {
+	start := p.Offset()
+	if start > len(ids) {
+		start = len(ids)
+	}
+	end := start + p.PageSize
+	if end > len(ids) {
+		end = len(ids)
+	}
+
+	accounts := make([]Account, 0, end-start)
+	for _, id := range ids[start:end] {
+		resp, err := h.accountClient.GetAccount(c.Request.Context(), &accountpb.GetAccountRequest{AccountId: id})
+		if err != nil {
+			if status.Code(err) == codes.NotFound {
+				continue
+			}
+			h.respondRPCError(c, err)
+			return
+		}
+		accounts = append(accounts, accountJSON(resp.GetAccount()))
+	}
+	respondPage(c, "accounts", accounts, len(ids), p)
 }
 
 // GetAccount handles GET /api/v1/accounts/:id
 func (h *Handler) GetAccount(c *gin.Context) {
 	id := c.Param("id")
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"account_id": id,
-	})
+	if !authorizeAccount(c, id) {
+		return
+	}
+
+	resp, err := h.accountClient.GetAccount(c.Request.Context(), &accountpb.GetAccountRequest{AccountId: id})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, accountJSON(resp.GetAccount()))
+}
+
+// accountJSON converts a backend account
+func accountJSON(a *accountpb.Account) Account {
+	return Account{
+		AccountID:     a.GetAccountId(),
+		AccountName:   a.GetAccountName(),
+		AccountType:   enumName(a.GetAccountType().String(), "ACCOUNT_TYPE_"),
+		Broker:        a.GetBroker(),
+		Channel:       a.GetChannel(),
+		Status:        enumName(a.GetStatus().String(), "ACCOUNT_STATUS_"),
+		TotalAsset:    decimalValue(a.GetTotalAsset()),
+		CashBalance:   decimalValue(a.GetCashBalance()),
+		AvailableCash: decimalValue(a.GetAvailableCash()),
+		FrozenCash:    decimalValue(a.GetFrozenCash()),
+		MarketValue:   decimalValue(a.GetMarketValue()),
+		TotalPnL:      decimalValue(a.GetTotalPnl()),
+		TodayPnL:      decimalValue(a.GetTodayPnl()),
+		RiskLevel:     a.GetRiskLevel(),
+		CreatedAt:     formatTimestamp(a.GetCreatedAt()),
+		UpdatedAt:     formatTimestamp(a.GetUpdatedAt()),
+	}
 }
 
 // CreateAccount handles POST /api/v1/accounts
@@ -96,55 +333,30 @@ func (h *Handler) CreateAccount(c *gin.Context) {
 // Position Endpoints
 // ============================================================================
 
-// ListPositions handles GET /api/v1/positions
+// ListPositions handles GET /api/{v1,v2}/positions
 func (h *Handler) ListPositions(c *gin.Context) {
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"positions": []gin.H{},
-		"total":     0,
-	})
-}
-
-// GetTargetPortfolio handles GET /api/v1/portfolios/:account_id/target
-func (h *Handler) GetTargetPortfolio(c *gin.Context) {
-	accountID := c.Param("account_id")
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"account_id": accountID,
-		"weights":    []gin.H{},
-	})
-}
-
-// SetTargetPortfolio handles POST /api/v1/portfolios/:account_id/target
-func (h *Handler) SetTargetPortfolio(c *gin.Context) {
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"message": "target portfolio set",
-	})
-}
-
-// GetTradeList handles GET /api/v1/portfolios/:account_id/trades
-func (h *Handler) GetTradeList(c *gin.Context) {
-	accountID := c.Param("account_id")
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"account_id": accountID,
-		"buy_list":   []gin.H{},
-		"sell_list":  []gin.H{},
-	})
+	p, err := parsePagination(c)
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	// TODO: Implement with gRPC call, forwarding p as the PageRequest
+	respondPage(c, "positions", []gin.H{}, 0, p)
 }
 
 // ============================================================================
 // Order Endpoints
 // ============================================================================
 
-// ListOrders handles GET /api/v1/orders
+// ListOrders handles GET /api/{v1,v2}/orders
 func (h *Handler) ListOrders(c *gin.Context) {
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"orders": []gin.H{},
-		"total":  0,
-	})
+	p, err := parsePagination(c)
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	// TODO: Implement with gRPC call, forwarding p as the PageRequest
+	respondPage(c, "orders", []gin.H{}, 0, p)
 }
 
 // GetOrder handles GET /api/v1/orders/:id
@@ -188,76 +400,139 @@ func (h *Handler) CancelOrder(c *gin.Context) {
 // Trade/Deal Endpoints
 // ============================================================================
 
-// ListDeals handles GET /api/v1/deals
-func (h *Handler) ListDeals(c *gin.Context) {
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"deals": []gin.H{},
-		"total": 0,
-	})
+// Deal is the JSON shape of a trade execution
+type Deal struct {
+	DealID    string `json:"deal_id"`
+	OrderID   string `json:"order_id"`
+	AccountID string `json:"account_id"`
+	Code      string `json:"code"`
+	Name      string `json:"name"`
+	Exchange  string `json:"exchange"`
+	Side      string `json:"side"`
+	Quantity  string `json:"quantity"`
+	Price     string `json:"price"`
+	Amount    string `json:"amount"`
+	Fee       string `json:"fee"`
+	DealTime  string `json:"deal_time"`
 }
 
-// ============================================================================
-// Data Endpoints
-// ============================================================================
-
-// GetQuote handles GET /api/v1/data/quotes/:code
-func (h *Handler) GetQuote(c *gin.Context) {
-	code := c.Param("code")
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"code": code,
-	})
-}
+// ListDeals handles GET /api/{v1,v2}/deals
+// Filters: account_id, code, start_date and end_date (YYYY-MM-DD, inclusive).
+// Non-admin users only see deals of their own accounts.
+func (h *Handler) ListDeals(c *gin.Context) {
+	p, err := parsePagination(c)
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	startDate, start, err := parseDateQuery(c, "start_date")
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	endDate, end, err := parseDateQuery(c, "end_date")
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	if startDate != nil && endDate != nil && start.After(end) {
+		errs.Respond(c, http.StatusBadRequest, "invalid_argument", "start_date must not be after end_date")
+		return
+	}
+	accountID, ok := scopeAccount(c, c.Query("account_id"))
+	if !ok {
+		return
+	}
 
-// GetOHLCV handles GET /api/v1/data/ohlcv/:code
-func (h *Handler) GetOHLCV(c *gin.Context) {
-	code := c.Param("code")
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"code": code,
-		"bars": []gin.H{},
-	})
-}
+	req := &tradepb.ListDealsRequest{
+		AccountId: accountID,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Page:      &commonpb.PageRequest{Page: int32(p.Page), PageSize: int32(p.PageSize)},
+	}
+	if code := c.Query("code"); code != "" {
+		req.SecurityId = &commonpb.SecurityId{Code: code}
+	}
 
-// ============================================================================
-// Risk Endpoints
-// ============================================================================
+	resp, err := h.tradeClient.ListDeals(c.Request.Context(), req)
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
 
-// GetPortfolioRisk handles GET /api/v1/risk/portfolio/:account_id
-func (h *Handler) GetPortfolioRisk(c *gin.Context) {
-	accountID := c.Param("account_id")
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"account_id": accountID,
-	})
-}
+	deals := make([]Deal, 0, len(resp.GetDeals()))
+	for _, d := range resp.GetDeals() {
+		deals = append(deals, Deal{
+			DealID:    d.GetDealId(),
+			OrderID:   d.GetOrderId(),
+			AccountID: d.GetAccountId(),
+			Code:      d.GetSecurityId().GetCode(),
+			Name:      d.GetName(),
+			Exchange:  enumName(d.GetExchange().String(), "EXCHANGE_"),
+			Side:      sideName(d.GetSide()),
+			Quantity:  decimalValue(d.GetQuantity()),
+			Price:     decimalValue(d.GetPrice()),
+			Amount:    decimalValue(d.GetAmount()),
+			Fee:       decimalValue(d.GetTotalFee()),
+			DealTime:  formatTimestamp(d.GetDealTime()),
+		})
+	}
 
-// GetRiskDecomposition handles GET /api/v1/risk/decomposition/:account_id
-func (h *Handler) GetRiskDecomposition(c *gin.Context) {
-	accountID := c.Param("account_id")
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"account_id": accountID,
-	})
+	respondPage(c, "deals", deals, int(resp.GetPage().GetTotal()), p)
 }
 
 // ============================================================================
-// Signal Endpoints
+// Data Endpoints
 // ============================================================================
 
-// GetTimingSignal handles GET /api/v1/signals/timing
-func (h *Handler) GetTimingSignal(c *gin.Context) {
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"signals": []gin.H{},
-	})
+// Quote is the JSON shape of a real-time quote
+type Quote struct {
+	Code        string `json:"code"`
+	Timestamp   string `json:"timestamp"`
+	LastPrice   string `json:"last_price"`
+	PrevClose   string `json:"prev_close"`
+	Open        string `json:"open"`
+	High        string `json:"high"`
+	Low         string `json:"low"`
+	Volume      string `json:"volume"`
+	Amount      string `json:"amount"`
+	Change      string `json:"change"`
+	ChangePct   string `json:"change_pct"`
+	UpperLimit  string `json:"upper_limit"`
+	LowerLimit  string `json:"lower_limit"`
+	IsSuspended bool   `json:"is_suspended"`
 }
 
-// GetAlphaSignal handles GET /api/v1/signals/alpha
-func (h *Handler) GetAlphaSignal(c *gin.Context) {
-	// TODO: Implement with gRPC call
-	c.JSON(http.StatusOK, gin.H{
-		"scores": []gin.H{},
+// GetQuote handles GET /api/v1/data/quotes/:code
+// The code is normalized first (see normalizeCode); invalid codes are 400.
+func (h *Handler) GetQuote(c *gin.Context) {
+	id, code, err := h.normalizeCode(c.Param("code"))
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+
+	resp, err := h.dataClient.GetQuote(c.Request.Context(), &datapb.GetQuoteRequest{SecurityId: id})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+	q := resp.GetQuote()
+
+	c.JSON(http.StatusOK, Quote{
+		Code:        code,
+		Timestamp:   formatTimestamp(q.GetTimestamp()),
+		LastPrice:   decimalValue(q.GetLastPrice()),
+		PrevClose:   decimalValue(q.GetPrevClose()),
+		Open:        decimalValue(q.GetOpen()),
+		High:        decimalValue(q.GetHigh()),
+		Low:         decimalValue(q.GetLow()),
+		Volume:      decimalValue(q.GetVolume()),
+		Amount:      decimalValue(q.GetAmount()),
+		Change:      decimalValue(q.GetChange()),
+		ChangePct:   decimalValue(q.GetChangePct()),
+		UpperLimit:  decimalValue(q.GetUpperLimit()),
+		LowerLimit:  decimalValue(q.GetLowerLimit()),
+		IsSuspended: q.GetIsSuspended(),
 	})
 }
@@ -0,0 +1,246 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/middleware"
+	accountpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/account"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufnet serves fake backends over in-memory listeners, keyed by the
+// address the handler dials
+type bufnet struct {
+	mu        sync.Mutex
+	listeners map[string]*bufconn.Listener
+}
+
+func (n *bufnet) dial(ctx context.Context, addr string) (net.Conn, error) {
+	n.mu.Lock()
+	lis, ok := n.listeners[addr]
+	n.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("no backend at %s", addr)
+	}
+	return lis.DialContext(ctx)
+}
+
+// testHandler is a Handler whose backends are served by serve
+type testHandler struct {
+	*Handler
+	net *bufnet
+}
+
+func newTestHandler(t *testing.T) *testHandler {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	n := &bufnet{listeners: make(map[string]*bufconn.Listener)}
+	h := &Handler{
+		cfg: &config.Config{GRPC: config.GRPCClientConfig{
+			MaxRecvMsgBytes:    4 << 20,
+			MaxSendMsgBytes:    4 << 20,
+			KeepaliveTimeMs:    30000,
+			KeepaliveTimeoutMs: 10000,
+			DialTimeoutMs:      1000,
+		}},
+		logger:   zap.NewNop(),
+		dialer:   n.dial,
+		stopping: make(chan struct{}),
+	}
+	t.Cleanup(h.Close)
+	return &testHandler{Handler: h, net: n}
+}
+
+// serve starts an in-memory gRPC server for the named backend, with the
+// services added by register, and dials it. The returned server may be
+// stopped to take the backend down.
+func (th *testHandler) serve(t *testing.T, name string, register func(*grpc.Server)) (*grpc.ClientConn, *grpc.Server) {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	th.net.mu.Lock()
+	th.net.listeners[name] = lis
+	th.net.mu.Unlock()
+
+	conn, err := th.dial(name, name)
+	if err != nil {
+		t.Fatalf("dial %s: %v", name, err)
+	}
+	return conn, srv
+}
+
+// withClaims stands in for the Auth middleware, setting the claims of a
+// verified token
+func withClaims(claims *middleware.Claims) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims != nil {
+			c.Set("claims", claims)
+		}
+		c.Next()
+	}
+}
+
+// fakeAccounts is an account service holding a fixed set of accounts
+type fakeAccounts struct {
+	accountpb.UnimplementedAccountServiceServer
+	accounts []*accountpb.Account
+
+	mu       sync.Mutex
+	lastPage *commonpb.PageRequest
+}
+
+func (f *fakeAccounts) GetAccount(_ context.Context, req *accountpb.GetAccountRequest) (*accountpb.GetAccountResponse, error) {
+	for _, a := range f.accounts {
+		if a.GetAccountId() == req.GetAccountId() {
+			return &accountpb.GetAccountResponse{Account: a}, nil
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "account %s not found", req.GetAccountId())
+}
+
+func (f *fakeAccounts) ListAccounts(_ context.Context, req *accountpb.ListAccountsRequest) (*accountpb.ListAccountsResponse, error) {
+	f.mu.Lock()
+	f.lastPage = req.GetPage()
+	f.mu.Unlock()
+
+	size := int(req.GetPage().GetPageSize())
+	start := int(req.GetPage().GetPage()-1) * size
+	if start > len(f.accounts) {
+		start = len(f.accounts)
+	}
+	end := start + size
+	if end > len(f.accounts) {
+		end = len(f.accounts)
+	}
+	return &accountpb.ListAccountsResponse{
+		Accounts: f.accounts[start:end],
+		Page:     &commonpb.PageResponse{Total: int64(len(f.accounts))},
+	}, nil
+}
+
+func newAccountRouter(t *testing.T, claims *middleware.Claims) (*gin.Engine, *fakeAccounts) {
+	t.Helper()
+	fake := &fakeAccounts{accounts: []*accountpb.Account{
+		{AccountId: "a1", AccountName: "Alpha", Broker: "b1"},
+		{AccountId: "a2", AccountName: "Beta", Broker: "b1"},
+		{AccountId: "a3", AccountName: "Gamma", Broker: "b2"},
+	}}
+	th := newTestHandler(t)
+	conn, _ := th.serve(t, "account", func(s *grpc.Server) {
+		accountpb.RegisterAccountServiceServer(s, fake)
+	})
+	th.accountClient = accountpb.NewAccountServiceClient(conn)
+
+	r := gin.New()
+	r.Use(withClaims(claims))
+	r.GET("/accounts", th.ListAccounts)
+	r.GET("/accounts/:id", th.GetAccount)
+	return r, fake
+}
+
+func TestGetAccount(t *testing.T) {
+	user := &middleware.Claims{UserID: "u1", AccountIDs: []string{"a1", "missing"}}
+	admin := &middleware.Claims{UserID: "root", Role: middleware.RoleAdmin}
+
+	tests := []struct {
+		name     string
+		claims   *middleware.Claims
+		id       string
+		wantCode int
+		wantName string
+	}{
+		{"owner", user, "a1", http.StatusOK, "Alpha"},
+		{"admin reads any account", admin, "a2", http.StatusOK, "Beta"},
+		{"not owned", user, "a2", http.StatusForbidden, ""},
+		{"anonymous", nil, "a1", http.StatusForbidden, ""},
+		{"backend not found", user, "missing", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newAccountRouter(t, tt.claims)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts/"+tt.id, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("GET /accounts/%s = %d %s, want %d", tt.id, w.Code, w.Body, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var got Account
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.AccountID != tt.id || got.AccountName != tt.wantName {
+				t.Errorf("account = %+v, want %s %s", got, tt.id, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestListAccounts(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    *middleware.Claims
+		query     string
+		wantIDs   []string
+		wantTotal int
+	}{
+		{"admin pages through the backend", &middleware.Claims{Role: middleware.RoleAdmin}, "?page=2&pageSize=2", []string{"a3"}, 3},
+		{"user sees own accounts", &middleware.Claims{AccountIDs: []string{"a3", "a1"}}, "", []string{"a3", "a1"}, 2},
+		{"user skips missing accounts", &middleware.Claims{AccountIDs: []string{"a1", "missing"}}, "", []string{"a1"}, 2},
+		{"anonymous sees nothing", nil, "", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fake := newAccountRouter(t, tt.claims)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/accounts"+tt.query, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("GET /accounts%s = %d %s, want 200", tt.query, w.Code, w.Body)
+			}
+
+			var got struct {
+				Data  []Account `json:"data"`
+				Total int       `json:"total"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if got.Total != tt.wantTotal || len(got.Data) != len(tt.wantIDs) {
+				t.Fatalf("response = %s, want accounts %v of %d", w.Body, tt.wantIDs, tt.wantTotal)
+			}
+			for i, id := range tt.wantIDs {
+				if got.Data[i].AccountID != id {
+					t.Errorf("account %d = %s, want %s", i, got.Data[i].AccountID, id)
+				}
+			}
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			if isAdmin := tt.claims != nil && tt.claims.IsAdmin(); isAdmin != (fake.lastPage != nil) {
+				t.Errorf("ListAccounts RPC called = %v, want %v", fake.lastPage != nil, isAdmin)
+			}
+			if fake.lastPage != nil && (fake.lastPage.GetPage() != 2 || fake.lastPage.GetPageSize() != 2) {
+				t.Errorf("backend page = %v, want page 2 of size 2", fake.lastPage)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	datapb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/data"
+	"go.uber.org/zap"
+)
+
+// ohlcvFlushEvery is how many bars are written between flushes
+const ohlcvFlushEvery = 256
+
+// Bar is the JSON shape of a daily OHLCV bar
+type Bar struct {
+	Date   string `json:"date"`
+	Open   string `json:"open"`
+	High   string `json:"high"`
+	Low    string `json:"low"`
+	Close  string `json:"close"`
+	Volume string `json:"volume"`
+	Amount string `json:"amount"`
+}
+
+// GetOHLCV handles GET /api/v1/data/ohlcv/:code
+// Filters: start_date and end_date (YYYY-MM-DD, inclusive). The code is
+// normalized first (see normalizeCode); invalid codes are 400.
+//
+// Bars are streamed to the client as they arrive from the data service, so
+// large ranges are never buffered. The default format is a chunked
+// {"code": ..., "bars": [...]} document; ?format=ndjson writes one bar per
+// line instead. A backend failure after the first bar cannot change the
+// status code, so it is reported in-band: as a trailing "error" field of the
+// document, or as a final {"error": ...} line. A client disconnect cancels
+// the upstream stream.
+func (h *Handler) GetOHLCV(c *gin.Context) {
+	id, code, err := h.normalizeCode(c.Param("code"))
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	startDate, start, err := parseDateQuery(c, "start_date")
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	endDate, end, err := parseDateQuery(c, "end_date")
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	if startDate != nil && endDate != nil && start.After(end) {
+		errs.Respond(c, http.StatusBadRequest, "invalid_argument", "start_date must not be after end_date")
+		return
+	}
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "ndjson" {
+		errs.Respond(c, http.StatusBadRequest, "invalid_argument", "format must be json or ndjson")
+		return
+	}
+
+	ctx, done := h.beginStream(c)
+	defer done()
+
+	stream, err := h.dataClient.StreamOHLCV(ctx, &datapb.GetOHLCVRequest{
+		SecurityId: id,
+		StartDate:  startDate,
+		EndDate:    endDate,
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	// Wait for the first bar so that errors the backend reports up front
+	// still get a proper status code
+	first, err := stream.Recv()
+	if err != nil && !errors.Is(err, io.EOF) {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	w := &ohlcvWriter{w: c.Writer, ndjson: format == "ndjson"}
+	if w.ndjson {
+		c.Header("Content-Type", "application/x-ndjson")
+	} else {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+	}
+	c.Status(http.StatusOK)
+	if !w.open(code) {
+		return
+	}
+
+	for bar := first; bar != nil; {
+		if !w.bar(toBar(bar)) {
+			return
+		}
+		bar, err = stream.Recv()
+		if err != nil {
+			break
+		}
+	}
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		if ctx.Err() != nil && c.Request.Context().Err() != nil {
+			// The client went away; nobody is left to tell
+			return
+		}
+		h.logger.Error("ohlcv stream failed",
+			zap.String("code", code),
+			zap.Int("bars_written", w.count),
+			zap.Error(err),
+			zap.String("request_id", c.GetString("request_id")),
+		)
+		_, resp := errs.FromRPC(err)
+		w.close(resp.Error)
+		return
+	}
+	w.close("")
+}
+
+// ohlcvWriter writes a bar stream as a chunked JSON document or NDJSON
+type ohlcvWriter struct {
+	w      gin.ResponseWriter
+	ndjson bool
+	count  int
+}
+
+// open writes the document head; it reports false once the client is gone
+func (ow *ohlcvWriter) open(code string) bool {
+	if ow.ndjson {
+		return true
+	}
+	rawCode, _ := json.Marshal(code)
+	_, err := ow.w.Write([]byte(`{"code":` + string(rawCode) + `,"bars":[`))
+	return err == nil
+}
+
+// bar writes one bar, flushing periodically
+func (ow *ohlcvWriter) bar(b Bar) bool {
+	raw, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	switch {
+	case ow.ndjson:
+		raw = append(raw, '\n')
+	case ow.count > 0:
+		raw = append([]byte{','}, raw...)
+	}
+	if _, err := ow.w.Write(raw); err != nil {
+		return false
+	}
+	ow.count++
+	if ow.count%ohlcvFlushEvery == 0 {
+		ow.w.Flush()
+	}
+	return true
+}
+
+// close ends the stream, reporting errMsg in-band when it is set
+func (ow *ohlcvWriter) close(errMsg string) {
+	var tail []byte
+	switch {
+	case ow.ndjson && errMsg != "":
+		tail, _ = json.Marshal(gin.H{"error": errMsg})
+		tail = append(tail, '\n')
+	case ow.ndjson:
+	case errMsg != "":
+		rawMsg, _ := json.Marshal(errMsg)
+		tail = []byte(`],"error":` + string(rawMsg) + `}`)
+	default:
+		tail = []byte(`]}`)
+	}
+	if len(tail) > 0 {
+		ow.w.Write(tail)
+	}
+	ow.w.Flush()
+}
+
+// toBar converts a backend bar
+func toBar(b *commonpb.OHLCVBar) Bar {
+	return Bar{
+		Date:   formatDate(b.GetDate()),
+		Open:   decimalValue(b.GetOpen()),
+		High:   decimalValue(b.GetHigh()),
+		Low:    decimalValue(b.GetLow()),
+		Close:  decimalValue(b.GetClose()),
+		Volume: decimalValue(b.GetVolume()),
+		Amount: decimalValue(b.GetAmount()),
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/middleware"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Pagination holds the standard page/pageSize query parameters. Page and
+// PageSize map directly onto common.PageRequest for backend list RPCs.
+type Pagination struct {
+	Page     int
+	PageSize int
+}
+
+// parsePagination reads page and pageSize using the shared api bounds
+func parsePagination(c *gin.Context) (Pagination, error) {
+	page, pageSize, err := api.ParsePagination(c)
+	if err != nil {
+		return Pagination{}, err
+	}
+	return Pagination{Page: page, PageSize: pageSize}, nil
+}
+
+// Offset returns the number of items to skip for the page
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.PageSize
+}
+
+// respondPage writes a paginated list. v2 uses the api.PaginatedResponse
+// envelope; v1 keeps its original {<key>: items, total} shape with the page
+// fields added.
+func respondPage[T any](c *gin.Context, key string, items []T, total int, p Pagination) {
+	if middleware.APIVersionFrom(c) != middleware.APIV1 {
+		api.RespondPaginated(c, items, total, p.Page, p.PageSize)
+		return
+	}
+
+	resp := api.NewPaginatedResponse(items, total, p.Page, p.PageSize)
+	c.JSON(http.StatusOK, gin.H{
+		key:        resp.Data,
+		"total":    resp.Total,
+		"page":     resp.Page,
+		"pageSize": resp.PageSize,
+	})
+}
+
+// pageFetcher calls a backend list RPC for one page, returning its items and
+// the page metadata of the response
+type pageFetcher[T any] func(ctx context.Context, page *commonpb.PageRequest) ([]T, *commonpb.PageResponse, error)
+
+// fetchAllPages reads every page of a backend list, pageSize items at a
+// time. It fails with FailedPrecondition once more than limit items exist,
+// and with DeadlineExceeded when all pages take longer than timeout, so a
+// huge list can neither exhaust memory nor hold the request indefinitely.
+func fetchAllPages[T any](ctx context.Context, fetch pageFetcher[T], pageSize, limit int, timeout time.Duration) ([]T, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var items []T
+	for page := int32(1); ; page++ {
+		got, meta, err := fetch(ctx, &commonpb.PageRequest{Page: page, PageSize: int32(pageSize)})
+		if err != nil {
+			return nil, err
+		}
+		if meta.GetTotal() > int64(limit) || len(items)+len(got) > limit {
+			return nil, status.Errorf(codes.FailedPrecondition, "list has more than %d items", limit)
+		}
+		items = append(items, got...)
+		if len(got) < pageSize || int64(len(items)) >= meta.GetTotal() {
+			return items, nil
+		}
+	}
+}
@@ -0,0 +1,530 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	datapb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/data"
+	positionpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/position"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+)
+
+// TargetWeight is one entry of a target portfolio
+type TargetWeight struct {
+	Code   string      `json:"code"`
+	Name   string      `json:"name,omitempty"`
+	Weight json.Number `json:"weight"`
+}
+
+// TargetPortfolio is the JSON shape of an account's target portfolio
+type TargetPortfolio struct {
+	AccountID   string         `json:"account_id"`
+	PortfolioID string         `json:"portfolio_id,omitempty"`
+	Date        string         `json:"date,omitempty"`
+	Version     int64          `json:"version"`
+	Weights     []TargetWeight `json:"weights"`
+	CashWeight  string         `json:"cash_weight"`
+	CreatedAt   string         `json:"created_at,omitempty"`
+}
+
+// SetTargetPortfolioRequest is the body of POST /portfolios/:account_id/target
+type SetTargetPortfolioRequest struct {
+	Date    string         `json:"date"` // YYYY-MM-DD, defaults to today on the position service
+	Weights []TargetWeight `json:"weights" binding:"required"`
+}
+
+// GetTargetPortfolio handles GET /api/v1/portfolios/:account_id/target
+// An optional ?date= (YYYY-MM-DD) selects a historical target.
+func (h *Handler) GetTargetPortfolio(c *gin.Context) {
+	accountID := c.Param("account_id")
+	if !authorizeAccount(c, accountID) {
+		return
+	}
+	date, _, err := parseDateQuery(c, "date")
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+
+	resp, err := h.positionClient.GetTargetPortfolio(c.Request.Context(), &positionpb.GetTargetPortfolioRequest{
+		AccountId: accountID,
+		Date:      date,
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, targetPortfolioJSON(accountID, resp.GetPortfolio()))
+}
+
+// SetTargetPortfolio handles POST /api/v1/portfolios/:account_id/target
+// Weights must be non-negative and sum to at most 1 (exactly 1 when
+// PORTFOLIO_REQUIRE_FULL_WEIGHT is set); the remainder is held as cash.
+// Every code must be known to the data service.
+func (h *Handler) SetTargetPortfolio(c *gin.Context) {
+	accountID := c.Param("account_id")
+	if !authorizeAccount(c, accountID) {
+		return
+	}
+
+	var req SetTargetPortfolioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	var date *commonpb.Date
+	if req.Date != "" {
+		t, err := time.Parse(time.DateOnly, req.Date)
+		if err != nil {
+			errs.Respond(c, http.StatusBadRequest, "invalid_argument", "date must be a date in YYYY-MM-DD format")
+			return
+		}
+		date = toDate(t)
+	}
+
+	weights, cash, err := validateWeights(req.Weights, h.cfg.Portfolio.RequireFullWeight)
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+
+	ok, err := h.checkCodes(c, req.Weights, date)
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	resp, err := h.positionClient.SetTargetPortfolio(c.Request.Context(), &positionpb.SetTargetPortfolioRequest{
+		AccountId:  accountID,
+		Date:       date,
+		Weights:    weights,
+		CashWeight: &commonpb.Decimal{Value: cash},
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, targetPortfolioJSON(accountID, resp.GetPortfolio()))
+}
+
+// tradeListPageSize is the page size used to read all of an account's positions
+const tradeListPageSize = 500
+
+// TradeItem is one order of a trade list
+type TradeItem struct {
+	Code            string `json:"code"`
+	Name            string `json:"name,omitempty"`
+	Side            string `json:"side"`
+	Quantity        string `json:"quantity"`
+	Price           string `json:"price"`
+	CurrentWeight   string `json:"current_weight"`
+	TargetWeight    string `json:"target_weight"`
+	WeightDiff      string `json:"weight_diff"`
+	EstimatedAmount string `json:"estimated_amount"`
+
+	amount float64
+}
+
+// TradeList is the set of orders that moves an account to its target
+type TradeList struct {
+	AccountID       string      `json:"account_id"`
+	Version         int64       `json:"version"`
+	TotalValue      string      `json:"total_value"`
+	BuyList         []TradeItem `json:"buy_list"`
+	SellList        []TradeItem `json:"sell_list"`
+	TotalBuyAmount  string      `json:"total_buy_amount"`
+	TotalSellAmount string      `json:"total_sell_amount"`
+	CashDiff        string      `json:"cash_diff"` // sells minus buys
+}
+
+// holding is the current and target state of one code
+type holding struct {
+	code         string
+	name         string
+	price        float64
+	value        float64
+	available    float64
+	targetWeight float64
+}
+
+// GetTradeList handles GET /api/v1/portfolios/:account_id/trades
+// The list is computed from the account's positions and its target
+// portfolio (?date= selects a historical target). Portfolio value defaults
+// to the market value of the positions; pass ?total_value= to include cash.
+func (h *Handler) GetTradeList(c *gin.Context) {
+	accountID := c.Param("account_id")
+	if !authorizeAccount(c, accountID) {
+		return
+	}
+	date, _, err := parseDateQuery(c, "date")
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	var totalValue float64
+	if v := c.Query("total_value"); v != "" {
+		totalValue, err = strconv.ParseFloat(v, 64)
+		if err != nil || totalValue <= 0 {
+			errs.Respond(c, http.StatusBadRequest, "invalid_argument", "total_value must be a positive number")
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	target, err := h.positionClient.GetTargetPortfolio(ctx, &positionpb.GetTargetPortfolioRequest{
+		AccountId: accountID,
+		Date:      date,
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+	portfolio := target.GetPortfolio()
+	if portfolio == nil || portfolio.GetVersion() == 0 {
+		errs.Respond(c, http.StatusNotFound, "not_found", "account has no target portfolio")
+		return
+	}
+
+	positions, err := h.listAllPositions(c, accountID)
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	holdings := make(map[string]*holding)
+	var held float64
+	for _, p := range positions {
+		code := p.GetSecurityId().GetCode()
+		value := parseDecimal(p.GetMarketValue())
+		quantity := parseDecimal(p.GetQuantity())
+		price := parseDecimal(p.GetLastPrice())
+		if price <= 0 && quantity > 0 {
+			price = value / quantity
+		}
+		holdings[code] = &holding{
+			code:      code,
+			name:      p.GetName(),
+			price:     price,
+			value:     value,
+			available: parseDecimal(p.GetAvailableQty()),
+		}
+		held += value
+	}
+
+	var unpriced []*commonpb.SecurityId
+	for _, w := range portfolio.GetWeights() {
+		code := w.GetSecurityId().GetCode()
+		hd, ok := holdings[code]
+		if !ok {
+			hd = &holding{code: code, name: w.GetName()}
+			holdings[code] = hd
+		}
+		hd.targetWeight = parseDecimal(w.GetWeight())
+		if hd.price <= 0 && hd.targetWeight > 0 {
+			unpriced = append(unpriced, w.GetSecurityId())
+		}
+	}
+
+	if totalValue == 0 {
+		totalValue = held
+	}
+	if totalValue <= 0 {
+		errs.Respond(c, http.StatusBadRequest, "invalid_argument", "total_value is required when the account holds no positions")
+		return
+	}
+
+	if len(unpriced) > 0 {
+		ok, err := h.priceHoldings(c, holdings, unpriced)
+		if err != nil {
+			h.respondRPCError(c, err)
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+
+	list := buildTradeList(holdings, totalValue, h.cfg.Portfolio.LotSize, h.cfg.Portfolio.MinTradeAmount)
+	list.AccountID = accountID
+	list.Version = portfolio.GetVersion()
+	c.JSON(http.StatusOK, list)
+}
+
+// listAllPositions reads every position of an account page by page, up to
+// the configured maximum
+func (h *Handler) listAllPositions(c *gin.Context, accountID string) ([]*positionpb.Position, error) {
+	fetch := func(ctx context.Context, page *commonpb.PageRequest) ([]*positionpb.Position, *commonpb.PageResponse, error) {
+		resp, err := h.positionClient.ListPositions(ctx, &positionpb.ListPositionsRequest{
+			AccountId: accountID,
+			Page:      page,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.GetPositions(), resp.GetPage(), nil
+	}
+	cfg := h.cfg.Portfolio
+	return fetchAllPages(c.Request.Context(), fetch, tradeListPageSize, cfg.MaxPositions,
+		time.Duration(cfg.FetchTimeoutMs)*time.Millisecond)
+}
+
+// priceHoldings fills in quotes for target codes the account does not hold,
+// writing a 422 naming the codes without a usable price
+func (h *Handler) priceHoldings(c *gin.Context, holdings map[string]*holding, ids []*commonpb.SecurityId) (bool, error) {
+	resp, err := h.dataClient.GetQuotes(c.Request.Context(), &datapb.GetQuotesRequest{SecurityIds: ids})
+	if err != nil {
+		return false, err
+	}
+	for _, q := range resp.GetQuotes() {
+		hd, ok := holdings[q.GetSecurityId().GetCode()]
+		if !ok {
+			continue
+		}
+		hd.price = parseDecimal(q.GetLastPrice())
+		if hd.price <= 0 {
+			hd.price = parseDecimal(q.GetPrevClose())
+		}
+	}
+
+	var missing []string
+	for _, id := range ids {
+		if holdings[id.GetCode()].price <= 0 {
+			missing = append(missing, id.GetCode())
+		}
+	}
+	if len(missing) > 0 {
+		body := errs.New(c, "failed_precondition", "no price available")
+		body.Details = gin.H{"codes": missing}
+		c.JSON(http.StatusUnprocessableEntity, body)
+		return false, nil
+	}
+	return true, nil
+}
+
+// buildTradeList computes the orders that move holdings to their target
+// weights of totalValue. Quantities are rounded down to whole lots, except
+// that a position leaving the target is sold in full, odd lot included;
+// sells never exceed the available quantity. Orders below minAmount are
+// dropped. Both lists are sorted by estimated amount, largest first.
+func buildTradeList(holdings map[string]*holding, totalValue float64, lotSize int, minAmount float64) TradeList {
+	lot := float64(lotSize)
+	list := TradeList{
+		TotalValue: formatFloat(totalValue, 2),
+		BuyList:    []TradeItem{},
+		SellList:   []TradeItem{},
+	}
+
+	var buys, sells float64
+	for _, hd := range holdings {
+		if hd.price <= 0 {
+			continue
+		}
+		diff := hd.targetWeight*totalValue - hd.value
+
+		var side string
+		var quantity float64
+		switch {
+		case diff > 0:
+			side = "buy"
+			quantity = math.Floor(diff/hd.price/lot) * lot
+		case diff < 0 && hd.targetWeight == 0:
+			side = "sell"
+			quantity = hd.available
+		case diff < 0:
+			side = "sell"
+			quantity = math.Min(math.Floor(-diff/hd.price/lot)*lot, hd.available)
+		}
+		amount := quantity * hd.price
+		if quantity <= 0 || amount < minAmount {
+			continue
+		}
+
+		currentWeight := hd.value / totalValue
+		item := TradeItem{
+			Code:            hd.code,
+			Name:            hd.name,
+			Side:            side,
+			Quantity:        formatFloat(quantity, 0),
+			Price:           formatFloat(hd.price, 4),
+			CurrentWeight:   formatFloat(currentWeight, 6),
+			TargetWeight:    formatFloat(hd.targetWeight, 6),
+			WeightDiff:      formatFloat(hd.targetWeight-currentWeight, 6),
+			EstimatedAmount: formatFloat(amount, 2),
+			amount:          amount,
+		}
+		if side == "buy" {
+			list.BuyList = append(list.BuyList, item)
+			buys += amount
+		} else {
+			list.SellList = append(list.SellList, item)
+			sells += amount
+		}
+	}
+
+	for _, items := range [][]TradeItem{list.BuyList, list.SellList} {
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].amount != items[j].amount {
+				return items[i].amount > items[j].amount
+			}
+			return items[i].Code < items[j].Code
+		})
+	}
+	list.TotalBuyAmount = formatFloat(buys, 2)
+	list.TotalSellAmount = formatFloat(sells, 2)
+	list.CashDiff = formatFloat(sells-buys, 2)
+	return list
+}
+
+// validateWeights checks target weights and converts them for the position
+// service, returning the implied cash weight. Weights are summed exactly so
+// that e.g. 0.1 + 0.2 + 0.7 is accepted as 1.
+func validateWeights(items []TargetWeight, requireFull bool) ([]*positionpb.PositionWeight, string, error) {
+	if len(items) == 0 {
+		return nil, "", api.Invalidf("at least one weight is required")
+	}
+
+	one := big.NewRat(1, 1)
+	sum := new(big.Rat)
+	seen := make(map[string]bool, len(items))
+	weights := make([]*positionpb.PositionWeight, 0, len(items))
+	for i, item := range items {
+		if item.Code == "" {
+			return nil, "", api.Invalidf("weights[%d]: code is required", i)
+		}
+		if seen[item.Code] {
+			return nil, "", api.Invalidf("weights[%d]: duplicate code %s", i, item.Code)
+		}
+		seen[item.Code] = true
+
+		w, ok := new(big.Rat).SetString(item.Weight.String())
+		if !ok {
+			return nil, "", api.Invalidf("weights[%d]: weight must be a number", i)
+		}
+		if w.Sign() < 0 {
+			return nil, "", api.Invalidf("weights[%d]: weight must not be negative", i)
+		}
+		sum.Add(sum, w)
+
+		weights = append(weights, &positionpb.PositionWeight{
+			SecurityId: &commonpb.SecurityId{Code: item.Code},
+			Weight:     &commonpb.Decimal{Value: trimZeros(w.FloatString(10))},
+		})
+	}
+
+	switch cmp := sum.Cmp(one); {
+	case cmp > 0:
+		return nil, "", api.Invalidf("weights sum to %s, more than 1", sum.FloatString(6))
+	case cmp < 0 && requireFull:
+		return nil, "", api.Invalidf("weights sum to %s, must sum to exactly 1", sum.FloatString(6))
+	}
+
+	cash := new(big.Rat).Sub(one, sum)
+	return weights, trimZeros(cash.FloatString(10)), nil
+}
+
+// checkCodes verifies that every code is in the data service's stock
+// universe for the date, writing a 400 naming the unknown codes otherwise
+func (h *Handler) checkCodes(c *gin.Context, items []TargetWeight, date *commonpb.Date) (bool, error) {
+	known, err := h.universeCodes(c.Request.Context(), datapb.StockUniverse_STOCK_UNIVERSE_ALL, date)
+	if err != nil {
+		return false, err
+	}
+
+	var unknown []string
+	for _, item := range items {
+		if !known[item.Code] {
+			unknown = append(unknown, item.Code)
+		}
+	}
+	if len(unknown) > 0 {
+		body := errs.New(c, "invalid_argument", "unknown codes")
+		body.Details = gin.H{"codes": unknown}
+		c.JSON(http.StatusBadRequest, body)
+		return false, nil
+	}
+	return true, nil
+}
+
+// universeCodes returns the member codes of a stock universe on the date
+func (h *Handler) universeCodes(ctx context.Context, universe datapb.StockUniverse, date *commonpb.Date) (map[string]bool, error) {
+	resp, err := h.dataClient.GetStockUniverse(ctx, &datapb.GetStockUniverseRequest{
+		Universe: universe,
+		Date:     date,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make(map[string]bool, len(resp.GetStocks()))
+	for _, s := range resp.GetStocks() {
+		codes[s.GetCode()] = true
+	}
+	return codes, nil
+}
+
+// targetPortfolioJSON converts a stored target portfolio; a missing target
+// renders as an empty, version 0 portfolio
+func targetPortfolioJSON(accountID string, p *positionpb.TargetPortfolio) TargetPortfolio {
+	out := TargetPortfolio{
+		AccountID:   accountID,
+		PortfolioID: p.GetPortfolioId(),
+		Date:        formatDate(p.GetDate()),
+		Version:     p.GetVersion(),
+		Weights:     make([]TargetWeight, 0, len(p.GetWeights())),
+		CashWeight:  decimalValue(p.GetCashWeight()),
+		CreatedAt:   formatTimestamp(p.GetCreatedAt()),
+	}
+	if p == nil {
+		out.CashWeight = "1"
+	}
+	for _, w := range p.GetWeights() {
+		out.Weights = append(out.Weights, TargetWeight{
+			Code:   w.GetSecurityId().GetCode(),
+			Name:   w.GetName(),
+			Weight: json.Number(decimalValue(w.GetWeight())),
+		})
+	}
+	return out
+}
+
+// parseDecimal reads a common.Decimal as a float, treating unset or
+// malformed values as 0
+func parseDecimal(d *commonpb.Decimal) float64 {
+	f, err := strconv.ParseFloat(d.GetValue(), 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
+
+// formatFloat renders f with prec decimals, trailing zeros removed
+func formatFloat(f float64, prec int) string {
+	s := trimZeros(strconv.FormatFloat(f, 'f', prec, 64))
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// trimZeros strips trailing fractional zeros from a decimal string
+func trimZeros(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
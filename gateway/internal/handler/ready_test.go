@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+type readyResponse struct {
+	Status      string          `json:"status"`
+	Unavailable []string        `json:"unavailable"`
+	Services    []BackendStatus `json:"services"`
+}
+
+func getReady(t *testing.T, r *gin.Engine) (int, readyResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ready?verbose=true", nil))
+	var resp readyResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %s: %v", w.Body, err)
+	}
+	return w.Code, resp
+}
+
+func TestReadyCheck(t *testing.T) {
+	th := newTestHandler(t)
+	th.serve(t, "account", func(*grpc.Server) {})
+	orderConn, order := th.serve(t, "order", func(*grpc.Server) {})
+
+	r := gin.New()
+	r.GET("/ready", th.ReadyCheck)
+
+	code, resp := getReady(t, r)
+	if code != http.StatusOK || resp.Status != "ready" || len(resp.Unavailable) != 0 {
+		t.Fatalf("healthy backends: %d %+v, want 200 ready", code, resp)
+	}
+	for _, s := range resp.Services {
+		if !s.Ready || s.State != "ready" {
+			t.Errorf("healthy service %+v, want ready", s)
+		}
+	}
+
+	order.Stop()
+	// Wait for the client to notice the closed connection
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for orderConn.GetState() == connectivity.Ready {
+		if !orderConn.WaitForStateChange(ctx, connectivity.Ready) {
+			t.Fatal("order connection still ready after the server stopped")
+		}
+	}
+
+	code, resp = getReady(t, r)
+	if code != http.StatusServiceUnavailable || resp.Status != "not_ready" {
+		t.Fatalf("stopped backend: %d %+v, want 503 not_ready", code, resp)
+	}
+	if len(resp.Unavailable) != 1 || resp.Unavailable[0] != "order" {
+		t.Errorf("unavailable = %v, want [order]", resp.Unavailable)
+	}
+	want := map[string]bool{"account": true, "order": false}
+	if len(resp.Services) != len(want) {
+		t.Fatalf("services = %+v, want account and order", resp.Services)
+	}
+	for _, s := range resp.Services {
+		if ready, ok := want[s.Service]; !ok || s.Ready != ready {
+			t.Errorf("service %+v, want ready %v", s, ready)
+		}
+		if s.Service == "order" && s.State != "transient_failure" {
+			t.Errorf("order state = %s, want transient_failure", s.State)
+		}
+	}
+}
@@ -0,0 +1,380 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	positionpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/position"
+	riskpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/risk"
+)
+
+// VaR parameters used for the portfolio risk summary
+const (
+	riskVaRMethod       = "HISTORICAL"
+	riskVaRHorizonDays  = 1
+	riskVaRLookbackDays = 250
+)
+
+// VaR is a portfolio value-at-risk estimate
+type VaR struct {
+	Method      string `json:"method"`
+	HorizonDays int    `json:"horizon_days"`
+	VaR95       string `json:"var_95"`
+	VaR99       string `json:"var_99"`
+	CVaR95      string `json:"cvar_95"`
+	CVaR99      string `json:"cvar_99"`
+}
+
+// Concentration summarises how concentrated a portfolio's weights are
+type Concentration struct {
+	Top1Weight  string `json:"top1_weight"`
+	Top5Weight  string `json:"top5_weight"`
+	Top10Weight string `json:"top10_weight"`
+	HHI         string `json:"hhi"` // Herfindahl-Hirschman index of the weights
+}
+
+// PortfolioRisk is the risk summary of an account
+type PortfolioRisk struct {
+	AccountID      string        `json:"account_id"`
+	AsOf           string        `json:"as_of,omitempty"`
+	PortfolioValue string        `json:"portfolio_value"`
+	Holdings       int           `json:"holdings"`
+	TotalRisk      string        `json:"total_risk"`
+	DailyVol       string        `json:"daily_vol"`
+	AnnualVol      string        `json:"annual_vol"`
+	Beta           *float64      `json:"beta"` // null when the risk model has no beta exposure
+	VaR            VaR           `json:"var"`
+	Concentration  Concentration `json:"concentration"`
+}
+
+// FactorRisk is one factor's contribution to portfolio risk
+type FactorRisk struct {
+	Factor          string `json:"factor"`
+	Exposure        string `json:"exposure"`
+	Contribution    string `json:"contribution"`
+	ContributionPct string `json:"contribution_pct"`
+}
+
+// PositionRisk is one position's contribution to portfolio risk
+type PositionRisk struct {
+	Code                 string `json:"code"`
+	Name                 string `json:"name,omitempty"`
+	Weight               string `json:"weight"`
+	MarginalContribution string `json:"marginal_contribution"`
+	Contribution         string `json:"contribution"`
+	ContributionPct      string `json:"contribution_pct"`
+}
+
+// RiskDecomposition splits an account's risk into factor and position
+// contributions
+type RiskDecomposition struct {
+	AccountID      string         `json:"account_id"`
+	AsOf           string         `json:"as_of,omitempty"`
+	TotalRisk      string         `json:"total_risk"`
+	SystematicRisk string         `json:"systematic_risk"`
+	SpecificRisk   string         `json:"specific_risk"`
+	TrackingError  string         `json:"tracking_error"`
+	Factors        []FactorRisk   `json:"factors"`
+	Positions      []PositionRisk `json:"positions"`
+}
+
+// riskHolding is one position weighted for the risk service
+type riskHolding struct {
+	id     *commonpb.SecurityId
+	weight float64
+	value  float64
+}
+
+// GetPortfolioRisk handles GET /api/v1/risk/portfolio/:account_id
+// It returns volatility, one-day historical VaR, beta and concentration for
+// the current positions, or for those held on ?as_of= (YYYY-MM-DD).
+func (h *Handler) GetPortfolioRisk(c *gin.Context) {
+	accountID := c.Param("account_id")
+	if !authorizeAccount(c, accountID) {
+		return
+	}
+	asOf, asOfTime, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	key := "portfolio|" + accountID + "|" + c.Query("as_of")
+	if cached, ok := h.riskCache.get(key); ok {
+		c.Header("X-Cache", "HIT")
+		c.JSON(http.StatusOK, cached)
+		return
+	}
+
+	holdings, ok := h.riskHoldings(c, accountID, asOf)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	weights, total := riskWeights(holdings)
+
+	risk, err := h.riskClient.CalculatePortfolioRisk(ctx, &riskpb.CalculatePortfolioRiskRequest{
+		Holdings: weights,
+		Date:     asOf,
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+	v, err := h.riskClient.CalculateVaR(ctx, &riskpb.CalculateVaRRequest{
+		Holdings:       weights,
+		PortfolioValue: &commonpb.Decimal{Value: formatFloat(total, 2)},
+		HorizonDays:    riskVaRHorizonDays,
+		Method:         riskVaRMethod,
+		LookbackDays:   riskVaRLookbackDays,
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+	exposure, err := h.riskClient.GetPortfolioExposure(ctx, &riskpb.GetPortfolioExposureRequest{
+		Holdings: weights,
+		Date:     asOf,
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	out := PortfolioRisk{
+		AccountID:      accountID,
+		AsOf:           c.Query("as_of"),
+		PortfolioValue: formatFloat(total, 2),
+		Holdings:       len(holdings),
+		TotalRisk:      decimalValue(risk.GetTotalRisk()),
+		DailyVol:       decimalValue(risk.GetDailyVol()),
+		AnnualVol:      decimalValue(risk.GetAnnualVol()),
+		Beta:           styleExposure(exposure.GetExposure(), riskpb.BarraFactor_BARRA_FACTOR_BETA),
+		VaR: VaR{
+			Method:      riskVaRMethod,
+			HorizonDays: riskVaRHorizonDays,
+			VaR95:       decimalValue(v.GetVar().GetVar_95()),
+			VaR99:       decimalValue(v.GetVar().GetVar_99()),
+			CVaR95:      decimalValue(v.GetVar().GetCvar_95()),
+			CVaR99:      decimalValue(v.GetVar().GetCvar_99()),
+		},
+		Concentration: concentration(holdings),
+	}
+	if m := v.GetVar().GetMethod(); m != "" {
+		out.VaR.Method = m
+	}
+
+	h.riskCache.set(key, out, h.riskCacheTTL(asOfTime))
+	c.Header("X-Cache", "MISS")
+	c.JSON(http.StatusOK, out)
+}
+
+// GetRiskDecomposition handles GET /api/v1/risk/decomposition/:account_id
+// It returns factor and per-position risk contributions for the current
+// positions, or for those held on ?as_of= (YYYY-MM-DD).
+func (h *Handler) GetRiskDecomposition(c *gin.Context) {
+	accountID := c.Param("account_id")
+	if !authorizeAccount(c, accountID) {
+		return
+	}
+	asOf, asOfTime, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	key := "decomposition|" + accountID + "|" + c.Query("as_of")
+	if cached, ok := h.riskCache.get(key); ok {
+		c.Header("X-Cache", "HIT")
+		c.JSON(http.StatusOK, cached)
+		return
+	}
+
+	holdings, ok := h.riskHoldings(c, accountID, asOf)
+	if !ok {
+		return
+	}
+	weights, _ := riskWeights(holdings)
+
+	resp, err := h.riskClient.DecomposeRisk(c.Request.Context(), &riskpb.DecomposeRiskRequest{
+		Holdings: weights,
+		Date:     asOf,
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+	d := resp.GetDecomposition()
+
+	out := RiskDecomposition{
+		AccountID:      accountID,
+		AsOf:           c.Query("as_of"),
+		TotalRisk:      decimalValue(d.GetTotalRisk()),
+		SystematicRisk: decimalValue(d.GetSystematicRisk()),
+		SpecificRisk:   decimalValue(d.GetSpecificRisk()),
+		TrackingError:  decimalValue(d.GetTrackingError()),
+		Factors:        make([]FactorRisk, 0, len(d.GetFactorContributions())),
+		Positions:      make([]PositionRisk, 0, len(d.GetTopContributors())),
+	}
+	for _, f := range d.GetFactorContributions() {
+		out.Factors = append(out.Factors, FactorRisk{
+			Factor:          f.GetFactorName(),
+			Exposure:        decimalValue(f.GetExposure()),
+			Contribution:    decimalValue(f.GetContribution()),
+			ContributionPct: decimalValue(f.GetContributionPct()),
+		})
+	}
+	for _, p := range d.GetTopContributors() {
+		out.Positions = append(out.Positions, PositionRisk{
+			Code:                 p.GetSecurityId().GetCode(),
+			Name:                 p.GetName(),
+			Weight:               decimalValue(p.GetWeight()),
+			MarginalContribution: decimalValue(p.GetMarginalContribution()),
+			Contribution:         decimalValue(p.GetContribution()),
+			ContributionPct:      decimalValue(p.GetContributionPct()),
+		})
+	}
+
+	h.riskCache.set(key, out, h.riskCacheTTL(asOfTime))
+	c.Header("X-Cache", "MISS")
+	c.JSON(http.StatusOK, out)
+}
+
+// parseAsOf reads the optional ?as_of= date, writing a 400 if it is
+// malformed or in the future
+func parseAsOf(c *gin.Context) (*commonpb.Date, time.Time, bool) {
+	asOf, t, err := parseDateQuery(c, "as_of")
+	if err != nil {
+		errs.BadRequest(c, err)
+		return nil, time.Time{}, false
+	}
+	if asOf != nil && t.After(time.Now()) {
+		errs.Respond(c, http.StatusBadRequest, "invalid_argument", "as_of must not be in the future")
+		return nil, time.Time{}, false
+	}
+	return asOf, t, true
+}
+
+// riskCacheTTL returns how long a risk response may be cached. Risk as of a
+// past date does not change intraday and is kept longer.
+func (h *Handler) riskCacheTTL(asOf time.Time) time.Duration {
+	today := time.Now().Format(time.DateOnly)
+	if !asOf.IsZero() && asOf.Format(time.DateOnly) < today {
+		return time.Duration(h.cfg.Risk.HistoricalCacheTTL) * time.Second
+	}
+	return time.Duration(h.cfg.Risk.CacheTTL) * time.Second
+}
+
+// riskHoldings loads the account's positions, from the position history when
+// asOf is set. It writes an error response and returns false when the
+// account holds nothing to assess.
+func (h *Handler) riskHoldings(c *gin.Context, accountID string, asOf *commonpb.Date) ([]riskHolding, bool) {
+	var holdings []riskHolding
+	if asOf != nil {
+		resp, err := h.positionClient.GetPositionHistory(c.Request.Context(), &positionpb.GetPositionHistoryRequest{
+			AccountId: accountID,
+			StartDate: asOf,
+			EndDate:   asOf,
+		})
+		if err != nil {
+			h.respondRPCError(c, err)
+			return nil, false
+		}
+		for _, s := range resp.GetSnapshots() {
+			holdings = append(holdings, riskHolding{
+				id:     s.GetSecurityId(),
+				weight: parseDecimal(s.GetWeight()),
+				value:  parseDecimal(s.GetMarketValue()),
+			})
+		}
+		if len(holdings) == 0 {
+			errs.Respond(c, http.StatusNotFound, "not_found", "no position history for account on as_of date")
+			return nil, false
+		}
+		return holdings, true
+	}
+
+	positions, err := h.listAllPositions(c, accountID)
+	if err != nil {
+		h.respondRPCError(c, err)
+		return nil, false
+	}
+	for _, p := range positions {
+		holdings = append(holdings, riskHolding{
+			id:    p.GetSecurityId(),
+			value: parseDecimal(p.GetMarketValue()),
+		})
+	}
+	if len(holdings) == 0 {
+		errs.Respond(c, http.StatusUnprocessableEntity, "failed_precondition", "account holds no positions")
+		return nil, false
+	}
+	return holdings, true
+}
+
+// riskWeights converts holdings for the risk service, filling in weights
+// from market value where they are missing, and returns the portfolio value
+func riskWeights(holdings []riskHolding) ([]*riskpb.PortfolioHolding, float64) {
+	var total float64
+	for _, hd := range holdings {
+		total += hd.value
+	}
+
+	weights := make([]*riskpb.PortfolioHolding, 0, len(holdings))
+	for i := range holdings {
+		if holdings[i].weight == 0 && total > 0 {
+			holdings[i].weight = holdings[i].value / total
+		}
+		weights = append(weights, &riskpb.PortfolioHolding{
+			SecurityId: holdings[i].id,
+			Weight:     &commonpb.Decimal{Value: formatFloat(holdings[i].weight, 8)},
+		})
+	}
+	return weights, total
+}
+
+// concentration computes top-N weights and the HHI of the holdings
+func concentration(holdings []riskHolding) Concentration {
+	weights := make([]float64, 0, len(holdings))
+	for _, hd := range holdings {
+		weights = append(weights, hd.weight)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(weights)))
+
+	var top1, top5, top10, hhi float64
+	for i, w := range weights {
+		if i < 1 {
+			top1 += w
+		}
+		if i < 5 {
+			top5 += w
+		}
+		if i < 10 {
+			top10 += w
+		}
+		hhi += w * w
+	}
+	return Concentration{
+		Top1Weight:  formatFloat(top1, 6),
+		Top5Weight:  formatFloat(top5, 6),
+		Top10Weight: formatFloat(top10, 6),
+		HHI:         formatFloat(hhi, 6),
+	}
+}
+
+// styleExposure looks up a Barra style factor in a portfolio exposure. The
+// risk service keys exposures by factor name, which may or may not carry the
+// enum prefix, so both forms are matched case-insensitively.
+func styleExposure(exposure *riskpb.PortfolioFactorExposure, factor riskpb.BarraFactor) *float64 {
+	name := enumName(factor.String(), "BARRA_FACTOR_")
+	for k, v := range exposure.GetStyleExposures() {
+		if strings.EqualFold(k, name) || strings.EqualFold(k, factor.String()) {
+			v := v
+			return &v
+		}
+	}
+	return nil
+}
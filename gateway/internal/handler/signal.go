@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	datapb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/data"
+	signalpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/signal"
+)
+
+// SignalEnvelope is the response shape shared by the signal endpoints
+type SignalEnvelope[T any] struct {
+	Universe string `json:"universe"`
+	Date     string `json:"date,omitempty"`
+	Count    int    `json:"count"`
+	Data     []T    `json:"data"`
+}
+
+// InstrumentTiming is a directional timing signal for one instrument
+type InstrumentTiming struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name,omitempty"`
+	Direction string  `json:"direction"` // long, short, or "" when flat
+	Strength  float64 `json:"strength"`
+	AsOf      string  `json:"as_of"`
+}
+
+// AlphaScore is one ranked alpha score
+type AlphaScore struct {
+	Rank            int                `json:"rank"`
+	Code            string             `json:"code"`
+	Score           float64            `json:"score"`
+	NormalizedScore float64            `json:"normalized_score"`
+	Percentile      float64            `json:"percentile"`
+	FactorScores    map[string]float64 `json:"factor_scores,omitempty"`
+}
+
+// signalQuery holds the parsed query of a signal request
+type signalQuery struct {
+	date     *commonpb.Date
+	label    string          // universe as echoed in the response
+	universe string          // universe name forwarded to the signal service
+	members  map[string]bool // codes results are restricted to
+	codeList bool            // universe was given as a list of codes
+	limit    int
+}
+
+// GetTimingSignal handles GET /api/v1/signals/timing
+// Query: ?universe= (a named universe such as CSI300, or comma-separated
+// codes; default ALL), ?date= (YYYY-MM-DD) and ?limit=. Signals are ordered
+// by strength, strongest first.
+func (h *Handler) GetTimingSignal(c *gin.Context) {
+	ctx, cancel := h.signalContext(c)
+	defer cancel()
+
+	q, ok := h.parseSignalQuery(ctx, c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.signalClient.GetTimingSignal(ctx, &signalpb.GetTimingSignalRequest{
+		Date:     q.date,
+		Universe: q.universe,
+		Limit:    q.backendLimit(),
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	signals := make([]InstrumentTiming, 0, len(resp.GetInstruments()))
+	for _, s := range resp.GetInstruments() {
+		code := s.GetSecurityId().GetCode()
+		if !q.members[code] {
+			continue
+		}
+		signals = append(signals, InstrumentTiming{
+			Code:      code,
+			Name:      s.GetName(),
+			Direction: enumName(s.GetDirection().String(), "DIRECTION_"),
+			Strength:  s.GetStrength(),
+			AsOf:      formatTimestamp(s.GetAsOf()),
+		})
+	}
+	sort.SliceStable(signals, func(i, j int) bool {
+		return signals[i].Strength > signals[j].Strength
+	})
+	if len(signals) > q.limit {
+		signals = signals[:q.limit]
+	}
+
+	date := formatDate(q.date)
+	if date == "" {
+		date = formatDate(resp.GetSignal().GetDate())
+	}
+	c.JSON(http.StatusOK, SignalEnvelope[InstrumentTiming]{
+		Universe: q.label,
+		Date:     date,
+		Count:    len(signals),
+		Data:     signals,
+	})
+}
+
+// GetAlphaSignal handles GET /api/v1/signals/alpha
+// It takes the same query as GetTimingSignal and returns alpha scores
+// ranked by normalized score, highest first.
+func (h *Handler) GetAlphaSignal(c *gin.Context) {
+	ctx, cancel := h.signalContext(c)
+	defer cancel()
+
+	q, ok := h.parseSignalQuery(ctx, c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.signalClient.GetAlphaSignal(ctx, &signalpb.GetAlphaSignalRequest{
+		Date:     q.date,
+		Universe: q.universe,
+		Limit:    q.backendLimit(),
+	})
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	scores := make([]AlphaScore, 0, len(resp.GetSignal().GetScores()))
+	for _, s := range resp.GetSignal().GetScores() {
+		code := s.GetSecurityId().GetCode()
+		if !q.members[code] {
+			continue
+		}
+		scores = append(scores, AlphaScore{
+			Code:            code,
+			Score:           s.GetRawScore(),
+			NormalizedScore: s.GetNormalizedScore(),
+			Percentile:      s.GetPercentile(),
+			FactorScores:    s.GetFactorScores(),
+		})
+	}
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].NormalizedScore > scores[j].NormalizedScore
+	})
+	if len(scores) > q.limit {
+		scores = scores[:q.limit]
+	}
+	for i := range scores {
+		scores[i].Rank = i + 1
+	}
+
+	date := formatDate(q.date)
+	if date == "" {
+		date = formatDate(resp.GetSignal().GetDate())
+	}
+	c.JSON(http.StatusOK, SignalEnvelope[AlphaScore]{
+		Universe: q.label,
+		Date:     date,
+		Count:    len(scores),
+		Data:     scores,
+	})
+}
+
+// signalContext bounds the backend calls of a signal request so that a slow
+// model surfaces as DeadlineExceeded, and hence a 504, instead of hanging
+func (h *Handler) signalContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), time.Duration(h.cfg.Signal.TimeoutMs)*time.Millisecond)
+}
+
+// parseSignalQuery reads date, limit and universe, validating the universe
+// against the data service. It writes an error response and returns false
+// on failure.
+func (h *Handler) parseSignalQuery(ctx context.Context, c *gin.Context) (signalQuery, bool) {
+	var q signalQuery
+	var err error
+
+	q.date, _, err = parseDateQuery(c, "date")
+	if err != nil {
+		errs.BadRequest(c, err)
+		return q, false
+	}
+
+	q.limit = h.cfg.Signal.DefaultLimit
+	if v := c.Query("limit"); v != "" {
+		q.limit, err = strconv.Atoi(v)
+		if err != nil || q.limit < 1 || q.limit > h.cfg.Signal.MaxLimit {
+			errs.Respond(c, http.StatusBadRequest, "invalid_argument", "limit must be between 1 and "+strconv.Itoa(h.cfg.Signal.MaxLimit))
+			return q, false
+		}
+	}
+
+	raw := strings.TrimSpace(c.DefaultQuery("universe", "ALL"))
+	if universe, ok := namedUniverse(raw); ok {
+		q.universe = strings.ToUpper(raw)
+		q.label = q.universe
+		q.members, err = h.universeCodes(ctx, universe, q.date)
+		if err != nil {
+			h.respondRPCError(c, err)
+			return q, false
+		}
+		return q, true
+	}
+
+	// Not a named universe: a comma-separated list of codes, each of which
+	// must be known to the data service
+	known, err := h.universeCodes(ctx, datapb.StockUniverse_STOCK_UNIVERSE_ALL, q.date)
+	if err != nil {
+		h.respondRPCError(c, err)
+		return q, false
+	}
+	q.label = raw
+	q.universe = "ALL"
+	q.codeList = true
+	q.members = make(map[string]bool)
+	var unknown []string
+	for _, code := range strings.Split(raw, ",") {
+		if code = strings.TrimSpace(code); code == "" {
+			continue
+		}
+		if !known[code] {
+			unknown = append(unknown, code)
+			continue
+		}
+		q.members[code] = true
+	}
+	if len(unknown) > 0 || len(q.members) == 0 {
+		body := errs.New(c, "invalid_argument", "universe must be a known universe name or a list of known codes")
+		body.Details = gin.H{"codes": unknown}
+		c.JSON(http.StatusBadRequest, body)
+		return q, false
+	}
+	return q, true
+}
+
+// backendLimit is the limit forwarded to the signal service. A code list is
+// filtered after the call, so the backend must return the whole universe.
+func (q signalQuery) backendLimit() int32 {
+	if q.codeList {
+		return 0
+	}
+	return int32(q.limit)
+}
+
+// namedUniverse maps a universe name such as "csi300" to the data service
+// enum; the unspecified and custom values are not selectable by name
+func namedUniverse(name string) (datapb.StockUniverse, bool) {
+	v, ok := datapb.StockUniverse_value["STOCK_UNIVERSE_"+strings.ToUpper(name)]
+	if !ok {
+		return 0, false
+	}
+	universe := datapb.StockUniverse(v)
+	switch universe {
+	case datapb.StockUniverse_STOCK_UNIVERSE_UNSPECIFIED, datapb.StockUniverse_STOCK_UNIVERSE_CUSTOM:
+		return 0, false
+	}
+	return universe, true
+}
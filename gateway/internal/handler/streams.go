@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// streamDrainTimeout bounds how long Close waits for streaming responses to
+// finish after they have been told to stop
+const streamDrainTimeout = 10 * time.Second
+
+// beginStream registers a long-lived streaming response. The returned
+// context is cancelled when the client goes away or the gateway shuts down;
+// the handler must stop writing when it is done and call the returned
+// function before returning.
+func (h *Handler) beginStream(c *gin.Context) (context.Context, func()) {
+	h.streams.Add(1)
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	go func() {
+		select {
+		case <-h.stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		cancel()
+		h.streams.Done()
+	}
+}
+
+// StopStreams tells every active streaming response to finish. It is safe
+// to call more than once; register it with http.Server.RegisterOnShutdown
+// so that Shutdown is not left waiting on open streams.
+func (h *Handler) StopStreams() {
+	h.stopOnce.Do(func() { close(h.stopping) })
+}
+
+// drainStreams stops active streams and waits up to streamDrainTimeout for
+// them to finish
+func (h *Handler) drainStreams() {
+	h.StopStreams()
+
+	done := make(chan struct{})
+	go func() {
+		h.streams.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(streamDrainTimeout):
+		h.logger.Warn("streams still open at shutdown", zap.Duration("waited", streamDrainTimeout))
+	}
+}
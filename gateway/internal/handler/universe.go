@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	commonpb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/common"
+	datapb "github.com/mellivora-tech/mellivora-mind-studio/gen/go/data"
+)
+
+// Instrument is one entry of the instrument directory
+type Instrument struct {
+	Code        string    `json:"code"`   // bare code, e.g. "600000"
+	Symbol      string    `json:"symbol"` // normalized code, e.g. "600000.SH"
+	Name        string    `json:"name"`
+	FullName    string    `json:"full_name,omitempty"`
+	Market      string    `json:"market"`
+	Exchange    string    `json:"exchange"`
+	Sector      *Industry `json:"sector,omitempty"`
+	ListDate    string    `json:"list_date,omitempty"`
+	DelistDate  string    `json:"delist_date,omitempty"`
+	IsST        bool      `json:"is_st"`
+	IsSuspended bool      `json:"is_suspended"`
+	Active      bool      `json:"active"`
+}
+
+// Industry is the level 1 industry of an instrument
+type Industry struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// universePage is a cached page of the instrument directory
+type universePage struct {
+	items []Instrument
+	total int
+}
+
+// ListUniverse handles GET /api/{v1,v2}/universe
+// Filters: market (a configured market suffix, e.g. SH), sector (level 1
+// industry code) and active (default true: listed and not suspended;
+// false includes delisted and suspended instruments). Pages are cached for
+// UNIVERSE_CACHE_TTL, since the directory changes rarely.
+func (h *Handler) ListUniverse(c *gin.Context) {
+	p, err := parsePagination(c)
+	if err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+
+	req := &datapb.ListStocksRequest{
+		IndustryCode: strings.TrimSpace(c.Query("sector")),
+		Page:         &commonpb.PageRequest{Page: int32(p.Page), PageSize: int32(p.PageSize)},
+	}
+	market := strings.ToUpper(strings.TrimSpace(c.Query("market")))
+	if market != "" {
+		rule, ok := h.marketRule(market)
+		if !ok {
+			errs.Respond(c, http.StatusBadRequest, "invalid_argument", "unknown market "+strconv.Quote(market))
+			return
+		}
+		req.Exchange = rule.exchange
+	}
+	active := true
+	if v := c.Query("active"); v != "" {
+		active, err = strconv.ParseBool(v)
+		if err != nil {
+			errs.Respond(c, http.StatusBadRequest, "invalid_argument", "active must be true or false")
+			return
+		}
+	}
+	req.IncludeDelisted = !active
+	req.IncludeSuspended = !active
+
+	ttl := time.Duration(h.cfg.Universe.CacheTTL) * time.Second
+	key := strings.Join([]string{market, req.IndustryCode, strconv.FormatBool(active), strconv.Itoa(p.Page), strconv.Itoa(p.PageSize)}, "|")
+	if cached, ok := h.universeCache.get(key); ok {
+		page := cached.(universePage)
+		c.Header("X-Cache", "HIT")
+		c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(ttl.Seconds())))
+		respondPage(c, "instruments", page.items, page.total, p)
+		return
+	}
+
+	resp, err := h.dataClient.ListStocks(c.Request.Context(), req)
+	if err != nil {
+		h.respondRPCError(c, err)
+		return
+	}
+
+	items := make([]Instrument, 0, len(resp.GetStocks()))
+	for _, s := range resp.GetStocks() {
+		items = append(items, h.toInstrument(s))
+	}
+	page := universePage{items: items, total: int(resp.GetPage().GetTotal())}
+
+	h.universeCache.set(key, page, ttl)
+	c.Header("X-Cache", "MISS")
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(ttl.Seconds())))
+	respondPage(c, "instruments", page.items, page.total, p)
+}
+
+// marketRule returns the code rule of a market suffix
+func (h *Handler) marketRule(suffix string) (codeRule, bool) {
+	for _, rule := range h.codeRules {
+		if rule.suffix == suffix {
+			return rule, true
+		}
+	}
+	return codeRule{}, false
+}
+
+// toInstrument converts a backend stock, deriving the market suffix from
+// the first configured market of its exchange
+func (h *Handler) toInstrument(s *datapb.StockInfo) Instrument {
+	id := s.GetSecurityId()
+	inst := Instrument{
+		Code:        id.GetCode(),
+		Symbol:      id.GetCode(),
+		Name:        s.GetName(),
+		FullName:    s.GetFullName(),
+		Exchange:    enumName(id.GetExchange().String(), "EXCHANGE_"),
+		ListDate:    formatDate(s.GetListDate()),
+		DelistDate:  formatDate(s.GetDelistDate()),
+		IsST:        s.GetIsSt(),
+		IsSuspended: s.GetIsSuspended(),
+		Active:      s.GetDelistDate() == nil && !s.GetIsSuspended(),
+	}
+	for _, rule := range h.codeRules {
+		if rule.exchange == id.GetExchange() {
+			inst.Market = rule.suffix
+			inst.Symbol = id.GetCode() + "." + rule.suffix
+			break
+		}
+	}
+	if ind := s.GetIndustry(); ind.GetLevel1Code() != "" {
+		inst.Sector = &Industry{Code: ind.GetLevel1Code(), Name: ind.GetLevel1Name()}
+	}
+	return inst
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+)
+
+// RoleAdmin is the role allowed to act on any account
+const RoleAdmin = auth.RoleAdmin
+
+// claimsKey is the gin context key for the verified token claims
+const claimsKey = "claims"
+
+// Claims are the gateway's JWT claims, shared with the services that
+// verify its tokens
+type Claims = auth.Claims
+
+// ClaimsFrom returns the verified claims of the request, or nil on routes
+// outside Auth
+func ClaimsFrom(c *gin.Context) *Claims {
+	if v, ok := c.Get(claimsKey); ok {
+		if claims, ok := v.(*Claims); ok {
+			return claims
+		}
+	}
+	return nil
+}
+
+// RequireAdmin returns a Gin middleware that answers 403 unless the token
+// carries the admin role. Register it after Auth.
+func (m *Middleware) RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if claims := ClaimsFrom(c); claims == nil || !claims.IsAdmin() {
+			errs.Abort(c, http.StatusForbidden, "permission_denied", "admin role required")
+			return
+		}
+		c.Next()
+	}
+}
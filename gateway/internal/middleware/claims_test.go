@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+)
+
+func TestAuthWithSigningKeys(t *testing.T) {
+	m := newTestMiddleware(t, &config.Config{Auth: config.AuthConfig{
+		TokenExpiry:  3600,
+		SigningKeys:  map[string]string{"2025-12": "old-secret", "2026-01": "current-secret"},
+		CurrentKeyID: "2026-01",
+	}})
+	current, err := m.SignToken(&Claims{UserID: "u1", TenantID: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A token signed before the rotation to 2026-01
+	old, err := auth.NewKeySet("", map[string]string{"2025-12": "old-secret"}, "2025-12").
+		Sign(&Claims{UserID: "u1", TenantID: "t1", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.DELETE("/admin/jwt-keys/:kid", m.RetireSigningKey)
+	r.Use(m.Auth())
+	r.GET("/me", func(c *gin.Context) {
+		claims := ClaimsFrom(c)
+		c.JSON(http.StatusOK, gin.H{"user": claims.UserID, "tenant": c.GetString("tenant_id")})
+	})
+
+	get := func(header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, token := range []string{current, old} {
+		if w := get("Bearer " + token); w.Code != http.StatusOK || w.Body.String() != `{"tenant":"t1","user":"u1"}` {
+			t.Fatalf("GET /me = %d %s, want u1 of t1", w.Code, w.Body)
+		}
+	}
+	for _, header := range []string{"", "Basic dTE6cw==", "Bearer not-a-token"} {
+		if w := get(header); w.Code != http.StatusUnauthorized {
+			t.Errorf("GET /me with %q = %d, want 401", header, w.Code)
+		}
+	}
+
+	retire := func(kid string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/jwt-keys/"+kid, nil))
+		return w.Code
+	}
+	if code := retire("2026-01"); code != http.StatusConflict {
+		t.Errorf("retire the current key = %d, want 409", code)
+	}
+	if code := retire("2024-01"); code != http.StatusNotFound {
+		t.Errorf("retire an unknown key = %d, want 404", code)
+	}
+	if code := retire("2025-12"); code != http.StatusNoContent {
+		t.Fatalf("retire 2025-12 = %d, want 204", code)
+	}
+	if w := get("Bearer " + old); w.Code != http.StatusUnauthorized {
+		t.Errorf("GET /me with a retired key = %d, want 401", w.Code)
+	}
+	if w := get("Bearer " + current); w.Code != http.StatusOK {
+		t.Errorf("GET /me with the current key = %d, want 200", w.Code)
+	}
+}
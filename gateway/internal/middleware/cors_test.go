@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+)
+
+func TestCORS(t *testing.T) {
+	allowlist := config.CORSConfig{AllowedOrigins: []string{"https://studio.example.com"}, AllowCredentials: true}
+	wildcard := config.CORSConfig{AllowedOrigins: []string{"*"}}
+
+	tests := []struct {
+		name            string
+		cors            config.CORSConfig
+		origin          string
+		wantOrigin      string
+		wantVary        string
+		wantCredentials string
+	}{
+		{"allowed origin", allowlist, "https://studio.example.com", "https://studio.example.com", "Origin", "true"},
+		{"allowed origin in another case", allowlist, "https://Studio.Example.com", "https://Studio.Example.com", "Origin", "true"},
+		{"disallowed origin", allowlist, "https://evil.example.com", "", "Origin", ""},
+		{"no origin", allowlist, "", "", "Origin", ""},
+		{"wildcard", wildcard, "https://any.example.com", "*", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestMiddleware(t, &config.Config{CORS: tt.cors})
+			r := gin.New()
+			r.Use(m.CORS())
+			r.GET("/x", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for _, method := range []string{http.MethodGet, http.MethodOptions} {
+				req := httptest.NewRequest(method, "/x", nil)
+				if tt.origin != "" {
+					req.Header.Set("Origin", tt.origin)
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				if method == http.MethodOptions && w.Code != http.StatusNoContent {
+					t.Errorf("preflight status = %d, want 204", w.Code)
+				}
+				h := w.Header()
+				if got := h.Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+					t.Errorf("%s Access-Control-Allow-Origin = %q, want %q", method, got, tt.wantOrigin)
+				}
+				if got := h.Get("Vary"); got != tt.wantVary {
+					t.Errorf("%s Vary = %q, want %q", method, got, tt.wantVary)
+				}
+				if got := h.Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+					t.Errorf("%s Access-Control-Allow-Credentials = %q, want %q", method, got, tt.wantCredentials)
+				}
+			}
+		})
+	}
+}
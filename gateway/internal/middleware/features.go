@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	"go.uber.org/zap"
+)
+
+// FeatureFlag is the runtime state of a feature flag. A flag is on for
+// every tenant when Enabled, otherwise only for the listed tenants.
+type FeatureFlag struct {
+	Name    string   `json:"name"`
+	Enabled bool     `json:"enabled"`
+	Tenants []string `json:"tenants"`
+}
+
+// enabledFor reports whether the flag is on for a tenant
+func (f FeatureFlag) enabledFor(tenantID string) bool {
+	if f.Enabled {
+		return true
+	}
+	for _, t := range f.Tenants {
+		if t == tenantID {
+			return true
+		}
+	}
+	return false
+}
+
+// flagStore holds feature flags, seeded from config and changed at runtime
+// through the admin endpoints
+type flagStore struct {
+	mu    sync.RWMutex
+	flags map[string]FeatureFlag
+}
+
+func newFlagStore(features map[string]config.FeatureFlagConfig) *flagStore {
+	fs := &flagStore{flags: make(map[string]FeatureFlag, len(features))}
+	for name, f := range features {
+		fs.flags[name] = FeatureFlag{Name: name, Enabled: f.Enabled, Tenants: f.Tenants}
+	}
+	return fs
+}
+
+// FlagEnabled reports whether a feature flag is on for a tenant. Unknown
+// flags are off.
+func (m *Middleware) FlagEnabled(name, tenantID string) bool {
+	m.flags.mu.RLock()
+	defer m.flags.mu.RUnlock()
+
+	flag, ok := m.flags.flags[name]
+	return ok && flag.enabledFor(tenantID)
+}
+
+// RequireFlag returns a Gin middleware that answers 404 unless the feature
+// flag is on for the requesting tenant, so a dark endpoint looks like it
+// does not exist. The tenant comes from the token claims, so register it
+// after Auth.
+func (m *Middleware) RequireFlag(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := ""
+		if claims := ClaimsFrom(c); claims != nil {
+			tenantID = claims.TenantID
+		}
+		if !m.FlagEnabled(name, tenantID) {
+			errs.Abort(c, http.StatusNotFound, "not_found", "not found")
+			return
+		}
+		c.Next()
+	}
+}
+
+// ListFlags handles GET /admin/flags
+func (m *Middleware) ListFlags(c *gin.Context) {
+	m.flags.mu.RLock()
+	flags := make([]FeatureFlag, 0, len(m.flags.flags))
+	for _, f := range m.flags.flags {
+		flags = append(flags, f)
+	}
+	m.flags.mu.RUnlock()
+
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	c.JSON(http.StatusOK, gin.H{"flags": flags})
+}
+
+// PutFlag handles PUT /admin/flags/:name
+func (m *Middleware) PutFlag(c *gin.Context) {
+	var flag FeatureFlag
+	if err := c.ShouldBindJSON(&flag); err != nil {
+		errs.BadRequest(c, err)
+		return
+	}
+	flag.Name = c.Param("name")
+	if flag.Tenants == nil {
+		flag.Tenants = []string{}
+	}
+
+	m.flags.mu.Lock()
+	m.flags.flags[flag.Name] = flag
+	m.flags.mu.Unlock()
+
+	m.logger.Info("feature flag updated",
+		zap.String("flag", flag.Name),
+		zap.Bool("enabled", flag.Enabled),
+		zap.Strings("tenants", flag.Tenants),
+		zap.String("request_id", c.GetString("request_id")),
+	)
+
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFlag handles DELETE /admin/flags/:name, turning the flag off for
+// every tenant
+func (m *Middleware) DeleteFlag(c *gin.Context) {
+	name := c.Param("name")
+
+	m.flags.mu.Lock()
+	_, ok := m.flags.flags[name]
+	delete(m.flags.flags, name)
+	m.flags.mu.Unlock()
+
+	if !ok {
+		errs.Respond(c, http.StatusNotFound, "not_found", "feature flag not found")
+		return
+	}
+	m.logger.Info("feature flag removed",
+		zap.String("flag", name),
+		zap.String("request_id", c.GetString("request_id")),
+	)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+)
+
+// newIPFilterRouter serves GET /x behind an IPFilter of rules, honoring
+// X-Forwarded-For from trustedProxies
+func newIPFilterRouter(t *testing.T, rules config.IPRules, trustedProxies []string) *gin.Engine {
+	t.Helper()
+	m := newTestMiddleware(t, &config.Config{})
+	r := gin.New()
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		t.Fatal(err)
+	}
+	r.Use(m.IPFilter(rules))
+	r.GET("/x", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestIPFilter(t *testing.T) {
+	office := config.IPRules{
+		Allow: []string{"10.1.0.0/16", "2001:db8:1::/48"},
+		Deny:  []string{"10.1.9.0/24", "2001:db8:1:9::/64", "10.1.2.3"},
+	}
+	blocklist := config.IPRules{Deny: []string{"203.0.113.0/24", "2001:db8:bad::/48"}}
+
+	tests := []struct {
+		name       string
+		rules      config.IPRules
+		remoteAddr string
+		wantCode   int
+	}{
+		{"ipv4 in allow list", office, "10.1.4.5:1234", http.StatusOK},
+		{"ipv4 outside allow list", office, "10.2.4.5:1234", http.StatusForbidden},
+		{"ipv4 deny wins over allow", office, "10.1.9.5:1234", http.StatusForbidden},
+		{"bare ipv4 denied", office, "10.1.2.3:1234", http.StatusForbidden},
+		{"bare ipv4 neighbor allowed", office, "10.1.2.4:1234", http.StatusOK},
+		{"ipv4-mapped ipv6 in allow list", office, "[::ffff:10.1.4.5]:1234", http.StatusOK},
+		{"ipv6 in allow list", office, "[2001:db8:1:4::5]:1234", http.StatusOK},
+		{"ipv6 outside allow list", office, "[2001:db8:2::5]:1234", http.StatusForbidden},
+		{"ipv6 deny wins over allow", office, "[2001:db8:1:9::5]:1234", http.StatusForbidden},
+		{"ipv4 on blocklist", blocklist, "203.0.113.7:1234", http.StatusForbidden},
+		{"ipv4 off blocklist", blocklist, "198.51.100.7:1234", http.StatusOK},
+		{"ipv6 on blocklist", blocklist, "[2001:db8:bad::1]:1234", http.StatusForbidden},
+		{"ipv6 off blocklist", blocklist, "[2001:db8:900d::1]:1234", http.StatusOK},
+		{"no rules", config.IPRules{}, "203.0.113.7:1234", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newIPFilterRouter(t, tt.rules, nil)
+			req := httptest.NewRequest(http.MethodGet, "/x", nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Errorf("GET from %s = %d, want %d", tt.remoteAddr, w.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestIPFilterForwardedFor(t *testing.T) {
+	rules := config.IPRules{Allow: []string{"198.51.100.0/24", "2001:db8:1::/48"}}
+	proxies := []string{"10.0.0.0/8", "fd00::/8"}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor string
+		wantCode     int
+	}{
+		{"trusted ipv4 proxy forwards an allowed client", "10.0.0.1:1234", "198.51.100.7", http.StatusOK},
+		{"trusted ipv4 proxy forwards a blocked client", "10.0.0.1:1234", "203.0.113.7", http.StatusForbidden},
+		{"trusted ipv6 proxy forwards an allowed client", "[fd00::1]:1234", "2001:db8:1::7", http.StatusOK},
+		{"trusted ipv6 proxy forwards a blocked client", "[fd00::1]:1234", "2001:db8:2::7", http.StatusForbidden},
+		{"untrusted peer cannot spoof the header", "203.0.113.7:1234", "198.51.100.7", http.StatusForbidden},
+		{"allowed peer without a proxy", "198.51.100.7:1234", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newIPFilterRouter(t, rules, proxies)
+			req := httptest.NewRequest(http.MethodGet, "/x", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Errorf("GET from %s for %q = %d, want %d", tt.remoteAddr, tt.forwardedFor, w.Code, tt.wantCode)
+			}
+		})
+	}
+}
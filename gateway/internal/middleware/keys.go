@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+	"go.uber.org/zap"
+)
+
+// SignToken mints an HS256 JWT for the claims, signed with the current key,
+// or with the legacy secret and no kid when no key set is configured. Claims
+// without exp expire after the configured token expiry.
+func (m *Middleware) SignToken(claims *Claims) (string, error) {
+	if claims.ExpiresAt == 0 {
+		withExp := *claims
+		withExp.ExpiresAt = time.Now().Add(time.Duration(m.cfg.Auth.TokenExpiry) * time.Second).Unix()
+		claims = &withExp
+	}
+	return m.keys.Sign(claims)
+}
+
+// ListSigningKeys handles GET /admin/jwt-keys. Secrets are never returned.
+func (m *Middleware) ListSigningKeys(c *gin.Context) {
+	kids, current := m.keys.KeyIDs()
+	c.JSON(http.StatusOK, gin.H{
+		"current": current,
+		"keys":    kids,
+	})
+}
+
+// RetireSigningKey handles DELETE /admin/jwt-keys/:kid. Tokens signed with a
+// retired key are rejected from then on, so retire a kid only after the
+// tokens it signed have expired. The current key cannot be retired. The
+// change lasts until restart; set JWT_RETIRED_KIDS to make it permanent.
+func (m *Middleware) RetireSigningKey(c *gin.Context) {
+	kid := c.Param("kid")
+
+	switch err := m.keys.Retire(kid); {
+	case errors.Is(err, auth.ErrUnknownKey):
+		errs.Respond(c, http.StatusNotFound, "not_found", "signing key not found")
+		return
+	case errors.Is(err, auth.ErrCurrentKey):
+		errs.Respond(c, http.StatusConflict, "failed_precondition", "the current signing key cannot be retired")
+		return
+	}
+
+	m.logger.Info("jwt signing key retired",
+		zap.String("kid", kid),
+		zap.String("request_id", c.GetString("request_id")),
+	)
+	c.Status(http.StatusNoContent)
+}
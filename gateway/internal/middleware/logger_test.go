@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLoggerLevels(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	m := newTestMiddleware(t, &config.Config{Log: config.LogConfig{SlowRequestMs: 1000, SampleRate: 1}})
+	m.logger = zap.New(core)
+
+	r := gin.New()
+	r.Use(m.Logger())
+	r.GET("/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/bad", func(c *gin.Context) { c.Status(http.StatusBadRequest) })
+	r.GET("/missing", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	r.GET("/broken", func(c *gin.Context) { c.Status(http.StatusBadGateway) })
+
+	tests := []struct {
+		path string
+		want zapcore.Level
+	}{
+		{"/ok", zap.InfoLevel},
+		{"/bad", zap.ErrorLevel},
+		{"/missing", zap.ErrorLevel},
+		{"/broken", zap.ErrorLevel},
+	}
+	for _, tt := range tests {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		entries := logs.TakeAll()
+		if len(entries) != 1 || entries[0].Level != tt.want {
+			t.Errorf("GET %s logged %v, want one entry at %s", tt.path, entries, tt.want)
+		}
+	}
+}
+
+func TestLoggerBackendTimings(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	m := newTestMiddleware(t, &config.Config{Log: config.LogConfig{SlowRequestMs: 1000, SampleRate: 1}})
+	m.logger = zap.New(core)
+
+	r := gin.New()
+	r.Use(m.Logger())
+	r.GET("/risk", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		RecordBackendTiming(ctx, "/risk.RiskService/CalculateVaR", 20*time.Millisecond)
+		RecordBackendTiming(ctx, "/risk.RiskService/CalculateVaR", 5*time.Millisecond)
+		RecordBackendTiming(ctx, "/position.PositionService/GetPositions", 10*time.Millisecond)
+		c.Status(http.StatusBadGateway)
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/risk", nil))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	var timings map[string]time.Duration
+	for _, f := range entries[0].Context {
+		if f.Key == "backend_timings" {
+			timings, _ = f.Interface.(map[string]time.Duration)
+		}
+	}
+	if timings == nil {
+		t.Fatalf("entry has no backend_timings: %v", entries[0].ContextMap())
+	}
+	want := map[string]time.Duration{
+		"/risk.RiskService/CalculateVaR":         25 * time.Millisecond,
+		"/position.PositionService/GetPositions": 10 * time.Millisecond,
+	}
+	for call, d := range want {
+		if got := timings[call]; got != d {
+			t.Errorf("backend_timings[%s] = %v, want %v", call, got, d)
+		}
+	}
+
+	// Outside a logged request there is nowhere to record to
+	RecordBackendTiming(httptest.NewRequest(http.MethodGet, "/", nil).Context(), "/x.X/Y", time.Second)
+}
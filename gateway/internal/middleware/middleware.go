@@ -1,58 +1,128 @@
 package middleware
 
 import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mellivora-mind/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
 	"go.uber.org/zap"
 	"golang.org/x/time/rate"
 )
 
+// backendTimingsKey is the request context key for per-request backend
+// call timings
+type backendTimingsKey struct{}
+
+// backendTimings accumulates the time a request spends in each backend
+// call. Handlers may call backends concurrently.
+type backendTimings struct {
+	mu     sync.Mutex
+	byCall map[string]time.Duration
+}
+
 // Middleware holds all middleware dependencies
 type Middleware struct {
 	cfg     *config.Config
 	logger  *zap.Logger
 	limiter *rateLimiter
+
+	// logCounter drives access log sampling
+	logCounter atomic.Uint64
+
+	// maintenance holds the current maintenance mode state
+	maintenance atomic.Pointer[MaintenanceState]
+
+	// flags holds the feature flags
+	flags *flagStore
+
+	// keys holds the JWT signing keys, and verifier checks tokens against them
+	keys     *auth.KeySet
+	verifier *auth.Verifier
+
+	// stop ends the rate limiter janitor
+	stop     chan struct{}
+	stopOnce sync.Once
 }
 
 // rateLimiter implements per-IP rate limiting
 type rateLimiter struct {
 	mu       sync.Mutex
-	limiters map[string]*rate.Limiter
+	limiters map[string]*limiterEntry
 	rps      int
 	burst    int
+	now      func() time.Time // the clock; replaced in tests
+}
+
+// limiterEntry is the limiter of one key and when it was last used
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
 }
 
 // New creates a new Middleware instance
 func New(cfg *config.Config, logger *zap.Logger) *Middleware {
-	return &Middleware{
+	m := &Middleware{
 		cfg:    cfg,
 		logger: logger,
 		limiter: &rateLimiter{
-			limiters: make(map[string]*rate.Limiter),
+			limiters: make(map[string]*limiterEntry),
 			rps:      cfg.RateLimit.RequestsPerSec,
 			burst:    cfg.RateLimit.BurstSize,
+			now:      time.Now,
 		},
+		flags: newFlagStore(cfg.Features),
+		keys:  auth.NewKeySet(cfg.Auth.JWTSecret, cfg.Auth.SigningKeys, cfg.Auth.CurrentKeyID),
+		stop:  make(chan struct{}),
 	}
+	m.verifier = auth.NewVerifier(m.keys, time.Duration(cfg.Auth.TokenExpiry)*time.Second)
+	m.SetMaintenance(MaintenanceState{
+		Enabled:    cfg.Maintenance.Enabled,
+		BlockReads: cfg.Maintenance.BlockReads,
+		RetryAfter: cfg.Maintenance.RetryAfter,
+	})
+	go m.evictIdleLimiters(time.Duration(cfg.RateLimit.IdleTTL) * time.Second)
+	return m
 }
 
-// Logger returns a Gin middleware for logging requests
+// Stop ends the background work started by New
+func (m *Middleware) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// Logger returns a Gin middleware for logging requests.
+// Error statuses (4xx and 5xx) always log at error, requests slower than the
+// configured threshold log at warn, and other non-2xx responses always log
+// at info.
+// Successful requests are sampled: 1 in Log.SampleRate logs at info and the
+// rest at debug.
 func (m *Middleware) Logger() gin.HandlerFunc {
+	slowThreshold := time.Duration(m.cfg.Log.SlowRequestMs) * time.Millisecond
+
 	return func(c *gin.Context) {
 		start := time.Now()
 		path := c.Request.URL.Path
 		query := c.Request.URL.RawQuery
+		timings := &backendTimings{}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), backendTimingsKey{}, timings))
 
 		c.Next()
 
 		latency := time.Since(start)
 		status := c.Writer.Status()
+		slow := slowThreshold > 0 && latency >= slowThreshold
 
-		m.logger.Info("request",
+		fields := []zap.Field{
 			zap.String("method", c.Request.Method),
 			zap.String("path", path),
 			zap.String("query", query),
@@ -60,8 +130,65 @@ func (m *Middleware) Logger() gin.HandlerFunc {
 			zap.Duration("latency", latency),
 			zap.String("ip", c.ClientIP()),
 			zap.String("user_agent", c.Request.UserAgent()),
-		)
+			zap.String("request_id", c.GetString("request_id")),
+		}
+		if slow || status >= http.StatusBadRequest {
+			fields = append(fields, zap.String("route", c.FullPath()))
+			if byCall := timings.snapshot(); len(byCall) > 0 {
+				fields = append(fields, zap.Any("backend_timings", byCall))
+			}
+		}
+
+		switch {
+		case status >= http.StatusBadRequest:
+			m.logger.Error("request", fields...)
+		case slow:
+			m.logger.Warn("slow request", fields...)
+		case status < http.StatusOK || status >= http.StatusMultipleChoices:
+			m.logger.Info("request", fields...)
+		case m.sampleAccessLog():
+			m.logger.Info("request", fields...)
+		default:
+			m.logger.Debug("request", fields...)
+		}
+	}
+}
+
+// RecordBackendTiming records how long a backend call took for the request
+// of ctx so slow-request logs can show where the time went. Calls made
+// outside a request logged by Logger are not recorded.
+func RecordBackendTiming(ctx context.Context, call string, d time.Duration) {
+	timings, ok := ctx.Value(backendTimingsKey{}).(*backendTimings)
+	if !ok {
+		return
 	}
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	if timings.byCall == nil {
+		timings.byCall = make(map[string]time.Duration)
+	}
+	timings.byCall[call] += d
+}
+
+// sampleAccessLog reports whether the current successful request should be logged
+func (m *Middleware) sampleAccessLog() bool {
+	rate := m.cfg.Log.SampleRate
+	if rate <= 1 {
+		return true
+	}
+	return m.logCounter.Add(1)%uint64(rate) == 1
+}
+
+// snapshot returns a copy of the recorded timings, so a call still running
+// past the response cannot change them while they are logged
+func (t *backendTimings) snapshot() map[string]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	byCall := make(map[string]time.Duration, len(t.byCall))
+	for call, d := range t.byCall {
+		byCall[call] = d
+	}
+	return byCall
 }
 
 // Recovery returns a Gin middleware for panic recovery
@@ -73,19 +200,30 @@ func (m *Middleware) Recovery() gin.HandlerFunc {
 					zap.Any("error", err),
 					zap.String("path", c.Request.URL.Path),
 				)
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"error": "internal server error",
-				})
+				errs.Abort(c, http.StatusInternalServerError, "internal", "internal server error")
 			}
 		}()
 		c.Next()
 	}
 }
 
-// CORS returns a Gin middleware for CORS
+// CORS returns a Gin middleware for CORS. Requests from origins outside
+// the allowlist get no Access-Control-Allow-Origin, so browsers refuse to
+// read the response.
 func (m *Middleware) CORS() gin.HandlerFunc {
+	cors := m.cfg.CORS
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
+		if cors.AllowsAny() {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Writer.Header().Add("Vary", "Origin")
+			if origin := c.GetHeader("Origin"); origin != "" && cors.Allows(origin) {
+				c.Header("Access-Control-Allow-Origin", origin)
+				if cors.AllowCredentials {
+					c.Header("Access-Control-Allow-Credentials", "true")
+				}
+			}
+		}
 		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH")
 		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Request-ID")
 		c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID")
@@ -105,40 +243,39 @@ func (m *Middleware) Auth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "authorization header required",
-			})
+			errs.Abort(c, http.StatusUnauthorized, "unauthenticated", "authorization header required")
 			return
 		}
 
 		parts := strings.SplitN(authHeader, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid authorization header format",
-			})
+			errs.Abort(c, http.StatusUnauthorized, "unauthenticated", "invalid authorization header format")
 			return
 		}
 
-		token := parts[1]
-		
-		// TODO: Validate JWT token
-		// For now, just check token is not empty
-		if token == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
-				"error": "invalid token",
-			})
+		claims, err := m.verifier.Verify(parts[1])
+		if err != nil {
+			m.logger.Debug("token rejected",
+				zap.Error(err),
+				zap.String("request_id", c.GetString("request_id")),
+			)
+			errs.Abort(c, http.StatusUnauthorized, "unauthenticated", "invalid or expired token")
 			return
 		}
 
 		// Set user info in context
-		// c.Set("user_id", claims.UserID)
-		// c.Set("tenant_id", claims.TenantID)
+		c.Set(claimsKey, claims)
+		c.Set("user_id", claims.UserID)
+		c.Set("tenant_id", claims.TenantID)
 
 		c.Next()
 	}
 }
 
-// RateLimit returns a Gin middleware for rate limiting
+// RateLimit returns a Gin middleware for rate limiting. Every response
+// carries X-RateLimit-Limit (the bucket size) and X-RateLimit-Remaining;
+// a 429 also carries Retry-After and retryAfterMs, the wait until the next
+// token is available.
 func (m *Middleware) RateLimit() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if !m.cfg.RateLimit.Enabled {
@@ -146,25 +283,50 @@ func (m *Middleware) RateLimit() gin.HandlerFunc {
 			return
 		}
 
-		ip := c.ClientIP()
-		limiter := m.limiter.getLimiter(ip)
+		key, rule := m.routeRateLimit(c)
+		limiter := m.limiter.getLimiter(key, rule)
 
-		if !limiter.Allow() {
-			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
-				"error": "rate limit exceeded",
-			})
+		now := m.limiter.now()
+		reservation := limiter.ReserveN(now, 1)
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rule.BurstSize))
+
+		if !reservation.OK() {
+			// A zero burst never admits a request, so there is no time to wait for
+			c.Header("X-RateLimit-Remaining", "0")
+			errs.Abort(c, http.StatusTooManyRequests, "resource_exhausted", "rate limit exceeded")
 			return
 		}
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			// Hand the token back: the request is rejected, not queued
+			reservation.CancelAt(now)
+			c.Header("X-RateLimit-Remaining", "0")
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			resp := errs.New(c, "resource_exhausted", "rate limit exceeded")
+			resp.Details = gin.H{"retryAfterMs": int64(math.Ceil(float64(delay) / float64(time.Millisecond)))}
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, resp)
+			return
+		}
+
+		remaining := int(math.Floor(limiter.TokensAt(now)))
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(remaining))
 
 		c.Next()
 	}
 }
 
-// RequestID adds a unique request ID to each request
+// maxRequestIDLength bounds inbound request IDs echoed back to the client
+const maxRequestIDLength = 128
+
+// RequestID adds a unique request ID to each request. An inbound
+// X-Request-ID is kept when it is a safe token, so callers can correlate
+// their own IDs; anything else is replaced.
 func (m *Middleware) RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
+		if !validRequestID(requestID) {
 			requestID = generateRequestID()
 		}
 		c.Set("request_id", requestID)
@@ -173,22 +335,48 @@ func (m *Middleware) RequestID() gin.HandlerFunc {
 	}
 }
 
-// getLimiter returns a rate limiter for the given key
-func (rl *rateLimiter) getLimiter(key string) *rate.Limiter {
+// getLimiter returns a rate limiter for the given key, creating it with the
+// rule's limits on first use
+func (rl *rateLimiter) getLimiter(key string, rule config.RateLimitRule) *rate.Limiter {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	if limiter, exists := rl.limiters[key]; exists {
-		return limiter
+	now := rl.now()
+	if entry, exists := rl.limiters[key]; exists {
+		entry.lastSeen = now
+		return entry.limiter
 	}
 
-	limiter := rate.NewLimiter(rate.Limit(rl.rps), rl.burst)
-	rl.limiters[key] = limiter
+	limiter := rate.NewLimiter(rate.Limit(rule.RequestsPerSec), rule.BurstSize)
+	rl.limiters[key] = &limiterEntry{limiter: limiter, lastSeen: now}
 	return limiter
 }
 
-// generateRequestID generates a unique request ID
+// generateRequestID generates a random (version 4) UUID
 func generateRequestID() string {
-	// Simple implementation - in production, use UUID
-	return time.Now().Format("20060102150405.000000")
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand only fails when the OS entropy source is unusable
+		panic(fmt.Sprintf("generate request id: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// validRequestID reports whether an inbound request ID is short and made of
+// letters, digits and "-._:" only, so it is safe to echo in headers and logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '.', r == '_', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
 }
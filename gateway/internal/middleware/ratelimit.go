@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+	"go.uber.org/zap"
+)
+
+// routeRateLimit returns the limiter key and limits for a request. Routes
+// with an override get a bucket of their own per client IP, so heavy
+// endpoints are throttled without using up the client's global budget.
+func (m *Middleware) routeRateLimit(c *gin.Context) (string, config.RateLimitRule) {
+	ip := c.ClientIP()
+	if key, rule, ok := m.routeLimit(c); ok {
+		return key + "|" + ip, rule
+	}
+	if routes := m.cfg.RateLimit.Routes; len(routes) > 0 {
+		if pattern := routePattern(c); pattern != "" {
+			if rule, ok := routes[pattern]; ok {
+				return pattern + "|" + ip, rule
+			}
+		}
+		if group := RouteGroup(c); group != "" {
+			if rule, ok := routes[group]; ok {
+				return group + "|" + ip, rule
+			}
+		}
+	}
+	return ip, config.RateLimitRule{RequestsPerSec: m.limiter.rps, BurstSize: m.limiter.burst}
+}
+
+// routeLimit returns the RouteLimits key and rule with the longest path
+// prefix matching the request, and ok=false when none matches
+func (m *Middleware) routeLimit(c *gin.Context) (key string, rule config.RateLimitRule, ok bool) {
+	path := c.Request.URL.Path
+	longest := -1
+	for k, r := range m.cfg.RateLimit.RouteLimits {
+		method, prefix, _ := strings.Cut(k, " ")
+		if method != c.Request.Method || len(prefix) <= longest || !hasPathPrefix(path, prefix) {
+			continue
+		}
+		key, rule, ok, longest = k, r, true, len(prefix)
+	}
+	return key, rule, ok
+}
+
+// hasPathPrefix reports whether path is prefix or lies below it, so
+// "/data/quotes" matches "/data/quotes/600519.SH" but not "/data/quotesx"
+func hasPathPrefix(path, prefix string) bool {
+	return prefix == "/" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// routePattern returns the matched route with any /api/vN prefix removed,
+// e.g. "/risk/decomposition/:account_id", so one override covers every
+// API version
+func routePattern(c *gin.Context) string {
+	path := c.FullPath()
+	parts := strings.SplitN(path, "/", 4)
+	if len(parts) == 4 && parts[0] == "" && parts[1] == "api" {
+		return "/" + parts[3]
+	}
+	return path
+}
+
+// LimiterState is a snapshot of one rate limiter
+type LimiterState struct {
+	Key      string    `json:"key"`
+	Tokens   float64   `json:"tokens"` // requests that may be made right now
+	Limit    float64   `json:"limit"`  // requests per second
+	Burst    int       `json:"burst"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// snapshot returns the state of every limiter, most recently used first
+func (rl *rateLimiter) snapshot() []LimiterState {
+	rl.mu.Lock()
+	states := make([]LimiterState, 0, len(rl.limiters))
+	for key, entry := range rl.limiters {
+		states = append(states, LimiterState{
+			Key:      key,
+			Tokens:   entry.limiter.Tokens(),
+			Limit:    float64(entry.limiter.Limit()),
+			Burst:    entry.limiter.Burst(),
+			LastSeen: entry.lastSeen,
+		})
+	}
+	rl.mu.Unlock()
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].LastSeen.After(states[j].LastSeen)
+	})
+	return states
+}
+
+// reset drops the limiter of a key, so its next request starts with a full
+// bucket. It reports whether the key had a limiter.
+func (rl *rateLimiter) reset(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	_, ok := rl.limiters[key]
+	delete(rl.limiters, key)
+	return ok
+}
+
+// evictIdleLimiters drops limiters unused for longer than ttl until Stop is
+// called. Every client IP gets a limiter, so without eviction the map grows
+// with each address ever seen. An evicted client starts over with a full
+// bucket, which an idle limiter would have refilled to anyway.
+func (m *Middleware) evictIdleLimiters(ttl time.Duration) {
+	ticker := time.NewTicker(ttl / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			if n := m.limiter.evictIdle(m.limiter.now().Add(-ttl)); n > 0 {
+				m.logger.Debug("idle rate limiters evicted", zap.Int("count", n))
+			}
+		}
+	}
+}
+
+// evictIdle drops the limiters last used before cutoff and returns how many
+// were dropped
+func (rl *rateLimiter) evictIdle(cutoff time.Time) int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	evicted := 0
+	for key, entry := range rl.limiters {
+		if entry.lastSeen.Before(cutoff) {
+			delete(rl.limiters, key)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// ListRateLimiters handles GET /admin/ratelimit
+func (m *Middleware) ListRateLimiters(c *gin.Context) {
+	limiters := m.limiter.snapshot()
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":  m.cfg.RateLimit.Enabled,
+		"limiters": limiters,
+		"count":    len(limiters),
+	})
+}
+
+// ResetRateLimiter handles DELETE /admin/ratelimit/*key. The key is a
+// wildcard because route override keys contain the route pattern.
+func (m *Middleware) ResetRateLimiter(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !m.limiter.reset(key) {
+		errs.Respond(c, http.StatusNotFound, "not_found", "rate limiter not found")
+		return
+	}
+
+	m.logger.Info("rate limiter reset",
+		zap.String("key", key),
+		zap.String("request_id", c.GetString("request_id")),
+	)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"go.uber.org/zap"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestMiddleware returns a Middleware for cfg that is stopped when the
+// test ends
+func newTestMiddleware(t *testing.T, cfg *config.Config) *Middleware {
+	t.Helper()
+	if cfg.RateLimit.IdleTTL == 0 {
+		cfg.RateLimit.IdleTTL = 600
+	}
+	m := New(cfg, zap.NewNop())
+	t.Cleanup(m.Stop)
+	return m
+}
+
+func TestRateLimitRouteLimits(t *testing.T) {
+	m := newTestMiddleware(t, &config.Config{RateLimit: config.RateLimitConfig{
+		Enabled:        true,
+		RequestsPerSec: 100,
+		BurstSize:      100,
+		RouteLimits: map[string]config.RateLimitRule{
+			"GET /api/v1/data/quotes": {RequestsPerSec: 1, BurstSize: 2},
+		},
+	}})
+
+	r := gin.New()
+	r.Use(m.RateLimit())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.GET("/api/v1/data/quotes", ok)
+	r.GET("/api/v1/data/quotes/:code", ok)
+	r.GET("/api/v1/accounts", ok)
+	r.POST("/api/v1/data/quotes", ok)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// The quotes bucket holds two requests, shared by paths below the prefix
+	for _, path := range []string{"/api/v1/data/quotes", "/api/v1/data/quotes/600519.SH"} {
+		if w := do(http.MethodGet, path); w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d, want 200", path, w.Code)
+		}
+	}
+	w := do(http.MethodGet, "/api/v1/data/quotes")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third quotes request = %d, want 429", w.Code)
+	}
+	if got := w.Header().Get("X-RateLimit-Limit"); got != "2" {
+		t.Errorf("X-RateLimit-Limit = %q, want the route burst 2", got)
+	}
+
+	// The same client still has its global budget on other routes and on
+	// other methods of the limited path
+	for i := 0; i < 5; i++ {
+		if w := do(http.MethodGet, "/api/v1/accounts"); w.Code != http.StatusOK {
+			t.Fatalf("accounts request %d = %d, want 200", i, w.Code)
+		}
+		if w := do(http.MethodPost, "/api/v1/data/quotes"); w.Code != http.StatusOK {
+			t.Fatalf("POST quotes request %d = %d, want 200", i, w.Code)
+		}
+	}
+}
+
+func TestRouteLimitLongestPrefix(t *testing.T) {
+	m := newTestMiddleware(t, &config.Config{RateLimit: config.RateLimitConfig{
+		RouteLimits: map[string]config.RateLimitRule{
+			"GET /api/v1/data":        {RequestsPerSec: 50, BurstSize: 50},
+			"GET /api/v1/data/quotes": {RequestsPerSec: 5, BurstSize: 5},
+		},
+	}})
+
+	tests := []struct {
+		path    string
+		wantKey string
+	}{
+		{"/api/v1/data/quotes", "GET /api/v1/data/quotes"},
+		{"/api/v1/data/quotes/600519.SH", "GET /api/v1/data/quotes"},
+		{"/api/v1/data/quotesx", "GET /api/v1/data"},
+		{"/api/v1/data/bars", "GET /api/v1/data"},
+		{"/api/v1/datasets", ""},
+	}
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, tt.path, nil)
+		key, _, ok := m.routeLimit(c)
+		if key != tt.wantKey || ok != (tt.wantKey != "") {
+			t.Errorf("routeLimit(%s) = %q, %v; want %q", tt.path, key, ok, tt.wantKey)
+		}
+	}
+}
+
+// fakeClock is a settable clock for rateLimiter.now
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestEvictIdle(t *testing.T) {
+	clock := &fakeClock{t: time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC)}
+	rl := &rateLimiter{limiters: make(map[string]*limiterEntry), now: clock.now}
+	rule := config.RateLimitRule{RequestsPerSec: 1, BurstSize: 2}
+	const ttl = 10 * time.Minute
+
+	idle := rl.getLimiter("192.0.2.1", rule)
+	idle.Allow()
+	idle.Allow()
+	rl.getLimiter("192.0.2.2", rule)
+	clock.advance(6 * time.Minute)
+	rl.getLimiter("192.0.2.2", rule) // used again, so still fresh
+	rl.getLimiter("192.0.2.3", rule)
+	clock.advance(5 * time.Minute)
+
+	if n := rl.evictIdle(clock.now().Add(-ttl)); n != 1 {
+		t.Fatalf("evictIdle evicted %d limiters, want 1", n)
+	}
+	if _, ok := rl.limiters["192.0.2.1"]; ok {
+		t.Error("the limiter idle for 11m was kept")
+	}
+	for _, key := range []string{"192.0.2.2", "192.0.2.3"} {
+		if _, ok := rl.limiters[key]; !ok {
+			t.Errorf("the limiter of %s, used 5m ago, was evicted", key)
+		}
+	}
+
+	// An evicted client starts over with a full bucket
+	if fresh := rl.getLimiter("192.0.2.1", rule); fresh == idle || fresh.TokensAt(clock.now()) != 2 {
+		t.Error("the evicted client did not get a new, full limiter")
+	}
+
+	// A limiter idle for exactly the ttl is kept until the next tick
+	clock.advance(ttl)
+	if n := rl.evictIdle(clock.now().Add(-ttl)); n != 2 {
+		t.Errorf("evictIdle evicted %d limiters, want the 2 idle for 15m", n)
+	}
+	if _, ok := rl.limiters["192.0.2.1"]; !ok {
+		t.Error("the limiter idle for exactly the ttl was evicted")
+	}
+	clock.advance(time.Nanosecond)
+	if n := rl.evictIdle(clock.now().Add(-ttl)); n != 1 || len(rl.limiters) != 0 {
+		t.Errorf("evictIdle evicted %d limiters and kept %d, want 1 and none", n, len(rl.limiters))
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+)
+
+var uuidV4 = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestGenerateRequestIDUnique(t *testing.T) {
+	const n = 10000
+	ids := make([]string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids[i] = generateRequestID()
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, n)
+	for _, id := range ids {
+		if !uuidV4.MatchString(id) {
+			t.Fatalf("request ID %q is not a version 4 UUID", id)
+		}
+		if seen[id] {
+			t.Fatalf("request ID %q generated twice", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestRequestIDInbound(t *testing.T) {
+	m := newTestMiddleware(t, &config.Config{})
+	r := gin.New()
+	r.Use(m.RequestID())
+	r.GET("/", func(c *gin.Context) { c.String(http.StatusOK, c.GetString("request_id")) })
+
+	tests := []struct {
+		name string
+		id   string
+		keep bool
+	}{
+		{"caller token", "client-42.retry_1:a", true},
+		{"longest allowed", strings.Repeat("a", maxRequestIDLength), true},
+		{"none", "", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+		{"space", "abc def", false},
+		{"header injection", "abc\r\nSet-Cookie: x=1", false},
+		{"log forging", "abc\nlevel=error", false},
+		{"quotes", `abc"def`, false},
+		{"non-ASCII", "请求-1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.id != "" {
+				req.Header["X-Request-Id"] = []string{tt.id}
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			got := w.Header().Get("X-Request-ID")
+			if got != w.Body.String() {
+				t.Fatalf("header %q and context %q request IDs differ", got, w.Body.String())
+			}
+			if tt.keep {
+				if got != tt.id {
+					t.Errorf("request ID = %q, want the inbound %q kept", got, tt.id)
+				}
+				return
+			}
+			if got == tt.id || !uuidV4.MatchString(got) {
+				t.Errorf("request ID = %q, want the inbound ID replaced by a generated one", got)
+			}
+		})
+	}
+}
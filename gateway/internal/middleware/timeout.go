@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/errs"
+)
+
+// Timeout returns a Gin middleware that gives each request a deadline, taken
+// from the route group's override or the default. Handlers derive their
+// backend call contexts from c.Request.Context(), so an exceeded deadline
+// surfaces as a 504; if a handler returns without writing anything after
+// the deadline passes, the middleware writes the 504 itself.
+func (m *Middleware) Timeout() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d := m.cfg.Timeout.For(RouteGroup(c))
+		if d <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			errs.Abort(c, http.StatusGatewayTimeout, "deadline_exceeded", "request timed out")
+		}
+	}
+}
+
+// RouteGroup returns the route group of the matched route: the first path
+// segment after the API version for /api/vN routes (e.g. "risk"), and the
+// first segment otherwise (e.g. "admin"). Unmatched routes have no group.
+func RouteGroup(c *gin.Context) string {
+	parts := strings.Split(strings.TrimPrefix(c.FullPath(), "/"), "/")
+	if len(parts) >= 3 && parts[0] == "api" {
+		return parts[2]
+	}
+	return parts[0]
+}
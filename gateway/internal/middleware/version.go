@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// API versions served by the gateway
+const (
+	APIV1 = "v1"
+	APIV2 = "v2"
+)
+
+// apiVersionKey is the gin context key for the API version of the route
+const apiVersionKey = "api_version"
+
+// APIVersion returns a Gin middleware that tags requests with the API version
+// of the route group, so shared handlers can pick the response shape
+func (m *Middleware) APIVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, version)
+		c.Next()
+	}
+}
+
+// APIVersionFrom returns the API version of the current request, defaulting
+// to the latest version for routes outside a versioned group
+func APIVersionFrom(c *gin.Context) string {
+	if version := c.GetString(apiVersionKey); version != "" {
+		return version
+	}
+	return APIV2
+}
+
+// Deprecated returns a Gin middleware that marks routes as deprecated.
+// Responses carry Deprecation, Sunset (RFC 8594) and a successor-version
+// Link header, and every use is logged so migration can be tracked.
+// It can be applied to a whole group or to a single route.
+func (m *Middleware) Deprecated(sunset string) gin.HandlerFunc {
+	sunsetHeader := ""
+	if t, err := time.Parse(time.DateOnly, sunset); err == nil {
+		sunsetHeader = t.UTC().Format(http.TimeFormat)
+	} else if sunset != "" {
+		m.logger.Warn("invalid sunset date", zap.String("sunset", sunset), zap.Error(err))
+	}
+
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		if sunsetHeader != "" {
+			c.Header("Sunset", sunsetHeader)
+		}
+		if successor := successorPath(c.Request.URL.Path); successor != "" {
+			c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+
+		m.logger.Info("deprecated endpoint used",
+			zap.String("method", c.Request.Method),
+			zap.String("route", c.FullPath()),
+			zap.String("ip", c.ClientIP()),
+			zap.String("user_agent", c.Request.UserAgent()),
+			zap.String("request_id", c.GetString("request_id")),
+		)
+
+		c.Next()
+	}
+}
+
+// successorPath maps a v1 path to its v2 equivalent
+func successorPath(path string) string {
+	if rest, ok := strings.CutPrefix(path, "/api/"+APIV1+"/"); ok {
+		return "/api/" + APIV2 + "/" + rest
+	}
+	return ""
+}
@@ -2,99 +2,156 @@ package router
 
 import (
 	"github.com/gin-gonic/gin"
-	"github.com/mellivora-mind/mellivora-mind-studio/gateway/internal/handler"
-	"github.com/mellivora-mind/mellivora-mind-studio/gateway/internal/middleware"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/handler"
+	"github.com/mellivora-tech/mellivora-mind-studio/gateway/internal/middleware"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
 	"go.uber.org/zap"
 )
 
 // New creates and configures the Gin router
-func New(h *handler.Handler, mw *middleware.Middleware, logger *zap.Logger) *gin.Engine {
+func New(cfg *config.Config, h *handler.Handler, mw *middleware.Middleware, logger *zap.Logger) *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 
 	r := gin.New()
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal("invalid trusted proxies", zap.Error(err))
+	}
 
 	// Global middleware
 	r.Use(mw.RequestID())
 	r.Use(mw.Logger())
 	r.Use(mw.Recovery())
+	r.Use(mw.IPFilter(cfg.IPFilter.Global))
 	r.Use(mw.CORS())
 	r.Use(mw.RateLimit())
+	r.Use(mw.Timeout())
 
 	// Health endpoints (no auth required)
 	r.GET("/health", h.HealthCheck)
 	r.GET("/ready", h.ReadyCheck)
+	r.GET("/version", h.Version)
 
-	// API v1
-	v1 := r.Group("/api/v1")
+	// Admin endpoints (outside maintenance mode so it can be switched off)
+	admin := r.Group("/admin")
+	admin.Use(mw.IPFilter(cfg.IPFilter.Admin), mw.Auth())
 	{
-		// Public endpoints (no auth)
-		public := v1.Group("")
+		admin.GET("/maintenance", mw.GetMaintenance)
+		admin.PUT("/maintenance", mw.RequireAdmin(), mw.PutMaintenance)
+
+		flags := admin.Group("/flags", mw.RequireAdmin())
 		{
-			// Data endpoints (some may be public)
-			data := public.Group("/data")
-			{
-				data.GET("/quotes/:code", h.GetQuote)
-				data.GET("/ohlcv/:code", h.GetOHLCV)
-			}
+			flags.GET("", mw.ListFlags)
+			flags.PUT("/:name", mw.PutFlag)
+			flags.DELETE("/:name", mw.DeleteFlag)
 		}
 
-		// Protected endpoints (auth required)
-		protected := v1.Group("")
-		protected.Use(mw.Auth())
+		ratelimit := admin.Group("/ratelimit", mw.RequireAdmin())
 		{
-			// Account endpoints
-			accounts := protected.Group("/accounts")
-			{
-				accounts.GET("", h.ListAccounts)
-				accounts.GET("/:id", h.GetAccount)
-				accounts.POST("", h.CreateAccount)
-			}
-
-			// Position endpoints
-			positions := protected.Group("/positions")
-			{
-				positions.GET("", h.ListPositions)
-			}
-
-			// Portfolio endpoints
-			portfolios := protected.Group("/portfolios")
-			{
-				portfolios.GET("/:account_id/target", h.GetTargetPortfolio)
-				portfolios.POST("/:account_id/target", h.SetTargetPortfolio)
-				portfolios.GET("/:account_id/trades", h.GetTradeList)
-			}
-
-			// Order endpoints
-			orders := protected.Group("/orders")
-			{
-				orders.GET("", h.ListOrders)
-				orders.GET("/:id", h.GetOrder)
-				orders.POST("", h.CreateOrder)
-				orders.POST("/:id/submit", h.SubmitOrder)
-				orders.POST("/:id/cancel", h.CancelOrder)
-			}
-
-			// Deal endpoints
-			deals := protected.Group("/deals")
-			{
-				deals.GET("", h.ListDeals)
-			}
-
-			// Risk endpoints
-			risk := protected.Group("/risk")
-			{
-				risk.GET("/portfolio/:account_id", h.GetPortfolioRisk)
-				risk.GET("/decomposition/:account_id", h.GetRiskDecomposition)
-			}
-
-			// Signal endpoints
-			signals := protected.Group("/signals")
-			{
-				signals.GET("/timing", h.GetTimingSignal)
-				signals.GET("/alpha", h.GetAlphaSignal)
-			}
+			ratelimit.GET("", mw.ListRateLimiters)
+			ratelimit.DELETE("/*key", mw.ResetRateLimiter)
 		}
+
+		keys := admin.Group("/jwt-keys", mw.RequireAdmin())
+		{
+			keys.GET("", mw.ListSigningKeys)
+			keys.DELETE("/:kid", mw.RetireSigningKey)
+		}
+	}
+
+	// API v1
+	v1 := r.Group("/api/v1")
+	v1.Use(mw.APIVersion(middleware.APIV1), mw.Maintenance())
+	if cfg.API.V1Sunset != "" {
+		v1.Use(mw.Deprecated(cfg.API.V1Sunset))
 	}
+	registerAPI(v1, h, mw)
+
+	// API v2
+	v2 := r.Group("/api/v2")
+	v2.Use(mw.APIVersion(middleware.APIV2), mw.Maintenance())
+	registerAPI(v2, h, mw)
+
+	api.HandleUnmatched(r)
+	h.SetDispatcher(r)
 
 	return r
 }
+
+// registerAPI registers the routes shared by every API version. Handlers
+// that differ between versions branch on middleware.APIVersionFrom.
+func registerAPI(api *gin.RouterGroup, h *handler.Handler, mw *middleware.Middleware) {
+	// Public endpoints (no auth)
+	public := api.Group("")
+	{
+		// Data endpoints (some may be public)
+		data := public.Group("/data")
+		{
+			data.GET("/quotes/:code", h.GetQuote)
+			data.GET("/ohlcv/:code", h.GetOHLCV)
+		}
+
+		// Instrument directory
+		public.GET("/universe", h.ListUniverse)
+	}
+
+	// Protected endpoints (auth required)
+	protected := api.Group("")
+	protected.Use(mw.Auth())
+	{
+		// Batch endpoint
+		protected.POST("/batch", mw.RequireFlag("batch"), h.Batch)
+
+		// Account endpoints
+		accounts := protected.Group("/accounts")
+		{
+			accounts.GET("", h.ListAccounts)
+			accounts.GET("/:id", h.GetAccount)
+			accounts.POST("", h.CreateAccount)
+		}
+
+		// Position endpoints
+		positions := protected.Group("/positions")
+		{
+			positions.GET("", h.ListPositions)
+		}
+
+		// Portfolio endpoints
+		portfolios := protected.Group("/portfolios")
+		{
+			portfolios.GET("/:account_id/target", h.GetTargetPortfolio)
+			portfolios.POST("/:account_id/target", h.SetTargetPortfolio)
+			portfolios.GET("/:account_id/trades", h.GetTradeList)
+		}
+
+		// Order endpoints
+		orders := protected.Group("/orders")
+		{
+			orders.GET("", h.ListOrders)
+			orders.GET("/:id", h.GetOrder)
+			orders.POST("", h.CreateOrder)
+			orders.POST("/:id/submit", h.SubmitOrder)
+			orders.POST("/:id/cancel", h.CancelOrder)
+		}
+
+		// Deal endpoints
+		deals := protected.Group("/deals")
+		{
+			deals.GET("", h.ListDeals)
+		}
+
+		// Risk endpoints
+		risk := protected.Group("/risk")
+		{
+			risk.GET("/portfolio/:account_id", h.GetPortfolioRisk)
+			risk.GET("/decomposition/:account_id", h.GetRiskDecomposition)
+		}
+
+		// Signal endpoints
+		signals := protected.Group("/signals")
+		{
+			signals.GET("/timing", h.GetTimingSignal)
+			signals.GET("/alpha", h.GetAlphaSignal)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+-- =============================================================================
+-- Mellivora Mind Studio - Datasource Credential Rotation
+-- =============================================================================
+
+-- Incremented every time a datasource's secret config fields are rotated
+ALTER TABLE etl_datasources
+    ADD COLUMN credential_version INTEGER NOT NULL DEFAULT 1;
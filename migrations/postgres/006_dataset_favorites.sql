@@ -0,0 +1,15 @@
+-- =============================================================================
+-- Mellivora Mind Studio - Dataset Favorites
+-- =============================================================================
+
+-- Datasets starred by a user; rows go away with the dataset
+CREATE TABLE etl_dataset_favorites (
+    user_id VARCHAR(100) NOT NULL,
+    dataset_id UUID NOT NULL REFERENCES etl_datasets(id) ON DELETE CASCADE,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (user_id, dataset_id)
+);
+
+CREATE INDEX idx_etl_dataset_favorites_dataset ON etl_dataset_favorites(dataset_id);
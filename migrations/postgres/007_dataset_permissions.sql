@@ -0,0 +1,24 @@
+-- =============================================================================
+-- Mellivora Mind Studio - Dataset Ownership & Permissions
+-- =============================================================================
+
+CREATE TYPE dataset_role AS ENUM ('editor', 'viewer');
+
+-- Datasets created before ownership existed keep a NULL owner and stay open
+ALTER TABLE etl_datasets
+    ADD COLUMN owner_id VARCHAR(100);
+
+CREATE INDEX idx_etl_datasets_owner ON etl_datasets(owner_id);
+
+-- Per-user grants on a dataset besides its owner
+CREATE TABLE etl_dataset_grants (
+    dataset_id UUID NOT NULL REFERENCES etl_datasets(id) ON DELETE CASCADE,
+    user_id VARCHAR(100) NOT NULL,
+    role dataset_role NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (dataset_id, user_id)
+);
+
+CREATE INDEX idx_etl_dataset_grants_user ON etl_dataset_grants(user_id);
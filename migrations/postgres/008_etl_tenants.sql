@@ -0,0 +1,29 @@
+-- =============================================================================
+-- Mellivora Mind Studio - ETL Tenant Isolation
+-- =============================================================================
+
+-- Rows created before tenants existed belong to the default tenant
+ALTER TABLE etl_datasources
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT 'default';
+ALTER TABLE etl_datasets
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT 'default';
+ALTER TABLE etl_pipelines
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT 'default';
+ALTER TABLE etl_schedules
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT 'default';
+ALTER TABLE etl_executions
+    ADD COLUMN tenant_id VARCHAR(100) NOT NULL DEFAULT 'default';
+
+-- Names only need to be unique within a tenant
+ALTER TABLE etl_datasources DROP CONSTRAINT etl_datasources_name_key;
+ALTER TABLE etl_datasources ADD CONSTRAINT etl_datasources_tenant_name_key UNIQUE (tenant_id, name);
+ALTER TABLE etl_datasets DROP CONSTRAINT etl_datasets_name_key;
+ALTER TABLE etl_datasets ADD CONSTRAINT etl_datasets_tenant_name_key UNIQUE (tenant_id, name);
+ALTER TABLE etl_pipelines DROP CONSTRAINT etl_pipelines_name_key;
+ALTER TABLE etl_pipelines ADD CONSTRAINT etl_pipelines_tenant_name_key UNIQUE (tenant_id, name);
+ALTER TABLE etl_schedules DROP CONSTRAINT etl_schedules_name_key;
+ALTER TABLE etl_schedules ADD CONSTRAINT etl_schedules_tenant_name_key UNIQUE (tenant_id, name);
+
+-- Executions are written by the scheduler, which must copy the tenant of the
+-- pipeline it runs; lists are filtered and sorted by creation time per tenant
+CREATE INDEX idx_etl_executions_tenant ON etl_executions(tenant_id, created_at DESC);
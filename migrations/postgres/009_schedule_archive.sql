@@ -0,0 +1,10 @@
+-- =============================================================================
+-- Mellivora Mind Studio - Schedule Archiving
+-- =============================================================================
+
+-- Schedules with execution history are archived instead of deleted, so
+-- their executions keep pointing at them
+ALTER TABLE etl_schedules
+    ADD COLUMN archived_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_etl_schedules_active ON etl_schedules(tenant_id, created_at DESC) WHERE archived_at IS NULL;
@@ -0,0 +1,19 @@
+-- =============================================================================
+-- Mellivora Mind Studio - ETL Resource Authorship
+-- =============================================================================
+
+-- The acting user of the request that created or last modified a resource;
+-- NULL for rows written before tracking existed or by anonymous requests
+ALTER TABLE etl_datasources
+    ADD COLUMN created_by VARCHAR(100),
+    ADD COLUMN updated_by VARCHAR(100);
+ALTER TABLE etl_datasets
+    ADD COLUMN created_by VARCHAR(100),
+    ADD COLUMN updated_by VARCHAR(100);
+ALTER TABLE etl_pipelines
+    ADD COLUMN created_by VARCHAR(100),
+    ADD COLUMN updated_by VARCHAR(100);
+
+CREATE INDEX idx_etl_datasources_created_by ON etl_datasources(created_by);
+CREATE INDEX idx_etl_datasets_created_by ON etl_datasets(created_by);
+CREATE INDEX idx_etl_pipelines_created_by ON etl_pipelines(created_by);
@@ -0,0 +1,7 @@
+-- =============================================================================
+-- Mellivora Mind Studio - Execution Queue Stats Index
+-- =============================================================================
+
+-- Serves the queue-stats aggregation, which only reads pending and running
+-- executions plus recent failures
+CREATE INDEX idx_etl_executions_status_created ON etl_executions(status, created_at);
@@ -0,0 +1,82 @@
+-- =============================================================================
+-- Mellivora Mind Studio - ETL Execution Webhooks
+-- =============================================================================
+
+CREATE TYPE webhook_delivery_status AS ENUM ('pending', 'delivered', 'dead');
+
+-- Outbound webhooks notified when executions change status
+CREATE TABLE etl_webhooks (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id VARCHAR(100) NOT NULL DEFAULT 'default',
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,  -- HMAC-SHA256 key of the X-Webhook-Signature header
+    events TEXT[] NOT NULL DEFAULT '{}',  -- execution statuses to deliver; empty means all
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    created_by VARCHAR(100),
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_etl_webhooks_tenant ON etl_webhooks(tenant_id);
+
+CREATE TRIGGER update_etl_webhooks_updated_at
+    BEFORE UPDATE ON etl_webhooks
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- One delivery per webhook and execution status transition. The payload is
+-- fixed when the transition happens, so retries send the same body.
+CREATE TABLE etl_webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id UUID NOT NULL REFERENCES etl_webhooks(id) ON DELETE CASCADE,
+    execution_id UUID NOT NULL REFERENCES etl_executions(id) ON DELETE CASCADE,
+    event VARCHAR(20) NOT NULL,
+    payload JSONB NOT NULL,
+    status webhook_delivery_status NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    response_status INTEGER,
+    last_error TEXT,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_etl_webhook_deliveries_webhook ON etl_webhook_deliveries(webhook_id, created_at DESC);
+CREATE INDEX idx_etl_webhook_deliveries_due ON etl_webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+-- Queue a delivery for every matching webhook of the execution's tenant
+-- whenever an execution changes status, whoever writes the change
+CREATE OR REPLACE FUNCTION enqueue_etl_webhook_deliveries()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO etl_webhook_deliveries (webhook_id, execution_id, event, payload)
+    SELECT w.id, NEW.id, NEW.status::text, jsonb_build_object(
+        'event', 'execution.' || NEW.status::text,
+        'executionId', NEW.id,
+        'tenantId', NEW.tenant_id,
+        'scheduleId', NEW.schedule_id,
+        'pipelineId', NEW.pipeline_id,
+        'status', NEW.status,
+        'previousStatus', OLD.status,
+        'trigger', NEW.trigger,
+        'startedAt', NEW.started_at,
+        'finishedAt', NEW.finished_at,
+        'duration', NEW.duration,
+        'errorMessage', NEW.error_message,
+        'occurredAt', NOW()
+    )
+    FROM etl_webhooks w
+    WHERE w.enabled
+      AND w.tenant_id = NEW.tenant_id
+      AND (cardinality(w.events) = 0 OR NEW.status::text = ANY(w.events));
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER enqueue_etl_webhook_deliveries
+    AFTER UPDATE OF status ON etl_executions
+    FOR EACH ROW
+    WHEN (OLD.status IS DISTINCT FROM NEW.status)
+    EXECUTE FUNCTION enqueue_etl_webhook_deliveries();
@@ -0,0 +1,11 @@
+-- =============================================================================
+-- Mellivora Mind Studio - Schedule Active Window
+-- =============================================================================
+
+-- An enabled schedule only runs between active_from and active_until; a
+-- missing bound leaves that side of the window open
+ALTER TABLE etl_schedules
+    ADD COLUMN active_from TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN active_until TIMESTAMP WITH TIME ZONE,
+    ADD CONSTRAINT etl_schedules_active_window_check
+        CHECK (active_from IS NULL OR active_until IS NULL OR active_from < active_until);
@@ -0,0 +1,12 @@
+-- =============================================================================
+-- Mellivora Mind Studio - Pipeline Tags and Schedule Minimum Interval
+-- =============================================================================
+
+-- Free-form pipeline tags; schedules running a pipeline tagged "heavy" must
+-- not fire more often than the configured minimum interval
+ALTER TABLE etl_pipelines
+    ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Set by an admin to exempt a schedule from the minimum interval
+ALTER TABLE etl_schedules
+    ADD COLUMN allow_frequent BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,7 @@
+-- =============================================================================
+-- Mellivora Mind Studio - Execution Finished Index
+-- =============================================================================
+
+-- The metrics collector reads executions in the order they finish
+CREATE INDEX idx_etl_executions_finished ON etl_executions(finished_at, id)
+    WHERE finished_at IS NOT NULL;
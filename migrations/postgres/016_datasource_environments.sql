@@ -0,0 +1,9 @@
+-- =============================================================================
+-- Mellivora Mind Studio - Data Source Environment Overrides
+-- =============================================================================
+
+-- Per-environment config overrides, keyed by environment name (e.g. "dev",
+-- "prod"). Each override is merged over the base config; unset fields fall
+-- back to it.
+ALTER TABLE etl_datasources
+    ADD COLUMN environments JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,105 @@
+-- =============================================================================
+-- Mellivora Mind Studio - ETL Config Audit Log
+-- =============================================================================
+
+-- One entry per create, update, archive or delete of a data source, dataset,
+-- pipeline or schedule. changes maps each changed column to its before and
+-- after value; creates have no before and deletes no after.
+CREATE TABLE etl_audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id VARCHAR(100) NOT NULL DEFAULT 'default',
+    actor VARCHAR(100),  -- NULL for anonymous requests and system writes
+    entity_type VARCHAR(20) NOT NULL,
+    entity_id UUID NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_etl_audit_log_entity ON etl_audit_log(tenant_id, entity_type, entity_id, created_at DESC);
+CREATE INDEX idx_etl_audit_log_tenant ON etl_audit_log(tenant_id, created_at DESC);
+
+-- Record a change of an ETL config row, whoever writes it. The arguments are
+-- the entity type, then comma-separated lists of the columns to ignore
+-- (runtime state such as next_run_at) and of the JSONB columns whose values
+-- may hold secrets; for those only the changed keys are recorded. Updates
+-- that only touch ignored columns are not recorded. The actor is read from
+-- the etl.actor setting of the writing transaction.
+CREATE OR REPLACE FUNCTION record_etl_audit()
+RETURNS TRIGGER AS $$
+DECLARE
+    old_row JSONB := CASE WHEN TG_OP = 'INSERT' THEN '{}'::jsonb ELSE to_jsonb(OLD) END;
+    new_row JSONB := CASE WHEN TG_OP = 'DELETE' THEN '{}'::jsonb ELSE to_jsonb(NEW) END;
+    ignored TEXT[] := string_to_array(TG_ARGV[1], ',') || ARRAY['created_at', 'updated_at', 'created_by', 'updated_by'];
+    redacted TEXT[] := string_to_array(TG_ARGV[2], ',');
+    entry_action TEXT := CASE TG_OP WHEN 'INSERT' THEN 'create' WHEN 'UPDATE' THEN 'update' ELSE 'delete' END;
+    entry_changes JSONB := '{}';
+    col TEXT;
+    old_value JSONB;
+    new_value JSONB;
+BEGIN
+    FOR col IN SELECT jsonb_object_keys(old_row || new_row) LOOP
+        CONTINUE WHEN col = ANY(ignored);
+        old_value := old_row -> col;
+        new_value := new_row -> col;
+        CONTINUE WHEN old_value IS NOT DISTINCT FROM new_value;
+        -- Creates and deletes leave out the columns that are null
+        CONTINUE WHEN TG_OP <> 'UPDATE' AND COALESCE(old_value, new_value) = 'null'::jsonb;
+
+        IF col = ANY(redacted) THEN
+            entry_changes := entry_changes || jsonb_build_object(col, jsonb_build_object('changedKeys', (
+                SELECT COALESCE(jsonb_agg(k ORDER BY k), '[]'::jsonb)
+                FROM jsonb_object_keys(COALESCE(old_value, '{}') || COALESCE(new_value, '{}')) AS k
+                WHERE old_value -> k IS DISTINCT FROM new_value -> k
+            )));
+        ELSIF TG_OP = 'INSERT' THEN
+            entry_changes := entry_changes || jsonb_build_object(col, jsonb_build_object('after', new_value));
+        ELSIF TG_OP = 'DELETE' THEN
+            entry_changes := entry_changes || jsonb_build_object(col, jsonb_build_object('before', old_value));
+        ELSE
+            entry_changes := entry_changes || jsonb_build_object(col, jsonb_build_object('before', old_value, 'after', new_value));
+        END IF;
+    END LOOP;
+
+    IF TG_OP = 'UPDATE' THEN
+        IF entry_changes = '{}'::jsonb THEN
+            RETURN NULL;
+        END IF;
+        IF old_row ->> 'archived_at' IS NULL AND new_row ->> 'archived_at' IS NOT NULL THEN
+            entry_action := 'archive';
+        END IF;
+    END IF;
+
+    INSERT INTO etl_audit_log (tenant_id, actor, entity_type, entity_id, action, changes)
+    VALUES (
+        COALESCE(new_row ->> 'tenant_id', old_row ->> 'tenant_id'),
+        NULLIF(current_setting('etl.actor', true), ''),
+        TG_ARGV[0],
+        COALESCE(new_row ->> 'id', old_row ->> 'id')::uuid,
+        entry_action,
+        entry_changes
+    );
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_etl_datasources_audit
+    AFTER INSERT OR UPDATE OR DELETE ON etl_datasources
+    FOR EACH ROW
+    EXECUTE FUNCTION record_etl_audit('datasource', 'status,error_message,last_sync_at', 'config,environments');
+
+CREATE TRIGGER record_etl_datasets_audit
+    AFTER INSERT OR UPDATE OR DELETE ON etl_datasets
+    FOR EACH ROW
+    EXECUTE FUNCTION record_etl_audit('dataset', '', '');
+
+CREATE TRIGGER record_etl_pipelines_audit
+    AFTER INSERT OR UPDATE OR DELETE ON etl_pipelines
+    FOR EACH ROW
+    EXECUTE FUNCTION record_etl_audit('pipeline', '', '');
+
+CREATE TRIGGER record_etl_schedules_audit
+    AFTER INSERT OR UPDATE OR DELETE ON etl_schedules
+    FOR EACH ROW
+    EXECUTE FUNCTION record_etl_audit('schedule', 'last_run_at,next_run_at', '');
@@ -0,0 +1,3 @@
+// Package api holds the HTTP response envelopes and request helpers shared
+// by the gateway and the Go services, so every JSON API has the same shape.
+package api
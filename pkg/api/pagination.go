@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pagination bounds for list endpoints
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+// ParsePagination reads page and pageSize from the query string. Missing
+// values fall back to page 1 and DefaultPageSize; a non-integer or
+// non-positive value is an error, and pageSize is capped at MaxPageSize.
+func ParsePagination(c *gin.Context) (page, pageSize int, err error) {
+	page, err = parsePositive(c, "page", 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	pageSize, err = parsePositive(c, "pageSize", DefaultPageSize)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	return page, pageSize, nil
+}
+
+// ParseWithTotal reads withTotal from the query string. Lists are counted
+// unless it is false; skipping the count suits infinite scrolling, which
+// only needs to know whether another page follows.
+func ParseWithTotal(c *gin.Context) (bool, error) {
+	switch c.Query("withTotal") {
+	case "", "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	return false, Invalidf("withTotal must be true or false")
+}
+
+// PageLimit returns the row limit of a list query: one row past the page
+// when the list is not counted, so NewPaginatedResponse can tell whether
+// another page follows
+func PageLimit(pageSize int, withTotal bool) int {
+	if withTotal {
+		return pageSize
+	}
+	return pageSize + 1
+}
+
+// RespondPaginated writes a 200 PaginatedResponse, encoding a nil slice as
+// an empty JSON array
+func RespondPaginated[T any](c *gin.Context, items []T, total, page, pageSize int) {
+	c.JSON(http.StatusOK, NewPaginatedResponse(items, total, page, pageSize))
+}
+
+// parsePositive reads a positive integer query parameter
+func parsePositive(c *gin.Context, key string, defaultValue int) (int, error) {
+	raw, ok := c.GetQuery(key)
+	if !ok || raw == "" {
+		return defaultValue, nil
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, Invalidf("%s must be a positive integer", key)
+	}
+	return value, nil
+}
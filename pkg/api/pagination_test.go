@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryContext returns a context for a GET request with the query string
+func queryContext(query string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/items"+query, nil)
+	return c
+}
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		query        string
+		wantPage     int
+		wantPageSize int
+		wantErr      string
+	}{
+		{"", 1, DefaultPageSize, ""},
+		{"?page=&pageSize=", 1, DefaultPageSize, ""},
+		{"?page=3&pageSize=50", 3, 50, ""},
+		{"?page=1&pageSize=1", 1, 1, ""},
+		{"?pageSize=100", 1, MaxPageSize, ""},
+		{"?pageSize=101", 1, MaxPageSize, ""},
+		{"?pageSize=1000000", 1, MaxPageSize, ""},
+		{"?page=0", 0, 0, "page must be a positive integer"},
+		{"?page=-1", 0, 0, "page must be a positive integer"},
+		{"?pageSize=0", 0, 0, "pageSize must be a positive integer"},
+		{"?page=two", 0, 0, "page must be a positive integer"},
+		{"?page=1.5", 0, 0, "page must be a positive integer"},
+		{"?pageSize=20abc", 0, 0, "pageSize must be a positive integer"},
+		{"?page=99999999999999999999", 0, 0, "page must be a positive integer"},
+	}
+	for _, tt := range tests {
+		page, pageSize, err := ParsePagination(queryContext(tt.query))
+		if tt.wantErr != "" {
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParsePagination(%q) error = %v, want %q", tt.query, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || page != tt.wantPage || pageSize != tt.wantPageSize {
+			t.Errorf("ParsePagination(%q) = %d, %d, %v; want %d, %d", tt.query, page, pageSize, err, tt.wantPage, tt.wantPageSize)
+		}
+	}
+}
+
+func TestParseWithTotal(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    bool
+		wantErr bool
+	}{
+		{"", true, false},
+		{"?withTotal=", true, false},
+		{"?withTotal=true", true, false},
+		{"?withTotal=false", false, false},
+		{"?withTotal=0", false, true},
+		{"?withTotal=TRUE", false, true},
+		{"?withTotal=yes", false, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseWithTotal(queryContext(tt.query))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseWithTotal(%q) = %v, %v; want %v, error %v", tt.query, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
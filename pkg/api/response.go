@@ -0,0 +1,76 @@
+package api
+
+import "fmt"
+
+// PaginatedResponse is a generic paginated response. Total is UnknownTotal
+// when the list was not counted.
+type PaginatedResponse[T any] struct {
+	Data     []T  `json:"data"`
+	Total    int  `json:"total"`
+	Page     int  `json:"page"`
+	PageSize int  `json:"pageSize"`
+	HasMore  bool `json:"hasMore"`
+}
+
+// UnknownTotal is the total of a list fetched without counting its rows
+const UnknownTotal = -1
+
+// APIResponse is a generic API response
+type APIResponse[T any] struct {
+	Data    T      `json:"data"`
+	Message string `json:"message,omitempty"`
+}
+
+// ErrorResponse is the error envelope returned by every API. Code is a
+// machine-readable reason such as "not_found"; Error is written for the
+// client and never carries internal error text.
+type ErrorResponse struct {
+	Code      string      `json:"code,omitempty"`
+	Error     string      `json:"error"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
+}
+
+// InvalidError is a request validation error. Its message is written for
+// the client, so unlike other errors it may be returned in a response.
+type InvalidError struct {
+	Message string
+}
+
+// Error implements error
+func (e *InvalidError) Error() string {
+	return e.Message
+}
+
+// Invalidf returns an *InvalidError with a formatted message
+func Invalidf(format string, args ...interface{}) error {
+	return &InvalidError{Message: fmt.Sprintf(format, args...)}
+}
+
+// NewPaginatedResponse builds a PaginatedResponse, encoding a nil slice as
+// an empty JSON array. With total UnknownTotal, items may hold one row past
+// the page, fetched only to tell whether another page follows; it is
+// dropped from the response.
+func NewPaginatedResponse[T any](items []T, total, page, pageSize int) PaginatedResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+
+	var hasMore bool
+	if total == UnknownTotal {
+		hasMore = len(items) > pageSize
+		if hasMore {
+			items = items[:pageSize]
+		}
+	} else {
+		hasMore = page*pageSize < total
+	}
+
+	return PaginatedResponse[T]{
+		Data:     items,
+		Total:    total,
+		Page:     page,
+		PageSize: pageSize,
+		HasMore:  hasMore,
+	}
+}
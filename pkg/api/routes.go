@@ -0,0 +1,85 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID the gateway assigns to every request
+const RequestIDHeader = "X-Request-ID"
+
+// HandleUnmatched makes r answer requests no route matches with the JSON
+// error envelope: a 404 for unknown paths, and a 405 with an Allow header
+// listing the registered methods for known paths requested with another
+// method. The routes are read per request, so routes registered after the
+// call are covered too.
+func HandleUnmatched(r *gin.Engine) {
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, ErrorResponse{
+			Code:      "not_found",
+			Error:     "no route for " + c.Request.Method + " " + c.Request.URL.Path,
+			RequestID: requestID(c),
+		})
+	})
+	r.NoMethod(func(c *gin.Context) {
+		c.Header("Allow", strings.Join(allowedMethods(r.Routes(), c.Request.URL.Path), ", "))
+		c.JSON(http.StatusMethodNotAllowed, ErrorResponse{
+			Code:      "method_not_allowed",
+			Error:     "method " + c.Request.Method + " not allowed for " + c.Request.URL.Path,
+			RequestID: requestID(c),
+		})
+	})
+}
+
+// requestID returns the ID of a request: the one set by the gateway's
+// request ID middleware, or the one forwarded in RequestIDHeader
+func requestID(c *gin.Context) string {
+	if id := c.GetString("request_id"); id != "" {
+		return id
+	}
+	return c.GetHeader(RequestIDHeader)
+}
+
+// allowedMethods returns the methods of the routes whose pattern matches
+// path, sorted
+func allowedMethods(routes gin.RoutesInfo, path string) []string {
+	seen := make(map[string]bool)
+	var methods []string
+	for _, route := range routes {
+		if !seen[route.Method] && matchRoute(route.Path, path) {
+			seen[route.Method] = true
+			methods = append(methods, route.Method)
+		}
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// matchRoute reports whether path matches a gin route pattern, where
+// ":name" matches one segment and "*name" the rest of the path
+func matchRoute(pattern, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+	for i, part := range patternParts {
+		if strings.HasPrefix(part, "*") {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
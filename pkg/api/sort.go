@@ -0,0 +1,45 @@
+package api
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sort is the parsed ?sort=field&order=asc|desc query. An empty Field means
+// the endpoint's default ordering.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort reads sort and order from the query string. The sort field must
+// be one of allowed; order defaults to asc.
+func ParseSort(c *gin.Context, allowed []string) (Sort, error) {
+	field := c.Query("sort")
+	order := strings.ToLower(c.Query("order"))
+
+	var s Sort
+	switch order {
+	case "", "asc":
+	case "desc":
+		s.Desc = true
+	default:
+		return Sort{}, Invalidf("order must be asc or desc")
+	}
+
+	if field == "" {
+		if order != "" {
+			return Sort{}, Invalidf("order requires sort")
+		}
+		return s, nil
+	}
+
+	for _, name := range allowed {
+		if name == field {
+			s.Field = field
+			return s, nil
+		}
+	}
+	return Sort{}, Invalidf("unknown sort field %q, expected one of: %s", field, strings.Join(allowed, ", "))
+}
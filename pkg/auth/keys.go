@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// Errors returned by KeySet.Retire
+var (
+	ErrUnknownKey = errors.New("signing key not found")
+	ErrCurrentKey = errors.New("the current signing key cannot be retired")
+)
+
+// KeySet holds the JWT signing keys. Tokens are accepted when signed by any
+// key still in the set, so a secret can be rotated by adding a new kid,
+// making it current, and retiring the old kid once its tokens have expired.
+type KeySet struct {
+	mu      sync.RWMutex
+	keys    map[string]string // kid -> secret
+	current string            // kid new tokens are signed with
+	legacy  string            // secret for tokens without a kid
+}
+
+// NewKeySet returns a key set of the kid -> secret keys, signing with the
+// current kid, and accepting tokens without a kid when legacy is set
+func NewKeySet(legacy string, keys map[string]string, current string) *KeySet {
+	ks := &KeySet{
+		keys:    make(map[string]string, len(keys)),
+		current: current,
+		legacy:  legacy,
+	}
+	for kid, secret := range keys {
+		ks.keys[kid] = secret
+	}
+	return ks
+}
+
+// Sign mints an HS256 JWT for the claims, signed with the current key, or
+// with the legacy secret and no kid when there is no current key
+func (ks *KeySet) Sign(claims *Claims) (string, error) {
+	ks.mu.RLock()
+	header := tokenHeader{Alg: "HS256", Typ: "JWT", Kid: ks.current}
+	secret := ks.legacy
+	if header.Kid != "" {
+		secret = ks.keys[header.Kid]
+	}
+	ks.mu.RUnlock()
+
+	if secret == "" {
+		return "", errors.New("no signing key configured")
+	}
+	return encode(header, claims, secret)
+}
+
+// KeyIDs returns the kids in the set, sorted, and the current kid
+func (ks *KeySet) KeyIDs() (kids []string, current string) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	kids = make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	return kids, ks.current
+}
+
+// Retire removes a kid, so tokens signed with it are rejected from then on.
+// The current kid cannot be retired.
+func (ks *KeySet) Retire(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if _, ok := ks.keys[kid]; !ok {
+		return ErrUnknownKey
+	}
+	if kid == ks.current {
+		return ErrCurrentKey
+	}
+	delete(ks.keys, kid)
+	return nil
+}
+
+// secretFor returns the secret that verifies tokens with the given kid
+func (ks *KeySet) secretFor(kid string) (string, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if kid == "" {
+		if ks.legacy == "" {
+			return "", errors.New("token has no kid")
+		}
+		return ks.legacy, nil
+	}
+	secret, ok := ks.keys[kid]
+	if !ok {
+		return "", errors.New("unknown token signing key")
+	}
+	return secret, nil
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signWith returns a token with the given header and claims signed with
+// secret
+func signWith(t *testing.T, header tokenHeader, claims Claims, secret string) string {
+	t.Helper()
+	token, err := encode(header, &claims, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerify(t *testing.T) {
+	const maxLifetime = time.Hour
+	v := NewVerifier(NewKeySet("legacy-secret", map[string]string{"2026-01": "current-secret"}, "2026-01"), maxLifetime)
+	exp := time.Now().Add(time.Minute).Unix()
+	valid := Claims{UserID: "u1", TenantID: "t1", Role: RoleAdmin, AccountIDs: []string{"a1"}, ExpiresAt: exp}
+	current := tokenHeader{Alg: "HS256", Typ: "JWT", Kid: "2026-01"}
+	legacy := tokenHeader{Alg: "HS256", Typ: "JWT"}
+
+	validToken := signWith(t, current, valid, "current-secret")
+	parts := strings.Split(validToken, ".")
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string
+	}{
+		{"valid with kid", validToken, ""},
+		{"valid legacy secret", signWith(t, legacy, valid, "legacy-secret"), ""},
+		{"expired", signWith(t, current, Claims{UserID: "u1", ExpiresAt: time.Now().Add(-time.Second).Unix()}, "current-secret"), "token expired"},
+		{"no exp", signWith(t, current, Claims{UserID: "u1"}, "current-secret"), "token has no exp"},
+		{"lifetime too long", signWith(t, current, Claims{UserID: "u1", ExpiresAt: time.Now().Add(2 * maxLifetime).Unix()}, "current-secret"), "token lifetime exceeds the allowed expiry"},
+		{"no user", signWith(t, current, Claims{ExpiresAt: exp}, "current-secret"), "token has no user_id"},
+		{"wrong signature", signWith(t, current, valid, "other-secret"), "invalid token signature"},
+		{"kid signed with the legacy secret", signWith(t, current, valid, "legacy-secret"), "invalid token signature"},
+		{"tampered claims", parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(`{"user_id":"u2","exp":%d}`, exp))) + "." + parts[2], "invalid token signature"},
+		{"alg none", signWith(t, tokenHeader{Alg: "none", Kid: "2026-01"}, valid, "current-secret"), "unsupported token algorithm"},
+		{"alg none unsigned", base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`)) + "." + parts[1] + ".", "unsupported token algorithm"},
+		{"unknown kid", signWith(t, tokenHeader{Alg: "HS256", Kid: "2025-01"}, valid, "current-secret"), "unknown token signing key"},
+		{"malformed: two segments", parts[0] + "." + parts[1], "malformed token"},
+		{"malformed header", "!!!." + parts[1] + "." + parts[2], "malformed token header"},
+		{"malformed signature", parts[0] + "." + parts[1] + ".!!!", "malformed token signature"},
+		{"empty", "", "malformed token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := v.Verify(tt.token)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Verify = %+v, %v; want error %q", claims, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.UserID != "u1" || claims.TenantID != "t1" || !claims.IsAdmin() || !claims.OwnsAccount("a1") {
+				t.Errorf("claims = %+v, want %+v", claims, valid)
+			}
+		})
+	}
+}
+
+func TestVerifyLegacySecretUnset(t *testing.T) {
+	v := NewVerifier(NewKeySet("", map[string]string{"2026-01": "current-secret"}, "2026-01"), time.Hour)
+	token := signWith(t, tokenHeader{Alg: "HS256"}, Claims{UserID: "u1", ExpiresAt: time.Now().Add(time.Minute).Unix()}, "")
+	if _, err := v.Verify(token); err == nil || err.Error() != "token has no kid" {
+		t.Fatalf("Verify error = %v, want token has no kid", err)
+	}
+}
+
+func TestSignRoundTrip(t *testing.T) {
+	claims := &Claims{UserID: "u1", TenantID: "t1", ExpiresAt: time.Now().Add(time.Minute).Unix()}
+	for name, ks := range map[string]*KeySet{
+		"current kid":   NewKeySet("", map[string]string{"2026-01": "current-secret"}, "2026-01"),
+		"legacy secret": NewKeySet("legacy-secret", nil, ""),
+	} {
+		t.Run(name, func(t *testing.T) {
+			token, err := ks.Sign(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := NewVerifier(ks, time.Hour).Verify(token)
+			if err != nil || got.UserID != "u1" || got.TenantID != "t1" {
+				t.Errorf("Verify = %+v, %v; want the signed claims", got, err)
+			}
+		})
+	}
+
+	if _, err := NewKeySet("", nil, "").Sign(claims); err == nil {
+		t.Error("Sign without keys succeeded, want an error")
+	}
+}
+
+func TestRetire(t *testing.T) {
+	ks := NewKeySet("", map[string]string{"2025-12": "old-secret", "2026-01": "current-secret"}, "2026-01")
+	old := signWith(t, tokenHeader{Alg: "HS256", Kid: "2025-12"}, Claims{UserID: "u1", ExpiresAt: time.Now().Add(time.Minute).Unix()}, "old-secret")
+	v := NewVerifier(ks, time.Hour)
+	if _, err := v.Verify(old); err != nil {
+		t.Fatalf("Verify before retiring: %v", err)
+	}
+
+	if err := ks.Retire("2026-01"); !errors.Is(err, ErrCurrentKey) {
+		t.Errorf("Retire current = %v, want ErrCurrentKey", err)
+	}
+	if err := ks.Retire("2024-01"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Retire unknown = %v, want ErrUnknownKey", err)
+	}
+	if err := ks.Retire("2025-12"); err != nil {
+		t.Fatalf("Retire: %v", err)
+	}
+
+	if _, err := v.Verify(old); err == nil || err.Error() != "unknown token signing key" {
+		t.Errorf("Verify after retiring = %v, want unknown token signing key", err)
+	}
+	if kids, current := ks.KeyIDs(); len(kids) != 1 || kids[0] != "2026-01" || current != "2026-01" {
+		t.Errorf("KeyIDs = %v, %s; want only the current key", kids, current)
+	}
+}
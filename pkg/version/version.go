@@ -0,0 +1,60 @@
+// Package version holds the build metadata of a binary. Release builds set
+// it at link time:
+//
+//	go build -ldflags "-X github.com/mellivora-tech/mellivora-mind-studio/pkg/version.Version=1.2.0 \
+//	  -X github.com/mellivora-tech/mellivora-mind-studio/pkg/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/mellivora-tech/mellivora-mind-studio/pkg/version.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Without ldflags, Commit and BuildTime fall back to the VCS stamp the Go
+// toolchain embeds when building inside a git checkout.
+package version
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags "-X"; Version keeps its static value when unset
+var (
+	Version   = "0.1.0"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info is the build metadata reported by health and version endpoints
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Get returns the build metadata of the running binary. Unknown values are
+// reported as "unknown".
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildTime == "" {
+		info.BuildTime = "unknown"
+	}
+	return info
+}
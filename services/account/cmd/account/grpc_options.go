@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// Defaults of the gRPC server options. Bar and OHLCV responses outgrow
+// gRPC's 4MB default, and the gateway pings idle connections every 30s,
+// so the enforced minimum ping interval stays below that.
+const (
+	defaultMaxMsgBytes      = 16 << 20
+	defaultKeepaliveTime    = 60 * time.Second
+	defaultKeepaliveTimeout = 20 * time.Second
+	defaultKeepaliveMinTime = 15 * time.Second
+)
+
+// serverOptions returns the gRPC server's message size limits and keepalive
+// parameters, read from GRPC_MAX_RECV_MSG_BYTES, GRPC_MAX_SEND_MSG_BYTES,
+// GRPC_KEEPALIVE_TIME_MS, GRPC_KEEPALIVE_TIMEOUT_MS and
+// GRPC_KEEPALIVE_MIN_TIME_MS
+func serverOptions() ([]grpc.ServerOption, error) {
+	maxRecv, err := envPositive("GRPC_MAX_RECV_MSG_BYTES", defaultMaxMsgBytes)
+	if err != nil {
+		return nil, err
+	}
+	maxSend, err := envPositive("GRPC_MAX_SEND_MSG_BYTES", defaultMaxMsgBytes)
+	if err != nil {
+		return nil, err
+	}
+	keepaliveTime, err := envPositive("GRPC_KEEPALIVE_TIME_MS", int(defaultKeepaliveTime/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	keepaliveTimeout, err := envPositive("GRPC_KEEPALIVE_TIMEOUT_MS", int(defaultKeepaliveTimeout/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+	minTime, err := envPositive("GRPC_KEEPALIVE_MIN_TIME_MS", int(defaultKeepaliveMinTime/time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(maxRecv),
+		grpc.MaxSendMsgSize(maxSend),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    time.Duration(keepaliveTime) * time.Millisecond,
+			Timeout: time.Duration(keepaliveTimeout) * time.Millisecond,
+		}),
+		// Clients pinging more often than MinTime are disconnected
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             time.Duration(minTime) * time.Millisecond,
+			PermitWithoutStream: true,
+		}),
+	}, nil
+}
+
+// envPositive reads a positive integer from the environment
+func envPositive(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid %s %q: must be a positive integer", key, value)
+	}
+	return n, nil
+}
@@ -39,7 +39,11 @@ func main() {
 	}
 
 	// Create gRPC server
-	server := grpc.NewServer()
+	opts, err := serverOptions()
+	if err != nil {
+		logger.Fatal("invalid gRPC server options", zap.Error(err))
+	}
+	server := grpc.NewServer(opts...)
 
 	// TODO: Register AccountService
 	// accountpb.RegisterAccountServiceServer(server, accountService)
@@ -1,4 +1,4 @@
-module github.com/mellivora-mind/mellivora-mind-studio/services/alert
+module github.com/mellivora-tech/mellivora-mind-studio/services/alert
 
 go 1.22
 
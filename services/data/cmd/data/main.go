@@ -35,7 +35,11 @@ func main() {
 		logger.Fatal("failed to listen", zap.Error(err))
 	}
 
-	server := grpc.NewServer()
+	opts, err := serverOptions()
+	if err != nil {
+		logger.Fatal("invalid gRPC server options", zap.Error(err))
+	}
+	server := grpc.NewServer(opts...)
 	// TODO: Register DataService
 	reflection.Register(server)
 
@@ -1,22 +1,30 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata" // schedule time zones validate even without system tzdata
 
 	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/version"
 	"go.uber.org/zap"
 
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/connpool"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/handler"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/metrics"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/webhook"
 )
 
-const (
-	serviceName = "etl-config"
-	defaultPort = "8080"
-)
+const serviceName = "etl-config"
 
 func main() {
 	// Initialize logger
@@ -27,41 +35,145 @@ func main() {
 	}
 	defer logger.Sync()
 
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Fatal("failed to load config", zap.Error(err))
+	}
+
 	// Initialize database
-	logger.Info("connecting to database...")
-	if err := repository.InitDB(); err != nil {
+	poolSettings, err := repository.LoadPoolSettings()
+	if err != nil {
+		logger.Fatal("invalid database pool settings", zap.Error(err))
+	}
+	logger.Info("connecting to database...",
+		zap.Int32("max_conns", poolSettings.MaxConns),
+		zap.Int32("min_conns", poolSettings.MinConns),
+		zap.Duration("max_conn_lifetime", poolSettings.MaxConnLifetime),
+		zap.Duration("acquire_timeout", poolSettings.AcquireTimeout),
+		zap.Duration("health_check_period", poolSettings.HealthCheckPeriod),
+	)
+	if err := repository.InitDB(poolSettings); err != nil {
 		logger.Fatal("failed to connect to database", zap.Error(err))
 	}
 	defer repository.CloseDB()
-	logger.Info("database connected successfully")
+	logger.Info("database connected successfully", zap.Bool("read_replica", repository.ReplicaDB != nil))
+
+	if status, err := repository.CheckSchema(context.Background()); err != nil {
+		logger.Error("failed to check database schema", zap.Error(err))
+	} else if !status.OK() {
+		logger.Error("database schema is incomplete, run the migrations",
+			zap.Strings("missing_types", status.MissingTypes),
+			zap.Strings("missing_tables", status.MissingTables),
+		)
+	}
 
 	// Setup Gin router
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Fatal("invalid trusted proxies", zap.Error(err))
+	}
 	router.Use(gin.Recovery())
-	router.Use(corsMiddleware())
+	router.Use(corsMiddleware(cfg.CORS))
+	router.Use(primaryForWrites())
+	api.HandleUnmatched(router)
+
+	// Backend connections of data sources, shared by connection tests
+	conns := connpool.NewManager(cfg.DataSourcePool)
+	defer conns.Close()
 
 	// Initialize handlers
-	dsHandler := handler.NewDataSourceHandler()
+	dsHandler := handler.NewDataSourceHandler(conns, cfg.Limits, cfg.Environment, cfg.DataSourcePool.TestTimeout)
 	pluginHandler := handler.NewPluginHandler()
-	datasetHandler := handler.NewDataSetHandler()
-	pipelineHandler := handler.NewPipelineHandler()
-	scheduleHandler := handler.NewScheduleHandler()
+	datasetHandler := handler.NewDataSetHandler(cfg.Limits)
+	pipelineHandler := handler.NewPipelineHandler(cfg.Limits)
+	scheduleHandler := handler.NewScheduleHandler(cfg.Limits)
 	executionHandler := handler.NewExecutionHandler()
+	webhookHandler := handler.NewWebhookHandler()
+	auditHandler := handler.NewAuditHandler()
+	summaryHandler := handler.NewSummaryHandler()
+
+	// Plugins are registered by migrations, so their config schemas are
+	// checked once the database is up
+	if invalid, err := pluginHandler.CheckSchemas(context.Background()); err != nil {
+		logger.Error("failed to check plugin config schemas", zap.Error(err))
+	} else {
+		for name, err := range invalid {
+			logger.Error("invalid plugin config schema", zap.String("plugin", name), zap.Error(err))
+		}
+	}
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok", "service": serviceName})
+		info := version.Get()
+		c.JSON(200, gin.H{
+			"status":    "ok",
+			"service":   serviceName,
+			"version":   info.Version,
+			"commit":    info.Commit,
+			"buildTime": info.BuildTime,
+		})
+	})
+
+	// API description
+	spec := apiSpec()
+	router.GET("/openapi.json", spec.Handler(router))
+
+	// Domain metrics
+	registry := metrics.NewRegistry()
+	collector := metrics.NewCollector(registry, cfg.Metrics.Interval, logger)
+	router.GET("/metrics", registry.Handler())
+
+	// Build metadata
+	router.GET("/version", func(c *gin.Context) {
+		c.JSON(200, version.Get())
+	})
+
+	// Readiness check: the database is reachable and fully migrated
+	router.GET("/ready", func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 3*time.Second)
+		defer cancel()
+
+		if err := repository.DB.Ping(ctx); err != nil {
+			c.JSON(503, gin.H{"status": "unavailable", "service": serviceName, "error": err.Error()})
+			return
+		}
+		if repository.ReplicaDB != nil {
+			if err := repository.ReplicaDB.Ping(ctx); err != nil {
+				c.JSON(503, gin.H{"status": "unavailable", "service": serviceName, "error": "replica: " + err.Error()})
+				return
+			}
+		}
+		status, err := repository.CheckSchema(ctx)
+		if err != nil {
+			c.JSON(503, gin.H{"status": "unavailable", "service": serviceName, "error": err.Error()})
+			return
+		}
+		if !status.OK() {
+			c.JSON(503, gin.H{"status": "unavailable", "service": serviceName, "schema": status})
+			return
+		}
+		c.JSON(200, gin.H{"status": "ready", "service": serviceName})
 	})
 
 	// API routes
+	// Only the gateway signs tokens, so the key set has no current kid
+	verifier := auth.NewVerifier(auth.NewKeySet(cfg.Auth.JWTSecret, cfg.Auth.SigningKeys, ""), cfg.Auth.TokenExpiry)
+	if cfg.Auth.AllowAnonymous {
+		logger.Warn("AUTH_ALLOW_ANONYMOUS is set: requests without a token act on the default tenant")
+	}
 	api := router.Group("/api")
 	{
 		// ETL routes
-		etl := api.Group("/etl")
+		etl := api.Group("/etl", handler.Identity(verifier, cfg.Auth.AllowAnonymous))
 		{
+			// Dashboard
+			etl.GET("/summary", summaryHandler.Get)
+
 			// Plugins
 			etl.GET("/plugins", pluginHandler.List)
+			etl.GET("/plugins/:name/schema", pluginHandler.GetSchema)
 
 			// Data Sources
 			etl.GET("/datasources", dsHandler.List)
@@ -70,25 +182,41 @@ func main() {
 			etl.PUT("/datasources/:id", dsHandler.Update)
 			etl.DELETE("/datasources/:id", dsHandler.Delete)
 			etl.POST("/datasources/:id/test", dsHandler.Test)
+			etl.POST("/datasources/:id/rotate-credentials", dsHandler.RotateCredentials)
 
 			// Datasets
 			etl.GET("/datasets", datasetHandler.List)
 			etl.GET("/datasets/categories", datasetHandler.GetCategories)
+			etl.GET("/datasets/discover", datasetHandler.Discover)
 			etl.GET("/datasets/:id", datasetHandler.Get)
+			etl.GET("/datasets/:id/schema", datasetHandler.ExportSchema)
 			etl.POST("/datasets", datasetHandler.Create)
+			etl.POST("/datasets/bulk-import", handler.DecompressBody(int64(cfg.Limits.MaxImportBytes)), datasetHandler.BulkImport)
+			etl.POST("/datasets/infer-schema", datasetHandler.InferSchema)
 			etl.PUT("/datasets/:id", datasetHandler.Update)
+			etl.PATCH("/datasets/:id", datasetHandler.Patch)
 			etl.DELETE("/datasets/:id", datasetHandler.Delete)
+			etl.POST("/datasets/:id/favorite", datasetHandler.AddFavorite)
+			etl.DELETE("/datasets/:id/favorite", datasetHandler.RemoveFavorite)
+			etl.GET("/datasets/:id/permissions", datasetHandler.GetPermissions)
+			etl.POST("/datasets/:id/permissions", datasetHandler.UpdatePermissions)
 
 			// Pipelines
 			etl.GET("/pipelines", pipelineHandler.List)
 			etl.GET("/pipelines/:id", pipelineHandler.Get)
 			etl.POST("/pipelines", pipelineHandler.Create)
+			etl.POST("/pipelines/validate", pipelineHandler.Validate)
+			etl.POST("/pipelines/:id/diff", pipelineHandler.Diff)
 			etl.PUT("/pipelines/:id", pipelineHandler.Update)
+			etl.POST("/pipelines/:id/publish", pipelineHandler.Publish)
+			etl.POST("/pipelines/:id/deactivate", pipelineHandler.Deactivate)
 			etl.DELETE("/pipelines/:id", pipelineHandler.Delete)
 
 			// Schedules
 			etl.GET("/schedules", scheduleHandler.List)
 			etl.GET("/schedules/:id", scheduleHandler.Get)
+			etl.GET("/schedules/:id/plan", scheduleHandler.Plan)
+			etl.GET("/schedules/:id/next-runs", scheduleHandler.NextRuns)
 			etl.POST("/schedules", scheduleHandler.Create)
 			etl.PUT("/schedules/:id", scheduleHandler.Update)
 			etl.DELETE("/schedules/:id", scheduleHandler.Delete)
@@ -97,24 +225,64 @@ func main() {
 
 			// Executions
 			etl.GET("/executions", executionHandler.List)
+			etl.POST("/executions/bulk", executionHandler.BulkCreate)
+			etl.GET("/executions/compare", executionHandler.Compare)
+			etl.GET("/executions/queue-stats", executionHandler.QueueStats)
 			etl.GET("/executions/:id", executionHandler.Get)
 			etl.GET("/executions/:id/logs", executionHandler.GetLogs)
+			etl.GET("/executions/:id/status/stream", executionHandler.StreamStatus)
+			etl.GET("/executions/:id/wait", executionHandler.Wait)
+
+			// Execution webhooks
+			etl.GET("/webhooks/executions", webhookHandler.List)
+			etl.GET("/webhooks/executions/:id", webhookHandler.Get)
+			etl.POST("/webhooks/executions", webhookHandler.Create)
+			etl.DELETE("/webhooks/executions/:id", webhookHandler.Delete)
+			etl.GET("/webhooks/executions/:id/deliveries", webhookHandler.ListDeliveries)
+
+			// Audit log
+			etl.GET("/audit", auditHandler.List)
 		}
 	}
 
-	// Get port
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = defaultPort
+	if missing := spec.Undocumented(router.Routes()); len(missing) > 0 {
+		logger.Warn("routes missing from the OpenAPI spec", zap.Strings("routes", missing))
+	}
+
+	// Create HTTP server
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           router,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
+	// Deliver execution webhooks, close idle data source connections and
+	// collect metrics until shutdown
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		webhook.NewWorker(cfg.Webhooks, logger).Run(workerCtx)
+	}()
+	go conns.Run(workerCtx)
+	go collector.Run(workerCtx)
+
 	// Start server in goroutine
 	go func() {
 		logger.Info("starting HTTP server",
 			zap.String("service", serviceName),
-			zap.String("port", port),
+			zap.String("version", version.Version),
+			zap.Int("port", cfg.Port),
+			zap.Duration("read_timeout", cfg.Server.ReadTimeout),
+			zap.Duration("write_timeout", cfg.Server.WriteTimeout),
+			zap.Duration("idle_timeout", cfg.Server.IdleTimeout),
+			zap.Strings("cors_allowed_origins", cfg.CORS.AllowedOrigins),
+			zap.String("environment", cfg.Environment),
 		)
-		if err := router.Run(":" + port); err != nil {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Fatal("failed to start server", zap.Error(err))
 		}
 	}()
@@ -125,14 +293,48 @@ func main() {
 	<-quit
 
 	logger.Info("shutting down server...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("server forced to shutdown", zap.Error(err))
+	}
+
+	stopWorker()
+	<-workerDone
+
 	logger.Info("server stopped")
 }
 
-// corsMiddleware adds CORS headers
-func corsMiddleware() gin.HandlerFunc {
+// primaryForWrites routes every repository read of a mutating request to the
+// primary, so existence and permission checks before a write never see
+// replica lag
+func primaryForWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != "GET" && c.Request.Method != "HEAD" {
+			c.Request = c.Request.WithContext(repository.WithPrimary(c.Request.Context()))
+		}
+		c.Next()
+	}
+}
+
+// corsMiddleware adds CORS headers for allowed origins. Requests from other
+// origins get no CORS headers, so browsers refuse to read the response.
+func corsMiddleware(cors config.CORSConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		if cors.AllowsAny() {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Writer.Header().Add("Vary", "Origin")
+			if origin := c.GetHeader("Origin"); origin != "" && cors.Allows(origin) {
+				c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
+				if cors.AllowCredentials {
+					c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+		}
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/version"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/openapi"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/schema"
+)
+
+// Shared query parameters
+var (
+	allTenantsParam = openapi.Param{Name: "allTenants", Type: "boolean", Description: "Cross-tenant view; admins only"}
+	forceParam      = openapi.Param{Name: "force", Type: "boolean", Description: "Delete even if pipelines reference it"}
+	createdByParam  = openapi.Param{Name: "createdBy", Type: "string"}
+	revealParam     = openapi.Param{Name: "reveal", Type: "boolean", Description: "Return secret config fields unmasked; admins only"}
+	fieldsParam     = openapi.Param{Name: "fields", Type: "string", Description: "summary leaves out heavy JSON fields and reports their sizes; full (default) keeps them"}
+)
+
+// apiSpec documents the request and response bodies of every route. Routes
+// are read from the router when the spec is served, so a route missing here
+// still appears, untyped, and is logged at startup.
+func apiSpec() *openapi.Spec {
+	spec := openapi.New("etl-config API", version.Get().Version)
+	doc := spec.Document
+
+	// Service
+	doc("GET", "/health", openapi.Operation{Summary: "Liveness and build version", Tag: "service",
+		Response: map[string]string{}, Bare: true})
+	doc("GET", "/version", openapi.Operation{Summary: "Build metadata", Tag: "service", Response: version.Info{}, Bare: true})
+	doc("GET", "/ready", openapi.Operation{Summary: "Readiness: database reachable and migrated", Tag: "service",
+		Response: map[string]interface{}{}, Bare: true})
+	doc("GET", "/metrics", openapi.Operation{Summary: "Domain metrics in the Prometheus text format", Tag: "service",
+		Response: "", Bare: true})
+	doc("GET", "/openapi.json", openapi.Operation{Summary: "This OpenAPI document", Tag: "service",
+		Response: map[string]interface{}{}, Bare: true})
+
+	// Dashboard
+	doc("GET", "/api/etl/summary", openapi.Operation{Summary: "Dashboard counts", Tag: "dashboard",
+		Query: []openapi.Param{allTenantsParam}, Response: model.Summary{}})
+
+	// Plugins
+	doc("GET", "/api/etl/plugins", openapi.Operation{Summary: "List plugins", Tag: "plugins",
+		Query: []openapi.Param{{Name: "type", Type: "string"}}, Response: []model.Plugin{}})
+	doc("GET", "/api/etl/plugins/:name/schema", openapi.Operation{Summary: "Plugin config JSON Schema", Tag: "plugins",
+		Response: map[string]interface{}{}})
+
+	// Data sources
+	dsFilters := []openapi.Param{{Name: "type", Type: "string"}, {Name: "status", Type: "string"}, {Name: "plugin", Type: "string"}, createdByParam, revealParam, fieldsParam}
+	doc("GET", "/api/etl/datasources", openapi.Operation{Summary: "List data sources", Tag: "datasources",
+		Query: dsFilters, Response: model.DataSource{}, List: true})
+	doc("GET", "/api/etl/datasources/:id", openapi.Operation{Summary: "Get a data source; config resolved for the X-ETL-Environment or service environment", Tag: "datasources",
+		Query: []openapi.Param{revealParam}, Response: model.DataSource{}})
+	doc("POST", "/api/etl/datasources", openapi.Operation{Summary: "Create a data source", Tag: "datasources",
+		Request: model.DataSourceForm{}, Response: model.DataSource{}, Status: 201})
+	doc("PUT", "/api/etl/datasources/:id", openapi.Operation{Summary: "Update a data source", Tag: "datasources",
+		Request: model.DataSourceForm{}, Response: model.DataSource{}})
+	doc("DELETE", "/api/etl/datasources/:id", openapi.Operation{Summary: "Delete a data source", Tag: "datasources",
+		Query: []openapi.Param{forceParam}})
+	doc("POST", "/api/etl/datasources/:id/test", openapi.Operation{Summary: "Test a data source connection in the active environment", Tag: "datasources",
+		Response: map[string]interface{}{}})
+	doc("POST", "/api/etl/datasources/:id/rotate-credentials", openapi.Operation{Summary: "Rotate secret config fields", Tag: "datasources",
+		Request: model.RotateCredentialsForm{}, Response: map[string]interface{}{}})
+
+	// Datasets
+	doc("GET", "/api/etl/datasets", openapi.Operation{Summary: "List datasets", Tag: "datasets",
+		Query: []openapi.Param{{Name: "category", Type: "string"}, {Name: "storage", Type: "string"}, createdByParam,
+			{Name: "favoritesOnly", Type: "boolean"}, fieldsParam},
+		Response: model.DataSet{}, List: true})
+	doc("GET", "/api/etl/datasets/categories", openapi.Operation{Summary: "List dataset categories", Tag: "datasets",
+		Response: []string{}})
+	doc("GET", "/api/etl/datasets/discover", openapi.Operation{Summary: "Discover datasets by capability", Tag: "datasets",
+		Query:    []openapi.Param{{Name: "capability", Type: "string", Description: "Repeat or comma-separate; all must match"}, {Name: "category", Type: "string"}},
+		Response: []model.DataSetSummary{}})
+	doc("GET", "/api/etl/datasets/:id", openapi.Operation{Summary: "Get a dataset", Tag: "datasets", Response: model.DataSet{}})
+	doc("GET", "/api/etl/datasets/:id/schema", openapi.Operation{Summary: "Export a dataset schema", Tag: "datasets",
+		Query: []openapi.Param{{Name: "format", Type: "string", Description: "json, avro or protobuf"}}, Response: map[string]interface{}{}, Bare: true})
+	doc("POST", "/api/etl/datasets", openapi.Operation{Summary: "Create a dataset", Tag: "datasets",
+		Query:   []openapi.Param{{Name: "upsert", Type: "boolean", Description: "Update the dataset of the same name instead (200)"}},
+		Request: model.DataSet{}, Response: model.DataSet{}, Status: 201})
+	doc("POST", "/api/etl/datasets/bulk-import", openapi.Operation{Summary: "Import datasets; accepts gzip bodies", Tag: "datasets",
+		Query:   []openapi.Param{{Name: "upsert", Type: "boolean"}, {Name: "continueOnError", Type: "boolean"}},
+		Request: []model.DataSet{}, Response: []model.DataSetImportResult{}})
+	doc("POST", "/api/etl/datasets/infer-schema", openapi.Operation{Summary: "Infer a schema from sample rows", Tag: "datasets",
+		Query: []openapi.Param{{Name: "format", Type: "string", Description: "json or csv"}}, Response: schema.InferResult{}})
+	doc("PUT", "/api/etl/datasets/:id", openapi.Operation{Summary: "Replace a dataset", Tag: "datasets",
+		Request: model.DataSet{}, Response: model.DataSet{}})
+	doc("PATCH", "/api/etl/datasets/:id", openapi.Operation{Summary: "Update a dataset with a JSON merge patch", Tag: "datasets",
+		Request: map[string]interface{}{}, Response: model.DataSet{}})
+	doc("DELETE", "/api/etl/datasets/:id", openapi.Operation{Summary: "Delete a dataset", Tag: "datasets",
+		Query: []openapi.Param{forceParam}})
+	doc("POST", "/api/etl/datasets/:id/favorite", openapi.Operation{Summary: "Favorite a dataset", Tag: "datasets"})
+	doc("DELETE", "/api/etl/datasets/:id/favorite", openapi.Operation{Summary: "Unfavorite a dataset", Tag: "datasets"})
+	doc("GET", "/api/etl/datasets/:id/permissions", openapi.Operation{Summary: "Get dataset permissions", Tag: "datasets",
+		Response: model.DataSetPermissions{}})
+	doc("POST", "/api/etl/datasets/:id/permissions", openapi.Operation{Summary: "Grant, revoke or transfer dataset roles", Tag: "datasets",
+		Request: model.DataSetPermissionsForm{}, Response: model.DataSetPermissions{}})
+
+	// Pipelines
+	doc("GET", "/api/etl/pipelines", openapi.Operation{Summary: "List pipelines", Tag: "pipelines",
+		Query: []openapi.Param{{Name: "status", Type: "string"}, createdByParam, fieldsParam}, Response: model.Pipeline{}, List: true})
+	doc("GET", "/api/etl/pipelines/:id", openapi.Operation{Summary: "Get a pipeline", Tag: "pipelines", Response: model.Pipeline{}})
+	doc("POST", "/api/etl/pipelines", openapi.Operation{Summary: "Create a pipeline", Tag: "pipelines",
+		Request: model.PipelineForm{}, Response: model.Pipeline{}, Status: 201})
+	doc("POST", "/api/etl/pipelines/validate", openapi.Operation{Summary: "Validate a pipeline without saving", Tag: "pipelines",
+		Request: model.PipelineForm{}, Response: model.PipelineValidation{}})
+	doc("POST", "/api/etl/pipelines/:id/diff", openapi.Operation{Summary: "Diff a desired definition; apply it if unchanged", Tag: "pipelines",
+		Query:   []openapi.Param{{Name: "apply", Type: "boolean", Description: "Save the definition if its diff equals expectedDiff"}},
+		Request: model.PipelineDiffForm{}, Response: model.PipelineDiff{}})
+	doc("PUT", "/api/etl/pipelines/:id", openapi.Operation{Summary: "Update a pipeline", Tag: "pipelines",
+		Request: model.PipelineForm{}, Response: model.Pipeline{}})
+	doc("POST", "/api/etl/pipelines/:id/publish", openapi.Operation{Summary: "Activate a draft or inactive pipeline", Tag: "pipelines",
+		Response: model.Pipeline{}})
+	doc("POST", "/api/etl/pipelines/:id/deactivate", openapi.Operation{Summary: "Deactivate an active pipeline", Tag: "pipelines",
+		Response: model.Pipeline{}})
+	doc("DELETE", "/api/etl/pipelines/:id", openapi.Operation{Summary: "Delete a pipeline", Tag: "pipelines"})
+
+	// Schedules
+	doc("GET", "/api/etl/schedules", openapi.Operation{Summary: "List schedules", Tag: "schedules",
+		Query:    []openapi.Param{{Name: "enabled", Type: "boolean"}, {Name: "active", Type: "boolean"}, {Name: "includeArchived", Type: "boolean"}},
+		Response: model.Schedule{}, List: true})
+	doc("GET", "/api/etl/schedules/:id", openapi.Operation{Summary: "Get a schedule", Tag: "schedules", Response: model.Schedule{}})
+	doc("GET", "/api/etl/schedules/:id/plan", openapi.Operation{Summary: "DAG run order and parallel levels", Tag: "schedules",
+		Response: model.SchedulePlan{}})
+	doc("GET", "/api/etl/schedules/:id/next-runs", openapi.Operation{Summary: "Preview upcoming fire times", Tag: "schedules",
+		Query: []openapi.Param{{Name: "count", Type: "integer"}}, Response: model.ScheduleNextRuns{}})
+	doc("POST", "/api/etl/schedules", openapi.Operation{Summary: "Create or upsert a schedule", Tag: "schedules",
+		Query: []openapi.Param{{Name: "upsert", Type: "boolean"}}, Request: model.ScheduleForm{}, Response: model.Schedule{}, Status: 201})
+	doc("PUT", "/api/etl/schedules/:id", openapi.Operation{Summary: "Update a schedule", Tag: "schedules",
+		Request: model.ScheduleForm{}, Response: model.Schedule{}})
+	doc("DELETE", "/api/etl/schedules/:id", openapi.Operation{Summary: "Delete or archive a schedule", Tag: "schedules",
+		Query: []openapi.Param{{Name: "force", Type: "boolean", Description: "Delete with its executions; admins only"}}})
+	doc("POST", "/api/etl/schedules/:id/enable", openapi.Operation{Summary: "Enable a schedule", Tag: "schedules", Response: model.Schedule{}})
+	doc("POST", "/api/etl/schedules/:id/disable", openapi.Operation{Summary: "Disable a schedule", Tag: "schedules", Response: model.Schedule{}})
+
+	// Executions
+	doc("GET", "/api/etl/executions", openapi.Operation{Summary: "List executions", Tag: "executions",
+		Query: []openapi.Param{{Name: "scheduleId", Type: "string"}, {Name: "pipelineId", Type: "string"}, {Name: "status", Type: "string"},
+			{Name: "startedAfter", Type: "string", Description: "RFC3339"}, {Name: "startedBefore", Type: "string", Description: "RFC3339"}},
+		Response: model.Execution{}, List: true})
+	doc("GET", "/api/etl/executions/compare", openapi.Operation{Summary: "Compare two executions task by task", Tag: "executions",
+		Query: []openapi.Param{{Name: "a", Type: "string"}, {Name: "b", Type: "string"}}, Response: model.ExecutionComparison{}})
+	doc("GET", "/api/etl/executions/queue-stats", openapi.Operation{Summary: "Execution queue statistics", Tag: "executions",
+		Response: model.ExecutionQueueStats{}})
+	doc("GET", "/api/etl/executions/:id", openapi.Operation{Summary: "Get an execution", Tag: "executions", Response: model.Execution{}})
+	doc("GET", "/api/etl/executions/:id/logs", openapi.Operation{Summary: "Execution log entries", Tag: "executions",
+		Query:    []openapi.Param{{Name: "taskId", Type: "string"}, {Name: "level", Type: "string"}, {Name: "fields", Type: "boolean"}},
+		Response: []model.ExecutionLog{}})
+	doc("GET", "/api/etl/executions/:id/status/stream", openapi.Operation{Summary: "Server-sent execution status events", Tag: "executions",
+		Response: "", Bare: true})
+	doc("POST", "/api/etl/executions/bulk", openapi.Operation{Summary: "Queue executions from an NDJSON stream of seeds", Tag: "executions",
+		Request: model.ExecutionSeed{}, Response: model.ExecutionSeedResult{}, Bare: true})
+	doc("GET", "/api/etl/executions/:id/wait", openapi.Operation{Summary: "Wait for an execution to finish", Tag: "executions",
+		Query:    []openapi.Param{{Name: "timeout", Type: "string", Description: "Duration such as 60s; default 30s, at most 5m"}},
+		Response: model.Execution{}})
+
+	// Execution webhooks
+	doc("GET", "/api/etl/webhooks/executions", openapi.Operation{Summary: "List execution webhooks", Tag: "webhooks",
+		Response: []model.Webhook{}})
+	doc("GET", "/api/etl/webhooks/executions/:id", openapi.Operation{Summary: "Get an execution webhook", Tag: "webhooks",
+		Response: model.Webhook{}})
+	doc("POST", "/api/etl/webhooks/executions", openapi.Operation{Summary: "Register an execution webhook", Tag: "webhooks",
+		Request: model.WebhookForm{}, Response: model.Webhook{}, Status: 201})
+	doc("DELETE", "/api/etl/webhooks/executions/:id", openapi.Operation{Summary: "Delete an execution webhook", Tag: "webhooks"})
+	doc("GET", "/api/etl/webhooks/executions/:id/deliveries", openapi.Operation{Summary: "List webhook deliveries", Tag: "webhooks",
+		Query: []openapi.Param{{Name: "status", Type: "string"}}, Response: model.WebhookDelivery{}, List: true})
+
+	// Audit log
+	doc("GET", "/api/etl/audit", openapi.Operation{Summary: "List config audit entries", Tag: "audit",
+		Query:    []openapi.Param{{Name: "entity", Type: "string"}, {Name: "id", Type: "string"}, {Name: "actor", Type: "string"}},
+		Response: model.AuditEntry{}, List: true})
+
+	return spec
+}
@@ -0,0 +1,323 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds etl-config service configuration
+type Config struct {
+	// Port is the HTTP listen port
+	Port int `json:"port"`
+
+	// TrustedProxies lists the proxy CIDRs whose X-Forwarded-For is honored
+	TrustedProxies []string `json:"trusted_proxies"`
+
+	// HTTP server settings
+	Server ServerConfig `json:"server"`
+
+	// Cross-origin request settings
+	CORS CORSConfig `json:"cors"`
+
+	// Definition size limits
+	Limits LimitsConfig `json:"limits"`
+
+	// Outbound execution webhook delivery
+	Webhooks WebhookConfig `json:"webhooks"`
+
+	// Cached connections to data source backends
+	DataSourcePool DataSourcePoolConfig `json:"datasource_pool"`
+
+	// Domain metrics served at /metrics
+	Metrics MetricsConfig `json:"metrics"`
+
+	// Verification of the gateway tokens that carry the caller identity
+	Auth AuthConfig `json:"auth"`
+
+	// Environment selects the data source config overrides reads and
+	// connection tests resolve; "" uses the base configs
+	Environment string `json:"environment"`
+}
+
+// ServerConfig bounds how long the HTTP server waits on clients. Streaming
+// responses clear their own write deadline.
+type ServerConfig struct {
+	ReadTimeout       time.Duration `json:"read_timeout"`        // whole request, including the body
+	ReadHeaderTimeout time.Duration `json:"read_header_timeout"` // request headers only
+	WriteTimeout      time.Duration `json:"write_timeout"`
+	IdleTimeout       time.Duration `json:"idle_timeout"`     // keep-alive connections between requests
+	ShutdownTimeout   time.Duration `json:"shutdown_timeout"` // in-flight requests on SIGTERM
+}
+
+// CORSConfig holds cross-origin request settings
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to call the API, e.g.
+	// "https://studio.example.com". "*" allows any origin.
+	AllowedOrigins []string `json:"allowed_origins"`
+
+	// AllowCredentials lets browsers send cookies and auth headers
+	// cross-origin. It requires an explicit origin list.
+	AllowCredentials bool `json:"allow_credentials"`
+}
+
+// Allows reports whether requests from origin are allowed
+func (c CORSConfig) Allows(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAny reports whether every origin is allowed
+func (c CORSConfig) AllowsAny() bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthConfig holds the secrets that verify the gateway's HS256 tokens. They
+// must match the gateway's JWT_SECRET, JWT_KEYS and JWT_TOKEN_EXPIRY.
+type AuthConfig struct {
+	JWTSecret   string            `json:"-"` // verifies tokens without a kid header
+	SigningKeys map[string]string `json:"-"` // kid -> secret
+	TokenExpiry time.Duration     `json:"token_expiry"`
+	// AllowAnonymous lets /api/etl requests without a token through as an
+	// anonymous user of the default tenant. For local development only.
+	AllowAnonymous bool `json:"allow_anonymous"`
+}
+
+// LimitsConfig bounds the size of schedule DAGs, pipeline step lists, JSON
+// fields and import bodies, so a pathological definition is rejected before it is
+// validated or run, and how often schedules may run heavy pipelines
+type LimitsConfig struct {
+	MaxDAGNodes      int `json:"max_dag_nodes"`
+	MaxDAGEdges      int `json:"max_dag_edges"` // dependsOn entries across all nodes
+	MaxDAGDepth      int `json:"max_dag_depth"` // nodes on the longest dependency chain
+	MaxPipelineSteps int `json:"max_pipeline_steps"`
+	MaxImportBytes   int `json:"max_import_bytes"` // decompressed size of a gzip import body
+	MaxFieldBytes    int `json:"max_field_bytes"`  // one JSON config, schema, storage or steps field
+
+	// MinHeavyInterval is the shortest gap allowed between runs of a
+	// schedule whose DAG includes a pipeline tagged "heavy"
+	MinHeavyInterval time.Duration `json:"min_heavy_interval"`
+}
+
+// WebhookConfig controls how execution webhooks are delivered. A delivery
+// that fails MaxAttempts times is marked dead and no longer retried.
+type WebhookConfig struct {
+	MaxAttempts  int           `json:"max_attempts"`
+	PollInterval time.Duration `json:"poll_interval"` // how often due deliveries are claimed
+	Timeout      time.Duration `json:"timeout"`       // one delivery request
+}
+
+// DataSourcePoolConfig bounds the connections kept open to each data
+// source's backend for connection tests and previews
+type DataSourcePoolConfig struct {
+	MaxConns       int32         `json:"max_conns"`       // per data source
+	IdleTimeout    time.Duration `json:"idle_timeout"`    // unused pools are closed after this
+	ConnectTimeout time.Duration `json:"connect_timeout"` // one backend connection attempt
+	TestTimeout    time.Duration `json:"test_timeout"`    // a whole connection test
+}
+
+// MetricsConfig controls how often the domain metrics are read from the
+// database
+type MetricsConfig struct {
+	Interval time.Duration `json:"interval"`
+}
+
+// DefaultTrustedProxies covers loopback and private network ranges
+var DefaultTrustedProxies = []string{
+	"127.0.0.0/8",
+	"10.0.0.0/8",
+	"172.16.0.0/12",
+	"192.168.0.0/16",
+	"::1/128",
+	"fc00::/7",
+}
+
+// Load loads configuration from environment variables. Durations use
+// time.ParseDuration syntax, e.g. "15s" or "1m".
+func Load() (*Config, error) {
+	cfg := &Config{
+		TrustedProxies: getEnvList("TRUSTED_PROXIES", DefaultTrustedProxies),
+		Environment:    os.Getenv("ETL_ENVIRONMENT"),
+		CORS: CORSConfig{
+			AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", []string{"*"}),
+		},
+		Auth: AuthConfig{
+			JWTSecret: os.Getenv("JWT_SECRET"),
+		},
+	}
+
+	var err error
+	if cfg.Port, err = getEnvInt("PORT", 8080); err != nil {
+		return nil, err
+	}
+	if cfg.Port < 1 || cfg.Port > 65535 {
+		return nil, fmt.Errorf("invalid PORT %d: must be between 1 and 65535", cfg.Port)
+	}
+
+	timeouts := []struct {
+		key          string
+		dest         *time.Duration
+		defaultValue time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", &cfg.Server.ReadTimeout, 15 * time.Second},
+		{"HTTP_READ_HEADER_TIMEOUT", &cfg.Server.ReadHeaderTimeout, 5 * time.Second},
+		{"HTTP_WRITE_TIMEOUT", &cfg.Server.WriteTimeout, 30 * time.Second},
+		{"HTTP_IDLE_TIMEOUT", &cfg.Server.IdleTimeout, 60 * time.Second},
+		{"SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout, 30 * time.Second},
+		{"WEBHOOK_POLL_INTERVAL", &cfg.Webhooks.PollInterval, 5 * time.Second},
+		{"WEBHOOK_TIMEOUT", &cfg.Webhooks.Timeout, 10 * time.Second},
+		{"DATASOURCE_POOL_IDLE_TIMEOUT", &cfg.DataSourcePool.IdleTimeout, 5 * time.Minute},
+		{"DATASOURCE_CONNECT_TIMEOUT", &cfg.DataSourcePool.ConnectTimeout, 10 * time.Second},
+		{"DATASOURCE_TEST_TIMEOUT", &cfg.DataSourcePool.TestTimeout, 10 * time.Second},
+		{"SCHEDULE_MIN_HEAVY_INTERVAL", &cfg.Limits.MinHeavyInterval, 5 * time.Minute},
+		{"METRICS_INTERVAL", &cfg.Metrics.Interval, 15 * time.Second},
+	}
+	for _, t := range timeouts {
+		if *t.dest, err = getEnvDuration(t.key, t.defaultValue); err != nil {
+			return nil, err
+		}
+		if *t.dest <= 0 {
+			return nil, fmt.Errorf("invalid %s %s: must be positive", t.key, *t.dest)
+		}
+	}
+
+	limits := []struct {
+		key          string
+		dest         *int
+		defaultValue int
+	}{
+		{"DAG_MAX_NODES", &cfg.Limits.MaxDAGNodes, 500},
+		{"DAG_MAX_EDGES", &cfg.Limits.MaxDAGEdges, 2000},
+		{"DAG_MAX_DEPTH", &cfg.Limits.MaxDAGDepth, 50},
+		{"PIPELINE_MAX_STEPS", &cfg.Limits.MaxPipelineSteps, 200},
+		{"IMPORT_MAX_BYTES", &cfg.Limits.MaxImportBytes, 32 << 20},
+		{"JSON_FIELD_MAX_BYTES", &cfg.Limits.MaxFieldBytes, 256 << 10},
+		{"WEBHOOK_MAX_ATTEMPTS", &cfg.Webhooks.MaxAttempts, 8},
+	}
+	for _, l := range limits {
+		if *l.dest, err = getEnvInt(l.key, l.defaultValue); err != nil {
+			return nil, err
+		}
+		if *l.dest < 1 {
+			return nil, fmt.Errorf("invalid %s %d: must be at least 1", l.key, *l.dest)
+		}
+	}
+
+	maxConns, err := getEnvInt("DATASOURCE_POOL_MAX_CONNS", 2)
+	if err != nil {
+		return nil, err
+	}
+	if maxConns < 1 || maxConns > 100 {
+		return nil, fmt.Errorf("invalid DATASOURCE_POOL_MAX_CONNS %d: must be between 1 and 100", maxConns)
+	}
+	cfg.DataSourcePool.MaxConns = int32(maxConns)
+
+	if len(cfg.CORS.AllowedOrigins) == 0 {
+		return nil, fmt.Errorf("CORS_ALLOWED_ORIGINS lists no origins")
+	}
+	if cfg.CORS.AllowCredentials, err = getEnvBool("CORS_ALLOW_CREDENTIALS", false); err != nil {
+		return nil, err
+	}
+	if cfg.CORS.AllowCredentials && cfg.CORS.AllowsAny() {
+		return nil, fmt.Errorf(`CORS_ALLOW_CREDENTIALS requires CORS_ALLOWED_ORIGINS to list origins instead of "*"`)
+	}
+
+	// In seconds, as the gateway reads it
+	expiry, err := getEnvInt("JWT_TOKEN_EXPIRY", 3600)
+	if err != nil {
+		return nil, err
+	}
+	if expiry < 1 {
+		return nil, fmt.Errorf("invalid JWT_TOKEN_EXPIRY %d: must be at least 1", expiry)
+	}
+	cfg.Auth.TokenExpiry = time.Duration(expiry) * time.Second
+	if cfg.Auth.AllowAnonymous, err = getEnvBool("AUTH_ALLOW_ANONYMOUS", false); err != nil {
+		return nil, err
+	}
+	if cfg.Auth.SigningKeys, err = parseSigningKeys(getEnvList("JWT_KEYS", nil)); err != nil {
+		return nil, fmt.Errorf("invalid JWT_KEYS: %w", err)
+	}
+	for _, kid := range getEnvList("JWT_RETIRED_KIDS", nil) {
+		delete(cfg.Auth.SigningKeys, kid)
+	}
+	if cfg.Auth.JWTSecret == "" && len(cfg.Auth.SigningKeys) == 0 {
+		return nil, fmt.Errorf("JWT_SECRET or JWT_KEYS must be set to verify gateway tokens")
+	}
+
+	return cfg, nil
+}
+
+// parseSigningKeys parses "kid=secret" entries
+func parseSigningKeys(list []string) (map[string]string, error) {
+	keys := make(map[string]string, len(list))
+	for _, entry := range list {
+		kid, secret, ok := strings.Cut(entry, "=")
+		kid = strings.TrimSpace(kid)
+		if !ok || kid == "" || secret == "" {
+			return nil, fmt.Errorf("an entry is not kid=secret")
+		}
+		keys[kid] = secret
+	}
+	return keys, nil
+}
+
+func getEnvInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return n, nil
+}
+
+func getEnvBool(key string, defaultValue bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return b, nil
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return d, nil
+}
+
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,235 @@
+// Package connpool caches connection pools to the backends of database data
+// sources, so repeated connection tests and previews reuse connections
+// instead of dialing the remote every time.
+package connpool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// ErrUnsupported is returned for data sources whose plugin has no pooled
+// connection support
+var ErrUnsupported = errors.New("connection pooling is not supported for this plugin")
+
+// postgresPlugin is the plugin of PostgreSQL data sources
+const postgresPlugin = "source-postgres"
+
+// entry is the cached pool of one data source
+type entry struct {
+	pool     *pgxpool.Pool
+	hash     [sha256.Size]byte // of the config the pool was opened with
+	lastUsed time.Time
+	users    int  // callers holding the pool
+	removed  bool // out of the cache; the last user to release it closes it
+}
+
+// Manager caches one pool per data source, keyed by data source ID and
+// checked against a hash of its config. A pool unused for the idle timeout
+// is closed by Run. A pool is taken out of the cache before it is closed,
+// and one still held by a caller is only closed once released, so callers
+// never see a pool closed under them.
+type Manager struct {
+	cfg config.DataSourcePoolConfig
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewManager creates a new Manager
+func NewManager(cfg config.DataSourcePoolConfig) *Manager {
+	return &Manager{
+		cfg:     cfg,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Supports reports whether ds has pooled connection support
+func Supports(ds *model.DataSource) bool {
+	return ds.Type == "database" && ds.Plugin == postgresPlugin
+}
+
+// Get returns the pool of ds, opening one on first use, and a release func
+// to call once done with it. A cached pool opened with a different config is
+// replaced; callers still holding it keep it until they release it.
+func (m *Manager) Get(ctx context.Context, ds *model.DataSource) (*pgxpool.Pool, func(), error) {
+	if !Supports(ds) {
+		return nil, nil, ErrUnsupported
+	}
+	hash := sha256.Sum256(append([]byte(ds.Plugin+"\x00"), ds.Config...))
+
+	var closing []*pgxpool.Pool
+	defer func() { closePools(closing) }()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[ds.ID]
+	if ok && e.hash != hash {
+		closing = m.remove(ds.ID, closing)
+		ok = false
+	}
+	if !ok {
+		poolConfig, err := m.poolConfig(ds.Config)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Opening is lazy, so holding the lock does not wait on the remote
+		pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		e = &entry{pool: pool, hash: hash}
+		m.entries[ds.ID] = e
+	}
+	e.lastUsed = time.Now()
+	e.users++
+
+	var once sync.Once
+	return e.pool, func() { once.Do(func() { m.release(e) }) }, nil
+}
+
+// release ends one caller's use of a pool, closing the pool if it was
+// removed from the cache meanwhile and no other caller holds it
+func (m *Manager) release(e *entry) {
+	m.mu.Lock()
+	e.users--
+	last := e.removed && e.users == 0
+	m.mu.Unlock()
+
+	if last {
+		e.pool.Close()
+	}
+}
+
+// Invalidate closes the cached pool of a data source, e.g. after its config
+// changed or it was deleted
+func (m *Manager) Invalidate(id string) {
+	m.mu.Lock()
+	closing := m.remove(id, nil)
+	m.mu.Unlock()
+
+	closePools(closing)
+}
+
+// Run closes idle pools until ctx is done
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.cfg.IdleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.evictIdle()
+		}
+	}
+}
+
+// evictIdle closes the pools no caller holds that were unused for the idle
+// timeout
+func (m *Manager) evictIdle() {
+	var closing []*pgxpool.Pool
+	m.mu.Lock()
+	for id, e := range m.entries {
+		if e.users == 0 && time.Since(e.lastUsed) >= m.cfg.IdleTimeout {
+			closing = m.remove(id, closing)
+		}
+	}
+	m.mu.Unlock()
+
+	closePools(closing)
+}
+
+// Close closes every cached pool; pools still held are closed when released
+func (m *Manager) Close() {
+	var closing []*pgxpool.Pool
+	m.mu.Lock()
+	for id := range m.entries {
+		closing = m.remove(id, closing)
+	}
+	m.mu.Unlock()
+
+	closePools(closing)
+}
+
+// remove takes the pool of a data source out of the cache. A pool no caller
+// holds is appended to closing for the caller to close once m.mu is
+// released; a held one is closed by its last release. m.mu must be held.
+func (m *Manager) remove(id string, closing []*pgxpool.Pool) []*pgxpool.Pool {
+	e, ok := m.entries[id]
+	if !ok {
+		return closing
+	}
+	delete(m.entries, id)
+	e.removed = true
+	if e.users == 0 {
+		closing = append(closing, e.pool)
+	}
+	return closing
+}
+
+// closePools closes pools, each of which waits for its acquired connections
+// to be released
+func closePools(pools []*pgxpool.Pool) {
+	for _, pool := range pools {
+		pool.Close()
+	}
+}
+
+// postgresConfig is the config of a PostgreSQL data source
+type postgresConfig struct {
+	Host     string      `json:"host"`
+	Port     json.Number `json:"port"`
+	Database string      `json:"database"`
+	Username string      `json:"username"`
+	Password string      `json:"password"`
+}
+
+// poolConfig builds the pool config of a PostgreSQL data source
+func (m *Manager) poolConfig(raw json.RawMessage) (*pgxpool.Config, error) {
+	var c postgresConfig
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, fmt.Errorf("invalid data source config: %w", err)
+	}
+	if c.Host == "" || c.Database == "" {
+		return nil, fmt.Errorf("data source config requires host and database")
+	}
+	port := 5432
+	if c.Port != "" {
+		n, err := strconv.Atoi(c.Port.String())
+		if err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid data source port %q", c.Port)
+		}
+		port = n
+	}
+
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.Username, c.Password),
+		Host:   net.JoinHostPort(c.Host, strconv.Itoa(port)),
+		Path:   "/" + c.Database,
+	}
+	poolConfig, err := pgxpool.ParseConfig(dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid data source config: %w", err)
+	}
+	poolConfig.MaxConns = m.cfg.MaxConns
+	poolConfig.MinConns = 0
+	poolConfig.MaxConnIdleTime = m.cfg.IdleTimeout
+	poolConfig.ConnConfig.ConnectTimeout = m.cfg.ConnectTimeout
+	return poolConfig, nil
+}
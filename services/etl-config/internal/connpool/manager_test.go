@@ -0,0 +1,185 @@
+package connpool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// newManager returns a Manager whose pools dial a port nothing listens on,
+// so using one fails fast instead of reaching a database
+func newManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager(config.DataSourcePoolConfig{MaxConns: 2, IdleTimeout: time.Minute, ConnectTimeout: time.Second})
+	t.Cleanup(m.Close)
+	return m
+}
+
+func newDataSource(id, database string) *model.DataSource {
+	return &model.DataSource{
+		ID:     id,
+		Type:   "database",
+		Plugin: postgresPlugin,
+		Config: json.RawMessage(`{"host": "127.0.0.1", "port": 1, "database": "` + database + `"}`),
+	}
+}
+
+// get returns the pool of ds, failing the test on error
+func get(t *testing.T, m *Manager, ds *model.DataSource) (*pgxpool.Pool, func()) {
+	t.Helper()
+	pool, release, err := m.Get(context.Background(), ds)
+	if err != nil {
+		t.Fatalf("Get(%s): %v", ds.ID, err)
+	}
+	return pool, release
+}
+
+// closed reports whether pool was closed: acquiring from a closed pool fails
+// at once, while an open one dials and is refused
+func closed(pool *pgxpool.Pool) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := pool.Acquire(ctx)
+	if err == nil {
+		conn.Release()
+		return false
+	}
+	return strings.Contains(err.Error(), "closed pool")
+}
+
+func TestGet(t *testing.T) {
+	m := newManager(t)
+	ds := newDataSource("ds1", "bars")
+
+	pool, release := get(t, m, ds)
+	again, releaseAgain := get(t, m, ds)
+	releaseAgain()
+	release()
+	if again != pool {
+		t.Error("Get of an unchanged data source opened a second pool")
+	}
+	if closed(pool) {
+		t.Error("released pool was closed while cached")
+	}
+
+	if _, _, err := m.Get(context.Background(), &model.DataSource{ID: "api", Type: "api"}); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Get of an API source = %v, want ErrUnsupported", err)
+	}
+	bad := newDataSource("ds2", "")
+	if _, _, err := m.Get(context.Background(), bad); err == nil {
+		t.Error("Get of a config without a database succeeded")
+	}
+	if len(m.entries) != 1 {
+		t.Errorf("%d pools cached, want 1", len(m.entries))
+	}
+}
+
+func TestGetConfigChange(t *testing.T) {
+	m := newManager(t)
+	old, release := get(t, m, newDataSource("ds1", "bars"))
+
+	// The pool is replaced, but the caller holding it may finish with it
+	changed, releaseChanged := get(t, m, newDataSource("ds1", "ticks"))
+	defer releaseChanged()
+	if changed == old {
+		t.Fatal("Get after a config change returned the old pool")
+	}
+	if closed(old) {
+		t.Fatal("replaced pool was closed while a caller held it")
+	}
+
+	release()
+	if !closed(old) {
+		t.Error("replaced pool was not closed by its last release")
+	}
+	if closed(changed) {
+		t.Error("new pool was closed")
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	m := newManager(t)
+	ds := newDataSource("ds1", "bars")
+
+	idle, release := get(t, m, ds)
+	release()
+	m.Invalidate(ds.ID)
+	if !closed(idle) {
+		t.Error("Invalidate left an unheld pool open")
+	}
+
+	held, release := get(t, m, ds)
+	if held == idle {
+		t.Fatal("Get after Invalidate returned the invalidated pool")
+	}
+	m.Invalidate(ds.ID)
+	if closed(held) {
+		t.Fatal("Invalidate closed a pool a caller held")
+	}
+	release()
+	release() // a second release is a no-op
+	if !closed(held) {
+		t.Error("invalidated pool was not closed by its release")
+	}
+	if len(m.entries) != 0 {
+		t.Errorf("%d pools cached after Invalidate, want 0", len(m.entries))
+	}
+
+	m.Invalidate("unknown")
+}
+
+func TestEvictIdle(t *testing.T) {
+	m := newManager(t)
+	idle, release := get(t, m, newDataSource("idle", "bars"))
+	release()
+	held, releaseHeld := get(t, m, newDataSource("held", "bars"))
+	fresh, releaseFresh := get(t, m, newDataSource("fresh", "bars"))
+	releaseFresh()
+
+	m.mu.Lock()
+	m.entries["idle"].lastUsed = time.Now().Add(-2 * time.Minute)
+	m.entries["held"].lastUsed = time.Now().Add(-2 * time.Minute)
+	m.mu.Unlock()
+	m.evictIdle()
+
+	if !closed(idle) {
+		t.Error("idle pool was not closed")
+	}
+	if closed(held) || closed(fresh) {
+		t.Error("evictIdle closed a held or recently used pool")
+	}
+	if _, ok := m.entries["idle"]; ok || len(m.entries) != 2 {
+		t.Errorf("cached %v, want the held and fresh pools", m.entries)
+	}
+	releaseHeld()
+}
+
+func TestClose(t *testing.T) {
+	m := newManager(t)
+	idle, release := get(t, m, newDataSource("idle", "bars"))
+	release()
+	held, releaseHeld := get(t, m, newDataSource("held", "bars"))
+
+	m.Close()
+	if !closed(idle) {
+		t.Error("Close left an unheld pool open")
+	}
+	if closed(held) {
+		t.Fatal("Close closed a pool a caller held")
+	}
+	releaseHeld()
+	if !closed(held) {
+		t.Error("pool held during Close was not closed by its release")
+	}
+	if len(m.entries) != 0 {
+		t.Errorf("%d pools cached after Close, want 0", len(m.entries))
+	}
+}
@@ -0,0 +1,281 @@
+// Package cron parses cron expressions of five fields, or six with leading
+// seconds, as the scheduler runs them, so fire times can be checked before
+// a schedule is saved.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Expr is a parsed cron expression: one bit per allowed value of each field
+type Expr struct {
+	second, minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny record a "*" day field; as in standard cron, when
+	// both day fields are restricted a day matching either one fires
+	domAny, dowAny bool
+}
+
+// field describes the values one position of an expression accepts
+type field struct {
+	name     string
+	min, max int
+	names    []string // value names starting at min, e.g. "jan"
+}
+
+var (
+	secondField = field{name: "second", min: 0, max: 59}
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}}
+	// 7 is accepted as Sunday and folded onto 0
+	dowField = field{name: "day of week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}}
+)
+
+// macros are the named expressions accepted in place of five fields
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses an expression of five fields (minute, hour, day of month,
+// month, day of week), six fields with a leading second, or a macro such as
+// @daily. Five-field expressions fire at second 0. Fields accept "*",
+// values, ranges "a-b", steps "*/n" and "a-b/n", comma-separated lists, and
+// month and weekday names.
+func Parse(s string) (*Expr, error) {
+	s = strings.TrimSpace(s)
+	if m, ok := macros[strings.ToLower(s)]; ok {
+		s = m
+	}
+	fields := strings.Fields(s)
+	if len(fields) != 5 && len(fields) != 6 {
+		return nil, fmt.Errorf("cron expression %q has %d fields, want 5, or 6 with seconds", s, len(fields))
+	}
+
+	second := uint64(1)
+	if len(fields) == 6 {
+		var err error
+		if second, err = secondField.parse(fields[0]); err != nil {
+			return nil, err
+		}
+		fields = fields[1:]
+	}
+
+	e := &Expr{
+		second: second,
+		domAny: fields[2] == "*" || fields[2] == "?",
+		dowAny: fields[4] == "*" || fields[4] == "?",
+	}
+	var err error
+	for i, f := range []struct {
+		dest *uint64
+		spec field
+	}{
+		{&e.minute, minuteField},
+		{&e.hour, hourField},
+		{&e.dom, domField},
+		{&e.month, monthField},
+		{&e.dow, dowField},
+	} {
+		if *f.dest, err = f.spec.parse(fields[i]); err != nil {
+			return nil, err
+		}
+	}
+	if e.dow&(1<<7) != 0 {
+		e.dow = e.dow&^(1<<7) | 1
+	}
+	return e, nil
+}
+
+// parse returns the bits of the values a field expression allows
+func (f field) parse(s string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(s, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		lo, hi := f.min, f.max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			a, b, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid %s range %q", f.name, rangePart)
+			}
+		default:
+			v, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if !hasStep {
+				hi = v
+			}
+		}
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid %s step %q", f.name, stepPart)
+			}
+			step = n
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+// value parses one number or name of a field
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q: must be between %d and %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next returns the first fire time after t, in t's location. It returns
+// the zero time when the expression never fires, e.g. "0 0 31 2 *".
+//
+// Fire times follow the wall clock of t's location across daylight saving
+// changes: a time skipped when clocks move forward does not fire that day,
+// and a time repeated when clocks move back fires once, at its first
+// occurrence.
+func (e *Expr) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	// Any expression that fires at all does so within four years
+	limit := t.AddDate(4, 0, 1)
+
+	for t.Before(limit) {
+		if e.month&(1<<uint(t.Month())) == 0 {
+			t = later(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !e.dayMatches(t) {
+			t = later(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+		// Hours and minutes are stepped in absolute time: stepping the wall
+		// clock through time.Date lands back before t inside a skipped hour
+		if e.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second).Add(time.Hour)
+			continue
+		}
+		if e.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(-time.Duration(t.Second()) * time.Second).Add(time.Minute)
+			continue
+		}
+		if e.second&(1<<uint(t.Second())) == 0 || repeated(t) {
+			t = t.Add(time.Second)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// later returns next, or the start of the hour after t when next is not
+// after t, e.g. because the midnight next names is skipped by a daylight
+// saving change
+func later(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second).Add(time.Hour)
+}
+
+// repeated reports whether the wall clock of t already occurred earlier,
+// because clocks were set back shortly before t
+func repeated(t time.Time) bool {
+	_, offset := t.Zone()
+	// Clocks are never set back by more than a few hours
+	_, before := t.Add(-3 * time.Hour).Zone()
+	if before <= offset {
+		return false
+	}
+	first := t.Add(-time.Duration(before-offset) * time.Second)
+	y1, m1, d1 := first.Date()
+	y2, m2, d2 := t.Date()
+	return y1 == y2 && m1 == m2 && d1 == d2 &&
+		first.Hour() == t.Hour() && first.Minute() == t.Minute() && first.Second() == t.Second()
+}
+
+// dayMatches reports whether the day of t matches the day fields
+func (e *Expr) dayMatches(t time.Time) bool {
+	dom := e.dom&(1<<uint(t.Day())) != 0
+	dow := e.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case e.domAny && e.dowAny:
+		return true
+	case e.domAny:
+		return dow
+	case e.dowAny:
+		return dom
+	default:
+		return dom || dow
+	}
+}
+
+// MinInterval returns the shortest gap between the first n fire times after
+// from. It reports false when the expression fires fewer than twice.
+func (e *Expr) MinInterval(from time.Time, n int) (time.Duration, bool) {
+	var shortest time.Duration
+	found := false
+	prev := e.Next(from)
+	for i := 1; i < n && !prev.IsZero(); i++ {
+		next := e.Next(prev)
+		if next.IsZero() {
+			break
+		}
+		if gap := next.Sub(prev); !found || gap < shortest {
+			shortest, found = gap, true
+		}
+		prev = next
+	}
+	return shortest, found
+}
+
+// Upcoming returns the next n fire times after from, in from's location,
+// that fall in the window between activeFrom and activeUntil (exclusive); a
+// nil bound leaves that side open. It returns fewer when the expression
+// stops firing or the window closes.
+func (e *Expr) Upcoming(from time.Time, n int, activeFrom, activeUntil *time.Time) []time.Time {
+	if activeFrom != nil && activeFrom.After(from) {
+		from = activeFrom.In(from.Location())
+	}
+	var times []time.Time
+	for t := e.Next(from); len(times) < n && !t.IsZero(); t = e.Next(t) {
+		if activeUntil != nil && !t.Before(*activeUntil) {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
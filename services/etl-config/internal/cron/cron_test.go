@@ -0,0 +1,212 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, expr string) *Expr {
+	t.Helper()
+	e, err := Parse(expr)
+	if err != nil {
+		t.Fatalf("Parse(%q): %v", expr, err)
+	}
+	return e
+}
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s unavailable: %v", name, err)
+	}
+	return loc
+}
+
+// nextN returns the n fire times following from, failing the test when
+// the expression stops firing
+func nextN(t *testing.T, e *Expr, from time.Time, n int) []time.Time {
+	t.Helper()
+	times := make([]time.Time, 0, n)
+	for next := from; len(times) < n; {
+		next = e.Next(next)
+		if next.IsZero() {
+			t.Fatalf("expression stopped firing after %v", times)
+		}
+		times = append(times, next)
+	}
+	return times
+}
+
+func TestNextSpringForward(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	// Clocks go from 02:00 EST to 03:00 EDT on 2026-03-08
+	from := time.Date(2026, 3, 7, 12, 0, 0, 0, ny)
+
+	tests := []struct {
+		expr string
+		want []time.Time
+	}{
+		{"0 9 * * *", []time.Time{
+			time.Date(2026, 3, 8, 9, 0, 0, 0, ny),
+			time.Date(2026, 3, 9, 9, 0, 0, 0, ny),
+		}},
+		// 02:30 does not exist on the 8th, so that day is skipped
+		{"30 2 * * *", []time.Time{
+			time.Date(2026, 3, 9, 2, 30, 0, 0, ny),
+			time.Date(2026, 3, 10, 2, 30, 0, 0, ny),
+		}},
+		{"0 * 8 3 *", []time.Time{
+			time.Date(2026, 3, 8, 0, 0, 0, 0, ny),
+			time.Date(2026, 3, 8, 1, 0, 0, 0, ny),
+			time.Date(2026, 3, 8, 3, 0, 0, 0, ny),
+		}},
+	}
+	for _, tt := range tests {
+		got := nextN(t, mustParse(t, tt.expr), from, len(tt.want))
+		for i := range tt.want {
+			if !got[i].Equal(tt.want[i]) {
+				t.Errorf("%q fire %d = %v, want %v", tt.expr, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestNextFallBack(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	// Clocks go from 02:00 EDT back to 01:00 EST on 2026-11-01
+	from := time.Date(2026, 10, 31, 12, 0, 0, 0, ny)
+	edt := time.FixedZone("EDT", -4*3600)
+	est := time.FixedZone("EST", -5*3600)
+
+	tests := []struct {
+		expr string
+		want []time.Time
+	}{
+		// 01:30 happens twice on the 1st and fires at its first occurrence
+		{"30 1 * * *", []time.Time{
+			time.Date(2026, 11, 1, 1, 30, 0, 0, edt),
+			time.Date(2026, 11, 2, 1, 30, 0, 0, est),
+		}},
+		{"0 * 1 11 *", []time.Time{
+			time.Date(2026, 11, 1, 0, 0, 0, 0, edt),
+			time.Date(2026, 11, 1, 1, 0, 0, 0, edt),
+			time.Date(2026, 11, 1, 2, 0, 0, 0, est),
+		}},
+	}
+	for _, tt := range tests {
+		got := nextN(t, mustParse(t, tt.expr), from, len(tt.want))
+		for i := range tt.want {
+			if !got[i].Equal(tt.want[i]) {
+				t.Errorf("%q fire %d = %v, want %v", tt.expr, i, got[i], tt.want[i])
+			}
+		}
+	}
+}
+
+func TestNextNonHourOffset(t *testing.T) {
+	kolkata := mustLoad(t, "Asia/Kolkata")
+	e := mustParse(t, "0 9 * * *")
+	got := e.Next(time.Date(2026, 3, 7, 12, 0, 0, 0, kolkata))
+	if want := time.Date(2026, 3, 8, 9, 0, 0, 0, kolkata); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
+
+func TestMinIntervalAcrossTransitions(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	e := mustParse(t, "0 9 * * *")
+	tests := []struct {
+		from time.Time
+		want time.Duration
+	}{
+		{time.Date(2026, 3, 1, 0, 0, 0, 0, ny), 23 * time.Hour},
+		{time.Date(2026, 10, 20, 0, 0, 0, 0, ny), 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		got, ok := e.MinInterval(tt.from, 64)
+		if !ok || got != tt.want {
+			t.Errorf("MinInterval from %v = %v, %v; want %v, true", tt.from, got, ok, tt.want)
+		}
+	}
+}
+
+func TestNextNever(t *testing.T) {
+	if got := mustParse(t, "0 0 31 2 *").Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next = %v, want the zero time", got)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	ny := mustLoad(t, "America/New_York")
+	e := mustParse(t, "0 9 * * *")
+	from := time.Date(2026, 3, 6, 12, 0, 0, 0, ny)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 9, 0, 0, 0, ny) }
+	ptr := func(t time.Time) *time.Time { return &t }
+
+	tests := []struct {
+		name                    string
+		n                       int
+		activeFrom, activeUntil *time.Time
+		want                    []time.Time
+	}{
+		{"count across spring forward", 3, nil, nil, []time.Time{day(7), day(8), day(9)}},
+		{"single", 1, nil, nil, []time.Time{day(7)}},
+		{"active from", 2, ptr(day(8)), nil, []time.Time{day(9), day(10)}},
+		{"active until is exclusive", 5, nil, ptr(day(9)), []time.Time{day(7), day(8)}},
+		{"window closed", 5, nil, ptr(from), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := e.Upcoming(from, tt.n, tt.activeFrom, tt.activeUntil)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Upcoming = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if !got[i].Equal(tt.want[i]) {
+					t.Errorf("run %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"0 9 * * *", false},
+		{"*/15 8-18 * * MON-FRI", false},
+		{"30 0 9 * * *", false},
+		{"*/10 * * * * *", false},
+		{"@daily", false},
+		{"", true},
+		{"0 9 * *", true},
+		{"0 0 9 * * * 2026", true},
+		{"60 9 * * *", true},
+		{"0 9 * * * *x", true},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(tt.expr); (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, want error %v", tt.expr, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNextWithSeconds(t *testing.T) {
+	from := time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"0 9 * * *", time.Date(2026, 3, 8, 9, 0, 0, 0, time.UTC)},
+		{"30 0 9 * * *", time.Date(2026, 3, 8, 9, 0, 30, 0, time.UTC)},
+		{"*/20 * * * * *", time.Date(2026, 3, 7, 12, 0, 20, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := mustParse(t, tt.expr).Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q Next = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
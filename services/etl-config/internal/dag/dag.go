@@ -0,0 +1,234 @@
+// Package dag models the dependency graphs of schedule DAGs and pipeline
+// step lists, so every feature that orders or bounds them reads the same
+// structure.
+package dag
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Node is one vertex of a graph and the IDs of the nodes it depends on
+type Node struct {
+	ID        string   `json:"id"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// UnmarshalJSON reads the dependencies from dependsOn and from depends_on,
+// the spelling the executor reads, so neither can hide an edge from
+// validation
+func (n *Node) UnmarshalJSON(data []byte) error {
+	var v struct {
+		ID             string   `json:"id"`
+		DependsOn      []string `json:"dependsOn"`
+		DependsOnSnake []string `json:"depends_on"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	n.ID = v.ID
+	n.DependsOn = v.DependsOn
+	for _, dep := range v.DependsOnSnake {
+		if !contains(n.DependsOn, dep) {
+			n.DependsOn = append(n.DependsOn, dep)
+		}
+	}
+	return nil
+}
+
+// Edge is a dependency: To runs after From
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Graph is a dependency graph. Nodes keep their definition order, which
+// orders nodes within a topological level.
+type Graph struct {
+	Nodes []Node
+}
+
+// Parse reads a graph from a JSON array of nodes, e.g. a stored schedule
+// DAG. Fields other than id and dependsOn or depends_on are ignored. Empty input and null
+// are an empty graph.
+func Parse(raw []byte) (*Graph, error) {
+	g := &Graph{}
+	if len(raw) == 0 || string(raw) == "null" {
+		return g, nil
+	}
+	if err := json.Unmarshal(raw, &g.Nodes); err != nil {
+		return nil, fmt.Errorf("dag must be an array of nodes: %v", err)
+	}
+	return g, nil
+}
+
+// Edges returns the dependency edges in node order
+func (g *Graph) Edges() []Edge {
+	var edges []Edge
+	for _, n := range g.Nodes {
+		for _, dep := range n.DependsOn {
+			edges = append(edges, Edge{From: dep, To: n.ID})
+		}
+	}
+	return edges
+}
+
+// Validate rejects nodes without an ID, duplicate IDs, dependencies on
+// unknown nodes or the node itself, and cycles
+func (g *Graph) Validate() error {
+	_, err := g.TopologicalLevels()
+	return err
+}
+
+// TopologicalLevels groups the node IDs into levels that can run in
+// parallel: every node of a level depends only on nodes of earlier levels.
+// Nodes within a level keep their definition order. It fails with the
+// error Validate reports for an invalid graph.
+func (g *Graph) TopologicalLevels() ([][]string, error) {
+	index, err := g.index()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]int, len(g.Nodes)) // unmet dependencies per node
+	dependents := make([][]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		for _, dep := range n.DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("node %q depends on %q which does not exist", n.ID, dep)
+			}
+			if j == i {
+				return nil, fmt.Errorf("node %q depends on itself", n.ID)
+			}
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	var ready []int
+	for i := range g.Nodes {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	levels := [][]string{}
+	placed := 0
+	for len(ready) > 0 {
+		level := make([]string, len(ready))
+		var next []int
+		for k, i := range ready {
+			level[k] = g.Nodes[i].ID
+			for _, d := range dependents[i] {
+				if pending[d]--; pending[d] == 0 {
+					next = append(next, d)
+				}
+			}
+		}
+		levels = append(levels, level)
+		placed += len(level)
+		sort.Ints(next)
+		ready = next
+	}
+
+	if placed < len(g.Nodes) {
+		var cyclic []string
+		for i, n := range g.Nodes {
+			if pending[i] > 0 {
+				cyclic = append(cyclic, n.ID)
+			}
+		}
+		return nil, fmt.Errorf("dependency cycle through nodes: %s", strings.Join(cyclic, ", "))
+	}
+	return levels, nil
+}
+
+// Depth returns the number of nodes on the longest dependency chain.
+// Unlike TopologicalLevels it works on invalid graphs: dependencies on
+// unknown nodes and edges closing a cycle are ignored, and a duplicated ID
+// contributes the dependencies of every node carrying it. Nodes are walked
+// in definition order, so which edge of a cycle is ignored, and with it the
+// depth, does not vary between calls.
+func (g *Graph) Depth() int {
+	deps := make(map[string][]string, len(g.Nodes))
+	for _, n := range g.Nodes {
+		deps[n.ID] = append(deps[n.ID], n.DependsOn...)
+	}
+
+	depth := make(map[string]int, len(deps))
+	visiting := make(map[string]bool)
+
+	var visit func(id string) int
+	visit = func(id string) int {
+		if d, ok := depth[id]; ok {
+			return d
+		}
+		if visiting[id] {
+			return 0
+		}
+		visiting[id] = true
+		longest := 0
+		for _, dep := range deps[id] {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+			if d := visit(dep); d > longest {
+				longest = d
+			}
+		}
+		visiting[id] = false
+		depth[id] = longest + 1
+		return longest + 1
+	}
+
+	max := 0
+	for _, n := range g.Nodes {
+		if d := visit(n.ID); d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+// DuplicateIDs returns every non-empty ID carried by more than one node, in
+// the order of their first occurrence
+func (g *Graph) DuplicateIDs() []string {
+	count := make(map[string]int, len(g.Nodes))
+	var dups []string
+	for _, n := range g.Nodes {
+		if n.ID == "" {
+			continue
+		}
+		if count[n.ID]++; count[n.ID] == 2 {
+			dups = append(dups, n.ID)
+		}
+	}
+	return dups
+}
+
+// index maps node IDs to their position, rejecting missing and duplicate IDs
+func (g *Graph) index() (map[string]int, error) {
+	index := make(map[string]int, len(g.Nodes))
+	for i, n := range g.Nodes {
+		if n.ID == "" {
+			return nil, fmt.Errorf("node %d has no id", i)
+		}
+		if j, ok := index[n.ID]; ok {
+			return nil, fmt.Errorf("node %d duplicates the id %q of node %d", i, n.ID, j)
+		}
+		index[n.ID] = i
+	}
+	return index, nil
+}
+
+// contains reports whether ids holds id
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
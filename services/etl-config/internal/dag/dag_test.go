@@ -0,0 +1,153 @@
+package dag
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, raw string) *Graph {
+	t.Helper()
+	g, err := Parse([]byte(raw))
+	if err != nil {
+		t.Fatalf("Parse(%s): %v", raw, err)
+	}
+	return g
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string // substring of the error, "" when valid
+	}{
+		{"valid", `[{"id": "extract"}, {"id": "transform", "dependsOn": ["extract"]}, {"id": "load", "dependsOn": ["transform"]}]`, ""},
+		{"self loop", `[{"id": "a", "dependsOn": ["a"]}]`, `node "a" depends on itself`},
+		{"multi-node cycle", `[{"id": "a", "dependsOn": ["c"]}, {"id": "b", "dependsOn": ["a"]}, {"id": "c", "dependsOn": ["b"]}, {"id": "d"}]`, "cycle through nodes: a, b, c"},
+		{"dangling dependency", `[{"id": "a"}, {"id": "b", "dependsOn": ["missing"]}]`, `node "b" depends on "missing" which does not exist`},
+		{"depends_on cycle", `[{"id": "a", "depends_on": ["b"]}, {"id": "b", "depends_on": ["a"]}]`, "cycle through nodes: a, b"},
+		{"depends_on dangling", `[{"id": "a", "depends_on": ["ghost"]}]`, `node "a" depends on "ghost"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mustParse(t, tt.raw).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseDependsOnSpellings(t *testing.T) {
+	g := mustParse(t, `[{"id": "a"}, {"id": "b"}, {"id": "c", "dependsOn": ["a"], "depends_on": ["a", "b"]}]`)
+	want := []Edge{{From: "a", To: "c"}, {From: "b", To: "c"}}
+	if got := g.Edges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Edges = %v, want %v", got, want)
+	}
+}
+
+func TestTopologicalLevels(t *testing.T) {
+	g := mustParse(t, `[{"id": "load", "depends_on": ["clean", "enrich"]}, {"id": "extract"}, {"id": "clean", "dependsOn": ["extract"]}, {"id": "enrich", "dependsOn": ["extract"]}]`)
+	got, err := g.TopologicalLevels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"extract"}, {"clean", "enrich"}, {"load"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TopologicalLevels = %v, want %v", got, want)
+	}
+}
+
+func TestValidateEdgeCases(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string // substring of the error, "" when valid
+	}{
+		{"no input", ``, ""},
+		{"null", `null`, ""},
+		{"empty array", `[]`, ""},
+		{"single node", `[{"id": "a"}]`, ""},
+		{"unknown fields ignored", `[{"id": "a", "pipelineId": "p1", "timeout": 60}]`, ""},
+		{"duplicate id", `[{"id": "a"}, {"id": "b"}, {"id": "a", "dependsOn": ["b"]}]`, `node 2 duplicates the id "a" of node 0`},
+		{"missing id", `[{"id": "a"}, {"dependsOn": ["a"]}]`, "node 1 has no id"},
+		{"empty id", `[{"id": ""}]`, "node 0 has no id"},
+		{"dependency on the empty id", `[{"id": "a", "dependsOn": [""]}]`, `node "a" depends on "" which does not exist`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mustParse(t, tt.raw).Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, raw := range []string{`{"id": "a"}`, `["a"]`, `[{"id": 1}]`, `[{"id": "a", "dependsOn": "b"}]`, `[{`} {
+		if _, err := Parse([]byte(raw)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want an error", raw)
+		}
+	}
+}
+
+func TestTopologicalLevelsEmpty(t *testing.T) {
+	got, err := mustParse(t, `[]`).TopologicalLevels()
+	if err != nil || got == nil || len(got) != 0 {
+		t.Errorf("TopologicalLevels = %v, %v; want no levels", got, err)
+	}
+}
+
+func TestDepth(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want int
+	}{
+		{"empty", `[]`, 0},
+		{"single node", `[{"id": "a"}]`, 1},
+		{"independent nodes", `[{"id": "a"}, {"id": "b"}]`, 1},
+		{"chain", `[{"id": "c", "dependsOn": ["b"]}, {"id": "b", "dependsOn": ["a"]}, {"id": "a"}]`, 3},
+		{"diamond", `[{"id": "a"}, {"id": "b", "dependsOn": ["a"]}, {"id": "c", "dependsOn": ["a"]}, {"id": "d", "dependsOn": ["b", "c"]}]`, 3},
+		{"dangling dependency ignored", `[{"id": "a", "dependsOn": ["ghost"]}]`, 1},
+		{"self loop", `[{"id": "a", "dependsOn": ["a"]}]`, 1},
+		{"two-node cycle", `[{"id": "a", "dependsOn": ["b"]}, {"id": "b", "dependsOn": ["a"]}]`, 2},
+		{"three-node cycle", `[{"id": "a", "dependsOn": ["c"]}, {"id": "b", "dependsOn": ["a"]}, {"id": "c", "dependsOn": ["b"]}]`, 3},
+		{"cycle with a tail", `[{"id": "a", "dependsOn": ["b"]}, {"id": "b", "dependsOn": ["a"]}, {"id": "c", "dependsOn": ["a"]}]`, 3},
+		{"duplicate id merges dependencies", `[{"id": "a"}, {"id": "b"}, {"id": "a", "dependsOn": ["b"]}]`, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := mustParse(t, tt.raw)
+			// Depth must not depend on map iteration order
+			for i := 0; i < 20; i++ {
+				if got := g.Depth(); got != tt.want {
+					t.Fatalf("Depth = %d, want %d", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDuplicateIDs(t *testing.T) {
+	g := mustParse(t, `[{"id": "b"}, {"id": "a"}, {"id": ""}, {"id": "a"}, {"id": "b"}, {"id": "a"}, {"id": ""}]`)
+	if got, want := g.DuplicateIDs(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("DuplicateIDs = %v, want %v", got, want)
+	}
+	if got := mustParse(t, `[{"id": "a"}, {"id": "b"}]`).DuplicateIDs(); got != nil {
+		t.Errorf("DuplicateIDs = %v, want none", got)
+	}
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+)
+
+// auditEntityTypes are the entity types the audit log records
+var auditEntityTypes = []string{model.AuditDataSource, model.AuditDataSet, model.AuditPipeline, model.AuditSchedule}
+
+// AuditHandler handles audit log HTTP requests
+type AuditHandler struct {
+	repo *repository.AuditRepository
+}
+
+// NewAuditHandler creates a new AuditHandler
+func NewAuditHandler() *AuditHandler {
+	return &AuditHandler{repo: repository.NewAuditRepository()}
+}
+
+// List returns the paginated audit entries of the tenant, newest first,
+// optionally filtered by entity type, entity id and actor
+func (h *AuditHandler) List(c *gin.Context) {
+	filter := model.AuditFilter{
+		EntityType: c.Query("entity"),
+		EntityID:   c.Query("id"),
+		Actor:      c.Query("actor"),
+	}
+	if filter.EntityType != "" && !contains(auditEntityTypes, filter.EntityType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity must be one of datasource, dataset, pipeline, schedule"})
+		return
+	}
+	if filter.EntityID != "" && !uuidPattern.MatchString(filter.EntityID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id is not a valid id"})
+		return
+	}
+	page, pageSize, err := api.ParsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	withTotal, err := api.ParseWithTotal(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, total, err := h.repo.List(c.Request.Context(), filter, page, pageSize, withTotal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	api.RespondPaginated(c, entries, total, page, pageSize)
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/dag"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// checkScheduleDAG rejects a schedule DAG with more nodes, dependency edges
+// or a longer dependency chain than the limits allow, and one that cannot be
+// ordered: missing or duplicate node IDs, unknown dependencies and cycles
+func checkScheduleDAG(limits config.LimitsConfig, raw json.RawMessage) error {
+	g, err := dag.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if dups := g.DuplicateIDs(); len(dups) > 0 {
+		// Task executions and log filters key on the node ID
+		return fmt.Errorf("dag has duplicate node ids: %s", strings.Join(dups, ", "))
+	}
+	if len(g.Nodes) > limits.MaxDAGNodes {
+		return fmt.Errorf("dag has %d nodes, more than the limit of %d", len(g.Nodes), limits.MaxDAGNodes)
+	}
+	if edges := len(g.Edges()); edges > limits.MaxDAGEdges {
+		return fmt.Errorf("dag has %d dependencies, more than the limit of %d", edges, limits.MaxDAGEdges)
+	}
+	if depth := g.Depth(); depth > limits.MaxDAGDepth {
+		return fmt.Errorf("dag has a dependency chain of %d nodes, more than the limit of %d", depth, limits.MaxDAGDepth)
+	}
+	if err := g.Validate(); err != nil {
+		return fmt.Errorf("invalid dag: %v", err)
+	}
+	return nil
+}
+
+// dagNameWarning returns a warning naming the node names of a schedule DAG
+// shared by several nodes, which make task executions hard to tell apart,
+// or "" when names are unique or the DAG does not decode
+func dagNameWarning(raw json.RawMessage) string {
+	var nodes []model.DAGNode
+	if len(raw) == 0 || json.Unmarshal(raw, &nodes) != nil {
+		return ""
+	}
+	count := make(map[string]int, len(nodes))
+	var dups []string
+	for _, n := range nodes {
+		if n.Name == "" {
+			continue
+		}
+		if count[n.Name]++; count[n.Name] == 2 {
+			dups = append(dups, n.Name)
+		}
+	}
+	if len(dups) == 0 {
+		return ""
+	}
+	return "warning: several dag nodes share the name " + strings.Join(dups, ", ")
+}
+
+// checkPipelineSteps rejects a pipeline with more steps or a longer chain of
+// step inputs than the limits allow. Steps that do not decode are left to
+// the pipeline validation to report.
+func checkPipelineSteps(limits config.LimitsConfig, raw json.RawMessage) error {
+	var steps []model.PipelineStep
+	if len(raw) == 0 || json.Unmarshal(raw, &steps) != nil {
+		return nil
+	}
+	if len(steps) > limits.MaxPipelineSteps {
+		return fmt.Errorf("pipeline has %d steps, more than the limit of %d", len(steps), limits.MaxPipelineSteps)
+	}
+
+	if depth := stepGraph(steps).Depth(); depth > limits.MaxDAGDepth {
+		return fmt.Errorf("pipeline has a chain of %d steps, more than the limit of %d", depth, limits.MaxDAGDepth)
+	}
+	return nil
+}
+
+// stepGraph returns the graph of a pipeline's steps: transform and load
+// steps depend on the step they read
+func stepGraph(steps []model.PipelineStep) *dag.Graph {
+	g := &dag.Graph{Nodes: make([]dag.Node, len(steps))}
+	for i, step := range steps {
+		g.Nodes[i].ID = step.ID
+		if step.Type != "extract" && step.Input != "" {
+			g.Nodes[i].DependsOn = []string{step.Input}
+		}
+	}
+	return g
+}
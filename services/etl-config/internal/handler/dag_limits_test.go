@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// dagJSON returns a schedule DAG of n nodes; node i depends on the nodes
+// deps(i) returns
+func dagJSON(t *testing.T, n int, deps func(i int) []int) json.RawMessage {
+	t.Helper()
+	nodes := make([]model.DAGNode, n)
+	for i := range nodes {
+		nodes[i].ID = fmt.Sprintf("n%d", i)
+		for _, d := range deps(i) {
+			nodes[i].DependsOn = append(nodes[i].DependsOn, fmt.Sprintf("n%d", d))
+		}
+	}
+	raw, err := json.Marshal(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// Shapes of a schedule DAG
+var (
+	independent = func(int) []int { return nil }
+	chain       = func(i int) []int {
+		if i == 0 {
+			return nil
+		}
+		return []int{i - 1}
+	}
+	star = func(i int) []int { // every node depends on the first
+		if i == 0 {
+			return nil
+		}
+		return []int{0}
+	}
+)
+
+func TestCheckScheduleDAGLimits(t *testing.T) {
+	limits := config.LimitsConfig{MaxDAGNodes: 5, MaxDAGEdges: 3, MaxDAGDepth: 3}
+	generous := config.LimitsConfig{MaxDAGNodes: 100, MaxDAGEdges: 100, MaxDAGDepth: 100}
+
+	tests := []struct {
+		name    string
+		limits  config.LimitsConfig
+		nodes   int
+		shape   func(int) []int
+		wantErr string
+	}{
+		{"nodes at the limit", config.LimitsConfig{MaxDAGNodes: 5, MaxDAGEdges: 100, MaxDAGDepth: 100}, 5, independent, ""},
+		{"nodes over the limit", config.LimitsConfig{MaxDAGNodes: 5, MaxDAGEdges: 100, MaxDAGDepth: 100}, 6, independent, "6 nodes, more than the limit of 5"},
+		{"edges at the limit", limits, 4, star, ""},
+		{"edges over the limit", limits, 5, star, "4 dependencies, more than the limit of 3"},
+		{"depth at the limit", limits, 3, chain, ""},
+		{"depth over the limit", config.LimitsConfig{MaxDAGNodes: 5, MaxDAGEdges: 100, MaxDAGDepth: 3}, 4, chain, "chain of 4 nodes, more than the limit of 3"},
+		{"empty", limits, 0, independent, ""},
+		{"long chain within generous limits", generous, 100, chain, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkScheduleDAG(tt.limits, dagJSON(t, tt.nodes, tt.shape))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkScheduleDAG: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkScheduleDAG = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// stepsJSON returns a pipeline of an extract step followed by n-1
+// transform steps, each reading the previous step when chained and the
+// extract step otherwise
+func stepsJSON(t *testing.T, n int, chained bool) json.RawMessage {
+	t.Helper()
+	steps := make([]model.PipelineStep, n)
+	for i := range steps {
+		steps[i] = model.PipelineStep{ID: fmt.Sprintf("s%d", i), Type: "transform", Input: "s0"}
+		if chained && i > 0 {
+			steps[i].Input = fmt.Sprintf("s%d", i-1)
+		}
+	}
+	if n > 0 {
+		steps[0].Type, steps[0].Input = "extract", "4f1c8a9e-0000-4000-8000-000000000001"
+	}
+	raw, err := json.Marshal(steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestCheckPipelineStepsLimits(t *testing.T) {
+	limits := config.LimitsConfig{MaxPipelineSteps: 5, MaxDAGDepth: 3}
+
+	tests := []struct {
+		name    string
+		steps   int
+		chained bool
+		wantErr string
+	}{
+		{"steps at the limit", 5, false, ""},
+		{"steps over the limit", 6, false, "6 steps, more than the limit of 5"},
+		{"depth at the limit", 3, true, ""},
+		{"depth over the limit", 4, true, "chain of 4 steps, more than the limit of 3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkPipelineSteps(limits, stepsJSON(t, tt.steps, tt.chained))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkPipelineSteps: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("checkPipelineSteps = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateRejectsOversizedDefinitions(t *testing.T) {
+	limits := config.LimitsConfig{MaxDAGNodes: 2, MaxDAGEdges: 2, MaxDAGDepth: 2, MaxPipelineSteps: 2, MaxFieldBytes: 1 << 20}
+
+	body := fmt.Sprintf(`{"name": "nightly", "cronExpr": "0 9 * * *", "dag": %s}`, dagJSON(t, 3, independent))
+	w := serve(t, http.MethodPost, "/schedules", "/schedules", body, NewScheduleHandler(limits).Create)
+	wantStatus(t, w, http.StatusBadRequest)
+
+	body = fmt.Sprintf(`{"name": "bars", "steps": %s}`, stepsJSON(t, 3, false))
+	w = serve(t, http.MethodPost, "/pipelines", "/pipelines", body, NewPipelineHandler(limits).Create)
+	wantStatus(t, w, http.StatusBadRequest)
+}
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// BulkImport creates datasets from a JSON array or a multi-document YAML
+// file in a single transaction. With ?upsert=true, datasets whose name
+// already exists are updated instead; a non-zero version in the definition
+// must then match the stored one.
+//
+// Every entry gets a result with its index, status and errors. By default
+// the import is transactional: any invalid definition rejects the whole
+// batch with a 400 and nothing is written. With ?continueOnError=true it is
+// best-effort: valid entries are written and, when any entry failed, the
+// response is a 207 Multi-Status so clients can fix and resend just the
+// failed ones.
+func (h *DataSetHandler) BulkImport(c *gin.Context) {
+	userID, ok := requireUser(c)
+	if !ok {
+		return
+	}
+	upsert := c.Query("upsert") == "true"
+	continueOnError := c.Query("continueOnError") == "true"
+
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var datasets []model.DataSet
+	if isYAML(c.ContentType()) {
+		datasets, err = decodeDataSetsYAML(body)
+	} else {
+		err = json.Unmarshal(body, &datasets)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(datasets) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one dataset is required"})
+		return
+	}
+
+	results := make([]model.DataSetImportResult, len(datasets))
+	pending := make([]*model.DataSet, 0, len(datasets))
+	pendingIdx := make([]int, 0, len(datasets))
+	seen := make(map[string]bool, len(datasets))
+	failed := false
+
+	for i := range datasets {
+		ds := &datasets[i]
+		results[i] = model.DataSetImportResult{Index: i, Name: ds.Name}
+
+		errs := validateImportedDataSet(ds, seen, h.limits.MaxFieldBytes)
+		if len(errs) == 0 {
+			if err := h.resolveImportTarget(c, ds, upsert, userID); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			results[i].Status = model.ImportFailed
+			results[i].Errors = errs
+			failed = true
+			continue
+		}
+
+		pending = append(pending, ds)
+		pendingIdx = append(pendingIdx, i)
+	}
+
+	if failed && !continueOnError {
+		for _, i := range pendingIdx {
+			results[i].Status = model.ImportSkipped
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bulk import rejected", "results": results})
+		return
+	}
+
+	if len(pending) > 0 {
+		updates := make([]bool, len(pending))
+		for j, ds := range pending {
+			updates[j] = ds.ID != ""
+		}
+
+		errs, err := h.repo.Import(c.Request.Context(), pending, continueOnError)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		for j, i := range pendingIdx {
+			if errs[j] != nil {
+				results[i].Status = model.ImportFailed
+				results[i].Errors = []string{errs[j].Error()}
+				failed = true
+				continue
+			}
+			results[i].Status = model.ImportCreated
+			if updates[j] {
+				results[i].Status = model.ImportUpdated
+			}
+			results[i].ID = pending[j].ID
+			results[i].Version = pending[j].Version
+		}
+	}
+
+	status := http.StatusOK
+	if failed {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, api.APIResponse[[]model.DataSetImportResult]{Data: results})
+}
+
+// validateImportedDataSet checks a single definition of a bulk import,
+// including that its name is not repeated within the batch and that no JSON
+// field exceeds maxFieldBytes. It reports every problem found rather than
+// only the first, so an entry can be fixed in one pass.
+func validateImportedDataSet(ds *model.DataSet, seen map[string]bool, maxFieldBytes int) []string {
+	var errs []string
+	switch {
+	case ds.Name == "":
+		errs = append(errs, "name is required")
+	case seen[ds.Name]:
+		errs = append(errs, fmt.Sprintf("duplicate dataset name %q in batch", ds.Name))
+	default:
+		seen[ds.Name] = true
+	}
+	if err := checkFieldSizes(maxFieldBytes, dataSetFields(ds)...); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	s, err := schema.Parse(ds.Schema)
+	if err != nil {
+		return append(errs, err.Error())
+	}
+	if err := schema.ValidateStorage(ds.Storage, s); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if err := schema.ValidateIndexes(ds.Indexes, s); err != nil {
+		errs = append(errs, err.Error())
+	}
+	return errs
+}
+
+// resolveImportTarget decides whether a definition creates a dataset or
+// updates the existing one with the same name, setting ds.ID for updates
+// and ds.OwnerID for creates
+func (h *DataSetHandler) resolveImportTarget(c *gin.Context, ds *model.DataSet, upsert bool, userID string) error {
+	existing, err := h.repo.GetByName(c.Request.Context(), ds.Name)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		ds.ID = ""
+		ds.OwnerID = &userID
+		return nil
+	}
+
+	if !upsert {
+		return fmt.Errorf("dataset %q already exists", ds.Name)
+	}
+	if ds.Version != 0 && ds.Version != existing.Version {
+		return fmt.Errorf("version %d does not match stored version %d", ds.Version, existing.Version)
+	}
+	ok, err := h.hasRole(c, existing, model.DataSetRoleEditor)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("insufficient permission on dataset")
+	}
+
+	ds.ID = existing.ID
+	return nil
+}
+
+// isYAML reports whether a content type denotes a YAML body
+func isYAML(contentType string) bool {
+	switch contentType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	}
+	return false
+}
+
+// decodeDataSetsYAML decodes one dataset per YAML document. Documents are
+// round-tripped through JSON so the usual JSON field names and raw message
+// fields apply.
+func decodeDataSetsYAML(body []byte) ([]model.DataSet, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(body))
+
+	var datasets []model.DataSet
+	for i := 0; ; i++ {
+		var doc interface{}
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		if doc == nil {
+			continue
+		}
+
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		var ds model.DataSet
+		if err := json.Unmarshal(raw, &ds); err != nil {
+			return nil, fmt.Errorf("document %d: %w", i, err)
+		}
+		datasets = append(datasets, ds)
+	}
+	return datasets, nil
+}
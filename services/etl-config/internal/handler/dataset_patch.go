@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// patchableDataSetFields are the dataset fields a merge patch may change;
+// the rest are managed by the service or have their own endpoints
+var patchableDataSetFields = []string{"category", "description", "schema", "storage", "indexes", "labels"}
+
+// checkDataSetPatch checks that a merge patch is a JSON object that only
+// touches patchable fields
+func checkDataSetPatch(patch []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil || fields == nil {
+		return errors.New("patch must be a JSON object")
+	}
+	for key := range fields {
+		if !contains(patchableDataSetFields, key) {
+			return fmt.Errorf("%s cannot be patched, expected one of: %s", key, strings.Join(patchableDataSetFields, ", "))
+		}
+	}
+	return nil
+}
+
+// applyDataSetPatch merges a checked patch into ds
+func applyDataSetPatch(ds *model.DataSet, patch []byte) error {
+	doc, err := json.Marshal(ds)
+	if err != nil {
+		return err
+	}
+	merged, err := mergePatch(doc, patch)
+	if err != nil {
+		return err
+	}
+
+	var patched model.DataSet
+	if err := json.Unmarshal(merged, &patched); err != nil {
+		return fmt.Errorf("patched dataset is invalid: %v", err)
+	}
+	if patched.Category == "" {
+		return errors.New("category is required")
+	}
+	if patched.Indexes == nil {
+		patched.Indexes = json.RawMessage(`[]`)
+	}
+	if patched.Labels == nil {
+		patched.Labels = json.RawMessage(`{}`)
+	}
+
+	*ds = patched
+	return nil
+}
+
+// mergePatch applies an RFC 7386 JSON merge patch to a JSON document
+func mergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decodeJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decodeJSON(patch)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+// mergeValue merges patch into target: an object patch is applied key by
+// key, a null value removes the key, and any other value replaces the
+// target outright
+func mergeValue(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{}, len(p))
+	}
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+		t[key] = mergeValue(t[key], value)
+	}
+	return t
+}
+
+// decodeJSON decodes a JSON value keeping numbers exact
+func decodeJSON(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+func TestInferSchemaRejectsDuplicateCSVHeader(t *testing.T) {
+	h := NewDataSetHandler(config.LimitsConfig{})
+	w := serve(t, http.MethodPost, "/datasets/infer-schema", "/datasets/infer-schema?format=csv",
+		"price,code,price\n1.5,600519.SH,2\n", h.InferSchema)
+	wantStatus(t, w, http.StatusBadRequest)
+}
+
+func TestCreateAndUpdateRejectInvalidSchemaDocument(t *testing.T) {
+	h := NewDataSetHandler(config.LimitsConfig{MaxFieldBytes: 1 << 20})
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{`{"fields": [{"name": "code", "type": "string"}, {"name": "code", "type": "int"}]}`, "schema.fields[1].name"},
+		{`{"fields": [{"name": "price", "type": "money"}]}`, "schema.fields[0].type"},
+	}
+	for _, tt := range tests {
+		body := `{"name": "quotes", "schema": ` + tt.schema + `}`
+		for _, w := range []*httptest.ResponseRecorder{
+			serve(t, http.MethodPost, "/datasets", "/datasets", body, h.Create),
+			serve(t, http.MethodPut, "/datasets/:id", "/datasets/4f1c8a9e-0000-4000-8000-000000000001", body, h.Update),
+		} {
+			wantStatus(t, w, http.StatusBadRequest)
+			if !strings.Contains(w.Body.String(), tt.want) {
+				t.Errorf("body %s, want it to name %s", w.Body, tt.want)
+			}
+		}
+	}
+}
+
+// contextAs returns a request context acting as claims, or anonymously
+// when claims is nil, as Identity leaves it
+func contextAs(claims *auth.Claims, method, target, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	if claims != nil {
+		c.Set(claimsKey, claims)
+	}
+	return c, w
+}
+
+func TestAccessRole(t *testing.T) {
+	h := NewDataSetHandler(testLimits)
+	alice := "alice"
+	owned := &model.DataSet{ID: "4f1c8a9e-0000-4000-8000-000000000001", OwnerID: &alice}
+	ownerless := &model.DataSet{ID: "4f1c8a9e-0000-4000-8000-000000000002"}
+
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		ds     *model.DataSet
+		want   string
+	}{
+		{"anonymous on an ownerless dataset", nil, ownerless, ""},
+		{"anonymous on an owned dataset", nil, owned, ""},
+		{"user on an ownerless dataset", &auth.Claims{UserID: "bob"}, ownerless, model.DataSetRoleEditor},
+		{"owner", &auth.Claims{UserID: "alice"}, owned, model.DataSetRoleOwner},
+		{"admin on an owned dataset", &auth.Claims{UserID: "root", Role: auth.RoleAdmin}, owned, model.DataSetRoleOwner},
+		{"admin on an ownerless dataset", &auth.Claims{UserID: "root", Role: auth.RoleAdmin}, ownerless, model.DataSetRoleOwner},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := contextAs(tt.claims, http.MethodGet, "/datasets/"+tt.ds.ID, "")
+			got, err := h.accessRole(c, tt.ds)
+			if err != nil || got != tt.want {
+				t.Errorf("accessRole = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateRequiresUser(t *testing.T) {
+	h := NewDataSetHandler(testLimits)
+	body := `{"name": "bars", "category": "market", "schema": {"fields": []}, "storage": {"type": "postgres"}}`
+
+	c, w := contextAs(nil, http.MethodPost, "/datasets", body)
+	h.Create(c)
+	wantStatus(t, w, http.StatusUnauthorized)
+
+	c, w = contextAs(nil, http.MethodPost, "/datasets/import", "["+body+"]")
+	h.BulkImport(c)
+	wantStatus(t, w, http.StatusUnauthorized)
+}
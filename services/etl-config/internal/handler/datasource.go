@@ -1,61 +1,124 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"os"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/connpool"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/ratelimit"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/schema"
 )
 
+// defaultTestInterval is the minimum time between connection tests of one
+// data source, overridable with DATASOURCE_TEST_INTERVAL (e.g. "10s")
+const defaultTestInterval = 10 * time.Second
+
 // DataSourceHandler handles data source HTTP requests
 type DataSourceHandler struct {
-	repo *repository.DataSourceRepository
+	repo         *repository.DataSourceRepository
+	pluginRepo   *repository.PluginRepository
+	pipelineRepo *repository.PipelineRepository
+	testLimiter  *ratelimit.Keyed
+	conns        *connpool.Manager
+	limits       config.LimitsConfig
+	environment  string
+	testTimeout  time.Duration
+	probes       dataSourceProbes
 }
 
-// NewDataSourceHandler creates a new DataSourceHandler
-func NewDataSourceHandler() *DataSourceHandler {
-	return &DataSourceHandler{
-		repo: repository.NewDataSourceRepository(),
+// NewDataSourceHandler creates a new DataSourceHandler. Connection tests
+// reuse the backend connections cached by conns and give up after
+// testTimeout. Reads and tests resolve configs for environment unless a
+// request selects another one.
+func NewDataSourceHandler(conns *connpool.Manager, limits config.LimitsConfig, environment string, testTimeout time.Duration) *DataSourceHandler {
+	interval := defaultTestInterval
+	if v := os.Getenv("DATASOURCE_TEST_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			interval = d
+		}
+	}
+
+	h := &DataSourceHandler{
+		repo:         repository.NewDataSourceRepository(),
+		pluginRepo:   repository.NewPluginRepository(),
+		pipelineRepo: repository.NewPipelineRepository(),
+		testLimiter:  ratelimit.NewKeyed(interval),
+		conns:        conns,
+		limits:       limits,
+		environment:  environment,
+		testTimeout:  testTimeout,
 	}
+	h.probes = dataSourceProbes{database: h.pingDataSource, api: probeAPI, messageQueue: probeBrokers}
+	return h
 }
 
 // List returns paginated data sources
 func (h *DataSourceHandler) List(c *gin.Context) {
-	typeFilter := c.Query("type")
-	statusFilter := c.Query("status")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
-
-	if page < 1 {
-		page = 1
+	reveal, ok := revealSecrets(c)
+	if !ok {
+		return
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	filter := model.DataSourceFilter{
+		Type:      c.Query("type"),
+		Status:    c.Query("status"),
+		Plugin:    c.Query("plugin"),
+		CreatedBy: c.Query("createdBy"),
+	}
+	page, pageSize, err := api.ParsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	withTotal, err := api.ParseWithTotal(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort, err := api.ParseSort(c, repository.DataSourceSortColumns.Fields())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Summary, err = parseSummary(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	datasources, total, err := h.repo.List(c.Request.Context(), typeFilter, statusFilter, page, pageSize)
+	datasources, total, err := h.repo.List(c.Request.Context(), filter, sort, page, pageSize, withTotal)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if datasources == nil {
-		datasources = []model.DataSource{}
+	if !reveal {
+		for i := range datasources {
+			maskDataSource(&datasources[i])
+		}
 	}
 
-	c.JSON(http.StatusOK, model.PaginatedResponse[model.DataSource]{
-		Data:     datasources,
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
-	})
+	api.RespondPaginated(c, datasources, total, page, pageSize)
 }
 
-// Get returns a data source by ID
+// Get returns a data source by ID, with its effective config in the active
+// environment
 func (h *DataSourceHandler) Get(c *gin.Context) {
 	id := c.Param("id")
+	reveal, ok := revealSecrets(c)
+	if !ok {
+		return
+	}
 
 	ds, err := h.repo.GetByID(c.Request.Context(), id)
 	if err != nil {
@@ -66,15 +129,30 @@ func (h *DataSourceHandler) Get(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "data source not found"})
 		return
 	}
+	if err := resolveEnvironment(ds, h.activeEnvironment(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, model.APIResponse[*model.DataSource]{Data: ds})
+	if !reveal {
+		maskDataSource(ds)
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[*model.DataSource]{Data: ds})
 }
 
 // Create creates a new data source
 func (h *DataSourceHandler) Create(c *gin.Context) {
 	var form model.DataSourceForm
 	if err := c.ShouldBindJSON(&form); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
+		return
+	}
+	if err := checkFieldSizes(h.limits.MaxFieldBytes, jsonField{"config", form.Config}, jsonField{"environments", form.Environments}); err != nil {
+		respondFieldSize(c, err)
+		return
+	}
+	if !h.checkEnvironments(c, &form) {
 		return
 	}
 
@@ -84,7 +162,8 @@ func (h *DataSourceHandler) Create(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, model.APIResponse[*model.DataSource]{Data: ds})
+	maskDataSource(ds)
+	c.JSON(http.StatusCreated, api.APIResponse[*model.DataSource]{Data: ds})
 }
 
 // Update updates a data source
@@ -93,7 +172,27 @@ func (h *DataSourceHandler) Update(c *gin.Context) {
 
 	var form model.DataSourceForm
 	if err := c.ShouldBindJSON(&form); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBindError(c, err)
+		return
+	}
+
+	// Secrets echoed back as "***" from a masked read keep their stored value
+	existing, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if existing == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "data source not found"})
+		return
+	}
+	form.Config = unmaskConfig(form.Config, existing.Config)
+	form.Environments = unmaskConfig(form.Environments, existing.Environments)
+	if err := checkFieldSizes(h.limits.MaxFieldBytes, jsonField{"config", form.Config}, jsonField{"environments", form.Environments}); err != nil {
+		respondFieldSize(c, err)
+		return
+	}
+	if !h.checkEnvironments(c, &form) {
 		return
 	}
 
@@ -102,23 +201,41 @@ func (h *DataSourceHandler) Update(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.conns.Invalidate(id)
 
-	c.JSON(http.StatusOK, model.APIResponse[*model.DataSource]{Data: ds})
+	maskDataSource(ds)
+	c.JSON(http.StatusOK, api.APIResponse[*model.DataSource]{Data: ds})
 }
 
-// Delete deletes a data source
+// Delete deletes a data source. One that pipelines still reference is a 409
+// listing them, unless force=true.
 func (h *DataSourceHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
+	ds, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if ds == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "data source not found"})
+		return
+	}
+	if !guardDependents(c, h.pipelineRepo, id, "data source") {
+		return
+	}
+
 	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.conns.Invalidate(id)
 
 	c.Status(http.StatusNoContent)
 }
 
-// Test tests a data source connection
+// Test tests a data source connection with its effective config in the
+// active environment
 func (h *DataSourceHandler) Test(c *gin.Context) {
 	id := c.Param("id")
 
@@ -132,17 +249,180 @@ func (h *DataSourceHandler) Test(c *gin.Context) {
 		return
 	}
 
-	// TODO: Actually test the connection based on plugin type
-	// For now, just update status to active
-	if err := h.repo.UpdateStatus(c.Request.Context(), id, "active", nil); err != nil {
+	if ok, wait := h.testLimiter.Allow(ds.ID); !ok {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "connection test rate limit exceeded"})
+		return
+	}
+	if err := resolveEnvironment(ds, h.activeEnvironment(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.testConnection(c.Request.Context(), ds)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[map[string]interface{}]{Data: result})
+}
+
+// RotateCredentials replaces only the secret fields of a data source's config
+func (h *DataSourceHandler) RotateCredentials(c *gin.Context) {
+	id := c.Param("id")
+
+	var form model.RotateCredentialsForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if len(form.Credentials) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one credential is required"})
+		return
+	}
+
+	ds, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if ds == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "data source not found"})
+		return
+	}
+
+	plugin, err := h.pluginRepo.GetByName(c.Request.Context(), ds.Plugin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if plugin == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "plugin not found: " + ds.Plugin})
+		return
+	}
+
+	secrets, err := secretFields(plugin)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, model.APIResponse[map[string]interface{}]{
-		Data: map[string]interface{}{
-			"success": true,
-			"message": "Connection successful",
-		},
-	})
+	updated := make([]string, 0, len(form.Credentials))
+	for name := range form.Credentials {
+		if !secrets[name] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "not a secret field: " + name})
+			return
+		}
+		updated = append(updated, name)
+	}
+	sort.Strings(updated)
+
+	ds, err = h.repo.RotateCredentials(c.Request.Context(), id, form.Credentials)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.conns.Invalidate(id)
+	if ds == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "data source not found"})
+		return
+	}
+
+	result := map[string]interface{}{
+		"id":                ds.ID,
+		"updatedFields":     updated,
+		"credentialVersion": ds.CredentialVersion,
+	}
+	if form.Test {
+		if err := resolveEnvironment(ds, h.activeEnvironment(c)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		testResult, err := h.testConnection(c.Request.Context(), ds)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		result["test"] = testResult
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[map[string]interface{}]{Data: result})
+}
+
+// testConnection checks connectivity for a data source within the test
+// timeout and records its status. Sources that cannot be probed, such as
+// files, are marked active without a latency.
+func (h *DataSourceHandler) testConnection(ctx context.Context, ds *model.DataSource) (map[string]interface{}, error) {
+	result, status, errMsg := h.runProbe(ctx, ds)
+	if err := h.repo.UpdateStatus(ctx, ds.ID, status, errMsg); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// runProbe probes a data source within the test timeout, returning the
+// response body of a connection test and the status and error message to
+// record
+func (h *DataSourceHandler) runProbe(ctx context.Context, ds *model.DataSource) (result map[string]interface{}, status string, errMsg *string) {
+	probeCtx, cancel := context.WithTimeout(ctx, h.testTimeout)
+	start := time.Now()
+	err := h.probeDataSource(probeCtx, ds)
+	latency := time.Since(start)
+	cancel()
+
+	if err != nil && !errors.Is(err, errNotProbed) {
+		msg := err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			msg = fmt.Sprintf("connection test timed out after %s", h.testTimeout)
+		}
+		return map[string]interface{}{
+			"success":    false,
+			"message":    msg,
+			"latency_ms": latency.Milliseconds(),
+		}, "error", &msg
+	}
+
+	result = map[string]interface{}{
+		"success": true,
+		"message": "Connection successful",
+	}
+	if err == nil {
+		result["latency_ms"] = latency.Milliseconds()
+	}
+	return result, "active", nil
+}
+
+// pingDataSource checks that a connection to the data source's backend can
+// be acquired and answers
+func (h *DataSourceHandler) pingDataSource(ctx context.Context, ds *model.DataSource) error {
+	pool, release, err := h.conns.Get(ctx, ds)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return pool.Ping(ctx)
+}
+
+// secretFields returns the names of config fields a plugin declares as
+// secret: "secret" entries of a field list, or write-only/password
+// properties of a JSON Schema
+func secretFields(plugin *model.Plugin) (map[string]bool, error) {
+	doc, err := schema.PluginConfigSchema(plugin.ConfigSchema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config schema for plugin %s: %w", plugin.Name, err)
+	}
+
+	secrets := make(map[string]bool)
+	properties, _ := doc["properties"].(map[string]interface{})
+	for name, p := range properties {
+		prop, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if prop["writeOnly"] == true || prop["format"] == "password" {
+			secrets[name] = true
+		}
+	}
+	return secrets, nil
 }
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/connpool"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// errNotProbed is returned for data sources whose backend cannot be probed
+var errNotProbed = errors.New("connection tests are not supported for this data source")
+
+// dataSourceProbes check the backend of each kind of data source
+type dataSourceProbes struct {
+	database     func(ctx context.Context, ds *model.DataSource) error
+	api          func(ctx context.Context, config json.RawMessage) error
+	messageQueue func(ctx context.Context, config json.RawMessage) error
+}
+
+// probeDataSource checks that the backend of a data source answers: database
+// sources are pinged over their cached pool, API sources are sent a GET and
+// message queues are dialed
+func (h *DataSourceHandler) probeDataSource(ctx context.Context, ds *model.DataSource) error {
+	switch {
+	case connpool.Supports(ds):
+		return h.probes.database(ctx, ds)
+	case ds.Type == "api":
+		return h.probes.api(ctx, ds.Config)
+	case ds.Type == "message_queue":
+		return h.probes.messageQueue(ctx, ds.Config)
+	}
+	return errNotProbed
+}
+
+// probeAPI sends a GET to the health_url of an API source, which must
+// answer 2xx, or else to its base_url or url, which must answer at all
+// without a server error
+func probeAPI(ctx context.Context, raw json.RawMessage) error {
+	cfg, err := decodeProbeConfig(raw)
+	if err != nil {
+		return err
+	}
+	target, strict := configString(cfg, "health_url"), true
+	if target == "" {
+		target, strict = configString(cfg, "base_url", "url"), false
+	}
+	if target == "" {
+		return errors.New("data source config requires health_url, base_url or url")
+	}
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid data source url %q", target)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= http.StatusInternalServerError || (strict && resp.StatusCode >= http.StatusMultipleChoices) {
+		return fmt.Errorf("%s answered %s", u.Redacted(), resp.Status)
+	}
+	return nil
+}
+
+// probeBrokers dials the brokers of a message queue source, succeeding once
+// one accepts a connection. Brokers are read from brokers,
+// bootstrap_servers or servers (a list or comma-separated string), url, or
+// host and port.
+func probeBrokers(ctx context.Context, raw json.RawMessage) error {
+	cfg, err := decodeProbeConfig(raw)
+	if err != nil {
+		return err
+	}
+	addrs, err := brokerAddresses(cfg)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	var errs []error
+	for _, addr := range addrs {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			return conn.Close()
+		}
+		errs = append(errs, err)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// brokerAddresses returns the host:port of every broker of a message queue
+// source config
+func brokerAddresses(cfg map[string]interface{}) ([]string, error) {
+	var entries []string
+	for _, key := range []string{"brokers", "bootstrap_servers", "servers"} {
+		switch v := cfg[key].(type) {
+		case string:
+			entries = append(entries, strings.Split(v, ",")...)
+		case []interface{}:
+			for _, item := range v {
+				if s, ok := item.(string); ok {
+					entries = append(entries, s)
+				}
+			}
+		}
+	}
+	if u := configString(cfg, "url"); u != "" {
+		entries = append(entries, strings.Split(u, ",")...)
+	}
+	if host := configString(cfg, "host"); host != "" {
+		entries = append(entries, net.JoinHostPort(host, configString(cfg, "port")))
+	}
+
+	var addrs []string
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		// Strip a scheme such as nats:// or amqp://, and any credentials
+		if u, err := url.Parse(entry); err == nil && u.Host != "" {
+			entry = u.Host
+		}
+		host, port, err := net.SplitHostPort(entry)
+		if err != nil || host == "" || port == "" {
+			return nil, fmt.Errorf("broker address %q must be host:port", entry)
+		}
+		addrs = append(addrs, entry)
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("data source config requires brokers, servers, url or host and port")
+	}
+	return addrs, nil
+}
+
+// decodeProbeConfig decodes a data source config as a JSON object
+func decodeProbeConfig(raw json.RawMessage) (map[string]interface{}, error) {
+	var cfg map[string]interface{}
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid data source config: %w", err)
+	}
+	return cfg, nil
+}
+
+// configString returns the first of keys set in cfg as a non-empty string
+// or number
+func configString(cfg map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := cfg[key].(type) {
+		case string:
+			if v = strings.TrimSpace(v); v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,255 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/testdb"
+)
+
+// fakeProbes returns probes that record which one ran and answer err
+func fakeProbes(ran *string, err error) dataSourceProbes {
+	return dataSourceProbes{
+		database: func(context.Context, *model.DataSource) error {
+			*ran = "database"
+			return err
+		},
+		api: func(context.Context, json.RawMessage) error {
+			*ran = "api"
+			return err
+		},
+		messageQueue: func(context.Context, json.RawMessage) error {
+			*ran = "message_queue"
+			return err
+		},
+	}
+}
+
+func TestProbeDataSourceDispatch(t *testing.T) {
+	tests := []struct {
+		typ, plugin string
+		want        string // probe run, "" for none
+	}{
+		{"database", "source-postgres", "database"},
+		{"database", "source-clickhouse", ""},
+		{"api", "source-tushare", "api"},
+		{"message_queue", "source-kafka", "message_queue"},
+		{"file", "source-csv", ""},
+	}
+	for _, tt := range tests {
+		var ran string
+		h := &DataSourceHandler{probes: fakeProbes(&ran, nil)}
+		err := h.probeDataSource(context.Background(), &model.DataSource{Type: tt.typ, Plugin: tt.plugin})
+		if ran != tt.want {
+			t.Errorf("%s/%s ran the %q probe, want %q", tt.typ, tt.plugin, ran, tt.want)
+		}
+		if wantErr := tt.want == ""; errors.Is(err, errNotProbed) != wantErr {
+			t.Errorf("%s/%s probe error = %v, want errNotProbed %v", tt.typ, tt.plugin, err, wantErr)
+		}
+	}
+}
+
+func TestRunProbe(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	block := func(ctx context.Context, _ json.RawMessage) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name        string
+		ds          model.DataSource
+		probes      func(*string) dataSourceProbes
+		wantStatus  string
+		wantMessage string
+		wantLatency bool
+	}{
+		{
+			"database up", model.DataSource{Type: "database", Plugin: "source-postgres"},
+			func(ran *string) dataSourceProbes { return fakeProbes(ran, nil) },
+			"active", "Connection successful", true,
+		},
+		{
+			"api down", model.DataSource{Type: "api"},
+			func(ran *string) dataSourceProbes {
+				return fakeProbes(ran, errors.New("https://api.example.com answered 503"))
+			},
+			"error", "https://api.example.com answered 503", true,
+		},
+		{
+			"queue unreachable", model.DataSource{Type: "message_queue"},
+			func(ran *string) dataSourceProbes { return fakeProbes(ran, errors.New("connection refused")) },
+			"error", "connection refused", true,
+		},
+		{
+			"timeout", model.DataSource{Type: "api"},
+			func(ran *string) dataSourceProbes {
+				p := fakeProbes(ran, nil)
+				p.api = block
+				return p
+			},
+			"error", "connection test timed out after 50ms", true,
+		},
+		{
+			"not probed", model.DataSource{Type: "file"},
+			func(ran *string) dataSourceProbes { return fakeProbes(ran, nil) },
+			"active", "Connection successful", false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ran string
+			h := &DataSourceHandler{testTimeout: timeout, probes: tt.probes(&ran)}
+			start := time.Now()
+			result, status, errMsg := h.runProbe(context.Background(), &tt.ds)
+			if elapsed := time.Since(start); elapsed > 10*timeout {
+				t.Errorf("runProbe took %s, want it bounded by the %s timeout", elapsed, timeout)
+			}
+
+			if status != tt.wantStatus || result["message"] != tt.wantMessage {
+				t.Errorf("runProbe = %s %v, want %s %q", status, result, tt.wantStatus, tt.wantMessage)
+			}
+			if result["success"] != (tt.wantStatus == "active") {
+				t.Errorf("success = %v, want %v", result["success"], tt.wantStatus == "active")
+			}
+			if (errMsg != nil) != (tt.wantStatus == "error") || (errMsg != nil && *errMsg != tt.wantMessage) {
+				t.Errorf("recorded error message = %v, want %q", errMsg, tt.wantMessage)
+			}
+			if _, ok := result["latency_ms"]; ok != tt.wantLatency {
+				t.Errorf("latency_ms present = %v, want %v", ok, tt.wantLatency)
+			}
+		})
+	}
+}
+
+func TestProbeAPI(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"healthy", `{"health_url": "` + srv.URL + `/health"}`, false},
+		{"health url must answer 2xx", `{"health_url": "` + srv.URL + `/missing"}`, true},
+		{"base url may answer 4xx", `{"base_url": "` + srv.URL + `/missing"}`, false},
+		{"server error", `{"url": "` + srv.URL + `/broken"}`, true},
+		{"no url", `{"token": "t"}`, true},
+		{"unsupported scheme", `{"url": "ftp://example.com"}`, true},
+		{"not an object", `[]`, true},
+	}
+	for _, tt := range tests {
+		if err := probeAPI(context.Background(), json.RawMessage(tt.config)); (err != nil) != tt.wantErr {
+			t.Errorf("%s: probeAPI = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProbeBrokers(t *testing.T) {
+	up, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer up.Close()
+	go func() {
+		for {
+			conn, err := up.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := closed.Addr().String()
+	closed.Close()
+
+	upHost, upPort, _ := net.SplitHostPort(up.Addr().String())
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{"one broker up", `{"brokers": ["` + down + `", "` + up.Addr().String() + `"]}`, false},
+		{"comma-separated servers", `{"bootstrap_servers": "` + down + `,` + up.Addr().String() + `"}`, false},
+		{"url with credentials", `{"url": "nats://user:pw@` + up.Addr().String() + `"}`, false},
+		{"host and port", `{"host": "` + upHost + `", "port": ` + upPort + `}`, false},
+		{"all brokers down", `{"brokers": ["` + down + `"]}`, true},
+		{"no port", `{"brokers": "localhost"}`, true},
+		{"no brokers", `{}`, true},
+	}
+	for _, tt := range tests {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		err := probeBrokers(ctx, json.RawMessage(tt.config))
+		cancel()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: probeBrokers = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && strings.Contains(err.Error(), "pw@") {
+			t.Errorf("%s: error %v leaks the url credentials", tt.name, err)
+		}
+	}
+}
+
+func TestTestRecordsStatus(t *testing.T) {
+	tenantID := testdb.Open(t)
+	claims := &auth.Claims{UserID: "alice", TenantID: tenantID}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	repo := repository.NewDataSourceRepository()
+	ds, err := repo.Create(testdb.Context(tenantID, "alice"), &model.DataSourceForm{
+		Name: "quotes", Type: "api", Plugin: "source-tushare",
+		Config: json.RawMessage(`{"health_url": "` + srv.URL + `"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewDataSourceHandler(nil, testLimits, "", time.Second)
+	w := serveAs(t, claims, http.MethodPost, "/datasources/:id/test", "/datasources/"+ds.ID+"/test", "", h.Test)
+	wantStatus(t, w, http.StatusOK)
+	var resp api.APIResponse[map[string]interface{}]
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data["success"] != false || !strings.Contains(resp.Data["message"].(string), "503") {
+		t.Errorf("test result = %v, want a failure naming the 503", resp.Data)
+	}
+
+	stored, err := repo.GetByID(testdb.Context(tenantID, ""), ds.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != "error" || stored.ErrorMessage == nil || *stored.ErrorMessage != resp.Data["message"] {
+		t.Errorf("stored status = %s %v, want error %v", stored.Status, stored.ErrorMessage, resp.Data["message"])
+	}
+}
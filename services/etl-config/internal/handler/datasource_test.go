@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/connpool"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/testdb"
+)
+
+func TestRotateCredentials(t *testing.T) {
+	tenantID := testdb.Open(t)
+	claims := &auth.Claims{UserID: "alice", TenantID: tenantID}
+
+	repo := repository.NewDataSourceRepository()
+	ds, err := repo.Create(testdb.Context(tenantID, "alice"), &model.DataSourceForm{
+		Name: "wind", Type: "api", Plugin: "source-wind",
+		Config: json.RawMessage(`{"username": "quant", "password": "0ld-s3cret"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewDataSourceHandler(connpool.NewManager(config.DataSourcePoolConfig{}), testLimits, "", time.Second)
+	route, target := "/datasources/:id/rotate-credentials", "/datasources/"+ds.ID+"/rotate-credentials"
+	rotate := func(body string) *httptest.ResponseRecorder {
+		return serveAs(t, claims, http.MethodPost, route, target, body, h.RotateCredentials)
+	}
+
+	// stored checks the stored config and credential version
+	stored := func(wantPassword string, wantVersion int) {
+		t.Helper()
+		got, err := repo.GetByID(testdb.Context(tenantID, ""), ds.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cfg map[string]interface{}
+		if err := json.Unmarshal(got.Config, &cfg); err != nil {
+			t.Fatal(err)
+		}
+		if cfg["username"] != "quant" || cfg["password"] != wantPassword {
+			t.Errorf("stored config = %v, want username quant and password %s", cfg, wantPassword)
+		}
+		if got.CredentialVersion != wantVersion {
+			t.Errorf("credential version = %d, want %d", got.CredentialVersion, wantVersion)
+		}
+	}
+
+	w := rotate(`{"credentials": {"username": "other"}}`)
+	wantStatus(t, w, http.StatusBadRequest)
+	if !strings.Contains(w.Body.String(), "not a secret field: username") {
+		t.Errorf("error = %s, want it to name the username field", w.Body)
+	}
+	wantStatus(t, rotate(`{"credentials": {"password": "n3w-s3cret", "username": "other"}}`), http.StatusBadRequest)
+	wantStatus(t, rotate(`{"credentials": {}}`), http.StatusBadRequest)
+	stored("0ld-s3cret", ds.CredentialVersion)
+
+	w = rotate(`{"credentials": {"password": "n3w-s3cret"}}`)
+	wantStatus(t, w, http.StatusOK)
+	if strings.Contains(w.Body.String(), "s3cret") {
+		t.Errorf("response %s echoes a secret", w.Body)
+	}
+	var resp api.APIResponse[struct {
+		ID                string   `json:"id"`
+		UpdatedFields     []string `json:"updatedFields"`
+		CredentialVersion int      `json:"credentialVersion"`
+	}]
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Data.ID != ds.ID || len(resp.Data.UpdatedFields) != 1 || resp.Data.UpdatedFields[0] != "password" ||
+		resp.Data.CredentialVersion != ds.CredentialVersion+1 {
+		t.Errorf("response = %+v, want password updated at credential version %d", resp.Data, ds.CredentialVersion+1)
+	}
+	stored("n3w-s3cret", ds.CredentialVersion+1)
+
+	w = serveAs(t, claims, http.MethodPost, route, "/datasources/00000000-0000-4000-8000-000000000000/rotate-credentials",
+		`{"credentials": {"password": "n3w-s3cret"}}`, h.RotateCredentials)
+	wantStatus(t, w, http.StatusNotFound)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+)
+
+// pipelineDependents returns the pipelines that reference the resource id:
+// extract steps reading it, load steps writing it, and any step config or
+// trigger value equal to it
+func pipelineDependents(ctx context.Context, repo *repository.PipelineRepository, id string) ([]model.PipelineDependent, error) {
+	pipelines, err := repo.ListMentioning(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	dependents := []model.PipelineDependent{}
+	for _, p := range pipelines {
+		if paths := pipelineReferences(p, id); len(paths) > 0 {
+			dependents = append(dependents, model.PipelineDependent{ID: p.ID, Name: p.Name, Paths: paths})
+		}
+	}
+	return dependents, nil
+}
+
+// pipelineReferences returns the paths of a pipeline's definition that
+// reference id. Definitions that do not decode are scanned as raw JSON.
+func pipelineReferences(p model.Pipeline, id string) []string {
+	var paths []string
+
+	var steps []model.PipelineStep
+	if err := json.Unmarshal(p.Steps, &steps); err == nil {
+		for i, step := range steps {
+			path := fmt.Sprintf("steps[%d]", i)
+			if step.Type == "extract" && step.Input == id {
+				paths = append(paths, path+".input")
+			}
+			if step.Type == "load" && step.Output == id {
+				paths = append(paths, path+".output")
+			}
+			paths = append(paths, jsonReferences(step.Config, path+".config", id)...)
+		}
+	} else {
+		paths = append(paths, jsonReferences(p.Steps, "steps", id)...)
+	}
+
+	paths = append(paths, jsonReferences(p.Trigger, "trigger", id)...)
+	return paths
+}
+
+// jsonReferences returns the paths of the string values equal to id in a
+// JSON document
+func jsonReferences(raw json.RawMessage, path, id string) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil
+	}
+
+	var paths []string
+	var walk func(v interface{}, path string)
+	walk = func(v interface{}, path string) {
+		switch v := v.(type) {
+		case string:
+			if v == id {
+				paths = append(paths, path)
+			}
+		case []interface{}:
+			for i, item := range v {
+				walk(item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				walk(v[k], path+"."+k)
+			}
+		}
+	}
+	walk(doc, path)
+	return paths
+}
+
+// guardDependents answers 409 with the dependent pipelines when a resource
+// about to be deleted is still referenced, unless force=true. It reports
+// whether the delete may go ahead.
+func guardDependents(c *gin.Context, repo *repository.PipelineRepository, id, kind string) bool {
+	if c.Query("force") == "true" {
+		return true
+	}
+
+	dependents, err := pipelineDependents(c.Request.Context(), repo, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if len(dependents) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":      fmt.Sprintf("%s is referenced by %d pipeline(s); update them or pass force=true", kind, len(dependents)),
+			"dependents": dependents,
+		})
+		return false
+	}
+	return true
+}
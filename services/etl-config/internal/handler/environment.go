@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/schema"
+)
+
+// environmentHeader selects the environment whose data source config a
+// request resolves, overriding the service's ETL_ENVIRONMENT
+const environmentHeader = "X-ETL-Environment"
+
+// activeEnvironment returns the environment a request resolves data source
+// configs for, or "" to use the base configs
+func (h *DataSourceHandler) activeEnvironment(c *gin.Context) string {
+	if env := c.GetHeader(environmentHeader); env != "" {
+		return env
+	}
+	return h.environment
+}
+
+// resolveEnvironment replaces the config of ds with its effective config in
+// env: the environment's override merged over the base config, so fields the
+// override leaves unset keep their base value. Environments without an
+// override use the base config unchanged.
+func resolveEnvironment(ds *model.DataSource, env string) error {
+	if env == "" {
+		return nil
+	}
+	ds.Environment = env
+
+	var overrides map[string]json.RawMessage
+	if len(ds.Environments) > 0 {
+		if err := json.Unmarshal(ds.Environments, &overrides); err != nil {
+			return fmt.Errorf("invalid environments of data source %s: %w", ds.ID, err)
+		}
+	}
+	override, ok := overrides[env]
+	if !ok {
+		return nil
+	}
+
+	base := ds.Config
+	if len(base) == 0 {
+		base = json.RawMessage(`{}`)
+	}
+	merged, err := mergePatch(base, override)
+	if err != nil {
+		return fmt.Errorf("invalid %s config of data source %s: %w", env, ds.ID, err)
+	}
+	ds.Config = merged
+	return nil
+}
+
+// checkEnvironments validates the environment overrides of a form against
+// its plugin's config schema, writing a 400 naming the first invalid
+// override and returning false when one is invalid
+func (h *DataSourceHandler) checkEnvironments(c *gin.Context, form *model.DataSourceForm) bool {
+	if len(form.Environments) == 0 || string(form.Environments) == "null" {
+		return true
+	}
+	var overrides map[string]json.RawMessage
+	if err := json.Unmarshal(form.Environments, &overrides); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "environments must map environment names to config objects"})
+		return false
+	}
+	if len(overrides) == 0 {
+		return true
+	}
+
+	plugin, err := h.pluginRepo.GetByName(c.Request.Context(), form.Plugin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if plugin == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "plugin not found: " + form.Plugin})
+		return false
+	}
+	doc, err := schema.PluginConfigSchema(plugin.ConfigSchema)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+
+	envs := make([]string, 0, len(overrides))
+	for env := range overrides {
+		envs = append(envs, env)
+	}
+	sort.Strings(envs)
+	for _, env := range envs {
+		if env == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "environments: environment names must not be empty"})
+			return false
+		}
+		if err := schema.CheckConfigOverride(doc, overrides[env]); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("environments.%s: %v", env, err)})
+			return false
+		}
+	}
+	return true
+}
@@ -1,10 +1,13 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
 )
@@ -23,35 +26,70 @@ func NewExecutionHandler() *ExecutionHandler {
 
 // List returns paginated executions
 func (h *ExecutionHandler) List(c *gin.Context) {
-	scheduleID := c.Query("scheduleId")
-	pipelineID := c.Query("pipelineId")
-	status := c.Query("status")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
-
-	if page < 1 {
-		page = 1
+	filter, err := parseExecutionFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	page, pageSize, err := api.ParsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	withTotal, err := api.ParseWithTotal(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort, err := api.ParseSort(c, repository.ExecutionSortColumns.Fields())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	executions, total, err := h.repo.List(c.Request.Context(), scheduleID, pipelineID, status, page, pageSize)
+	executions, total, err := h.repo.List(c.Request.Context(), filter, sort, page, pageSize, withTotal)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if executions == nil {
-		executions = []model.Execution{}
+	api.RespondPaginated(c, executions, total, page, pageSize)
+}
+
+// parseExecutionFilter reads the execution list filters from the query string.
+// startedAfter and startedBefore are RFC3339 timestamps.
+func parseExecutionFilter(c *gin.Context) (model.ExecutionFilter, error) {
+	filter := model.ExecutionFilter{
+		ScheduleID: c.Query("scheduleId"),
+		PipelineID: c.Query("pipelineId"),
+		Status:     c.Query("status"),
+	}
+
+	var err error
+	if filter.StartedAfter, err = parseTimeQuery(c, "startedAfter"); err != nil {
+		return filter, err
+	}
+	if filter.StartedBefore, err = parseTimeQuery(c, "startedBefore"); err != nil {
+		return filter, err
+	}
+	if filter.StartedAfter != nil && filter.StartedBefore != nil && filter.StartedAfter.After(*filter.StartedBefore) {
+		return filter, fmt.Errorf("startedAfter must not be later than startedBefore")
 	}
 
-	c.JSON(http.StatusOK, model.PaginatedResponse[model.Execution]{
-		Data:     executions,
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
-	})
+	return filter, nil
+}
+
+// parseTimeQuery reads an optional RFC3339 timestamp query parameter
+func parseTimeQuery(c *gin.Context, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC3339 timestamp", key)
+	}
+	return &t, nil
 }
 
 // Get returns an execution by ID
@@ -68,24 +106,355 @@ func (h *ExecutionHandler) Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, model.APIResponse[*model.Execution]{Data: e})
+	c.JSON(http.StatusOK, api.APIResponse[*model.Execution]{Data: e})
 }
 
-// GetLogs returns logs for an execution
+// GetLogs returns the log entries of an execution. With ?fields=true each
+// entry includes its structured fields.
 func (h *ExecutionHandler) GetLogs(c *gin.Context) {
 	id := c.Param("id")
 	taskID := c.Query("taskId")
 	level := c.Query("level")
+	withFields := c.Query("fields") == "true"
 
-	logs, err := h.repo.GetLogs(c.Request.Context(), id, taskID, level)
+	e, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if e == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "execution not found"})
+		return
+	}
+
+	logs, err := h.repo.GetLogs(c.Request.Context(), id, taskID, level, withFields)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	if logs == nil {
-		logs = []string{}
+		logs = []model.ExecutionLog{}
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[[]model.ExecutionLog]{Data: logs})
+}
+
+// QueueStats returns the size and age of the execution backlog, for
+// dashboards and alerting
+func (h *ExecutionHandler) QueueStats(c *gin.Context) {
+	stats, err := h.repo.QueueStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[*model.ExecutionQueueStats]{Data: stats})
+}
+
+// Compare returns a per-task comparison of two executions
+func (h *ExecutionHandler) Compare(c *gin.Context) {
+	idA := c.Query("a")
+	idB := c.Query("b")
+	if idA == "" || idB == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "both a and b execution ids are required"})
+		return
+	}
+
+	a, err := h.repo.GetByID(c.Request.Context(), idA)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if a == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "execution not found: " + idA})
+		return
 	}
 
-	c.JSON(http.StatusOK, model.APIResponse[[]string]{Data: logs})
+	b, err := h.repo.GetByID(c.Request.Context(), idB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if b == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "execution not found: " + idB})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[*model.ExecutionComparison]{Data: compareExecutions(a, b)})
+}
+
+// compareExecutions matches tasks by node id and computes status and metric deltas
+func compareExecutions(a, b *model.Execution) *model.ExecutionComparison {
+	cmp := &model.ExecutionComparison{
+		A:             a,
+		B:             b,
+		DurationDelta: delta(a.Duration, b.Duration),
+		Tasks:         []model.TaskComparison{},
+		OnlyInA:       []string{},
+		OnlyInB:       []string{},
+	}
+
+	tasksB := make(map[string]*model.TaskExecution, len(b.Tasks))
+	for i := range b.Tasks {
+		tasksB[b.Tasks[i].NodeID] = &b.Tasks[i]
+	}
+
+	seen := make(map[string]bool, len(a.Tasks))
+	for i := range a.Tasks {
+		ta := &a.Tasks[i]
+		seen[ta.NodeID] = true
+		tc := model.TaskComparison{
+			NodeID:    ta.NodeID,
+			NodeName:  ta.NodeName,
+			Presence:  "a_only",
+			StatusA:   &ta.Status,
+			DurationA: taskDuration(ta),
+		}
+
+		if tb, ok := tasksB[ta.NodeID]; ok {
+			tc.Presence = "both"
+			tc.StatusB = &tb.Status
+			tc.StatusChanged = ta.Status != tb.Status
+			tc.DurationB = taskDuration(tb)
+			tc.DurationDelta = delta(tc.DurationA, tc.DurationB)
+			tc.InputRowsDelta = delta(ta.InputRows, tb.InputRows)
+			tc.OutputRowsDelta = delta(ta.OutputRows, tb.OutputRows)
+		} else {
+			cmp.OnlyInA = append(cmp.OnlyInA, ta.NodeID)
+		}
+		cmp.Tasks = append(cmp.Tasks, tc)
+	}
+
+	for i := range b.Tasks {
+		tb := &b.Tasks[i]
+		if seen[tb.NodeID] {
+			continue
+		}
+		cmp.OnlyInB = append(cmp.OnlyInB, tb.NodeID)
+		cmp.Tasks = append(cmp.Tasks, model.TaskComparison{
+			NodeID:    tb.NodeID,
+			NodeName:  tb.NodeName,
+			Presence:  "b_only",
+			StatusB:   &tb.Status,
+			DurationB: taskDuration(tb),
+		})
+	}
+
+	return cmp
+}
+
+// taskDuration returns a task's run time in milliseconds, if it has finished
+func taskDuration(t *model.TaskExecution) *int64 {
+	if t.StartedAt == nil || t.FinishedAt == nil {
+		return nil
+	}
+	d := t.FinishedAt.Sub(*t.StartedAt).Milliseconds()
+	return &d
+}
+
+// delta returns b - a when both values are known
+func delta(a, b *int64) *int64 {
+	if a == nil || b == nil {
+		return nil
+	}
+	d := *b - *a
+	return &d
+}
+
+// statusPollInterval is how often StreamStatus polls an execution for changes
+const statusPollInterval = time.Second
+
+// statusKeepAlive is how often StreamStatus writes a comment line so idle
+// proxies don't drop the connection
+const statusKeepAlive = 15 * time.Second
+
+// StreamStatus streams task status for an execution over Server-Sent Events.
+// A "status" event carries the full task list whenever any task changes; a
+// final "done" event is sent once the execution reaches a terminal state and
+// the stream is closed.
+func (h *ExecutionHandler) StreamStatus(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	e, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if e == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "execution not found"})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// The stream outlives the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	poll := time.NewTicker(statusPollInterval)
+	defer poll.Stop()
+	keepAlive := time.NewTicker(statusKeepAlive)
+	defer keepAlive.Stop()
+
+	last := ""
+	for {
+		if fingerprint := statusFingerprint(e); fingerprint != last {
+			last = fingerprint
+			event := "status"
+			if isTerminalExecution(e.Status) {
+				event = "done"
+			}
+			c.SSEvent(event, executionStatusEvent(e))
+			c.Writer.Flush()
+		}
+		if isTerminalExecution(e.Status) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := c.Writer.WriteString(": keepalive\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+			continue
+		case <-poll.C:
+		}
+
+		next, err := h.repo.GetByID(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.SSEvent("error", gin.H{"error": err.Error()})
+			c.Writer.Flush()
+			return
+		}
+		if next == nil {
+			c.SSEvent("error", gin.H{"error": "execution not found"})
+			c.Writer.Flush()
+			return
+		}
+		e = next
+	}
+}
+
+// Bounds of the wait endpoint's timeout query parameter
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 5 * time.Minute
+)
+
+// Wait long-polls an execution: it returns as soon as the execution reaches
+// a terminal state, or when ?timeout (a duration such as "60s", default 30s,
+// at most 5m) elapses, with the current execution either way. Clients tell
+// the two apart by its status.
+func (h *ExecutionHandler) Wait(c *gin.Context) {
+	id := c.Param("id")
+	ctx := c.Request.Context()
+
+	timeout, err := parseWaitTimeout(c.Query("timeout"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	e, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if e == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "execution not found"})
+		return
+	}
+
+	// The wait may outlast the server's write timeout
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(timeout + statusKeepAlive))
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	poll := time.NewTicker(statusPollInterval)
+	defer poll.Stop()
+
+	for !isTerminalExecution(e.Status) {
+		select {
+		case <-ctx.Done():
+			// The client went away; nobody is left to answer
+			return
+		case <-deadline.C:
+			c.JSON(http.StatusOK, api.APIResponse[*model.Execution]{Data: e})
+			return
+		case <-poll.C:
+		}
+
+		next, err := h.repo.GetByID(ctx, id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if next == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "execution not found"})
+			return
+		}
+		e = next
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[*model.Execution]{Data: e})
+}
+
+// parseWaitTimeout parses the timeout of a wait, defaulting when empty
+func parseWaitTimeout(v string) (time.Duration, error) {
+	if v == "" {
+		return defaultWaitTimeout, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", v, err)
+	}
+	if d <= 0 || d > maxWaitTimeout {
+		return 0, fmt.Errorf("timeout must be positive and at most %s", maxWaitTimeout)
+	}
+	return d, nil
+}
+
+// executionStatusEvent is the payload of a status stream event
+func executionStatusEvent(e *model.Execution) gin.H {
+	tasks := e.Tasks
+	if tasks == nil {
+		tasks = []model.TaskExecution{}
+	}
+	return gin.H{
+		"executionId": e.ID,
+		"status":      e.Status,
+		"startedAt":   e.StartedAt,
+		"finishedAt":  e.FinishedAt,
+		"duration":    e.Duration,
+		"tasks":       tasks,
+	}
+}
+
+// statusFingerprint summarizes the fields a status stream reports, so an
+// event is only sent when something changed
+func statusFingerprint(e *model.Execution) string {
+	b, _ := json.Marshal(executionStatusEvent(e))
+	return string(b)
+}
+
+// isTerminalExecution reports whether an execution status is final
+func isTerminalExecution(status string) bool {
+	switch status {
+	case "success", "failed", "cancelled":
+		return true
+	}
+	return false
 }
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// Bounds of a bulk execution request: seeds are inserted bulkBatchSize per
+// transaction by up to bulkWorkers transactions at a time, so memory stays
+// flat however long the stream is
+const (
+	bulkBatchSize    = 500
+	bulkWorkers      = 4
+	maxSeedLineBytes = 64 << 10
+)
+
+// executionTriggers are the values of the execution_trigger enum
+var executionTriggers = []string{"scheduled", "manual", "retry"}
+
+// seedBatch is a batch of valid seeds and the lines they were read from
+type seedBatch struct {
+	lines []int
+	seeds []model.ExecutionSeed
+}
+
+// BulkCreate queues executions from an NDJSON stream of execution seeds,
+// one per line, e.g. for a backfill. Seeds are inserted in batched
+// transactions while the body is still being read, and a result line is
+// streamed back per seed as its batch commits, so results follow batch
+// order rather than line order. Invalid lines are reported without
+// stopping the stream; an unreadable body ends it with a result for the
+// line that could not be read.
+func (h *ExecutionHandler) BulkCreate(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	// The stream is read and answered at once, for longer than the server's
+	// timeouts allow
+	rc := http.NewResponseController(c.Writer)
+	_ = rc.EnableFullDuplex()
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	batches := make(chan seedBatch, bulkWorkers)
+	results := make(chan []model.ExecutionSeedResult, bulkWorkers)
+
+	var workers sync.WaitGroup
+	for i := 0; i < bulkWorkers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for b := range batches {
+				results <- h.createSeeds(ctx, b)
+			}
+		}()
+	}
+	go func() {
+		readSeeds(ctx, c.Request.Body, batches, results)
+		close(batches)
+		workers.Wait()
+		close(results)
+	}()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Status(http.StatusOK)
+	enc := json.NewEncoder(c.Writer)
+	failed := false
+	for rs := range results {
+		// Keep draining after a failed write so the workers can finish
+		for _, r := range rs {
+			if !failed && enc.Encode(r) != nil {
+				failed = true
+			}
+		}
+		if !failed {
+			c.Writer.Flush()
+		}
+	}
+}
+
+// readSeeds reads seeds from body into batches of valid seeds, sending a
+// result straight to results for every invalid line
+func readSeeds(ctx context.Context, body io.Reader, batches chan<- seedBatch, results chan<- []model.ExecutionSeedResult) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxSeedLineBytes)
+
+	var batch seedBatch
+	flush := func() bool {
+		if len(batch.seeds) == 0 {
+			return true
+		}
+		select {
+		case batches <- batch:
+			batch = seedBatch{}
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	reject := func(line int, err error) bool {
+		select {
+		case results <- []model.ExecutionSeedResult{{Line: line, Error: err.Error()}}:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		seed, err := parseSeed(raw)
+		if err != nil {
+			if !reject(line, err) {
+				return
+			}
+			continue
+		}
+		batch.lines = append(batch.lines, line)
+		batch.seeds = append(batch.seeds, seed)
+		if len(batch.seeds) == bulkBatchSize && !flush() {
+			return
+		}
+	}
+	if !flush() {
+		return
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			err = fmt.Errorf("line is longer than %d bytes", maxSeedLineBytes)
+		}
+		reject(line+1, fmt.Errorf("reading the stream stopped: %w", err))
+	}
+}
+
+// parseSeed decodes and checks one seed line, applying its defaults
+func parseSeed(raw []byte) (model.ExecutionSeed, error) {
+	var seed model.ExecutionSeed
+	if err := json.Unmarshal(raw, &seed); err != nil {
+		return seed, fmt.Errorf("invalid seed: %v", err)
+	}
+
+	if seed.ScheduleID == nil && seed.PipelineID == nil {
+		return seed, errors.New("scheduleId or pipelineId is required")
+	}
+	if seed.ScheduleID != nil && !uuidPattern.MatchString(*seed.ScheduleID) {
+		return seed, fmt.Errorf("scheduleId %q is not a valid id", *seed.ScheduleID)
+	}
+	if seed.PipelineID != nil && !uuidPattern.MatchString(*seed.PipelineID) {
+		return seed, fmt.Errorf("pipelineId %q is not a valid id", *seed.PipelineID)
+	}
+
+	if seed.Trigger == "" {
+		seed.Trigger = "manual"
+	}
+	if !contains(executionTriggers, seed.Trigger) {
+		return seed, fmt.Errorf("trigger must be one of: %s", strings.Join(executionTriggers, ", "))
+	}
+
+	if len(seed.Params) == 0 || string(seed.Params) == "null" {
+		seed.Params = json.RawMessage(`{}`)
+	} else if seed.Params[0] != '{' {
+		return seed, errors.New("params must be an object")
+	}
+	return seed, nil
+}
+
+// createSeeds inserts one batch and returns a result per seed. A failed
+// transaction fails every seed of the batch.
+func (h *ExecutionHandler) createSeeds(ctx context.Context, b seedBatch) []model.ExecutionSeedResult {
+	results := make([]model.ExecutionSeedResult, len(b.seeds))
+	ids, err := h.repo.CreateBatch(ctx, b.seeds)
+	for i, line := range b.lines {
+		results[i].Line = line
+		switch {
+		case err != nil:
+			results[i].Error = err.Error()
+		case ids[i] == "":
+			results[i].Error = "schedule or pipeline not found"
+		default:
+			results[i].ExecutionID = ids[i]
+		}
+	}
+	return results
+}
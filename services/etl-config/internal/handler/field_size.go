@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// jsonField is a named JSON field of a definition
+type jsonField struct {
+	name  string
+	value json.RawMessage
+}
+
+// fieldTooLargeError reports a JSON field over the size limit
+type fieldTooLargeError struct {
+	field       string
+	size, limit int
+}
+
+func (e *fieldTooLargeError) Error() string {
+	return fmt.Sprintf("%s is %d bytes, more than the limit of %d", e.field, e.size, e.limit)
+}
+
+// checkFieldSizes returns a *fieldTooLargeError for the first field larger
+// than limit bytes. Large JSON fields bloat their row and every read of it.
+func checkFieldSizes(limit int, fields ...jsonField) error {
+	for _, f := range fields {
+		if len(f.value) > limit {
+			return &fieldTooLargeError{field: f.name, size: len(f.value), limit: limit}
+		}
+	}
+	return nil
+}
+
+// dataSetFields returns the JSON fields of a dataset
+func dataSetFields(ds *model.DataSet) []jsonField {
+	return []jsonField{
+		{"schema", ds.Schema},
+		{"storage", ds.Storage},
+		{"indexes", ds.Indexes},
+		{"labels", ds.Labels},
+	}
+}
+
+// pipelineFields returns the JSON fields of a pipeline definition
+func pipelineFields(form *model.PipelineForm) []jsonField {
+	return []jsonField{
+		{"trigger", form.Trigger},
+		{"parameters", form.Parameters},
+		{"steps", form.Steps},
+	}
+}
+
+// respondFieldSize writes a 413 for a field over the size limit, or a 400
+// for any other error
+func respondFieldSize(c *gin.Context, err error) {
+	var tooLarge *fieldTooLargeError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
+
+// parseSummary reads the fields query parameter of list endpoints:
+// "summary" leaves the heavy JSON fields out of every item, reporting their
+// sizes instead; "full", the default, keeps them
+func parseSummary(c *gin.Context) (bool, error) {
+	switch fields := c.Query("fields"); fields {
+	case "", "full":
+		return false, nil
+	case "summary":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid fields %q: must be summary or full", fields)
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+)
+
+// testSecret signs the tokens of test requests
+const testSecret = "test-secret"
+
+// testVerifier accepts tokens signed with testSecret
+var testVerifier = auth.NewVerifier(auth.NewKeySet(testSecret, nil, ""), time.Hour)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// serve runs one request of a user of the default tenant through a router
+// holding only route, answered by h
+func serve(t *testing.T, method, route, target, body string, h gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	return serveAs(t, &auth.Claims{UserID: "tester"}, method, route, target, body, h)
+}
+
+// serveAs is serve for a request carrying a token for claims, or none when
+// claims is nil, verified by Identity
+func serveAs(t *testing.T, claims *auth.Claims, method, route, target, body string, h gin.HandlerFunc) *httptest.ResponseRecorder {
+	t.Helper()
+	r := gin.New()
+	r.Use(Identity(testVerifier, false))
+	r.Handle(method, route, h)
+
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if claims != nil {
+		if claims.ExpiresAt == 0 {
+			claims.ExpiresAt = time.Now().Add(time.Minute).Unix()
+		}
+		req.Header.Set("Authorization", bearer(t, testSecret, *claims))
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// wantStatus fails the test unless w answered status
+func wantStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d; body %s", w.Code, status, w.Body.String())
+	}
+}
+
+// bearer returns an Authorization header value carrying an HS256 token for
+// the claims, signed with secret
+func bearer(t *testing.T, secret string, claims auth.Claims) string {
+	t.Helper()
+	token, err := auth.NewKeySet(secret, nil, "").Sign(&claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
+
+// testLimits are the default limits of config.Load
+var testLimits = config.LimitsConfig{
+	MaxDAGNodes:      500,
+	MaxDAGEdges:      2000,
+	MaxDAGDepth:      50,
+	MaxPipelineSteps: 200,
+	MaxImportBytes:   32 << 20,
+	MaxFieldBytes:    256 << 10,
+}
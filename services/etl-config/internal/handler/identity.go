@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+)
+
+// claimsKey is the gin context key for the verified token claims
+const claimsKey = "claims"
+
+// claimsFrom returns the verified claims of the request, or nil for
+// anonymous requests
+func claimsFrom(c *gin.Context) *auth.Claims {
+	if v, ok := c.Get(claimsKey); ok {
+		if claims, ok := v.(*auth.Claims); ok {
+			return claims
+		}
+	}
+	return nil
+}
+
+// currentUserID returns the acting user, or "" for anonymous requests
+func currentUserID(c *gin.Context) string {
+	if claims := claimsFrom(c); claims != nil {
+		return claims.UserID
+	}
+	return ""
+}
+
+// isAdmin reports whether the acting user has the admin role
+func isAdmin(c *gin.Context) bool {
+	claims := claimsFrom(c)
+	return claims != nil && claims.IsAdmin()
+}
+
+// requireUser returns the acting user, writing a 401 and returning ok=false
+// when the request carries no user identity
+func requireUser(c *gin.Context) (userID string, ok bool) {
+	userID = currentUserID(c)
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user identity required"})
+		return "", false
+	}
+	return userID, true
+}
+
+// currentTenantID returns the tenant of the verified token, or "" for
+// anonymous requests and tokens without one, which fall back to
+// repository.DefaultTenant
+func currentTenantID(c *gin.Context) string {
+	if claims := claimsFrom(c); claims != nil {
+		return claims.TenantID
+	}
+	return ""
+}
+
+// Identity verifies the gateway token in the Authorization header and
+// carries its identity into the repository context. The service is
+// reachable without the gateway, so the user, role and tenant are only ever
+// taken from a verified token; a request without a valid token gets a 401,
+// unless allowAnonymous lets tokenless requests through as anonymous users
+// of the default tenant for local development. Every query of a request is
+// scoped to the token's tenant, so resources of other tenants are reported
+// as not found, and writes record the acting user as created_by/updated_by.
+// Tokens with the admin role may pass ?allTenants=true for a cross-tenant
+// view; anyone else asking for one gets a 403.
+func Identity(verifier *auth.Verifier, allowAnonymous bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		switch {
+		case header != "":
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid authorization header format"})
+				return
+			}
+			claims, err := verifier.Verify(token)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+				return
+			}
+			c.Set(claimsKey, claims)
+		case !allowAnonymous:
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
+			return
+		}
+
+		ctx := repository.WithTenant(c.Request.Context(), currentTenantID(c))
+		ctx = repository.WithActor(ctx, currentUserID(c))
+		if c.Query("allTenants") == "true" {
+			if !isAdmin(c) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "cross-tenant access requires the admin role"})
+				return
+			}
+			ctx = repository.WithAllTenants(ctx)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+)
+
+// whoami runs a request through Identity and returns the identity the
+// handler saw
+func whoami(t *testing.T, allowAnonymous bool, target string, headers map[string]string) (*httptest.ResponseRecorder, gin.H) {
+	t.Helper()
+	r := gin.New()
+	r.Use(Identity(testVerifier, allowAnonymous))
+	r.GET("/whoami", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user": currentUserID(c), "admin": isAdmin(c), "tenant": currentTenantID(c)})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var body gin.H
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w, body
+}
+
+func TestIdentityRequiresToken(t *testing.T) {
+	w, _ := whoami(t, false, "/whoami", nil)
+	wantStatus(t, w, http.StatusUnauthorized)
+
+	// Identity headers do not stand in for a token
+	spoofed := map[string]string{"X-User-ID": "mallory", "X-User-Role": "admin", "X-Tenant-ID": "acme"}
+	w, _ = whoami(t, false, "/whoami", spoofed)
+	wantStatus(t, w, http.StatusUnauthorized)
+}
+
+func TestIdentityIgnoresIdentityHeaders(t *testing.T) {
+	spoofed := map[string]string{"X-User-ID": "mallory", "X-User-Role": "admin", "X-Tenant-ID": "acme"}
+
+	w, body := whoami(t, true, "/whoami", spoofed)
+	wantStatus(t, w, http.StatusOK)
+	if body["user"] != "" || body["admin"] != false || body["tenant"] != "" {
+		t.Errorf("identity = %v, want an anonymous non-admin of the default tenant", body)
+	}
+
+	w, _ = whoami(t, true, "/whoami?allTenants=true", spoofed)
+	wantStatus(t, w, http.StatusForbidden)
+}
+
+func TestIdentityFromToken(t *testing.T) {
+	exp := time.Now().Add(time.Minute).Unix()
+	admin := bearer(t, testSecret, auth.Claims{UserID: "alice", Role: auth.RoleAdmin, ExpiresAt: exp})
+	viewer := bearer(t, testSecret, auth.Claims{UserID: "bob", Role: "viewer", ExpiresAt: exp})
+
+	w, body := whoami(t, false, "/whoami", map[string]string{"Authorization": admin})
+	wantStatus(t, w, http.StatusOK)
+	if body["user"] != "alice" || body["admin"] != true {
+		t.Errorf("identity = %v, want admin alice", body)
+	}
+
+	// The role header cannot raise a verified token's role
+	w, body = whoami(t, false, "/whoami", map[string]string{"Authorization": viewer, "X-User-Role": "admin"})
+	wantStatus(t, w, http.StatusOK)
+	if body["user"] != "bob" || body["admin"] != false {
+		t.Errorf("identity = %v, want non-admin bob", body)
+	}
+
+	w, _ = whoami(t, false, "/whoami?allTenants=true", map[string]string{"Authorization": admin})
+	wantStatus(t, w, http.StatusOK)
+	w, _ = whoami(t, false, "/whoami?allTenants=true", map[string]string{"Authorization": viewer})
+	wantStatus(t, w, http.StatusForbidden)
+}
+
+func TestIdentityRejectsInvalidTokens(t *testing.T) {
+	exp := time.Now().Add(time.Minute).Unix()
+	tests := map[string]string{
+		"wrong secret": bearer(t, "other-secret", auth.Claims{UserID: "alice", Role: auth.RoleAdmin, ExpiresAt: exp}),
+		"expired":      bearer(t, testSecret, auth.Claims{UserID: "alice", ExpiresAt: time.Now().Add(-time.Minute).Unix()}),
+		"not bearer":   "Basic YWxpY2U6c2VjcmV0",
+		"malformed":    "Bearer not-a-token",
+	}
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			w, _ := whoami(t, false, "/whoami", map[string]string{"Authorization": header})
+			wantStatus(t, w, http.StatusUnauthorized)
+		})
+	}
+}
+
+func TestIdentityTenantFromToken(t *testing.T) {
+	exp := time.Now().Add(time.Minute).Unix()
+	token := bearer(t, testSecret, auth.Claims{UserID: "bob", TenantID: "globex", Role: "viewer", ExpiresAt: exp})
+
+	// The tenant header cannot move a token to another tenant
+	w, body := whoami(t, false, "/whoami", map[string]string{"Authorization": token, "X-Tenant-ID": "acme"})
+	wantStatus(t, w, http.StatusOK)
+	if body["tenant"] != "globex" {
+		t.Errorf("tenant = %v, want globex", body["tenant"])
+	}
+
+	w, _ = whoami(t, false, "/whoami?allTenants=true", map[string]string{"Authorization": token, "X-User-Role": "admin"})
+	wantStatus(t, w, http.StatusForbidden)
+}
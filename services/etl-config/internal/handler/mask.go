@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// maskedValue replaces secret config values in API responses
+const maskedValue = "***"
+
+// sensitiveKeys are matched against normalized config keys as suffixes, so
+// "dbPassword", "client_secret" and "accessToken" are masked too
+var sensitiveKeys = []string{"password", "secret", "token", "apikey", "privatekey"}
+
+// revealSecrets reports whether the caller asked for unmasked config with
+// ?reveal=true. Only admins may reveal; for anyone else it writes a 403 and
+// returns ok=false.
+func revealSecrets(c *gin.Context) (reveal, ok bool) {
+	if c.Query("reveal") != "true" {
+		return false, true
+	}
+	if !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "revealing secrets requires the admin role"})
+		return false, false
+	}
+	return true, true
+}
+
+// maskDataSource redacts secret values in a data source config and its
+// environment overrides in place
+func maskDataSource(ds *model.DataSource) {
+	if ds != nil {
+		ds.Config = maskConfig(ds.Config)
+		ds.Environments = maskConfig(ds.Environments)
+	}
+}
+
+// maskConfig walks a config document and replaces the values of sensitive
+// keys at any depth with maskedValue
+func maskConfig(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return json.RawMessage(`{}`)
+	}
+
+	masked, err := json.Marshal(maskValue(doc))
+	if err != nil {
+		return json.RawMessage(`{}`)
+	}
+	return masked
+}
+
+func maskValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, child := range val {
+			if isSensitiveKey(key) && child != nil && child != "" {
+				val[key] = maskedValue
+				continue
+			}
+			val[key] = maskValue(child)
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = maskValue(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// unmaskConfig replaces masked placeholders in an incoming config with the
+// values from the stored config, so a client can round-trip a masked read
+// through an update without wiping its secrets
+func unmaskConfig(incoming, stored json.RawMessage) json.RawMessage {
+	if len(incoming) == 0 || len(stored) == 0 {
+		return incoming
+	}
+
+	var in, old interface{}
+	if err := json.Unmarshal(incoming, &in); err != nil {
+		return incoming
+	}
+	if err := json.Unmarshal(stored, &old); err != nil {
+		return incoming
+	}
+
+	merged, err := json.Marshal(unmaskValue(in, old))
+	if err != nil {
+		return incoming
+	}
+	return merged
+}
+
+func unmaskValue(in, old interface{}) interface{} {
+	switch val := in.(type) {
+	case map[string]interface{}:
+		oldMap, _ := old.(map[string]interface{})
+		for key, child := range val {
+			if child == maskedValue && isSensitiveKey(key) {
+				if prev, ok := oldMap[key]; ok {
+					val[key] = prev
+				} else {
+					delete(val, key)
+				}
+				continue
+			}
+			val[key] = unmaskValue(child, oldMap[key])
+		}
+		return val
+	case []interface{}:
+		oldList, _ := old.([]interface{})
+		for i, child := range val {
+			var prev interface{}
+			if i < len(oldList) {
+				prev = oldList[i]
+			}
+			val[i] = unmaskValue(child, prev)
+		}
+		return val
+	default:
+		return in
+	}
+}
+
+// isSensitiveKey reports whether a config key names a secret
+func isSensitiveKey(key string) bool {
+	normalized := strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, suffix := range sensitiveKeys {
+		if strings.HasSuffix(normalized, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,54 +2,67 @@ package handler
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
 )
 
 // PipelineHandler handles pipeline HTTP requests
 type PipelineHandler struct {
-	repo *repository.PipelineRepository
+	repo        *repository.PipelineRepository
+	pluginRepo  *repository.PluginRepository
+	dsRepo      *repository.DataSourceRepository
+	datasetRepo *repository.DataSetRepository
+	limits      config.LimitsConfig
 }
 
 // NewPipelineHandler creates a new PipelineHandler
-func NewPipelineHandler() *PipelineHandler {
+func NewPipelineHandler(limits config.LimitsConfig) *PipelineHandler {
 	return &PipelineHandler{
-		repo: repository.NewPipelineRepository(),
+		repo:        repository.NewPipelineRepository(),
+		pluginRepo:  repository.NewPluginRepository(),
+		dsRepo:      repository.NewDataSourceRepository(),
+		datasetRepo: repository.NewDataSetRepository(),
+		limits:      limits,
 	}
 }
 
 // List returns paginated pipelines
 func (h *PipelineHandler) List(c *gin.Context) {
-	status := c.Query("status")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
-
-	if page < 1 {
-		page = 1
+	filter := model.PipelineFilter{
+		Status:    c.Query("status"),
+		CreatedBy: c.Query("createdBy"),
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	page, pageSize, err := api.ParsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	pipelines, total, err := h.repo.List(c.Request.Context(), status, page, pageSize)
+	withTotal, err := api.ParseWithTotal(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort, err := api.ParseSort(c, repository.PipelineSortColumns.Fields())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if filter.Summary, err = parseSummary(c); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	if pipelines == nil {
-		pipelines = []model.Pipeline{}
+	pipelines, total, err := h.repo.List(c.Request.Context(), filter, sort, page, pageSize, withTotal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
-	c.JSON(http.StatusOK, model.PaginatedResponse[model.Pipeline]{
-		Data:     pipelines,
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
-	})
+	api.RespondPaginated(c, pipelines, total, page, pageSize)
 }
 
 // Get returns a pipeline by ID
@@ -66,49 +79,128 @@ func (h *PipelineHandler) Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, model.APIResponse[*model.Pipeline]{Data: p})
+	c.JSON(http.StatusOK, api.APIResponse[*model.Pipeline]{Data: p})
 }
 
-// Create creates a new pipeline
+// Create creates a new pipeline. A definition whose steps reference data
+// sources or datasets that do not exist is a 422 listing each missing
+// reference by path, so a pipeline that can never run is not saved.
 func (h *PipelineHandler) Create(c *gin.Context) {
-	var p model.Pipeline
-	if err := c.ShouldBindJSON(&p); err != nil {
+	var form model.PipelineForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if !h.checkDefinition(c, &form) {
+		return
+	}
+
+	result, err := h.repo.Create(c.Request.Context(), &form)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, api.APIResponse[*model.Pipeline]{Data: result})
+}
+
+// Validate handles POST /pipelines/validate: it runs the checks Create
+// applies against an unsaved definition and reports every error and warning,
+// so the editor can show feedback before saving
+func (h *PipelineHandler) Validate(c *gin.Context) {
+	var form model.PipelineForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if err := checkFieldSizes(h.limits.MaxFieldBytes, pipelineFields(&form)...); err != nil {
+		respondFieldSize(c, err)
+		return
+	}
+	if err := checkPipelineSteps(h.limits, form.Steps); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	result, err := h.repo.Create(c.Request.Context(), &p)
+	result, err := h.validate(c.Request.Context(), &form)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, model.APIResponse[*model.Pipeline]{Data: result})
+	c.JSON(http.StatusOK, api.APIResponse[*model.PipelineValidation]{Data: result})
 }
 
-// Update updates a pipeline
+// checkDefinition validates a pipeline before it is saved, writing a 413
+// when a JSON field is too large, a 400 when it exceeds the other size
+// limits or a 422 with the issues found, and returning false when it has
+// errors
+func (h *PipelineHandler) checkDefinition(c *gin.Context, form *model.PipelineForm) bool {
+	if err := checkFieldSizes(h.limits.MaxFieldBytes, pipelineFields(form)...); err != nil {
+		respondFieldSize(c, err)
+		return false
+	}
+	if err := checkPipelineSteps(h.limits, form.Steps); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+
+	result, err := h.validate(c.Request.Context(), form)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if !result.Valid {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":    "pipeline validation failed",
+			"errors":   result.Errors,
+			"warnings": result.Warnings,
+		})
+		return false
+	}
+	return true
+}
+
+// Update updates a pipeline, rejecting missing references like Create
 func (h *PipelineHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 
-	var p model.Pipeline
-	if err := c.ShouldBindJSON(&p); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	var form model.PipelineForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if !h.checkDefinition(c, &form) {
 		return
 	}
 
-	result, err := h.repo.Update(c.Request.Context(), id, &p)
+	result, err := h.repo.Update(c.Request.Context(), id, &form)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pipeline not found"})
+		return
+	}
 
-	c.JSON(http.StatusOK, model.APIResponse[*model.Pipeline]{Data: result})
+	c.JSON(http.StatusOK, api.APIResponse[*model.Pipeline]{Data: result})
 }
 
 // Delete deletes a pipeline
 func (h *PipelineHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
+	p, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if p == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pipeline not found"})
+		return
+	}
+
 	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
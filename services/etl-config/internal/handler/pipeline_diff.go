@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+)
+
+// Diff handles POST /pipelines/:id/diff: it reports how a desired definition
+// differs from the stored pipeline. With apply=true the desired definition
+// is saved, but only if its diff equals expectedDiff and the pipeline is
+// still at the version diffed; otherwise it is a 409 with the current diff,
+// so a reconciler never applies changes it did not review.
+func (h *PipelineHandler) Diff(c *gin.Context) {
+	id := c.Param("id")
+	apply := c.Query("apply") == "true"
+
+	var form model.PipelineDiffForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if apply && form.ExpectedDiff == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "apply=true requires expectedDiff"})
+		return
+	}
+
+	current, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if current == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pipeline not found"})
+		return
+	}
+
+	result := &model.PipelineDiff{
+		PipelineID: current.ID,
+		Version:    current.Version,
+		Changes:    diffPipeline(current, &form.Desired),
+	}
+	if !apply {
+		c.JSON(http.StatusOK, api.APIResponse[*model.PipelineDiff]{Data: result})
+		return
+	}
+
+	if !sameChanges(result.Changes, form.ExpectedDiff) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "the pipeline's diff no longer matches expectedDiff",
+			"diff":  result,
+		})
+		return
+	}
+	if len(result.Changes) == 0 {
+		c.JSON(http.StatusOK, api.APIResponse[*model.PipelineDiff]{Data: result})
+		return
+	}
+	if !h.checkDefinition(c, &form.Desired) {
+		return
+	}
+
+	updated, err := h.repo.UpdateAtVersion(c.Request.Context(), id, current.Version, &form.Desired)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if updated == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "the pipeline changed while the diff was applied; diff it again"})
+		return
+	}
+
+	result.Version = updated.Version
+	result.Applied = true
+	c.JSON(http.StatusOK, api.APIResponse[*model.PipelineDiff]{Data: result})
+}
+
+// diffPipeline returns the changes that saving desired would make to p, in
+// field order. The name is not compared: Update never changes it.
+func diffPipeline(p *model.Pipeline, desired *model.PipelineForm) []model.PipelineChange {
+	d := repository.PipelineDefaults(desired)
+
+	changes := []model.PipelineChange{}
+	fields := []struct {
+		path     string
+		from, to interface{}
+	}{
+		{"description", p.Description, d.Description},
+		{"trigger", p.Trigger, d.Trigger},
+		{"parameters", p.Parameters, d.Parameters},
+		{"steps", p.Steps, d.Steps},
+		{"tags", p.Tags, d.Tags},
+	}
+	for _, f := range fields {
+		changes = diffJSON(changes, f.path, genericJSON(f.from), genericJSON(f.to))
+	}
+	return changes
+}
+
+// genericJSON converts a value to its generic JSON form, keeping numbers
+// exact. A value that does not encode, e.g. invalid raw JSON, becomes nil.
+func genericJSON(v interface{}) interface{} {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	out, err := decodeJSON(raw)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// diffJSON appends the changes from one value to another at path. Objects
+// are compared key by key; arrays of objects that all carry a distinct "id"
+// (or "name") are matched by it, so reordering or inserting a step is not
+// reported as a change to every later one; other arrays go by index.
+func diffJSON(changes []model.PipelineChange, path string, from, to interface{}) []model.PipelineChange {
+	switch o := from.(type) {
+	case map[string]interface{}:
+		n, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, k := range unionKeys(o, n) {
+			ov, inFrom := o[k]
+			nv, inTo := n[k]
+			changes = diffEntry(changes, path+"."+k, ov, inFrom, nv, inTo)
+		}
+		return changes
+
+	case []interface{}:
+		n, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		if key := matchKey(o, n); key != "" {
+			oi, ni := indexBy(o, key), indexBy(n, key)
+			for _, k := range unionKeys(oi, ni) {
+				ov, inFrom := oi[k]
+				nv, inTo := ni[k]
+				changes = diffEntry(changes, fmt.Sprintf("%s[%s=%s]", path, key, k), ov, inFrom, nv, inTo)
+			}
+			return changes
+		}
+		for i := 0; i < len(o) || i < len(n); i++ {
+			var ov, nv interface{}
+			if i < len(o) {
+				ov = o[i]
+			}
+			if i < len(n) {
+				nv = n[i]
+			}
+			changes = diffEntry(changes, fmt.Sprintf("%s[%d]", path, i), ov, i < len(o), nv, i < len(n))
+		}
+		return changes
+	}
+
+	if !jsonEqual(from, to) {
+		changes = append(changes, model.PipelineChange{Path: path, Op: "changed", Old: encodeJSON(from), New: encodeJSON(to)})
+	}
+	return changes
+}
+
+// diffEntry appends the changes of one object member or array element,
+// present before and/or after the change
+func diffEntry(changes []model.PipelineChange, path string, from interface{}, inFrom bool, to interface{}, inTo bool) []model.PipelineChange {
+	switch {
+	case inFrom && !inTo:
+		return append(changes, model.PipelineChange{Path: path, Op: "removed", Old: encodeJSON(from)})
+	case !inFrom && inTo:
+		return append(changes, model.PipelineChange{Path: path, Op: "added", New: encodeJSON(to)})
+	default:
+		return diffJSON(changes, path, from, to)
+	}
+}
+
+// matchKey returns the member that identifies the elements of both arrays,
+// "id" or "name", or "" when some element lacks a distinct string value
+func matchKey(a, b []interface{}) string {
+	for _, key := range []string{"id", "name"} {
+		if identifiedBy(a, key) && identifiedBy(b, key) {
+			return key
+		}
+	}
+	return ""
+}
+
+// identifiedBy reports whether every element of items is an object with a
+// distinct non-empty string member key
+func identifiedBy(items []interface{}, key string) bool {
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		v, ok := obj[key].(string)
+		if !ok || v == "" || seen[v] {
+			return false
+		}
+		seen[v] = true
+	}
+	return true
+}
+
+// indexBy maps the elements of items, all objects, by their member key
+func indexBy(items []interface{}, key string) map[string]interface{} {
+	out := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		out[item.(map[string]interface{})[key].(string)] = item
+	}
+	return out
+}
+
+// unionKeys returns the keys of both maps, sorted
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// jsonEqual reports whether two generic JSON values encode identically.
+// encoding/json sorts object keys, so member order does not matter.
+func jsonEqual(a, b interface{}) bool {
+	return bytes.Equal(encodeJSON(a), encodeJSON(b))
+}
+
+func encodeJSON(v interface{}) json.RawMessage {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
+
+// sameChanges reports whether two change lists are equal, ignoring the
+// formatting of their values
+func sameChanges(a, b []model.PipelineChange) bool {
+	return jsonEqual(genericJSON(a), genericJSON(b))
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// pipelineTransitions maps each pipeline status to the statuses it may move
+// to. A draft is published once; after that a pipeline is switched between
+// active and inactive, and never goes back to draft.
+var pipelineTransitions = map[string][]string{
+	model.PipelineStatusDraft:    {model.PipelineStatusActive},
+	model.PipelineStatusActive:   {model.PipelineStatusInactive},
+	model.PipelineStatusInactive: {model.PipelineStatusActive},
+}
+
+// canTransitionPipeline reports whether a pipeline may move from status
+// from to status to
+func canTransitionPipeline(from, to string) bool {
+	for _, status := range pipelineTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish handles POST /pipelines/:id/publish: it activates a draft or
+// inactive pipeline
+func (h *PipelineHandler) Publish(c *gin.Context) {
+	h.transition(c, model.PipelineStatusActive)
+}
+
+// Deactivate handles POST /pipelines/:id/deactivate: it deactivates an
+// active pipeline
+func (h *PipelineHandler) Deactivate(c *gin.Context) {
+	h.transition(c, model.PipelineStatusInactive)
+}
+
+// transition moves the pipeline to status to, writing a 409 when its
+// current status does not allow it
+func (h *PipelineHandler) transition(c *gin.Context, to string) {
+	ctx := c.Request.Context()
+	id := c.Param("id")
+
+	p, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if p == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pipeline not found"})
+		return
+	}
+	if !canTransitionPipeline(p.Status, to) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("a %s pipeline cannot become %s", p.Status, to)})
+		return
+	}
+
+	result, err := h.repo.SetStatus(ctx, id, p.Status, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if result == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "the pipeline status changed meanwhile; try again"})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[*model.Pipeline]{Data: result})
+}
@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+func TestCanTransitionPipeline(t *testing.T) {
+	const (
+		draft    = model.PipelineStatusDraft
+		active   = model.PipelineStatusActive
+		inactive = model.PipelineStatusInactive
+	)
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{draft, active, true},
+		{active, inactive, true},
+		{inactive, active, true},
+		{draft, inactive, false},
+		{active, draft, false},
+		{inactive, draft, false},
+		{active, active, false},
+		{draft, draft, false},
+		{"archived", active, false},
+	}
+	for _, tt := range tests {
+		if got := canTransitionPipeline(tt.from, tt.to); got != tt.want {
+			t.Errorf("canTransitionPipeline(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/auth"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/testdb"
+)
+
+// pipelineBody returns a pipeline extracting from the data source and
+// loading into the dataset
+func pipelineBody(name, dataSourceID, dataSetID string) string {
+	return fmt.Sprintf(`{"name": %q, "steps": [
+		{"id": "extract", "type": "extract", "plugin": "source-postgres", "input": %q},
+		{"id": "load", "type": "load", "plugin": "target-postgres", "input": "extract", "output": %q}
+	]}`, name, dataSourceID, dataSetID)
+}
+
+func TestPipelineStepReferences(t *testing.T) {
+	tenantID := testdb.Open(t)
+	ctx := testdb.Context(tenantID, "alice")
+	claims := &auth.Claims{UserID: "alice", TenantID: tenantID}
+
+	source, err := repository.NewDataSourceRepository().Create(ctx, &model.DataSourceForm{
+		Name: "warehouse", Type: "database", Plugin: "source-postgres",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := repository.NewDataSetRepository().Create(ctx, &model.DataSet{
+		Name:     "daily_bars",
+		Category: "market",
+		Schema:   json.RawMessage(`{"fields": [{"name": "code", "type": "string"}]}`),
+		Storage:  json.RawMessage(`{"type": "postgres", "table": "daily_bars"}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const missingID = "4f1c8a9e-0000-4000-8000-00000000dead"
+
+	h := NewPipelineHandler(testLimits)
+	w := serveAs(t, claims, http.MethodPost, "/pipelines", "/pipelines",
+		pipelineBody("bars", source.ID, target.ID), h.Create)
+	wantStatus(t, w, http.StatusCreated)
+	var created api.APIResponse[*model.Pipeline]
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		wantPath string
+	}{
+		{"missing data source", pipelineBody("bars", missingID, target.ID), "steps[0].input"},
+		{"missing dataset", pipelineBody("bars", source.ID, missingID), "steps[1].output"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, w := range []*httptest.ResponseRecorder{
+				serveAs(t, claims, http.MethodPost, "/pipelines", "/pipelines", tt.body, h.Create),
+				serveAs(t, claims, http.MethodPut, "/pipelines/:id", "/pipelines/"+created.Data.ID, tt.body, h.Update),
+			} {
+				wantStatus(t, w, http.StatusUnprocessableEntity)
+				var resp struct {
+					Errors []model.ValidationIssue `json:"errors"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+					t.Fatal(err)
+				}
+				if len(resp.Errors) != 1 || resp.Errors[0].Path != tt.wantPath {
+					t.Errorf("errors = %+v, want one at %s", resp.Errors, tt.wantPath)
+				}
+			}
+		})
+	}
+
+	// Records of another tenant are as good as missing
+	other := &auth.Claims{UserID: "mallory", TenantID: tenantID + "-other"}
+	w = serveAs(t, other, http.MethodPost, "/pipelines/validate", "/pipelines/validate",
+		pipelineBody("bars", source.ID, target.ID), h.Validate)
+	wantStatus(t, w, http.StatusOK)
+	var validation api.APIResponse[*model.PipelineValidation]
+	if err := json.Unmarshal(w.Body.Bytes(), &validation); err != nil {
+		t.Fatal(err)
+	}
+	if validation.Data.Valid || len(validation.Data.Errors) != 2 {
+		t.Errorf("validation from another tenant = %+v, want both references missing", validation.Data)
+	}
+}
@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/schema"
+)
+
+// Allowed values of the step and trigger enums, in migration order
+var (
+	stepTypes     = []string{"extract", "transform", "load"}
+	errorHandling = []string{"skip_row", "fail", "default_value"}
+	triggerTypes  = []string{"schedule", "manual", "event"}
+)
+
+// uuidPattern matches the textual form of a UUID primary key
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// pipelineValidation collects the issues found in one pipeline definition
+// and caches the records it looks up, so a reference shared by several steps
+// is fetched once
+type pipelineValidation struct {
+	result      model.PipelineValidation
+	plugins     map[string]*model.Plugin
+	datasources map[string]*model.DataSource
+	datasets    map[string]*model.DataSet
+}
+
+func (v *pipelineValidation) errorf(path, format string, args ...interface{}) {
+	v.result.Errors = append(v.result.Errors, model.ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *pipelineValidation) warnf(path, format string, args ...interface{}) {
+	v.result.Warnings = append(v.result.Warnings, model.ValidationIssue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate checks a pipeline definition: the trigger and parameter
+// declarations, that steps have unique IDs (and, as a warning, unique
+// names) and only read the output of earlier steps, and that the plugins,
+// data sources and datasets they name exist in the acting tenant. Create
+// and Update reject definitions with errors; Validate reports them without
+// saving.
+func (h *PipelineHandler) validate(ctx context.Context, form *model.PipelineForm) (*model.PipelineValidation, error) {
+	v := &pipelineValidation{
+		result: model.PipelineValidation{
+			Errors:   []model.ValidationIssue{},
+			Warnings: []model.ValidationIssue{},
+		},
+		plugins:     make(map[string]*model.Plugin),
+		datasources: make(map[string]*model.DataSource),
+		datasets:    make(map[string]*model.DataSet),
+	}
+
+	trigger, parameters, steps := form.Trigger, form.Parameters, form.Steps
+
+	if len(trigger) > 0 {
+		var t model.PipelineTrigger
+		if err := json.Unmarshal(trigger, &t); err != nil {
+			v.errorf("trigger", "must be a trigger object: %v", err)
+		} else {
+			switch {
+			case !contains(triggerTypes, t.Type):
+				v.errorf("trigger.type", "must be one of: %s", strings.Join(triggerTypes, ", "))
+			case t.Type == "schedule" && t.Schedule == "":
+				v.errorf("trigger.schedule", "is required for schedule triggers")
+			}
+		}
+	}
+
+	if len(parameters) > 0 {
+		var params []model.PipelineParameter
+		if err := json.Unmarshal(parameters, &params); err != nil {
+			v.errorf("parameters", "must be an array of parameters: %v", err)
+		}
+		seen := make(map[string]int, len(params))
+		for i, p := range params {
+			path := fmt.Sprintf("parameters[%d]", i)
+			validateParameterType(v, path, p)
+			if p.Name == "" {
+				v.errorf(path+".name", "is required")
+				continue
+			}
+			if j, ok := seen[p.Name]; ok {
+				v.errorf(path+".name", "duplicates the name of parameters[%d]", j)
+				continue
+			}
+			seen[p.Name] = i
+		}
+	}
+
+	var parsed []model.PipelineStep
+	if len(steps) > 0 {
+		if err := json.Unmarshal(steps, &parsed); err != nil {
+			v.errorf("steps", "must be an array of steps: %v", err)
+			return &v.result, nil
+		}
+	}
+	if err := h.validateSteps(ctx, v, parsed); err != nil {
+		return nil, err
+	}
+
+	v.result.Valid = len(v.result.Errors) == 0
+	return &v.result, nil
+}
+
+// validateParameterType checks a parameter's declared type and that its
+// default, if any, is a value of that type
+func validateParameterType(v *pipelineValidation, path string, p model.PipelineParameter) {
+	if !contains(schema.ParameterTypes, p.Type) {
+		v.errorf(path+".type", "must be one of: %s", strings.Join(schema.ParameterTypes, ", "))
+		return
+	}
+	if len(p.Default) == 0 || string(p.Default) == "null" {
+		return
+	}
+	if err := schema.CheckParameterValue(p.Type, p.Default); err != nil {
+		v.errorf(path+".default", "%v", err)
+		return
+	}
+	if p.Required {
+		v.warnf(path+".required", "parameter %q has a default, so it never needs to be supplied", p.Name)
+	}
+}
+
+// validateSteps checks the step list of a pipeline
+func (h *PipelineHandler) validateSteps(ctx context.Context, v *pipelineValidation, steps []model.PipelineStep) error {
+	if len(steps) == 0 {
+		v.warnf("steps", "pipeline has no steps")
+		return nil
+	}
+
+	index := make(map[string]int, len(steps))
+	names := make(map[string]int, len(steps))
+	for i, step := range steps {
+		if step.Name != "" {
+			if j, ok := names[step.Name]; ok {
+				v.warnf(fmt.Sprintf("steps[%d].name", i), "duplicates the name of steps[%d]", j)
+			} else {
+				names[step.Name] = i
+			}
+		}
+		if step.ID == "" {
+			continue
+		}
+		if _, ok := index[step.ID]; !ok {
+			index[step.ID] = i
+		}
+	}
+
+	consumed := make([]bool, len(steps))
+	hasLoad := false
+	for i, step := range steps {
+		path := fmt.Sprintf("steps[%d]", i)
+
+		switch j := index[step.ID]; {
+		case step.ID == "":
+			v.errorf(path+".id", "is required")
+		case j != i:
+			v.errorf(path+".id", "duplicates the ID %q of steps[%d]", step.ID, j)
+		}
+		if step.OnError != "" && !contains(errorHandling, step.OnError) {
+			v.errorf(path+".onError", "must be one of: %s", strings.Join(errorHandling, ", "))
+		}
+		if !contains(stepTypes, step.Type) {
+			v.errorf(path+".type", "must be one of: %s", strings.Join(stepTypes, ", "))
+			continue
+		}
+		if err := h.validateStepPlugin(ctx, v, path, step); err != nil {
+			return err
+		}
+
+		switch step.Type {
+		case "extract":
+			if err := h.validateStepDataSource(ctx, v, path+".input", step.Input); err != nil {
+				return err
+			}
+		default:
+			if j, ok := validateStepInput(v, path+".input", step.Input, i, index); ok {
+				consumed[j] = true
+			}
+		}
+
+		if step.Type == "load" {
+			hasLoad = true
+			if err := h.validateStepDataSet(ctx, v, path+".output", step.Output); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, step := range steps {
+		if step.Type != "load" && step.ID != "" && !consumed[i] {
+			v.warnf(fmt.Sprintf("steps[%d]", i), "output of step %q is never used", step.ID)
+		}
+	}
+	if !hasLoad {
+		v.warnf("steps", "pipeline has no load step, so nothing is written")
+	}
+	return nil
+}
+
+// validateStepPlugin checks that a step's plugin exists, is enabled and
+// implements the step's type
+func (h *PipelineHandler) validateStepPlugin(ctx context.Context, v *pipelineValidation, path string, step model.PipelineStep) error {
+	if step.Plugin == "" {
+		v.errorf(path+".plugin", "is required")
+		return nil
+	}
+	plugin, ok := v.plugins[step.Plugin]
+	if !ok {
+		var err error
+		if plugin, err = h.pluginRepo.GetByName(ctx, step.Plugin); err != nil {
+			return err
+		}
+		v.plugins[step.Plugin] = plugin
+	}
+
+	switch {
+	case plugin == nil:
+		v.errorf(path+".plugin", "plugin %q does not exist", step.Plugin)
+	case !plugin.Enabled:
+		v.errorf(path+".plugin", "plugin %q is disabled", step.Plugin)
+	case plugin.Type != step.Type:
+		v.errorf(path+".plugin", "plugin %q is a %s plugin and cannot run a %s step", step.Plugin, plugin.Type, step.Type)
+	}
+	return nil
+}
+
+// validateStepInput checks that a transform or load step reads an earlier
+// step, returning the index of that step
+func validateStepInput(v *pipelineValidation, path, input string, i int, index map[string]int) (int, bool) {
+	if input == "" {
+		v.errorf(path, "is required")
+		return 0, false
+	}
+	j, ok := index[input]
+	switch {
+	case !ok:
+		v.errorf(path, "references step %q which does not exist", input)
+	case j == i:
+		v.errorf(path, "references its own step")
+	case j > i:
+		v.errorf(path, "references step %q which runs later (steps[%d])", input, j)
+	default:
+		return j, true
+	}
+	return 0, false
+}
+
+// validateStepDataSource checks the data source an extract step reads
+func (h *PipelineHandler) validateStepDataSource(ctx context.Context, v *pipelineValidation, path, id string) error {
+	if id == "" {
+		v.errorf(path, "is required")
+		return nil
+	}
+	if !uuidPattern.MatchString(id) {
+		v.errorf(path, "%q is not a valid data source ID", id)
+		return nil
+	}
+	ds, ok := v.datasources[id]
+	if !ok {
+		var err error
+		if ds, err = h.dsRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		v.datasources[id] = ds
+	}
+
+	switch {
+	case ds == nil:
+		v.errorf(path, "references data source %s which does not exist", id)
+	case ds.Status == "error":
+		v.warnf(path, "references data source %q which failed its last connection test", ds.Name)
+	case ds.Status == "inactive":
+		v.warnf(path, "references data source %q which is inactive", ds.Name)
+	}
+	return nil
+}
+
+// validateStepDataSet checks the dataset a load step writes
+func (h *PipelineHandler) validateStepDataSet(ctx context.Context, v *pipelineValidation, path, id string) error {
+	if id == "" {
+		v.errorf(path, "is required")
+		return nil
+	}
+	if !uuidPattern.MatchString(id) {
+		v.errorf(path, "%q is not a valid dataset ID", id)
+		return nil
+	}
+	ds, ok := v.datasets[id]
+	if !ok {
+		var err error
+		if ds, err = h.datasetRepo.GetByID(ctx, id); err != nil {
+			return err
+		}
+		v.datasets[id] = ds
+	}
+
+	switch {
+	case ds == nil:
+		v.errorf(path, "references dataset %s which does not exist", id)
+	case ds.Status == "inactive":
+		v.warnf(path, "references dataset %q which is inactive", ds.Name)
+	case ds.Status == "migrating":
+		v.warnf(path, "references dataset %q which is being migrated", ds.Name)
+	}
+	return nil
+}
+
+// contains reports whether values holds s
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/schema"
 )
 
 // PluginHandler handles plugin HTTP requests
@@ -34,5 +37,46 @@ func (h *PluginHandler) List(c *gin.Context) {
 		plugins = []model.Plugin{}
 	}
 
-	c.JSON(http.StatusOK, model.APIResponse[[]model.Plugin]{Data: plugins})
+	c.JSON(http.StatusOK, api.APIResponse[[]model.Plugin]{Data: plugins})
+}
+
+// GetSchema returns a plugin's config schema as a self-contained JSON Schema
+// with all $refs inlined
+func (h *PluginHandler) GetSchema(c *gin.Context) {
+	name := c.Param("name")
+
+	plugin, err := h.repo.GetByName(c.Request.Context(), name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if plugin == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "plugin not found"})
+		return
+	}
+
+	doc, err := schema.PluginConfigSchema(plugin.ConfigSchema)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[map[string]interface{}]{Data: doc})
+}
+
+// CheckSchemas resolves the config schema of every enabled plugin and
+// returns the error of each one that does not resolve
+func (h *PluginHandler) CheckSchemas(ctx context.Context) (map[string]error, error) {
+	plugins, err := h.repo.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	invalid := make(map[string]error)
+	for _, p := range plugins {
+		if _, err := schema.PluginConfigSchema(p.ConfigSchema); err != nil {
+			invalid[p.Name] = err
+		}
+	}
+	return invalid, nil
 }
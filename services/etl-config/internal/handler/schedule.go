@@ -1,62 +1,81 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/cron"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/dag"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
 )
 
+// Default and maximum count of fire times previewed by NextRuns
+const (
+	defaultNextRuns = 5
+	maxNextRuns     = 100
+)
+
 // ScheduleHandler handles schedule HTTP requests
 type ScheduleHandler struct {
-	repo *repository.ScheduleRepository
+	repo         *repository.ScheduleRepository
+	pipelineRepo *repository.PipelineRepository
+	limits       config.LimitsConfig
 }
 
 // NewScheduleHandler creates a new ScheduleHandler
-func NewScheduleHandler() *ScheduleHandler {
+func NewScheduleHandler(limits config.LimitsConfig) *ScheduleHandler {
 	return &ScheduleHandler{
-		repo: repository.NewScheduleRepository(),
+		repo:         repository.NewScheduleRepository(),
+		pipelineRepo: repository.NewPipelineRepository(),
+		limits:       limits,
 	}
 }
 
-// List returns paginated schedules
+// List returns paginated schedules. Archived schedules are hidden unless
+// includeArchived=true; active=true keeps only the schedules that would run
+// now, enabled and inside their active window.
 func (h *ScheduleHandler) List(c *gin.Context) {
 	enabledStr := c.Query("enabled")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
-
-	if page < 1 {
-		page = 1
+	page, pageSize, err := api.ParsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 20
+	withTotal, err := api.ParseWithTotal(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sort, err := api.ParseSort(c, repository.ScheduleSortColumns.Fields())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filter := model.ScheduleFilter{
+		ActiveNow:       c.Query("active") == "true",
+		IncludeArchived: c.Query("includeArchived") == "true",
 	}
 
 	// Parse enabled filter
-	var enabled *bool
 	if enabledStr != "" {
 		b := enabledStr == "true"
-		enabled = &b
+		filter.Enabled = &b
 	}
 
-	schedules, total, err := h.repo.List(c.Request.Context(), enabled, page, pageSize)
+	schedules, total, err := h.repo.List(c.Request.Context(), filter, sort, page, pageSize, withTotal)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	if schedules == nil {
-		schedules = []model.Schedule{}
-	}
-
-	c.JSON(http.StatusOK, model.PaginatedResponse[model.Schedule]{
-		Data:     schedules,
-		Total:    total,
-		Page:     page,
-		PageSize: pageSize,
-	})
+	api.RespondPaginated(c, schedules, total, page, pageSize)
 }
 
 // Get returns a schedule by ID
@@ -73,73 +92,302 @@ func (h *ScheduleHandler) Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, model.APIResponse[*model.Schedule]{Data: s})
+	c.JSON(http.StatusOK, api.APIResponse[*model.Schedule]{Data: s})
+}
+
+// Plan returns the order the nodes of a schedule's DAG run in, grouped into
+// levels of nodes that can run in parallel. A stored DAG that cannot be
+// ordered is a 400 naming the cause.
+func (h *ScheduleHandler) Plan(c *gin.Context) {
+	id := c.Param("id")
+
+	s, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if s == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return
+	}
+
+	g, err := dag.Parse(s.DAG)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	levels, err := g.TopologicalLevels()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dag: " + err.Error()})
+		return
+	}
+	order := make([]string, 0, len(g.Nodes))
+	for _, level := range levels {
+		order = append(order, level...)
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[*model.SchedulePlan]{Data: &model.SchedulePlan{
+		ScheduleID: s.ID,
+		Order:      order,
+		Levels:     levels,
+	}})
+}
+
+// NextRuns previews the next count fire times of a schedule (default
+// defaultNextRuns, at most maxNextRuns) from its cron expression and
+// timezone, inside its active window. Fewer are returned when the window
+// closes first.
+func (h *ScheduleHandler) NextRuns(c *gin.Context) {
+	count := defaultNextRuns
+	if raw := c.Query("count"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxNextRuns {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("count must be between 1 and %d", maxNextRuns)})
+			return
+		}
+		count = n
+	}
+
+	s, err := h.repo.GetByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if s == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return
+	}
+
+	expr, err := cron.Parse(s.CronExpr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cronExpr: " + err.Error()})
+		return
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid timezone: " + err.Error()})
+		return
+	}
+	runs := expr.Upcoming(time.Now().In(loc), count, s.ActiveFrom, s.ActiveUntil)
+	if runs == nil {
+		runs = []time.Time{}
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[*model.ScheduleNextRuns]{Data: &model.ScheduleNextRuns{
+		ScheduleID: s.ID,
+		Timezone:   s.Timezone,
+		Runs:       runs,
+	}})
 }
 
-// Create creates a new schedule
+// Create creates a new schedule. With upsert=true or an Idempotency-Key
+// header, a schedule of the same name is updated instead (200), so
+// re-applying a definition never creates a duplicate; otherwise a taken
+// name is a 409. A schedule running a heavy pipeline more often than the
+// minimum interval is a 400 unless an admin sets allowFrequent. Duplicate
+// node IDs are a 400; duplicate node names are saved with a warning in the
+// response message.
 func (h *ScheduleHandler) Create(c *gin.Context) {
-	var s model.Schedule
-	if err := c.ShouldBindJSON(&s); err != nil {
+	var form model.ScheduleForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if err := checkScheduleDAG(h.limits, form.DAG); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := checkActiveWindow(&form); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	if !checkAllowFrequent(c, &form) {
+		return
+	}
+
 	// Set default timezone if not provided
-	if s.Timezone == "" {
-		s.Timezone = "UTC"
+	if form.Timezone == "" {
+		form.Timezone = "UTC"
+	}
+	if err := checkCronSchedule(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	upsert := c.Query("upsert") == "true" || c.GetHeader("Idempotency-Key") != ""
+
+	exempt := form.AllowFrequent != nil && *form.AllowFrequent
+	if upsert && form.AllowFrequent == nil {
+		existing, err := h.repo.GetByName(c.Request.Context(), form.Name)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		exempt = existing != nil && existing.AllowFrequent
+	}
+	if !h.checkMinInterval(c, &form, exempt) {
+		return
+	}
+
+	if upsert {
+		result, created, err := h.repo.Upsert(c.Request.Context(), &form)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		c.JSON(status, api.APIResponse[*model.Schedule]{Data: result, Message: dagNameWarning(form.DAG)})
+		return
 	}
 
-	result, err := h.repo.Create(c.Request.Context(), &s)
+	result, err := h.repo.Create(c.Request.Context(), &form)
+	if repository.IsUniqueViolation(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "a schedule named " + form.Name + " already exists"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, model.APIResponse[*model.Schedule]{Data: result})
+	c.JSON(http.StatusCreated, api.APIResponse[*model.Schedule]{Data: result, Message: dagNameWarning(form.DAG)})
 }
 
-// Update updates a schedule
+// Update updates a schedule, with the same cron, timezone and minimum
+// interval checks as Create
 func (h *ScheduleHandler) Update(c *gin.Context) {
 	id := c.Param("id")
 
-	var s model.Schedule
-	if err := c.ShouldBindJSON(&s); err != nil {
+	var form model.ScheduleForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		respondBindError(c, err)
+		return
+	}
+	if err := checkScheduleDAG(h.limits, form.DAG); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := checkActiveWindow(&form); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	result, err := h.repo.Update(c.Request.Context(), id, &s)
+	if !checkAllowFrequent(c, &form) {
+		return
+	}
+
+	// Set default timezone if not provided
+	if form.Timezone == "" {
+		form.Timezone = "UTC"
+	}
+	if err := checkCronSchedule(&form); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	exempt := form.AllowFrequent != nil && *form.AllowFrequent
+	if form.AllowFrequent == nil {
+		existing, err := h.repo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if existing == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+			return
+		}
+		exempt = existing.AllowFrequent
+	}
+	if !h.checkMinInterval(c, &form, exempt) {
+		return
+	}
+
+	result, err := h.repo.Update(c.Request.Context(), id, &form)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return
+	}
 
-	c.JSON(http.StatusOK, model.APIResponse[*model.Schedule]{Data: result})
+	c.JSON(http.StatusOK, api.APIResponse[*model.Schedule]{Data: result, Message: dagNameWarning(form.DAG)})
 }
 
-// Delete deletes a schedule
+// Delete deletes a schedule without execution history with a 204. One
+// with history is archived instead and returned with a 200. Admins may pass
+// force=true to delete the schedule together with its executions.
 func (h *ScheduleHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
+	force := c.Query("force") == "true"
+	if force && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "force delete requires the admin role"})
+		return
+	}
+
+	s, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if s == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return
+	}
+
+	archived, err := h.repo.Delete(c.Request.Context(), id, force)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if !archived {
+		c.Status(http.StatusNoContent)
+		return
+	}
 
-	c.Status(http.StatusNoContent)
+	s, err = h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, api.APIResponse[*model.Schedule]{Data: s})
 }
 
-// Enable enables a schedule
+// Enable enables a schedule; archived schedules cannot be re-enabled
 func (h *ScheduleHandler) Enable(c *gin.Context) {
 	id := c.Param("id")
 
+	s, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if s == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return
+	}
+	if s.ArchivedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "schedule is archived"})
+		return
+	}
+
 	result, err := h.repo.SetEnabled(c.Request.Context(), id, true)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return
+	}
 
-	c.JSON(http.StatusOK, model.APIResponse[*model.Schedule]{Data: result})
+	c.JSON(http.StatusOK, api.APIResponse[*model.Schedule]{Data: result})
 }
 
 // Disable disables a schedule
@@ -151,6 +399,31 @@ func (h *ScheduleHandler) Disable(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if result == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "schedule not found"})
+		return
+	}
 
-	c.JSON(http.StatusOK, model.APIResponse[*model.Schedule]{Data: result})
+	c.JSON(http.StatusOK, api.APIResponse[*model.Schedule]{Data: result})
+}
+
+// checkCronSchedule rejects a cron expression or timezone the scheduler
+// cannot run, so a typo does not save a schedule that never fires
+func checkCronSchedule(form *model.ScheduleForm) error {
+	if _, err := cron.Parse(form.CronExpr); err != nil {
+		return fmt.Errorf("invalid cronExpr: %w", err)
+	}
+	// "Local" is the server's zone here but unknown to the scheduler
+	if _, err := time.LoadLocation(form.Timezone); err != nil || form.Timezone == "Local" {
+		return fmt.Errorf("invalid timezone %q: must be an IANA zone name such as Asia/Shanghai", form.Timezone)
+	}
+	return nil
+}
+
+// checkActiveWindow rejects an active window that ends before it starts
+func checkActiveWindow(form *model.ScheduleForm) error {
+	if form.ActiveFrom != nil && form.ActiveUntil != nil && !form.ActiveFrom.Before(*form.ActiveUntil) {
+		return fmt.Errorf("activeFrom must be earlier than activeUntil")
+	}
+	return nil
 }
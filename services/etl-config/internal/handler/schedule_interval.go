@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/cron"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// heavyTag marks pipelines whose schedules must respect the minimum interval
+const heavyTag = "heavy"
+
+// intervalSamples is how many upcoming fire times are compared to find the
+// shortest gap of a cron expression; enough to cover irregular steps such as
+// "*/7" wrapping at the hour
+const intervalSamples = 64
+
+// checkAllowFrequent answers 403 when a non-admin sets allowFrequent. It
+// reports whether the request may go ahead.
+func checkAllowFrequent(c *gin.Context, form *model.ScheduleForm) bool {
+	if form.AllowFrequent != nil && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "allowFrequent requires the admin role"})
+		return false
+	}
+	return true
+}
+
+// checkMinInterval answers 400 when a schedule whose DAG runs a pipeline
+// tagged heavy fires more often than the minimum interval, unless the
+// schedule is exempt. The gap is the shortest between its next fire times
+// in its timezone. It reports whether the request may go ahead.
+func (h *ScheduleHandler) checkMinInterval(c *gin.Context, form *model.ScheduleForm, exempt bool) bool {
+	if exempt {
+		return true
+	}
+
+	var nodes []model.DAGNode
+	if len(form.DAG) > 0 {
+		if err := json.Unmarshal(form.DAG, &nodes); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dag must be an array of nodes: " + err.Error()})
+			return false
+		}
+	}
+	var ids []string
+	for _, n := range nodes {
+		if n.PipelineID != "" {
+			ids = append(ids, n.PipelineID)
+		}
+	}
+	if len(ids) == 0 {
+		return true
+	}
+
+	heavy, err := h.pipelineRepo.ListTagged(c.Request.Context(), ids, heavyTag)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if len(heavy) == 0 {
+		return true
+	}
+
+	expr, err := cron.Parse(form.CronExpr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cronExpr: " + err.Error()})
+		return false
+	}
+	loc, err := time.LoadLocation(form.Timezone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid timezone: " + err.Error()})
+		return false
+	}
+	from := time.Now().In(loc)
+	if form.ActiveFrom != nil && form.ActiveFrom.After(from) {
+		from = form.ActiveFrom.In(loc)
+	}
+
+	gap, ok := expr.MinInterval(from, intervalSamples)
+	if ok && gap < h.limits.MinHeavyInterval {
+		names := make([]string, len(heavy))
+		for i, p := range heavy {
+			names[i] = p.Name
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf(
+			"cronExpr runs every %s, more often than the minimum of %s for heavy pipelines (%s)",
+			gap, h.limits.MinHeavyInterval, strings.Join(names, ", "),
+		)})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+func TestNextRunsRejectsCount(t *testing.T) {
+	h := NewScheduleHandler(config.LimitsConfig{})
+	for _, count := range []string{"0", "-1", "101", "five"} {
+		w := serve(t, http.MethodGet, "/schedules/:id/next-runs",
+			"/schedules/4f1c8a9e-0000-4000-8000-000000000001/next-runs?count="+count, "", h.NextRuns)
+		wantStatus(t, w, http.StatusBadRequest)
+	}
+}
+
+func TestCheckCronSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		cronExpr string
+		timezone string
+		wantErr  bool
+	}{
+		{"five fields", "0 9 * * *", "UTC", false},
+		{"six fields", "30 0 9 * * *", "Asia/Shanghai", false},
+		{"macro", "@hourly", "America/New_York", false},
+		{"four fields", "0 9 * *", "UTC", true},
+		{"seven fields", "0 0 9 * * * 2026", "UTC", true},
+		{"out of range", "0 25 * * *", "UTC", true},
+		{"unknown timezone", "0 9 * * *", "Mars/Olympus", true},
+		{"local timezone", "0 9 * * *", "Local", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCronSchedule(&model.ScheduleForm{CronExpr: tt.cronExpr, Timezone: tt.timezone})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkCronSchedule(%q, %q) = %v, want error %v", tt.cronExpr, tt.timezone, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCreateAndUpdateRejectInvalidSchedule(t *testing.T) {
+	h := NewScheduleHandler(config.LimitsConfig{})
+	bodies := map[string]string{
+		"too few fields":   `{"name": "nightly", "cronExpr": "0 9 * *"}`,
+		"too many fields":  `{"name": "nightly", "cronExpr": "0 0 9 * * * 2026"}`,
+		"bad field":        `{"name": "nightly", "cronExpr": "0 9 * * FUNDAY"}`,
+		"unknown timezone": `{"name": "nightly", "cronExpr": "0 9 * * *", "timezone": "Mars/Olympus"}`,
+	}
+	for name, body := range bodies {
+		t.Run(name, func(t *testing.T) {
+			w := serve(t, http.MethodPost, "/schedules", "/schedules", body, h.Create)
+			wantStatus(t, w, http.StatusBadRequest)
+			w = serve(t, http.MethodPut, "/schedules/:id",
+				"/schedules/4f1c8a9e-0000-4000-8000-000000000001", body, h.Update)
+			wantStatus(t, w, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestCreateRejectsInvalidDAG(t *testing.T) {
+	h := NewScheduleHandler(config.LimitsConfig{MaxDAGNodes: 10, MaxDAGEdges: 10, MaxDAGDepth: 10})
+	tests := []struct {
+		dag  string
+		want string
+	}{
+		{`[{"id": "a", "dependsOn": ["a"]}]`, `node \"a\" depends on itself`},
+		{`[{"id": "a", "depends_on": ["b"]}, {"id": "b", "depends_on": ["a"]}]`, "cycle through nodes: a, b"},
+		{`[{"id": "a", "depends_on": ["ghost"]}]`, `depends on \"ghost\"`},
+	}
+	for _, tt := range tests {
+		body := `{"name": "nightly", "cronExpr": "0 9 * * *", "dag": ` + tt.dag + `}`
+		w := serve(t, http.MethodPost, "/schedules", "/schedules", body, h.Create)
+		wantStatus(t, w, http.StatusBadRequest)
+		if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("dag %s: body %s, want it to name %s", tt.dag, w.Body, tt.want)
+		}
+	}
+}
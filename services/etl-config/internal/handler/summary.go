@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+)
+
+// summaryTTL is how long a computed summary is served before it is
+// recomputed. Dashboard counts need not be exact.
+const summaryTTL = 30 * time.Second
+
+// SummaryHandler handles dashboard summary HTTP requests
+type SummaryHandler struct {
+	repo *repository.SummaryRepository
+
+	mu     sync.Mutex
+	cached map[string]*model.Summary // by tenant, "*" for the cross-tenant view
+}
+
+// NewSummaryHandler creates a new SummaryHandler
+func NewSummaryHandler() *SummaryHandler {
+	return &SummaryHandler{
+		repo:   repository.NewSummaryRepository(),
+		cached: make(map[string]*model.Summary),
+	}
+}
+
+// Get returns the dashboard counts of the tenant. Summaries are cached for
+// summaryTTL, both here and by clients.
+func (h *SummaryHandler) Get(c *gin.Context) {
+	key := currentTenantID(c)
+	if key == "" {
+		key = repository.DefaultTenant
+	}
+	if c.Query("allTenants") == "true" {
+		key = "*"
+	}
+
+	summary, err := h.get(c, key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	maxAge := int((summaryTTL - time.Since(summary.GeneratedAt)).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	c.Header("Cache-Control", "private, max-age="+strconv.Itoa(maxAge))
+	c.Header("Vary", "Authorization")
+	c.JSON(http.StatusOK, api.APIResponse[*model.Summary]{Data: summary})
+}
+
+// get returns the cached summary for key, recomputing it once expired
+func (h *SummaryHandler) get(c *gin.Context, key string) (*model.Summary, error) {
+	h.mu.Lock()
+	summary, ok := h.cached[key]
+	h.mu.Unlock()
+	if ok && time.Since(summary.GeneratedAt) < summaryTTL {
+		return summary, nil
+	}
+
+	summary, err := h.repo.Get(c.Request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	h.cached[key] = summary
+	h.mu.Unlock()
+	return summary, nil
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+)
+
+// WebhookHandler handles execution webhook HTTP requests
+type WebhookHandler struct {
+	repo *repository.WebhookRepository
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler() *WebhookHandler {
+	return &WebhookHandler{
+		repo: repository.NewWebhookRepository(),
+	}
+}
+
+// List returns the execution webhooks of the tenant
+func (h *WebhookHandler) List(c *gin.Context) {
+	webhooks, err := h.repo.List(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if webhooks == nil {
+		webhooks = []model.Webhook{}
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[[]model.Webhook]{Data: webhooks})
+}
+
+// Get returns an execution webhook by ID
+func (h *WebhookHandler) Get(c *gin.Context) {
+	id := c.Param("id")
+
+	w, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if w == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, api.APIResponse[*model.Webhook]{Data: w})
+}
+
+// Create registers an execution webhook. Deliveries are signed with the
+// secret, which is never returned.
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var form model.WebhookForm
+	if err := c.ShouldBindJSON(&form); err != nil {
+		respondBindError(c, err)
+		return
+	}
+
+	w, err := h.repo.Create(c.Request.Context(), &form)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, api.APIResponse[*model.Webhook]{Data: w})
+}
+
+// Delete deletes an execution webhook together with its deliveries
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id := c.Param("id")
+
+	w, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if w == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+
+	if err := h.repo.Delete(c.Request.Context(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries returns the paginated deliveries of a webhook, newest
+// first, optionally filtered by status
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id := c.Param("id")
+	status := c.Query("status")
+	switch status {
+	case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be one of pending, delivered, dead"})
+		return
+	}
+	page, pageSize, err := api.ParsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	withTotal, err := api.ParseWithTotal(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	w, err := h.repo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if w == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+
+	deliveries, total, err := h.repo.ListDeliveries(c.Request.Context(), id, status, page, pageSize, withTotal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	api.RespondPaginated(c, deliveries, total, page, pageSize)
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+)
+
+// finishedBatch is the most finished executions read per query
+const finishedBatch = 500
+
+// durationBuckets are the upper bounds of the execution duration histogram,
+// in seconds
+var durationBuckets = []float64{1, 5, 15, 30, 60, 300, 900, 1800, 3600, 7200, 21600}
+
+// Collector keeps the domain metrics current. Executions are finished by the
+// ETL engine, so the collector reads them from the database as they finish:
+// each one is counted once per process, from the time the process started.
+type Collector struct {
+	repo     *repository.MetricsRepository
+	interval time.Duration
+	batch    int // most finished executions read per query
+	logger   *zap.Logger
+
+	enabledSchedules   *Gauge
+	erroredDataSources *Gauge
+	executions         *CounterVec
+	durations          *Histogram
+
+	// cursor of the last execution counted
+	lastFinishedAt time.Time
+	lastID         string
+}
+
+// NewCollector registers the domain metrics in r and returns the Collector
+// updating them every interval
+func NewCollector(r *Registry, interval time.Duration, logger *zap.Logger) *Collector {
+	return &Collector{
+		repo:     repository.NewMetricsRepository(),
+		interval: interval,
+		batch:    finishedBatch,
+		logger:   logger,
+
+		enabledSchedules: r.NewGauge("etl_schedules_enabled",
+			"Enabled schedules that are not archived."),
+		erroredDataSources: r.NewGauge("etl_datasources_error",
+			"Data sources in the error state."),
+		executions: r.NewCounterVec("etl_executions_finished_total",
+			"Executions that reached a final status, by status.", "status"),
+		durations: r.NewHistogram("etl_execution_duration_seconds",
+			"Duration of finished executions.", durationBuckets),
+
+		lastFinishedAt: time.Now(),
+	}
+}
+
+// Run updates the metrics every interval until ctx is done
+func (c *Collector) Run(ctx context.Context) {
+	ctx = repository.WithAllTenants(ctx)
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.collect(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// collect refreshes the gauges and counts the executions finished since the
+// last collection
+func (c *Collector) collect(ctx context.Context) {
+	schedules, dataSources, err := c.repo.Counts(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.logger.Error("failed to read metric counts", zap.Error(err))
+		}
+		return
+	}
+	c.enabledSchedules.Set(float64(schedules))
+	c.erroredDataSources.Set(float64(dataSources))
+
+	for {
+		finished, err := c.repo.ListFinishedAfter(ctx, c.lastFinishedAt, c.lastID, c.batch)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.logger.Error("failed to read finished executions", zap.Error(err))
+			}
+			return
+		}
+		for _, e := range finished {
+			c.executions.Inc(e.Status)
+			if e.Duration != nil {
+				c.durations.Observe(float64(*e.Duration) / 1000)
+			}
+			c.lastFinishedAt, c.lastID = e.FinishedAt, e.ID
+		}
+		if len(finished) < c.batch {
+			return
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/testdb"
+)
+
+// insertExecution stores an execution of the tenant with the given status,
+// finish time and duration in milliseconds; a zero finishedAt leaves it
+// unfinished
+func insertExecution(t *testing.T, tenantID, status string, finishedAt time.Time, duration int) {
+	t.Helper()
+	var finished *time.Time
+	if !finishedAt.IsZero() {
+		finished = &finishedAt
+	}
+	_, err := repository.DB.Exec(context.Background(), `
+		INSERT INTO etl_executions (status, finished_at, duration, tenant_id)
+		VALUES ($1, $2, $3, $4)`, status, finished, duration, tenantID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCollectPaging(t *testing.T) {
+	tenantID := testdb.Open(t)
+	ctx := repository.WithAllTenants(context.Background())
+
+	c := NewCollector(NewRegistry(), time.Minute, zap.NewNop())
+	c.batch = 2
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	c.lastFinishedAt = base
+
+	// Before the cursor: counted by an earlier process
+	insertExecution(t, tenantID, "success", base.Add(-time.Minute), 1000)
+	// Three share a finish time, so pages split ties by id
+	insertExecution(t, tenantID, "success", base.Add(time.Second), 1000)
+	insertExecution(t, tenantID, "failed", base.Add(time.Second), 2000)
+	insertExecution(t, tenantID, "success", base.Add(time.Second), 3000)
+	insertExecution(t, tenantID, "failed", base.Add(2*time.Second), 4000)
+	insertExecution(t, tenantID, "success", base.Add(3*time.Second), 5000)
+	insertExecution(t, tenantID, "running", time.Time{}, 0)
+
+	c.collect(ctx)
+	if got := c.executions.values; got["success"] != 3 || got["failed"] != 2 || len(got) != 2 {
+		t.Errorf("counted %v, want 3 success and 2 failed", got)
+	}
+	if c.durations.count != 5 || c.durations.sum != 15 {
+		t.Errorf("durations: count %d sum %v, want 5 and 15", c.durations.count, c.durations.sum)
+	}
+
+	// A second collection counts only what finished since
+	insertExecution(t, tenantID, "cancelled", base.Add(4*time.Second), 6000)
+	c.collect(ctx)
+	if got := c.executions.values; got["success"] != 3 || got["failed"] != 2 || got["cancelled"] != 1 {
+		t.Errorf("counted %v after a second collection, want one more cancelled", got)
+	}
+	if c.durations.count != 6 {
+		t.Errorf("durations: count %d after a second collection, want 6", c.durations.count)
+	}
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := NewRegistry()
+	gauge := r.NewGauge("etl_up", "Whether the service is up.\nAlways 1.")
+	counter := r.NewCounterVec("etl_runs_total", `Runs by status, e.g. "ok" or C:\tmp.`, "status")
+	histogram := r.NewHistogram("etl_run_seconds", "Run duration.", []float64{10, 1, 5})
+
+	gauge.Set(1)
+	counter.Inc("success")
+	counter.Inc("success")
+	counter.Inc(`say "hi"` + "\n" + `C:\tmp`)
+	for _, v := range []float64{0.5, 1, 3, 7, 20} {
+		histogram.Observe(v)
+	}
+
+	router := gin.New()
+	router.GET("/metrics", r.Handler())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if got := w.Header().Get("Content-Type"); got != contentType {
+		t.Errorf("Content-Type = %q, want %q", got, contentType)
+	}
+	want := `# HELP etl_up Whether the service is up.\nAlways 1.
+# TYPE etl_up gauge
+etl_up 1
+# HELP etl_runs_total Runs by status, e.g. "ok" or C:\\tmp.
+# TYPE etl_runs_total counter
+etl_runs_total{status="say \"hi\"\nC:\\tmp"} 1
+etl_runs_total{status="success"} 2
+# HELP etl_run_seconds Run duration.
+# TYPE etl_run_seconds histogram
+etl_run_seconds_bucket{le="1"} 2
+etl_run_seconds_bucket{le="5"} 3
+etl_run_seconds_bucket{le="10"} 4
+etl_run_seconds_bucket{le="+Inf"} 5
+etl_run_seconds_sum 31.5
+etl_run_seconds_count 5
+`
+	if got := w.Body.String(); got != want {
+		t.Errorf("body =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		v    float64
+		want string
+	}{
+		{0, "0"},
+		{0.25, "0.25"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+	}
+	for _, tt := range tests {
+		if got := formatFloat(tt.v); got != tt.want {
+			t.Errorf("formatFloat(%v) = %q, want %q", tt.v, got, tt.want)
+		}
+	}
+}
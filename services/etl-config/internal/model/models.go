@@ -5,20 +5,32 @@ import (
 	"time"
 )
 
-// DataSource represents an ETL data source
+// DataSource represents an ETL data source. Summary listings leave Config
+// and Environments out and report their sizes in Sizes instead.
+//
+// Environments maps environment names to config overrides merged over the
+// base Config. When a read resolves an environment, Config holds the merged
+// config and Environment names the environment.
 type DataSource struct {
-	ID           string          `json:"id" db:"id"`
-	Name         string          `json:"name" db:"name"`
-	Type         string          `json:"type" db:"type"`
-	Plugin       string          `json:"plugin" db:"plugin"`
-	Description  *string         `json:"description,omitempty" db:"description"`
-	Config       json.RawMessage `json:"config" db:"config"`
-	Capabilities []string        `json:"capabilities" db:"capabilities"`
-	Status       string          `json:"status" db:"status"`
-	LastSyncAt   *time.Time      `json:"lastSyncAt,omitempty" db:"last_sync_at"`
-	ErrorMessage *string         `json:"errorMessage,omitempty" db:"error_message"`
-	CreatedAt    time.Time       `json:"createdAt" db:"created_at"`
-	UpdatedAt    time.Time       `json:"updatedAt" db:"updated_at"`
+	ID                string          `json:"id" db:"id"`
+	TenantID          string          `json:"tenantId" db:"tenant_id"`
+	Name              string          `json:"name" db:"name"`
+	Type              string          `json:"type" db:"type"`
+	Plugin            string          `json:"plugin" db:"plugin"`
+	Description       *string         `json:"description,omitempty" db:"description"`
+	Config            json.RawMessage `json:"config,omitempty" db:"config"`
+	Environments      json.RawMessage `json:"environments,omitempty" db:"environments"`
+	Environment       string          `json:"environment,omitempty" db:"-"`
+	Capabilities      []string        `json:"capabilities" db:"capabilities"`
+	Status            string          `json:"status" db:"status"`
+	CredentialVersion int             `json:"credentialVersion" db:"credential_version"`
+	LastSyncAt        *time.Time      `json:"lastSyncAt,omitempty" db:"last_sync_at"`
+	ErrorMessage      *string         `json:"errorMessage,omitempty" db:"error_message"`
+	CreatedBy         *string         `json:"createdBy,omitempty" db:"created_by"`
+	UpdatedBy         *string         `json:"updatedBy,omitempty" db:"updated_by"`
+	CreatedAt         time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt         time.Time       `json:"updatedAt" db:"updated_at"`
+	Sizes             map[string]int  `json:"sizes,omitempty" db:"-"`
 }
 
 // DataSourceForm is the form for creating/updating a data source
@@ -28,57 +40,405 @@ type DataSourceForm struct {
 	Plugin       string          `json:"plugin" binding:"required"`
 	Description  *string         `json:"description"`
 	Config       json.RawMessage `json:"config"`
+	Environments json.RawMessage `json:"environments"`
 	Capabilities []string        `json:"capabilities"`
 }
 
-// DataSet represents an ETL dataset schema definition
+// DataSourceFilter holds the filters for listing data sources; empty fields
+// match everything
+type DataSourceFilter struct {
+	Type      string
+	Status    string
+	Plugin    string
+	CreatedBy string
+	Summary   bool // leave the config out of each item
+}
+
+// RotateCredentialsForm carries new values for a data source's secret config fields
+type RotateCredentialsForm struct {
+	Credentials map[string]interface{} `json:"credentials" binding:"required"`
+	Test        bool                   `json:"test"`
+}
+
+// DataSet represents an ETL dataset schema definition. Summary listings
+// leave Schema, Storage and Indexes out and report their sizes in Sizes.
 type DataSet struct {
 	ID          string          `json:"id" db:"id"`
+	TenantID    string          `json:"tenantId" db:"tenant_id"`
 	Name        string          `json:"name" db:"name"`
 	Version     int             `json:"version" db:"version"`
 	Category    string          `json:"category" db:"category"`
 	Description *string         `json:"description,omitempty" db:"description"`
-	Schema      json.RawMessage `json:"schema" db:"schema"`
-	Storage     json.RawMessage `json:"storage" db:"storage"`
-	Indexes     json.RawMessage `json:"indexes" db:"indexes"`
+	Schema      json.RawMessage `json:"schema,omitempty" db:"schema"`
+	Storage     json.RawMessage `json:"storage,omitempty" db:"storage"`
+	Indexes     json.RawMessage `json:"indexes,omitempty" db:"indexes"`
 	Labels      json.RawMessage `json:"labels" db:"labels"`
 	Status      string          `json:"status" db:"status"`
+	OwnerID     *string         `json:"ownerId,omitempty" db:"owner_id"`
+	CreatedBy   *string         `json:"createdBy,omitempty" db:"created_by"`
+	UpdatedBy   *string         `json:"updatedBy,omitempty" db:"updated_by"`
 	CreatedAt   time.Time       `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time       `json:"updatedAt" db:"updated_at"`
+	Sizes       map[string]int  `json:"sizes,omitempty" db:"-"`
+}
+
+// Dataset access roles, from least to most privileged
+const (
+	DataSetRoleViewer = "viewer"
+	DataSetRoleEditor = "editor"
+	DataSetRoleOwner  = "owner"
+)
+
+// DataSetGrant is a role granted to a user on a dataset
+type DataSetGrant struct {
+	UserID    string    `json:"userId" db:"user_id"`
+	Role      string    `json:"role" db:"role"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// DataSetPermissions lists who can access a dataset
+type DataSetPermissions struct {
+	OwnerID *string        `json:"ownerId"`
+	Grants  []DataSetGrant `json:"grants"`
+}
+
+// DataSetPermissionsForm changes dataset permissions. Role "none" revokes
+// the user's grant; setting Owner transfers ownership.
+type DataSetPermissionsForm struct {
+	Grants []struct {
+		UserID string `json:"userId" binding:"required"`
+		Role   string `json:"role" binding:"required,oneof=viewer editor none"`
+	} `json:"grants" binding:"dive"`
+	Owner *string `json:"owner"`
+}
+
+// Bulk import outcomes of a single dataset
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+	ImportSkipped = "skipped"
+)
+
+// DataSetImportResult reports the outcome of one dataset in a bulk import
+type DataSetImportResult struct {
+	Index   int      `json:"index"`
+	Name    string   `json:"name"`
+	Status  string   `json:"status"`
+	ID      string   `json:"id,omitempty"`
+	Version int      `json:"version,omitempty"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// DataSetSchema is the typed form of DataSet.Schema
+type DataSetSchema struct {
+	Fields []FieldDefinition `json:"fields"`
+}
+
+// Field types supported in dataset schemas (mirrors the field_type enum)
+const (
+	FieldTypeString   = "string"
+	FieldTypeInt      = "int"
+	FieldTypeBigint   = "bigint"
+	FieldTypeDecimal  = "decimal"
+	FieldTypeFloat    = "float"
+	FieldTypeDouble   = "double"
+	FieldTypeBool     = "bool"
+	FieldTypeDate     = "date"
+	FieldTypeDatetime = "datetime"
+	FieldTypeJSON     = "json"
+	FieldTypeEnum     = "enum"
+)
+
+// FieldDefinition describes a single column of a dataset schema
+type FieldDefinition struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Precision   *int        `json:"precision,omitempty"`
+	Scale       *int        `json:"scale,omitempty"`
+	EnumValues  []string    `json:"enumValues,omitempty"`
+	Primary     bool        `json:"primary"`
+	Nullable    bool        `json:"nullable"`
+	Default     interface{} `json:"default,omitempty"`
+	Description *string     `json:"description,omitempty"`
+}
+
+// DataSetSummary is the lightweight form of a dataset returned by discovery
+type DataSetSummary struct {
+	ID        string                `json:"id"`
+	Name      string                `json:"name"`
+	Version   int                   `json:"version"`
+	Category  string                `json:"category"`
+	Storage   string                `json:"storage"`
+	Labels    json.RawMessage       `json:"labels"`
+	Fields    []DataSetFieldSummary `json:"fields"`
+	UpdatedAt time.Time             `json:"updatedAt"`
+}
+
+// DataSetFieldSummary names a schema field and its type
+type DataSetFieldSummary struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// DataSetFilter holds the filters for listing datasets; empty fields match
+// everything
+type DataSetFilter struct {
+	Category    string
+	Storage     string
+	FavoritesOf string  // only datasets starred by this user
+	VisibleTo   *string // only datasets this user may view; nil skips the check
+	CreatedBy   string
+	Summary     bool // leave the schema, storage and indexes out of each item
+}
+
+// IndexDefinition is a dataset index over schema columns
+type IndexDefinition struct {
+	Name   string   `json:"name,omitempty"`
+	Fields []string `json:"fields"`
+	Unique bool     `json:"unique,omitempty"`
+}
+
+// Storage backends a dataset can be stored in (storage_type enum)
+const (
+	StoragePostgres   = "postgres"
+	StorageClickHouse = "clickhouse"
+	StorageRedis      = "redis"
+)
+
+// PostgresStorage is the storage config of a dataset stored in PostgreSQL
+type PostgresStorage struct {
+	Type        string `json:"type"`
+	Schema      string `json:"schema,omitempty"`
+	Table       string `json:"table"`
+	PartitionBy string `json:"partitionBy,omitempty"`
+}
+
+// ClickHouseStorage is the storage config of a dataset stored in ClickHouse
+type ClickHouseStorage struct {
+	Type        string   `json:"type"`
+	Database    string   `json:"database,omitempty"`
+	Table       string   `json:"table"`
+	PartitionBy string   `json:"partitionBy,omitempty"`
+	OrderBy     []string `json:"orderBy,omitempty"`
+	TTLDays     *int     `json:"ttlDays,omitempty"`
 }
 
-// Pipeline represents an ETL pipeline
+// RedisStorage is the storage config of a dataset stored in Redis; Table is
+// the key namespace
+type RedisStorage struct {
+	Type    string `json:"type"`
+	Table   string `json:"table"`
+	TTLDays *int   `json:"ttlDays,omitempty"`
+}
+
+// Pipeline represents an ETL pipeline. Summary listings leave Parameters
+// and Steps out and report their sizes in Sizes.
 type Pipeline struct {
 	ID          string          `json:"id" db:"id"`
+	TenantID    string          `json:"tenantId" db:"tenant_id"`
 	Name        string          `json:"name" db:"name"`
 	Version     int             `json:"version" db:"version"`
 	Description *string         `json:"description,omitempty" db:"description"`
 	Trigger     json.RawMessage `json:"trigger" db:"trigger"`
-	Parameters  json.RawMessage `json:"parameters" db:"parameters"`
-	Steps       json.RawMessage `json:"steps" db:"steps"`
+	Parameters  json.RawMessage `json:"parameters,omitempty" db:"parameters"`
+	Steps       json.RawMessage `json:"steps,omitempty" db:"steps"`
+	Tags        []string        `json:"tags" db:"tags"`
 	Status      string          `json:"status" db:"status"`
+	CreatedBy   *string         `json:"createdBy,omitempty" db:"created_by"`
+	UpdatedBy   *string         `json:"updatedBy,omitempty" db:"updated_by"`
 	CreatedAt   time.Time       `json:"createdAt" db:"created_at"`
 	UpdatedAt   time.Time       `json:"updatedAt" db:"updated_at"`
+	Sizes       map[string]int  `json:"sizes,omitempty" db:"-"`
+}
+
+// Pipeline statuses. New pipelines are drafts; only the publish and
+// deactivate endpoints change the status.
+const (
+	PipelineStatusDraft    = "draft"
+	PipelineStatusActive   = "active"
+	PipelineStatusInactive = "inactive"
+)
+
+// PipelineFilter holds the filters for listing pipelines; empty fields
+// match everything
+type PipelineFilter struct {
+	Status    string
+	CreatedBy string
+	Summary   bool // leave the parameters and steps out of each item
+}
+
+// PipelineForm is the form for creating/updating a pipeline
+type PipelineForm struct {
+	Name        string          `json:"name" binding:"required"`
+	Description *string         `json:"description"`
+	Trigger     json.RawMessage `json:"trigger"`
+	Parameters  json.RawMessage `json:"parameters"`
+	Steps       json.RawMessage `json:"steps"`
+	Tags        []string        `json:"tags"`
+}
+
+// PipelineStep is one entry of a pipeline's steps. Extract steps read the
+// data source named by Input, transform and load steps read the output of
+// the earlier step named by Input, and load steps write the dataset named
+// by Output.
+type PipelineStep struct {
+	ID       string          `json:"id"`
+	Name     string          `json:"name"`
+	Type     string          `json:"type"`
+	Plugin   string          `json:"plugin"`
+	Config   json.RawMessage `json:"config,omitempty"`
+	Input    string          `json:"input,omitempty"`
+	Output   string          `json:"output,omitempty"`
+	Parallel bool            `json:"parallel,omitempty"`
+	OnError  string          `json:"onError,omitempty"`
+}
+
+// PipelineTrigger is a pipeline's trigger definition
+type PipelineTrigger struct {
+	Type     string `json:"type"`
+	Schedule string `json:"schedule,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// PipelineParameter declares a parameter a pipeline run accepts
+type PipelineParameter struct {
+	Name     string          `json:"name"`
+	Type     string          `json:"type"`
+	Default  json.RawMessage `json:"default,omitempty"`
+	Required bool            `json:"required,omitempty"`
 }
 
-// Schedule represents a DAG-based schedule
+// PipelineDependent is a pipeline that references a resource about to be
+// deleted. Paths locate the references, e.g. "steps[0].input".
+type PipelineDependent struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Paths []string `json:"paths"`
+}
+
+// ValidationIssue is one problem found while validating a definition
+type ValidationIssue struct {
+	Path    string `json:"path"` // e.g. "steps[2].input"
+	Message string `json:"message"`
+}
+
+// PipelineValidation is the result of validating a pipeline definition.
+// Errors block saving; warnings do not.
+type PipelineValidation struct {
+	Valid    bool              `json:"valid"`
+	Errors   []ValidationIssue `json:"errors"`
+	Warnings []ValidationIssue `json:"warnings"`
+}
+
+// PipelineChange is one difference between a stored pipeline and a desired
+// definition. Path addresses the value, e.g. "steps[id=load].config.table";
+// Op is "added", "removed" or "changed".
+type PipelineChange struct {
+	Path string          `json:"path"`
+	Op   string          `json:"op"`
+	Old  json.RawMessage `json:"old,omitempty"`
+	New  json.RawMessage `json:"new,omitempty"`
+}
+
+// PipelineDiff is the difference between a stored pipeline at Version and a
+// desired definition. Applied is set when the desired definition was saved;
+// Version is then the new version.
+type PipelineDiff struct {
+	PipelineID string           `json:"pipelineId"`
+	Version    int              `json:"version"`
+	Changes    []PipelineChange `json:"changes"`
+	Applied    bool             `json:"applied"`
+}
+
+// PipelineDiffForm is the form for diffing a pipeline against a desired
+// definition. Applying requires ExpectedDiff, the changes the caller
+// reviewed, so a definition diffed against a stale pipeline is not saved.
+type PipelineDiffForm struct {
+	Desired      PipelineForm     `json:"desired" binding:"required"`
+	ExpectedDiff []PipelineChange `json:"expectedDiff"`
+}
+
+// Schedule represents a DAG-based schedule. An enabled schedule only runs
+// between ActiveFrom and ActiveUntil; a nil bound leaves that side open.
+// AllowFrequent exempts it from the minimum interval between runs of heavy
+// pipelines.
 type Schedule struct {
-	ID          string          `json:"id" db:"id"`
-	Name        string          `json:"name" db:"name"`
-	Description *string         `json:"description,omitempty" db:"description"`
-	CronExpr    string          `json:"cronExpr" db:"cron_expr"`
-	Timezone    string          `json:"timezone" db:"timezone"`
-	Enabled     bool            `json:"enabled" db:"enabled"`
-	DAG         json.RawMessage `json:"dag" db:"dag"`
-	LastRunAt   *time.Time      `json:"lastRunAt,omitempty" db:"last_run_at"`
-	NextRunAt   *time.Time      `json:"nextRunAt,omitempty" db:"next_run_at"`
-	CreatedAt   time.Time       `json:"createdAt" db:"created_at"`
-	UpdatedAt   time.Time       `json:"updatedAt" db:"updated_at"`
+	ID            string          `json:"id" db:"id"`
+	TenantID      string          `json:"tenantId" db:"tenant_id"`
+	Name          string          `json:"name" db:"name"`
+	Description   *string         `json:"description,omitempty" db:"description"`
+	CronExpr      string          `json:"cronExpr" db:"cron_expr"`
+	Timezone      string          `json:"timezone" db:"timezone"`
+	Enabled       bool            `json:"enabled" db:"enabled"`
+	ActiveFrom    *time.Time      `json:"activeFrom,omitempty" db:"active_from"`
+	ActiveUntil   *time.Time      `json:"activeUntil,omitempty" db:"active_until"`
+	AllowFrequent bool            `json:"allowFrequent" db:"allow_frequent"`
+	DAG           json.RawMessage `json:"dag" db:"dag"`
+	LastRunAt     *time.Time      `json:"lastRunAt,omitempty" db:"last_run_at"`
+	NextRunAt     *time.Time      `json:"nextRunAt,omitempty" db:"next_run_at"`
+	ArchivedAt    *time.Time      `json:"archivedAt,omitempty" db:"archived_at"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updatedAt" db:"updated_at"`
+}
+
+// DAGNode is one node of a schedule's DAG: a pipeline run once every node
+// it depends on has finished
+type DAGNode struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	PipelineID string          `json:"pipelineId"`
+	DependsOn  []string        `json:"dependsOn"`
+	Params     json.RawMessage `json:"params,omitempty"`
+	Timeout    *int            `json:"timeout,omitempty"`
+	Retries    *int            `json:"retries,omitempty"`
+}
+
+// SchedulePlan is the order a schedule's DAG nodes run in. Levels group
+// the nodes that can run in parallel: every node of a level depends only on
+// nodes of earlier levels.
+type SchedulePlan struct {
+	ScheduleID string     `json:"scheduleId"`
+	Order      []string   `json:"order"`
+	Levels     [][]string `json:"levels"`
+}
+
+// ScheduleNextRuns previews the upcoming fire times of a schedule in its
+// timezone, whether or not it is enabled
+type ScheduleNextRuns struct {
+	ScheduleID string      `json:"scheduleId"`
+	Timezone   string      `json:"timezone"`
+	Runs       []time.Time `json:"runs"`
+}
+
+// ScheduleFilter holds the filters for listing schedules; a nil Enabled
+// matches both states. ActiveNow keeps only schedules that would run now:
+// enabled and inside their active window.
+type ScheduleFilter struct {
+	Enabled         *bool
+	ActiveNow       bool
+	IncludeArchived bool
+}
+
+// ScheduleForm is the form for creating/updating a schedule. AllowFrequent
+// may only be set by admins; nil keeps the stored value.
+type ScheduleForm struct {
+	Name          string          `json:"name" binding:"required"`
+	Description   *string         `json:"description"`
+	CronExpr      string          `json:"cronExpr" binding:"required"`
+	Timezone      string          `json:"timezone" binding:"omitempty,timezone"`
+	Enabled       bool            `json:"enabled"`
+	ActiveFrom    *time.Time      `json:"activeFrom"`
+	ActiveUntil   *time.Time      `json:"activeUntil"`
+	AllowFrequent *bool           `json:"allowFrequent"`
+	DAG           json.RawMessage `json:"dag"`
 }
 
 // Execution represents an ETL execution
 type Execution struct {
 	ID           string          `json:"id" db:"id"`
+	TenantID     string          `json:"tenantId" db:"tenant_id"`
 	ScheduleID   *string         `json:"scheduleId,omitempty" db:"schedule_id"`
 	ScheduleName *string         `json:"scheduleName,omitempty" db:"schedule_name"`
 	PipelineID   *string         `json:"pipelineId,omitempty" db:"pipeline_id"`
@@ -108,6 +468,80 @@ type TaskExecution struct {
 	Error      *string    `json:"error,omitempty" db:"error"`
 }
 
+// ExecutionLog is one log line written while an execution ran. Fields holds
+// the structured context of the line and is only returned on request.
+type ExecutionLog struct {
+	ID        int64           `json:"id" db:"id"`
+	Timestamp time.Time       `json:"timestamp" db:"created_at"`
+	Level     string          `json:"level" db:"level"`
+	TaskID    *string         `json:"taskId,omitempty" db:"task_id"`
+	Message   string          `json:"message" db:"message"`
+	Fields    json.RawMessage `json:"fields,omitempty" db:"metadata"`
+}
+
+// ExecutionFilter holds the filters for listing executions; empty fields
+// and nil times match everything
+type ExecutionFilter struct {
+	ScheduleID    string
+	PipelineID    string
+	Status        string
+	StartedAfter  *time.Time
+	StartedBefore *time.Time
+}
+
+// ExecutionSeed is one line of a bulk execution request: a pending run of
+// a schedule, a pipeline or both. Trigger defaults to manual and Params to
+// an empty object.
+type ExecutionSeed struct {
+	ScheduleID *string         `json:"scheduleId"`
+	PipelineID *string         `json:"pipelineId"`
+	Trigger    string          `json:"trigger"`
+	Params     json.RawMessage `json:"params"`
+}
+
+// ExecutionSeedResult is the outcome of one line of a bulk execution
+// request: the created execution or the error that rejected the line
+type ExecutionSeedResult struct {
+	Line        int    `json:"line"`
+	ExecutionID string `json:"executionId,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ExecutionQueueStats summarizes the execution backlog for monitoring.
+// Durations are in milliseconds and nil when there is nothing to measure.
+type ExecutionQueueStats struct {
+	Pending            int    `json:"pending"`
+	Running            int    `json:"running"`
+	FailedLastHour     int    `json:"failedLastHour"`
+	OldestPendingAgeMs *int64 `json:"oldestPendingAgeMs"`
+	LongestRunningMs   *int64 `json:"longestRunningMs"`
+}
+
+// ExecutionComparison is a side-by-side diff of two executions
+type ExecutionComparison struct {
+	A             *Execution       `json:"a"`
+	B             *Execution       `json:"b"`
+	DurationDelta *int64           `json:"durationDelta,omitempty"`
+	Tasks         []TaskComparison `json:"tasks"`
+	OnlyInA       []string         `json:"onlyInA"`
+	OnlyInB       []string         `json:"onlyInB"`
+}
+
+// TaskComparison compares the runs of one DAG node across two executions
+type TaskComparison struct {
+	NodeID          string  `json:"nodeId"`
+	NodeName        string  `json:"nodeName"`
+	Presence        string  `json:"presence"` // both, a_only, b_only
+	StatusA         *string `json:"statusA,omitempty"`
+	StatusB         *string `json:"statusB,omitempty"`
+	StatusChanged   bool    `json:"statusChanged"`
+	DurationA       *int64  `json:"durationA,omitempty"`
+	DurationB       *int64  `json:"durationB,omitempty"`
+	DurationDelta   *int64  `json:"durationDelta,omitempty"`
+	InputRowsDelta  *int64  `json:"inputRowsDelta,omitempty"`
+	OutputRowsDelta *int64  `json:"outputRowsDelta,omitempty"`
+}
+
 // Plugin represents an ETL plugin
 type Plugin struct {
 	ID           string          `json:"id" db:"id"`
@@ -121,16 +555,107 @@ type Plugin struct {
 	Enabled      bool            `json:"enabled" db:"enabled"`
 }
 
-// PaginatedResponse is a generic paginated response
-type PaginatedResponse[T any] struct {
-	Data     []T `json:"data"`
-	Total    int `json:"total"`
-	Page     int `json:"page"`
-	PageSize int `json:"pageSize"`
+// PluginConfigField describes one entry of a plugin's config schema
+type PluginConfigField struct {
+	Name        string          `json:"name"`
+	Type        string          `json:"type"`
+	Label       string          `json:"label"`
+	Description *string         `json:"description,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+	Default     interface{}     `json:"default,omitempty"`
+	Options     json.RawMessage `json:"options,omitempty"`
+}
+
+// Webhook is an outbound webhook notified when executions of its tenant
+// change status. The secret signs deliveries and is never returned.
+type Webhook struct {
+	ID        string    `json:"id" db:"id"`
+	TenantID  string    `json:"tenantId" db:"tenant_id"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"-" db:"secret"`
+	Events    []string  `json:"events" db:"events"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	CreatedBy *string   `json:"createdBy,omitempty" db:"created_by"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// WebhookForm is the form for registering a webhook. Events lists the
+// execution statuses to deliver; empty means every status change.
+type WebhookForm struct {
+	URL     string   `json:"url" binding:"required,url"`
+	Secret  string   `json:"secret" binding:"required,min=16"`
+	Events  []string `json:"events" binding:"dive,oneof=pending running success failed cancelled"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// Webhook delivery statuses. A delivery is dead once it has failed the
+// maximum number of attempts.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery is one execution status change sent, or to be sent, to a
+// webhook
+type WebhookDelivery struct {
+	ID             int64           `json:"id" db:"id"`
+	WebhookID      string          `json:"webhookId" db:"webhook_id"`
+	ExecutionID    string          `json:"executionId" db:"execution_id"`
+	Event          string          `json:"event" db:"event"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt" db:"next_attempt_at"`
+	ResponseStatus *int            `json:"responseStatus,omitempty" db:"response_status"`
+	LastError      *string         `json:"lastError,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"createdAt" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty" db:"delivered_at"`
+}
+
+// Summary holds the resource counts shown on the ETL dashboard. Status maps
+// only list statuses with at least one resource.
+type Summary struct {
+	DataSources      map[string]int `json:"dataSources"`
+	Pipelines        map[string]int `json:"pipelines"`
+	Schedules        ScheduleCounts `json:"schedules"`
+	RecentExecutions map[string]int `json:"recentExecutions"` // created in the last 24 hours
+	GeneratedAt      time.Time      `json:"generatedAt"`
+}
+
+// ScheduleCounts counts the schedules that are not archived
+type ScheduleCounts struct {
+	Enabled  int `json:"enabled"`
+	Disabled int `json:"disabled"`
+}
+
+// Entity types recorded in the audit log
+const (
+	AuditDataSource = "datasource"
+	AuditDataSet    = "dataset"
+	AuditPipeline   = "pipeline"
+	AuditSchedule   = "schedule"
+)
+
+// AuditEntry records one create, update, archive or delete of an ETL config
+// resource. Changes maps each changed field to its before and after value;
+// for data source configs, which may hold secrets, only the changed keys are
+// recorded.
+type AuditEntry struct {
+	ID         int64           `json:"id" db:"id"`
+	Actor      *string         `json:"actor,omitempty" db:"actor"`
+	EntityType string          `json:"entityType" db:"entity_type"`
+	EntityID   string          `json:"entityId" db:"entity_id"`
+	Action     string          `json:"action" db:"action"`
+	Changes    json.RawMessage `json:"changes" db:"changes"`
+	CreatedAt  time.Time       `json:"createdAt" db:"created_at"`
 }
 
-// APIResponse is a generic API response
-type APIResponse[T any] struct {
-	Data    T      `json:"data"`
-	Message string `json:"message,omitempty"`
+// AuditFilter holds the filters for listing audit entries; empty fields
+// match every entry
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	Actor      string
 }
@@ -0,0 +1,149 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas generates JSON Schemas from Go types. Named struct types become
+// components referenced with $ref, so each is described once.
+type schemas struct {
+	components map[string]interface{}
+}
+
+// of returns the schema of the values of t as encoding/json writes and
+// reads them
+func (g *schemas) of(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawMessageType:
+		return map[string]interface{}{"description": "Any JSON value"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := g.of(t.Elem())
+		if _, ok := s["$ref"]; ok {
+			// OpenAPI 3.0 ignores siblings of $ref
+			return map[string]interface{}{"allOf": []interface{}{s}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.of(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		if _, ok := g.components[t.Name()]; !ok {
+			g.components[t.Name()] = nil // placeholder, so recursive types terminate
+			g.components[t.Name()] = g.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// object returns the schema of a struct. Fields of a struct with binding
+// tags (a request form) are required when bound as required; fields of
+// other structs when they are neither pointers nor omitempty.
+func (g *schemas) object(t reflect.Type) map[string]interface{} {
+	form := false
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("binding"); ok {
+			form = true
+			break
+		}
+	}
+
+	properties := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			embedded := g.object(f.Type)
+			for k, v := range embedded["properties"].(map[string]interface{}) {
+				properties[k] = v
+			}
+			if r, ok := embedded["required"].([]string); ok {
+				required = append(required, r...)
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		prop := g.of(f.Type)
+		binding := f.Tag.Get("binding")
+		if values, ok := bindingOneOf(binding); ok && f.Type.Kind() == reflect.String {
+			prop["enum"] = values
+		}
+		properties[name] = prop
+
+		if form {
+			if hasRule(binding, "required") {
+				required = append(required, name)
+			}
+		} else if f.Type.Kind() != reflect.Pointer && !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	out := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		// OpenAPI 3.0 rejects an empty required list
+		out["required"] = required
+	}
+	return out
+}
+
+// hasRule reports whether a binding tag holds rule
+func hasRule(binding, rule string) bool {
+	for _, r := range strings.Split(binding, ",") {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// bindingOneOf returns the values of a binding tag's oneof rule
+func bindingOneOf(binding string) ([]string, bool) {
+	for _, r := range strings.Split(binding, ",") {
+		if values, ok := strings.CutPrefix(r, "oneof="); ok {
+			return strings.Fields(values), true
+		}
+	}
+	return nil, false
+}
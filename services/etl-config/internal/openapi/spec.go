@@ -0,0 +1,252 @@
+// Package openapi builds the OpenAPI 3 description of the service from its
+// registered routes and the Go types of their request and response bodies.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Operation documents one route. Request and Response are zero values of
+// the body types, e.g. model.PipelineForm{}; Response is wrapped in the
+// {"data": ...} envelope, or the paginated envelope when List is set, unless
+// Bare is set.
+type Operation struct {
+	Summary  string
+	Tag      string
+	Query    []Param
+	Request  interface{} // nil when the route reads no body
+	Response interface{} // nil when the route answers 204
+	List     bool
+	Bare     bool
+	Status   int // success status; 0 means 200, or 204 without a Response
+}
+
+// Param is a query parameter of an operation
+type Param struct {
+	Name        string
+	Type        string // JSON Schema type, e.g. "string" or "integer"
+	Description string
+}
+
+// Spec collects operation documentation and builds the OpenAPI document
+type Spec struct {
+	title   string
+	version string
+	ops     map[string]Operation // by "METHOD path"
+
+	once sync.Once
+	doc  map[string]interface{}
+}
+
+// New creates an empty Spec
+func New(title, version string) *Spec {
+	return &Spec{title: title, version: version, ops: make(map[string]Operation)}
+}
+
+// Document records the documentation of the route registered as method and
+// gin path, e.g. "GET", "/api/etl/pipelines/:id"
+func (s *Spec) Document(method, path string, op Operation) {
+	s.ops[method+" "+path] = op
+}
+
+// Undocumented returns the routes that have no documentation, so a new
+// route without one is noticed at startup
+func (s *Spec) Undocumented(routes gin.RoutesInfo) []string {
+	var missing []string
+	for _, r := range routes {
+		if _, ok := s.ops[r.Method+" "+r.Path]; !ok {
+			missing = append(missing, r.Method+" "+r.Path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Handler serves the OpenAPI document of the router's routes. The document
+// is built on first request, once every route is registered.
+func (s *Spec) Handler(router *gin.Engine) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		s.once.Do(func() { s.doc = s.Build(router.Routes()) })
+		c.JSON(http.StatusOK, s.doc)
+	}
+}
+
+// Build returns the OpenAPI document of routes. Undocumented routes are
+// still listed, with untyped responses.
+func (s *Spec) Build(routes gin.RoutesInfo) map[string]interface{} {
+	g := &schemas{components: make(map[string]interface{})}
+	g.components["Error"] = map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"error": map[string]interface{}{"type": "string"}},
+		"required":   []string{"error"},
+	}
+
+	paths := make(map[string]interface{})
+	for _, r := range routes {
+		op, documented := s.ops[r.Method+" "+r.Path]
+		path, params := openAPIPath(r.Path)
+
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+		item[strings.ToLower(r.Method)] = s.operation(g, r, op, documented, params)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   s.title,
+			"version": s.version,
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": g.components},
+	}
+}
+
+// operation builds the operation object of one route
+func (s *Spec) operation(g *schemas, r gin.RouteInfo, op Operation, documented bool, pathParams []string) map[string]interface{} {
+	out := map[string]interface{}{
+		"operationId": operationID(r.Handler),
+	}
+	if op.Summary != "" {
+		out["summary"] = op.Summary
+	}
+	if op.Tag != "" {
+		out["tags"] = []string{op.Tag}
+	}
+
+	var parameters []interface{}
+	for _, name := range pathParams {
+		parameters = append(parameters, map[string]interface{}{
+			"name": name, "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, q := range op.Query {
+		p := map[string]interface{}{"name": q.Name, "in": "query", "schema": map[string]interface{}{"type": q.Type}}
+		if q.Description != "" {
+			p["description"] = q.Description
+		}
+		parameters = append(parameters, p)
+	}
+	if op.List {
+		parameters = append(parameters, listParams...)
+	}
+	if len(parameters) > 0 {
+		out["parameters"] = parameters
+	}
+
+	if op.Request != nil {
+		out["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  jsonContent(g.of(reflect.TypeOf(op.Request))),
+		}
+	}
+
+	errorResponse := map[string]interface{}{
+		"description": "Error",
+		"content":     jsonContent(map[string]interface{}{"$ref": "#/components/schemas/Error"}),
+	}
+	responses := map[string]interface{}{"default": errorResponse}
+
+	status := op.Status
+	switch {
+	case !documented:
+		responses["200"] = map[string]interface{}{"description": "Success"}
+	case op.Response == nil:
+		if status == 0 {
+			status = http.StatusNoContent
+		}
+		responses[strconv.Itoa(status)] = map[string]interface{}{"description": http.StatusText(status)}
+	default:
+		if status == 0 {
+			status = http.StatusOK
+		}
+		data := g.of(reflect.TypeOf(op.Response))
+		var envelope map[string]interface{}
+		switch {
+		case op.Bare:
+			envelope = data
+		case op.List:
+			envelope = paginatedEnvelope(data)
+		default:
+			envelope = map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"data":    data,
+					"message": map[string]interface{}{"type": "string"},
+				},
+				"required": []string{"data"},
+			}
+		}
+		responses[strconv.Itoa(status)] = map[string]interface{}{
+			"description": http.StatusText(status),
+			"content":     jsonContent(envelope),
+		}
+	}
+	out["responses"] = responses
+	return out
+}
+
+// listParams are the query parameters every paginated list accepts
+var listParams = []interface{}{
+	map[string]interface{}{"name": "page", "in": "query", "schema": map[string]interface{}{"type": "integer", "minimum": 1}},
+	map[string]interface{}{"name": "pageSize", "in": "query", "schema": map[string]interface{}{"type": "integer", "minimum": 1}},
+	map[string]interface{}{"name": "withTotal", "in": "query", "schema": map[string]interface{}{"type": "boolean"},
+		"description": "false skips counting; total is then -1"},
+	map[string]interface{}{"name": "sort", "in": "query", "schema": map[string]interface{}{"type": "string"}},
+	map[string]interface{}{"name": "order", "in": "query", "schema": map[string]interface{}{"type": "string", "enum": []string{"asc", "desc"}}},
+}
+
+// paginatedEnvelope is the schema of api.PaginatedResponse of items
+func paginatedEnvelope(item map[string]interface{}) map[string]interface{} {
+	integer := map[string]interface{}{"type": "integer"}
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"data":     map[string]interface{}{"type": "array", "items": item},
+			"total":    integer,
+			"page":     integer,
+			"pageSize": integer,
+			"hasMore":  map[string]interface{}{"type": "boolean"},
+		},
+		"required": []string{"data", "total", "page", "pageSize", "hasMore"},
+	}
+}
+
+// openAPIPath converts a gin path to an OpenAPI path, returning the names
+// of its parameters: "/pipelines/:id" becomes "/pipelines/{id}"
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if len(seg) > 1 && (seg[0] == ':' || seg[0] == '*') {
+			params = append(params, seg[1:])
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives an operation ID from a handler name such as
+// ".../handler.(*PipelineHandler).Get-fm", giving "PipelineHandler.Get"
+func operationID(handler string) string {
+	name := handler[strings.LastIndex(handler, "/")+1:]
+	name = strings.TrimSuffix(name, "-fm")
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.NewReplacer("(", "", ")", "", "*", "").Replace(name)
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
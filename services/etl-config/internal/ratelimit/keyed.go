@@ -0,0 +1,57 @@
+// Package ratelimit provides small in-process rate limiters.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Keyed allows one event per key per interval, e.g. one connection test per
+// data source every few seconds
+type Keyed struct {
+	mu       sync.Mutex
+	interval time.Duration
+	last     map[string]time.Time
+}
+
+// NewKeyed creates a Keyed limiter. A non-positive interval disables limiting.
+func NewKeyed(interval time.Duration) *Keyed {
+	return &Keyed{
+		interval: interval,
+		last:     make(map[string]time.Time),
+	}
+}
+
+// Allow records an event for key if the interval has passed since the last
+// one. Otherwise it returns false and how long to wait before retrying.
+func (k *Keyed) Allow(key string) (bool, time.Duration) {
+	if k.interval <= 0 {
+		return true, 0
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := k.last[key]; ok {
+		if wait := k.interval - now.Sub(last); wait > 0 {
+			return false, wait
+		}
+	}
+	k.last[key] = now
+	k.prune(now)
+	return true, 0
+}
+
+// prune drops keys whose interval has expired so the map doesn't grow
+// without bound. It only scans once the map has grown past a threshold.
+func (k *Keyed) prune(now time.Time) {
+	if len(k.last) < 1024 {
+		return
+	}
+	for key, last := range k.last {
+		if now.Sub(last) >= k.interval {
+			delete(k.last, key)
+		}
+	}
+}
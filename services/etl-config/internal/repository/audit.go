@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// auditEntryColumns is the column list read by scanAuditEntry
+const auditEntryColumns = `id, actor, entity_type, entity_id, action, changes, created_at`
+
+// AuditRepository reads the ETL config audit log. Entries are written by
+// database triggers on every change of a data source, dataset, pipeline or
+// schedule, attributed to the actor set with setAuditActor.
+type AuditRepository struct{}
+
+// NewAuditRepository creates a new AuditRepository
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+// List returns the audit entries of the tenant, newest first
+func (r *AuditRepository) List(ctx context.Context, filter model.AuditFilter, page, pageSize int, withTotal bool) ([]model.AuditEntry, int, error) {
+	query := `
+		SELECT ` + auditEntryColumns + `
+		FROM etl_audit_log
+		WHERE ($1 = '' OR entity_type = $1)
+		  AND ($2 = '' OR entity_id::text = $2)
+		  AND ($3 = '' OR actor = $3)
+		  AND ($4::text IS NULL OR tenant_id = $4)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5 OFFSET $6
+	`
+
+	countQuery := `
+		SELECT COUNT(*) FROM etl_audit_log
+		WHERE ($1 = '' OR entity_type = $1)
+		  AND ($2 = '' OR entity_id::text = $2)
+		  AND ($3 = '' OR actor = $3)
+		  AND ($4::text IS NULL OR tenant_id = $4)
+	`
+
+	args := []interface{}{filter.EntityType, filter.EntityID, filter.Actor, tenantFilter(ctx)}
+
+	offset := (page - 1) * pageSize
+
+	rows, err := readDB(ctx).Query(ctx, query, append(args, api.PageLimit(pageSize, withTotal), offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []model.AuditEntry
+	for rows.Next() {
+		var e model.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Actor, &e.EntityType, &e.EntityID, &e.Action, &e.Changes, &e.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if !withTotal {
+		return entries, api.UnknownTotal, nil
+	}
+
+	var total int
+	err = readDB(ctx).QueryRow(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+// setAuditActor attributes the audit entries of the writes in tx to the
+// acting user of ctx. The setting ends with the transaction.
+func setAuditActor(ctx context.Context, tx pgx.Tx) error {
+	_, err := tx.Exec(ctx, `SELECT set_config('etl.actor', COALESCE($1, ''), true)`, actorOf(ctx))
+	return err
+}
+
+// audited runs write in a transaction whose audit entries are attributed to
+// the acting user of ctx
+func audited(ctx context.Context, write func(tx pgx.Tx) error) error {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := setAuditActor(ctx, tx); err != nil {
+		return err
+	}
+	if err := write(tx); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
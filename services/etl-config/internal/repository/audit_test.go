@@ -0,0 +1,162 @@
+package repository_test
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/testdb"
+)
+
+// auditEntries returns the audit entries of an entity, oldest first
+func auditEntries(t *testing.T, ctx context.Context, entityType, entityID string) []model.AuditEntry {
+	t.Helper()
+	entries, _, err := repository.NewAuditRepository().List(ctx,
+		model.AuditFilter{EntityType: entityType, EntityID: entityID}, 1, 100, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries
+}
+
+// jsonEqual reports whether got holds the same JSON value as want
+func jsonEqual(t *testing.T, got json.RawMessage, want string) bool {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+// wantEntry checks the actor, action and changes of an audit entry
+func wantEntry(t *testing.T, e model.AuditEntry, actor, action, changes string) {
+	t.Helper()
+	if e.Actor == nil || *e.Actor != actor || e.Action != action {
+		t.Errorf("entry by %v %s, want %s %s", e.Actor, e.Action, actor, action)
+	}
+	if !jsonEqual(t, e.Changes, changes) {
+		t.Errorf("%s changes = %s, want %s", action, e.Changes, changes)
+	}
+}
+
+func TestAuditPipeline(t *testing.T) {
+	tenantID := testdb.Open(t)
+	pipelines := repository.NewPipelineRepository()
+
+	p, err := pipelines.Create(testdb.Context(tenantID, "alice"), &model.PipelineForm{
+		Name:  "bars",
+		Steps: json.RawMessage(`[{"id": "extract"}]`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := "daily bars"
+	_, err = pipelines.Update(testdb.Context(tenantID, "bob"), p.ID, &model.PipelineForm{
+		Name:        "bars",
+		Description: &desc,
+		Steps:       json.RawMessage(`[{"id": "extract"}, {"id": "load"}]`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := auditEntries(t, testdb.Context(tenantID, ""), "pipeline", p.ID)
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want one for the create and one for the update", len(entries))
+	}
+	create := entries[0]
+	if create.Action != "create" || create.Actor == nil || *create.Actor != "alice" {
+		t.Errorf("create entry by %v %s, want alice create", create.Actor, create.Action)
+	}
+	var created map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(create.Changes, &created); err != nil {
+		t.Fatal(err)
+	}
+	if !jsonEqual(t, created["name"]["after"], `"bars"`) || created["name"]["before"] != nil {
+		t.Errorf("create changes = %s, want the name only after", create.Changes)
+	}
+	if _, ok := created["description"]; ok {
+		t.Errorf("create changes = %s, want null columns left out", create.Changes)
+	}
+
+	wantEntry(t, entries[1], "bob", "update", `{
+		"description": {"before": null, "after": "daily bars"},
+		"steps": {"before": [{"id": "extract"}], "after": [{"id": "extract"}, {"id": "load"}]},
+		"version": {"before": 1, "after": 2}
+	}`)
+}
+
+func TestAuditDataSourceSecrets(t *testing.T) {
+	tenantID := testdb.Open(t)
+	sources := repository.NewDataSourceRepository()
+
+	ds, err := sources.Create(testdb.Context(tenantID, "alice"), &model.DataSourceForm{
+		Name:         "warehouse",
+		Type:         "database",
+		Plugin:       "source-postgres",
+		Config:       json.RawMessage(`{"host": "db1", "password": "first-s3cret"}`),
+		Environments: json.RawMessage(`{"prod": {"password": "prod-s3cret"}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sources.Update(testdb.Context(tenantID, "bob"), ds.ID, &model.DataSourceForm{
+		Name:         "warehouse",
+		Type:         "database",
+		Plugin:       "source-postgres",
+		Config:       json.RawMessage(`{"host": "db1", "password": "second-s3cret"}`),
+		Environments: json.RawMessage(`{"prod": {"password": "prod-s3cret"}}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := auditEntries(t, testdb.Context(tenantID, ""), "datasource", ds.ID)
+	if len(entries) != 2 {
+		t.Fatalf("got %d audit entries, want one for the create and one for the update", len(entries))
+	}
+	for _, e := range entries {
+		if strings.Contains(string(e.Changes), "s3cret") {
+			t.Errorf("%s entry records a secret: %s", e.Action, e.Changes)
+		}
+	}
+
+	if e := entries[0]; e.Action != "create" || e.Actor == nil || *e.Actor != "alice" {
+		t.Errorf("create entry by %v %s, want alice create", e.Actor, e.Action)
+	}
+	var created map[string]json.RawMessage
+	if err := json.Unmarshal(entries[0].Changes, &created); err != nil {
+		t.Fatal(err)
+	}
+	if !jsonEqual(t, created["config"], `{"changedKeys": ["host", "password"]}`) {
+		t.Errorf("create config changes = %s, want the changed keys only", created["config"])
+	}
+	if !jsonEqual(t, created["environments"], `{"changedKeys": ["prod"]}`) {
+		t.Errorf("create environments changes = %s, want the changed keys only", created["environments"])
+	}
+
+	wantEntry(t, entries[1], "bob", "update", `{"config": {"changedKeys": ["password"]}}`)
+}
+
+func TestAuditAnonymousWrite(t *testing.T) {
+	tenantID := testdb.Open(t)
+	p, err := repository.NewPipelineRepository().Create(testdb.Context(tenantID, ""), &model.PipelineForm{Name: "bars"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := auditEntries(t, testdb.Context(tenantID, ""), "pipeline", p.ID)
+	if len(entries) != 1 || entries[0].Actor != nil {
+		t.Errorf("entries = %+v, want one without an actor", entries)
+	}
+}
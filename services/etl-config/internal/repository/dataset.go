@@ -3,11 +3,24 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 )
 
+// dataSetColumns is the column list read by scanDataSet
+const dataSetColumns = `id, tenant_id, name, version, category, description, schema, storage, indexes, labels, status,
+		       owner_id, created_by, updated_by, created_at, updated_at`
+
+// dataSetSummaryColumns is dataSetColumns with the schema, storage and
+// indexes selected as NULL, followed by their sizes in bytes; read by
+// scanDataSetSummary
+const dataSetSummaryColumns = `id, tenant_id, name, version, category, description, NULL::jsonb, NULL::jsonb, NULL::jsonb,
+		       labels, status, owner_id, created_by, updated_by, created_at, updated_at,
+		       octet_length(schema::text), octet_length(storage::text), COALESCE(octet_length(indexes::text), 0)`
+
 // DataSetRepository handles dataset database operations
 type DataSetRepository struct{}
 
@@ -17,25 +30,50 @@ func NewDataSetRepository() *DataSetRepository {
 }
 
 // List returns paginated datasets
-func (r *DataSetRepository) List(ctx context.Context, category, storage string, page, pageSize int) ([]model.DataSet, int, error) {
+func (r *DataSetRepository) List(ctx context.Context, filter model.DataSetFilter, sort api.Sort, page, pageSize int, withTotal bool) ([]model.DataSet, int, error) {
+	columns, scan := dataSetColumns, scanDataSet
+	if filter.Summary {
+		columns, scan = dataSetSummaryColumns, scanDataSetSummary
+	}
+
 	query := `
-		SELECT id, name, version, category, description, schema, storage, indexes, labels, status, created_at, updated_at
+		SELECT ` + columns + `
 		FROM etl_datasets
 		WHERE ($1 = '' OR category = $1)
 		  AND ($2 = '' OR storage->>'type' = $2)
-		ORDER BY category, name
-		LIMIT $3 OFFSET $4
+		  AND ($3 = '' OR EXISTS (
+		      SELECT 1 FROM etl_dataset_favorites f
+		      WHERE f.dataset_id = etl_datasets.id AND f.user_id = $3))
+		  AND ($4::text IS NULL OR owner_id IS NULL OR owner_id = $4 OR EXISTS (
+		      SELECT 1 FROM etl_dataset_grants g
+		      WHERE g.dataset_id = etl_datasets.id AND g.user_id = $4))
+		  AND ($5::text IS NULL OR tenant_id = $5)
+		  AND ($6 = '' OR created_by = $6)
+		ORDER BY ` + DataSetSortColumns.OrderBy(sort, "category, name") + `
+		LIMIT $7 OFFSET $8
 	`
 
 	countQuery := `
 		SELECT COUNT(*) FROM etl_datasets
 		WHERE ($1 = '' OR category = $1)
 		  AND ($2 = '' OR storage->>'type' = $2)
+		  AND ($3 = '' OR EXISTS (
+		      SELECT 1 FROM etl_dataset_favorites f
+		      WHERE f.dataset_id = etl_datasets.id AND f.user_id = $3))
+		  AND ($4::text IS NULL OR owner_id IS NULL OR owner_id = $4 OR EXISTS (
+		      SELECT 1 FROM etl_dataset_grants g
+		      WHERE g.dataset_id = etl_datasets.id AND g.user_id = $4))
+		  AND ($5::text IS NULL OR tenant_id = $5)
+		  AND ($6 = '' OR created_by = $6)
 	`
 
+	args := []interface{}{
+		filter.Category, filter.Storage, filter.FavoritesOf, filter.VisibleTo, tenantFilter(ctx), filter.CreatedBy,
+	}
+
 	offset := (page - 1) * pageSize
 
-	rows, err := DB.Query(ctx, query, category, storage, pageSize, offset)
+	rows, err := readDB(ctx).Query(ctx, query, append(args, api.PageLimit(pageSize, withTotal), offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -43,20 +81,19 @@ func (r *DataSetRepository) List(ctx context.Context, category, storage string,
 
 	var datasets []model.DataSet
 	for rows.Next() {
-		var ds model.DataSet
-		err := rows.Scan(
-			&ds.ID, &ds.Name, &ds.Version, &ds.Category, &ds.Description,
-			&ds.Schema, &ds.Storage, &ds.Indexes, &ds.Labels, &ds.Status,
-			&ds.CreatedAt, &ds.UpdatedAt,
-		)
+		ds, err := scan(rows)
 		if err != nil {
 			return nil, 0, err
 		}
-		datasets = append(datasets, ds)
+		datasets = append(datasets, *ds)
+	}
+
+	if !withTotal {
+		return datasets, api.UnknownTotal, nil
 	}
 
 	var total int
-	err = DB.QueryRow(ctx, countQuery, category, storage).Scan(&total)
+	err = readDB(ctx).QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -64,20 +101,65 @@ func (r *DataSetRepository) List(ctx context.Context, category, storage string,
 	return datasets, total, nil
 }
 
+// Discover returns summaries of the datasets carrying every given label key
+// (capability), optionally restricted to a category. visibleTo limits the
+// result like DataSetFilter.VisibleTo.
+func (r *DataSetRepository) Discover(ctx context.Context, capabilities []string, category string, visibleTo *string) ([]model.DataSetSummary, error) {
+	query := `
+		SELECT id, name, version, category, COALESCE(storage->>'type', ''), labels,
+		       COALESCE((
+		           SELECT jsonb_agg(jsonb_build_object(
+		               'name', f->>'name',
+		               'type', f->>'type',
+		               'primary', COALESCE((f->>'primary')::boolean, false)))
+		           FROM jsonb_array_elements(
+		               CASE WHEN jsonb_typeof(schema->'fields') = 'array' THEN schema->'fields' ELSE '[]'::jsonb END
+		           ) f
+		       ), '[]'::jsonb),
+		       updated_at
+		FROM etl_datasets
+		WHERE labels ?& $1::text[]
+		  AND ($2 = '' OR category = $2)
+		  AND ($3::text IS NULL OR owner_id IS NULL OR owner_id = $3 OR EXISTS (
+		      SELECT 1 FROM etl_dataset_grants g
+		      WHERE g.dataset_id = etl_datasets.id AND g.user_id = $3))
+		  AND ($4::text IS NULL OR tenant_id = $4)
+		ORDER BY name
+	`
+
+	rows, err := readDB(ctx).Query(ctx, query, capabilities, category, visibleTo, tenantFilter(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []model.DataSetSummary
+	for rows.Next() {
+		var (
+			s      model.DataSetSummary
+			fields []byte
+		)
+		err := rows.Scan(&s.ID, &s.Name, &s.Version, &s.Category, &s.Storage, &s.Labels, &fields, &s.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(fields, &s.Fields); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, s)
+	}
+	return summaries, rows.Err()
+}
+
 // GetByID returns a dataset by ID
 func (r *DataSetRepository) GetByID(ctx context.Context, id string) (*model.DataSet, error) {
 	query := `
-		SELECT id, name, version, category, description, schema, storage, indexes, labels, status, created_at, updated_at
+		SELECT ` + dataSetColumns + `
 		FROM etl_datasets
-		WHERE id = $1
+		WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)
 	`
 
-	var ds model.DataSet
-	err := DB.QueryRow(ctx, query, id).Scan(
-		&ds.ID, &ds.Name, &ds.Version, &ds.Category, &ds.Description,
-		&ds.Schema, &ds.Storage, &ds.Indexes, &ds.Labels, &ds.Status,
-		&ds.CreatedAt, &ds.UpdatedAt,
-	)
+	ds, err := scanDataSet(readDB(ctx).QueryRow(ctx, query, id, tenantFilter(ctx)))
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -85,19 +167,76 @@ func (r *DataSetRepository) GetByID(ctx context.Context, id string) (*model.Data
 		return nil, err
 	}
 
-	return &ds, nil
+	return ds, nil
+}
+
+// GetByName returns a dataset by name. Names are unique per tenant, so the
+// lookup is always scoped to the context's own tenant.
+func (r *DataSetRepository) GetByName(ctx context.Context, name string) (*model.DataSet, error) {
+	query := `
+		SELECT ` + dataSetColumns + `
+		FROM etl_datasets
+		WHERE name = $1 AND tenant_id = $2
+	`
+
+	ds, err := scanDataSet(readDB(ctx).QueryRow(ctx, query, name, tenantOf(ctx)))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ds, nil
 }
 
 // Create creates a new dataset
 func (r *DataSetRepository) Create(ctx context.Context, ds *model.DataSet) (*model.DataSet, error) {
+	var result *model.DataSet
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		result, err = createDataSet(ctx, tx, ds)
+		return err
+	})
+	return result, err
+}
+
+// Update updates a dataset
+func (r *DataSetRepository) Update(ctx context.Context, id string, ds *model.DataSet) (*model.DataSet, error) {
+	var result *model.DataSet
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		result, err = updateDataSet(ctx, tx, id, ds)
+		return err
+	})
+	return result, err
+}
+
+// Upsert creates a dataset, or updates the dataset of the same name in the
+// tenant, in one statement so concurrent upserts of a name cannot race into
+// a duplicate. An update bumps the version. A non-zero ds.Version must match
+// the stored version; when it does not, Upsert returns nil. An update is only kept if allow accepts the
+// updated row, and allow's error is returned unchanged. It reports whether
+// the dataset was created.
+func (r *DataSetRepository) Upsert(ctx context.Context, ds *model.DataSet, allow func(ds *model.DataSet) error) (*model.DataSet, bool, error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback(ctx)
+	if err := setAuditActor(ctx, tx); err != nil {
+		return nil, false, err
+	}
+
 	query := `
-		INSERT INTO etl_datasets (name, category, description, schema, storage, indexes, labels)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, name, version, category, description, schema, storage, indexes, labels, status, created_at, updated_at
-	`
+		INSERT INTO etl_datasets (name, category, description, schema, storage, indexes, labels, owner_id, tenant_id,
+		                          created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		ON CONFLICT (tenant_id, name) DO UPDATE
+		SET category = EXCLUDED.category, description = EXCLUDED.description, schema = EXCLUDED.schema,
+		    storage = EXCLUDED.storage, indexes = EXCLUDED.indexes, labels = EXCLUDED.labels,
+		    updated_by = EXCLUDED.updated_by, version = etl_datasets.version + 1
+		WHERE $11 = 0 OR etl_datasets.version = $11
+		RETURNING ` + dataSetColumns + `, xmax = 0`
 
-	schemaJSON, _ := json.Marshal(ds.Schema)
-	storageJSON, _ := json.Marshal(ds.Storage)
 	indexesJSON := ds.Indexes
 	if indexesJSON == nil {
 		indexesJSON = json.RawMessage(`[]`)
@@ -107,56 +246,268 @@ func (r *DataSetRepository) Create(ctx context.Context, ds *model.DataSet) (*mod
 		labelsJSON = json.RawMessage(`{}`)
 	}
 
-	var result model.DataSet
-	err := DB.QueryRow(ctx, query,
-		ds.Name, ds.Category, ds.Description, schemaJSON, storageJSON, indexesJSON, labelsJSON,
-	).Scan(
-		&result.ID, &result.Name, &result.Version, &result.Category, &result.Description,
-		&result.Schema, &result.Storage, &result.Indexes, &result.Labels, &result.Status,
-		&result.CreatedAt, &result.UpdatedAt,
+	var created bool
+	row := tx.QueryRow(ctx, query,
+		ds.Name, ds.Category, ds.Description, ds.Schema, ds.Storage, indexesJSON, labelsJSON, ds.OwnerID, tenantOf(ctx),
+		actorOf(ctx), ds.Version,
 	)
+	result, err := scanDataSet(extraColumns{row, []interface{}{&created}})
+	if err == pgx.ErrNoRows {
+		// The conflict update was skipped: the stored version differs
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !created {
+		if err := allow(result); err != nil {
+			return nil, false, err
+		}
+	}
+	return result, created, tx.Commit(ctx)
+}
+
+// Patch updates a dataset by applying apply to the stored row while it is
+// locked, so concurrent partial updates do not overwrite each other. It
+// returns nil, nil when the dataset does not exist and apply's error
+// unchanged when it fails.
+func (r *DataSetRepository) Patch(ctx context.Context, id string, apply func(ds *model.DataSet) error) (*model.DataSet, error) {
+	tx, err := DB.Begin(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
+	if err := setAuditActor(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + dataSetColumns + `
+		FROM etl_datasets
+		WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)
+		FOR UPDATE
+	`
+	ds, err := scanDataSet(tx.QueryRow(ctx, query, id, tenantFilter(ctx)))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := apply(ds); err != nil {
+		return nil, err
+	}
 
-	return &result, nil
+	result, err := updateDataSet(ctx, tx, id, ds)
+	if err != nil {
+		return nil, err
+	}
+	return result, tx.Commit(ctx)
 }
 
-// Update updates a dataset
-func (r *DataSetRepository) Update(ctx context.Context, id string, ds *model.DataSet) (*model.DataSet, error) {
+// Import creates or updates datasets in a single transaction. Datasets with
+// an ID are updated, the rest are created, and each entry is replaced with
+// the stored row. With continueOnError every dataset runs in its own
+// savepoint, so a failing entry is reported in errs without aborting the
+// others; otherwise the first failure rolls back the whole import.
+func (r *DataSetRepository) Import(ctx context.Context, datasets []*model.DataSet, continueOnError bool) (errs []error, err error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	if err := setAuditActor(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	errs = make([]error, len(datasets))
+	for i, ds := range datasets {
+		if !continueOnError {
+			stored, err := importDataSet(ctx, tx, ds)
+			if err != nil {
+				return nil, fmt.Errorf("dataset %d (%s): %w", i, ds.Name, err)
+			}
+			datasets[i] = stored
+			continue
+		}
+
+		sp, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, err
+		}
+		stored, err := importDataSet(ctx, sp, ds)
+		if err != nil {
+			errs[i] = err
+			if err := sp.Rollback(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := sp.Commit(ctx); err != nil {
+			return nil, err
+		}
+		datasets[i] = stored
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// importDataSet creates or updates a single dataset of an import
+func importDataSet(ctx context.Context, q querier, ds *model.DataSet) (*model.DataSet, error) {
+	if ds.ID != "" {
+		return updateDataSet(ctx, q, ds.ID, ds)
+	}
+	return createDataSet(ctx, q, ds)
+}
+
+// querier is the subset of pgxpool.Pool and pgx.Tx used for dataset writes
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// createDataSet inserts a dataset
+func createDataSet(ctx context.Context, q querier, ds *model.DataSet) (*model.DataSet, error) {
+	query := `
+		INSERT INTO etl_datasets (name, category, description, schema, storage, indexes, labels, owner_id, tenant_id,
+		                          created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+		RETURNING ` + dataSetColumns
+
+	schemaJSON, _ := json.Marshal(ds.Schema)
+	storageJSON, _ := json.Marshal(ds.Storage)
+	indexesJSON := ds.Indexes
+	if indexesJSON == nil {
+		indexesJSON = json.RawMessage(`[]`)
+	}
+	labelsJSON := ds.Labels
+	if labelsJSON == nil {
+		labelsJSON = json.RawMessage(`{}`)
+	}
+
+	return scanDataSet(q.QueryRow(ctx, query,
+		ds.Name, ds.Category, ds.Description, schemaJSON, storageJSON, indexesJSON, labelsJSON, ds.OwnerID, tenantOf(ctx), actorOf(ctx),
+	))
+}
+
+// updateDataSet updates a dataset
+func updateDataSet(ctx context.Context, q querier, id string, ds *model.DataSet) (*model.DataSet, error) {
 	query := `
 		UPDATE etl_datasets
-		SET category = $2, description = $3, schema = $4, storage = $5, indexes = $6, labels = $7
-		WHERE id = $1
-		RETURNING id, name, version, category, description, schema, storage, indexes, labels, status, created_at, updated_at
+		SET category = $2, description = $3, schema = $4, storage = $5, indexes = $6, labels = $7,
+		    updated_by = $9
+		WHERE id = $1 AND ($8::text IS NULL OR tenant_id = $8)
+		RETURNING ` + dataSetColumns
+
+	return scanDataSet(q.QueryRow(ctx, query,
+		id, ds.Category, ds.Description, ds.Schema, ds.Storage, ds.Indexes, ds.Labels, tenantFilter(ctx), actorOf(ctx),
+	))
+}
+
+// AddFavorite stars a dataset for a user; starring twice is a no-op
+func (r *DataSetRepository) AddFavorite(ctx context.Context, userID, datasetID string) error {
+	query := `
+		INSERT INTO etl_dataset_favorites (user_id, dataset_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+	_, err := DB.Exec(ctx, query, userID, datasetID)
+	return err
+}
+
+// RemoveFavorite unstars a dataset for a user
+func (r *DataSetRepository) RemoveFavorite(ctx context.Context, userID, datasetID string) error {
+	query := `DELETE FROM etl_dataset_favorites WHERE user_id = $1 AND dataset_id = $2`
+	_, err := DB.Exec(ctx, query, userID, datasetID)
+	return err
+}
+
+// GetGrantRole returns the role granted to a user on a dataset, or "" if none
+func (r *DataSetRepository) GetGrantRole(ctx context.Context, datasetID, userID string) (string, error) {
+	query := `SELECT role FROM etl_dataset_grants WHERE dataset_id = $1 AND user_id = $2`
+
+	var role string
+	err := readDB(ctx).QueryRow(ctx, query, datasetID, userID).Scan(&role)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// ListGrants returns the grants on a dataset
+func (r *DataSetRepository) ListGrants(ctx context.Context, datasetID string) ([]model.DataSetGrant, error) {
+	query := `
+		SELECT user_id, role, created_at
+		FROM etl_dataset_grants
+		WHERE dataset_id = $1
+		ORDER BY role, user_id
 	`
 
-	var result model.DataSet
-	err := DB.QueryRow(ctx, query,
-		id, ds.Category, ds.Description, ds.Schema, ds.Storage, ds.Indexes, ds.Labels,
-	).Scan(
-		&result.ID, &result.Name, &result.Version, &result.Category, &result.Description,
-		&result.Schema, &result.Storage, &result.Indexes, &result.Labels, &result.Status,
-		&result.CreatedAt, &result.UpdatedAt,
-	)
+	rows, err := readDB(ctx).Query(ctx, query, datasetID)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+
+	var grants []model.DataSetGrant
+	for rows.Next() {
+		var g model.DataSetGrant
+		if err := rows.Scan(&g.UserID, &g.Role, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		grants = append(grants, g)
+	}
+	return grants, nil
+}
+
+// SetGrant grants a role on a dataset to a user, replacing any previous
+// grant. An empty role revokes access.
+func (r *DataSetRepository) SetGrant(ctx context.Context, datasetID, userID, role string) error {
+	if role == "" {
+		query := `DELETE FROM etl_dataset_grants WHERE dataset_id = $1 AND user_id = $2`
+		_, err := DB.Exec(ctx, query, datasetID, userID)
+		return err
+	}
 
-	return &result, nil
+	query := `
+		INSERT INTO etl_dataset_grants (dataset_id, user_id, role)
+		VALUES ($1, $2, $3::dataset_role)
+		ON CONFLICT (dataset_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`
+	_, err := DB.Exec(ctx, query, datasetID, userID, role)
+	return err
+}
+
+// SetOwner transfers ownership of a dataset
+func (r *DataSetRepository) SetOwner(ctx context.Context, datasetID, ownerID string) error {
+	query := `UPDATE etl_datasets SET owner_id = $2 WHERE id = $1 AND ($3::text IS NULL OR tenant_id = $3)`
+	return audited(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, datasetID, ownerID, tenantFilter(ctx))
+		return err
+	})
 }
 
 // Delete deletes a dataset
 func (r *DataSetRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM etl_datasets WHERE id = $1`
-	_, err := DB.Exec(ctx, query, id)
-	return err
+	query := `DELETE FROM etl_datasets WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)`
+	return audited(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, id, tenantFilter(ctx))
+		return err
+	})
 }
 
 // GetCategories returns all unique categories
 func (r *DataSetRepository) GetCategories(ctx context.Context) ([]string, error) {
-	query := `SELECT DISTINCT category FROM etl_datasets ORDER BY category`
-	rows, err := DB.Query(ctx, query)
+	query := `
+		SELECT DISTINCT category FROM etl_datasets
+		WHERE ($1::text IS NULL OR tenant_id = $1)
+		ORDER BY category
+	`
+	rows, err := readDB(ctx).Query(ctx, query, tenantFilter(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -172,3 +523,28 @@ func (r *DataSetRepository) GetCategories(ctx context.Context) ([]string, error)
 	}
 	return categories, nil
 }
+
+// scanDataSet scans a row selected with dataSetColumns
+func scanDataSet(row pgx.Row) (*model.DataSet, error) {
+	var ds model.DataSet
+	err := row.Scan(
+		&ds.ID, &ds.TenantID, &ds.Name, &ds.Version, &ds.Category, &ds.Description,
+		&ds.Schema, &ds.Storage, &ds.Indexes, &ds.Labels, &ds.Status,
+		&ds.OwnerID, &ds.CreatedBy, &ds.UpdatedBy, &ds.CreatedAt, &ds.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ds, nil
+}
+
+// scanDataSetSummary scans a row selected with dataSetSummaryColumns
+func scanDataSetSummary(row pgx.Row) (*model.DataSet, error) {
+	var schemaSize, storageSize, indexesSize int
+	ds, err := scanDataSet(extraColumns{row, []interface{}{&schemaSize, &storageSize, &indexesSize}})
+	if err != nil {
+		return nil, err
+	}
+	ds.Sizes = map[string]int{"schema": schemaSize, "storage": storageSize, "indexes": indexesSize}
+	return ds, nil
+}
@@ -0,0 +1,145 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/testdb"
+)
+
+func newDataSet(name, description string, version int) *model.DataSet {
+	return &model.DataSet{
+		Name:        name,
+		Version:     version,
+		Category:    "market",
+		Description: &description,
+		Schema:      json.RawMessage(`{"fields": [{"name": "ts", "type": "timestamp"}]}`),
+		Storage:     json.RawMessage(`{"type": "clickhouse", "table": "bars"}`),
+	}
+}
+
+func allowAll(*model.DataSet) error { return nil }
+
+func TestUpsertConcurrent(t *testing.T) {
+	tenantID := testdb.Open(t)
+	ctx := testdb.Context(tenantID, "alice")
+	datasets := repository.NewDataSetRepository()
+
+	const n = 8
+	var (
+		wg      sync.WaitGroup
+		ids     [n]string
+		created [n]bool
+		errs    [n]error
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ds, ok, err := datasets.Upsert(ctx, newDataSet("bars", fmt.Sprintf("writer %d", i), 0), allowAll)
+			if ds != nil {
+				ids[i] = ds.ID
+			}
+			created[i], errs[i] = ok, err
+		}(i)
+	}
+	wg.Wait()
+
+	creates := 0
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("upsert %d: %v", i, errs[i])
+		}
+		if ids[i] != ids[0] {
+			t.Errorf("upsert %d returned dataset %s, want %s", i, ids[i], ids[0])
+		}
+		if created[i] {
+			creates++
+		}
+	}
+	if creates != 1 {
+		t.Errorf("%d upserts created the dataset, want exactly one", creates)
+	}
+
+	list, total, err := datasets.List(ctx, model.DataSetFilter{}, api.Sort{}, 1, 100, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(list) != 1 || list[0].Name != "bars" {
+		t.Errorf("tenant holds %d datasets %v, want the one dataset bars", total, list)
+	}
+	// One create and n-1 updates, each of which bumps the version
+	if len(list) == 1 && list[0].Version != n {
+		t.Errorf("version = %d, want %d", list[0].Version, n)
+	}
+}
+
+func TestUpsertVersion(t *testing.T) {
+	tenantID := testdb.Open(t)
+	ctx := testdb.Context(tenantID, "alice")
+	datasets := repository.NewDataSetRepository()
+
+	ds, created, err := datasets.Upsert(ctx, newDataSet("bars", "first", 0), allowAll)
+	if err != nil || !created {
+		t.Fatalf("Upsert = %v, %v, want a created dataset", created, err)
+	}
+
+	// Of concurrent upserts of the stored version, exactly one applies
+	const n = 4
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		applied int
+	)
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got, created, err := datasets.Upsert(ctx, newDataSet("bars", fmt.Sprintf("writer %d", i), ds.Version), allowAll)
+			switch {
+			case err != nil:
+				errs <- err
+			case got == nil:
+			case created || got.ID != ds.ID || got.Version != ds.Version+1:
+				errs <- fmt.Errorf("upsert %d = %v, created %v, want an update of %s to version %d", i, got, created, ds.ID, ds.Version+1)
+			default:
+				mu.Lock()
+				applied++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if applied != 1 {
+		t.Errorf("%d upserts of version %d applied, want exactly one", applied, ds.Version)
+	}
+
+	got, _, err := datasets.Upsert(ctx, newDataSet("bars", "stale", ds.Version), allowAll)
+	if err != nil || got != nil {
+		t.Errorf("Upsert of a version that is no longer stored = %v, %v, want nil", got, err)
+	}
+
+	errDenied := errors.New("denied")
+	_, _, err = datasets.Upsert(ctx, newDataSet("bars", "denied", 0), func(*model.DataSet) error { return errDenied })
+	if !errors.Is(err, errDenied) {
+		t.Errorf("Upsert error = %v, want allow's error", err)
+	}
+	stored, err := datasets.GetByID(ctx, ds.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Description == nil || *stored.Description == "stale" || *stored.Description == "denied" {
+		t.Errorf("description = %v, want the last accepted write", stored.Description)
+	}
+}
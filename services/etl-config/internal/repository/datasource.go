@@ -5,9 +5,21 @@ import (
 	"encoding/json"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 )
 
+// dataSourceColumns is the column list read by scanDataSource
+const dataSourceColumns = `id, tenant_id, name, type, plugin, description, config, environments, capabilities, status,
+		       credential_version, last_sync_at, error_message, created_by, updated_by, created_at, updated_at`
+
+// dataSourceSummaryColumns is dataSourceColumns with the config and
+// environments selected as NULL, followed by their sizes in bytes; read by
+// scanDataSourceSummary
+const dataSourceSummaryColumns = `id, tenant_id, name, type, plugin, description, NULL::jsonb, NULL::jsonb, capabilities, status,
+		       credential_version, last_sync_at, error_message, created_by, updated_by, created_at, updated_at,
+		       octet_length(config::text), octet_length(environments::text)`
+
 // DataSourceRepository handles data source database operations
 type DataSourceRepository struct{}
 
@@ -17,26 +29,38 @@ func NewDataSourceRepository() *DataSourceRepository {
 }
 
 // List returns paginated data sources
-func (r *DataSourceRepository) List(ctx context.Context, typeFilter, statusFilter string, page, pageSize int) ([]model.DataSource, int, error) {
+func (r *DataSourceRepository) List(ctx context.Context, filter model.DataSourceFilter, sort api.Sort, page, pageSize int, withTotal bool) ([]model.DataSource, int, error) {
+	columns, scan := dataSourceColumns, scanDataSource
+	if filter.Summary {
+		columns, scan = dataSourceSummaryColumns, scanDataSourceSummary
+	}
+
 	query := `
-		SELECT id, name, type, plugin, description, config, capabilities, status, 
-		       last_sync_at, error_message, created_at, updated_at
+		SELECT ` + columns + `
 		FROM etl_datasources
 		WHERE ($1 = '' OR type = $1::datasource_type)
 		  AND ($2 = '' OR status = $2::datasource_status)
-		ORDER BY created_at DESC
-		LIMIT $3 OFFSET $4
+		  AND ($3 = '' OR plugin = $3)
+		  AND ($4::text IS NULL OR tenant_id = $4)
+		  AND ($5 = '' OR created_by = $5)
+		ORDER BY ` + DataSourceSortColumns.OrderBy(sort, "created_at DESC") + `
+		LIMIT $6 OFFSET $7
 	`
 
 	countQuery := `
 		SELECT COUNT(*) FROM etl_datasources
 		WHERE ($1 = '' OR type = $1::datasource_type)
 		  AND ($2 = '' OR status = $2::datasource_status)
+		  AND ($3 = '' OR plugin = $3)
+		  AND ($4::text IS NULL OR tenant_id = $4)
+		  AND ($5 = '' OR created_by = $5)
 	`
 
+	args := []interface{}{filter.Type, filter.Status, filter.Plugin, tenantFilter(ctx), filter.CreatedBy}
+
 	offset := (page - 1) * pageSize
 
-	rows, err := DB.Query(ctx, query, typeFilter, statusFilter, pageSize, offset)
+	rows, err := readDB(ctx).Query(ctx, query, append(args, api.PageLimit(pageSize, withTotal), offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -44,20 +68,19 @@ func (r *DataSourceRepository) List(ctx context.Context, typeFilter, statusFilte
 
 	var datasources []model.DataSource
 	for rows.Next() {
-		var ds model.DataSource
-		err := rows.Scan(
-			&ds.ID, &ds.Name, &ds.Type, &ds.Plugin, &ds.Description,
-			&ds.Config, &ds.Capabilities, &ds.Status,
-			&ds.LastSyncAt, &ds.ErrorMessage, &ds.CreatedAt, &ds.UpdatedAt,
-		)
+		ds, err := scan(rows)
 		if err != nil {
 			return nil, 0, err
 		}
-		datasources = append(datasources, ds)
+		datasources = append(datasources, *ds)
+	}
+
+	if !withTotal {
+		return datasources, api.UnknownTotal, nil
 	}
 
 	var total int
-	err = DB.QueryRow(ctx, countQuery, typeFilter, statusFilter).Scan(&total)
+	err = readDB(ctx).QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -68,18 +91,12 @@ func (r *DataSourceRepository) List(ctx context.Context, typeFilter, statusFilte
 // GetByID returns a data source by ID
 func (r *DataSourceRepository) GetByID(ctx context.Context, id string) (*model.DataSource, error) {
 	query := `
-		SELECT id, name, type, plugin, description, config, capabilities, status,
-		       last_sync_at, error_message, created_at, updated_at
+		SELECT ` + dataSourceColumns + `
 		FROM etl_datasources
-		WHERE id = $1
+		WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)
 	`
 
-	var ds model.DataSource
-	err := DB.QueryRow(ctx, query, id).Scan(
-		&ds.ID, &ds.Name, &ds.Type, &ds.Plugin, &ds.Description,
-		&ds.Config, &ds.Capabilities, &ds.Status,
-		&ds.LastSyncAt, &ds.ErrorMessage, &ds.CreatedAt, &ds.UpdatedAt,
-	)
+	ds, err := scanDataSource(readDB(ctx).QueryRow(ctx, query, id, tenantFilter(ctx)))
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -87,36 +104,31 @@ func (r *DataSourceRepository) GetByID(ctx context.Context, id string) (*model.D
 		return nil, err
 	}
 
-	return &ds, nil
+	return ds, nil
 }
 
 // Create creates a new data source
 func (r *DataSourceRepository) Create(ctx context.Context, form *model.DataSourceForm) (*model.DataSource, error) {
 	query := `
-		INSERT INTO etl_datasources (name, type, plugin, description, config, capabilities)
-		VALUES ($1, $2::datasource_type, $3, $4, $5, $6)
-		RETURNING id, name, type, plugin, description, config, capabilities, status,
-		          last_sync_at, error_message, created_at, updated_at
-	`
+		INSERT INTO etl_datasources (name, type, plugin, description, config, environments, capabilities, tenant_id,
+		                             created_by, updated_by)
+		VALUES ($1, $2::datasource_type, $3, $4, $5, $6, $7, $8, $9, $9)
+		RETURNING ` + dataSourceColumns
 
 	configJSON := form.Config
 	if configJSON == nil {
 		configJSON = json.RawMessage(`{}`)
 	}
 
-	var ds model.DataSource
-	err := DB.QueryRow(ctx, query,
-		form.Name, form.Type, form.Plugin, form.Description, configJSON, form.Capabilities,
-	).Scan(
-		&ds.ID, &ds.Name, &ds.Type, &ds.Plugin, &ds.Description,
-		&ds.Config, &ds.Capabilities, &ds.Status,
-		&ds.LastSyncAt, &ds.ErrorMessage, &ds.CreatedAt, &ds.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	return &ds, nil
+	var ds *model.DataSource
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		ds, err = scanDataSource(tx.QueryRow(ctx, query,
+			form.Name, form.Type, form.Plugin, form.Description, configJSON, environmentsOf(form), form.Capabilities,
+			tenantOf(ctx), actorOf(ctx),
+		))
+		return err
+	})
+	return ds, err
 }
 
 // Update updates a data source
@@ -124,37 +136,42 @@ func (r *DataSourceRepository) Update(ctx context.Context, id string, form *mode
 	query := `
 		UPDATE etl_datasources
 		SET name = $2, type = $3::datasource_type, plugin = $4, description = $5,
-		    config = $6, capabilities = $7
-		WHERE id = $1
-		RETURNING id, name, type, plugin, description, config, capabilities, status,
-		          last_sync_at, error_message, created_at, updated_at
-	`
+		    config = $6, environments = $10, capabilities = $7, updated_by = $9
+		WHERE id = $1 AND ($8::text IS NULL OR tenant_id = $8)
+		RETURNING ` + dataSourceColumns
 
 	configJSON := form.Config
 	if configJSON == nil {
 		configJSON = json.RawMessage(`{}`)
 	}
 
-	var ds model.DataSource
-	err := DB.QueryRow(ctx, query,
-		id, form.Name, form.Type, form.Plugin, form.Description, configJSON, form.Capabilities,
-	).Scan(
-		&ds.ID, &ds.Name, &ds.Type, &ds.Plugin, &ds.Description,
-		&ds.Config, &ds.Capabilities, &ds.Status,
-		&ds.LastSyncAt, &ds.ErrorMessage, &ds.CreatedAt, &ds.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
-	}
+	var ds *model.DataSource
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		ds, err = scanDataSource(tx.QueryRow(ctx, query,
+			id, form.Name, form.Type, form.Plugin, form.Description, configJSON, form.Capabilities, tenantFilter(ctx), actorOf(ctx),
+			environmentsOf(form),
+		))
+		return err
+	})
+	return ds, err
+}
 
-	return &ds, nil
+// environmentsOf returns the environment overrides of a form, defaulting to
+// none
+func environmentsOf(form *model.DataSourceForm) json.RawMessage {
+	if form.Environments == nil || string(form.Environments) == "null" {
+		return json.RawMessage(`{}`)
+	}
+	return form.Environments
 }
 
 // Delete deletes a data source
 func (r *DataSourceRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM etl_datasources WHERE id = $1`
-	_, err := DB.Exec(ctx, query, id)
-	return err
+	query := `DELETE FROM etl_datasources WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)`
+	return audited(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, id, tenantFilter(ctx))
+		return err
+	})
 }
 
 // UpdateStatus updates the status of a data source
@@ -162,8 +179,61 @@ func (r *DataSourceRepository) UpdateStatus(ctx context.Context, id string, stat
 	query := `
 		UPDATE etl_datasources
 		SET status = $2::datasource_status, error_message = $3, last_sync_at = NOW()
-		WHERE id = $1
+		WHERE id = $1 AND ($4::text IS NULL OR tenant_id = $4)
 	`
-	_, err := DB.Exec(ctx, query, id, status, errMsg)
+	_, err := DB.Exec(ctx, query, id, status, errMsg, tenantFilter(ctx))
 	return err
 }
+
+// RotateCredentials merges new secret values into the config and bumps the credential version
+func (r *DataSourceRepository) RotateCredentials(ctx context.Context, id string, secrets map[string]interface{}) (*model.DataSource, error) {
+	query := `
+		UPDATE etl_datasources
+		SET config = config || $2::jsonb, credential_version = credential_version + 1, updated_by = $4
+		WHERE id = $1 AND ($3::text IS NULL OR tenant_id = $3)
+		RETURNING ` + dataSourceColumns
+
+	secretsJSON, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	var ds *model.DataSource
+	err = audited(ctx, func(tx pgx.Tx) (err error) {
+		ds, err = scanDataSource(tx.QueryRow(ctx, query, id, secretsJSON, tenantFilter(ctx), actorOf(ctx)))
+		return err
+	})
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ds, nil
+}
+
+// scanDataSource scans a row selected with dataSourceColumns
+func scanDataSource(row pgx.Row) (*model.DataSource, error) {
+	var ds model.DataSource
+	err := row.Scan(
+		&ds.ID, &ds.TenantID, &ds.Name, &ds.Type, &ds.Plugin, &ds.Description,
+		&ds.Config, &ds.Environments, &ds.Capabilities, &ds.Status, &ds.CredentialVersion,
+		&ds.LastSyncAt, &ds.ErrorMessage, &ds.CreatedBy, &ds.UpdatedBy, &ds.CreatedAt, &ds.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &ds, nil
+}
+
+// scanDataSourceSummary scans a row selected with dataSourceSummaryColumns
+func scanDataSourceSummary(row pgx.Row) (*model.DataSource, error) {
+	var configSize, environmentsSize int
+	ds, err := scanDataSource(extraColumns{row, []interface{}{&configSize, &environmentsSize}})
+	if err != nil {
+		return nil, err
+	}
+	ds.Sizes = map[string]int{"config": configSize, "environments": environmentsSize}
+	return ds, nil
+}
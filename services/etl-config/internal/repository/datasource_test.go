@@ -0,0 +1,71 @@
+package repository_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/testdb"
+)
+
+func TestListDataSourcesFilter(t *testing.T) {
+	tenantID := testdb.Open(t)
+	ctx := testdb.Context(tenantID, "alice")
+	datasources := repository.NewDataSourceRepository()
+
+	for _, ds := range []struct {
+		name, typ, plugin, status string
+	}{
+		{"pg-live", "database", "source-postgres", "active"},
+		{"pg-down", "database", "source-postgres", "error"},
+		{"pg-new", "database", "source-postgres", ""},
+		{"ch-live", "database", "source-clickhouse", "active"},
+		{"tushare-live", "api", "source-tushare", "active"},
+		{"csv-new", "file", "source-csv", ""},
+	} {
+		created, err := datasources.Create(ctx, &model.DataSourceForm{
+			Name: ds.name, Type: ds.typ, Plugin: ds.plugin, Config: json.RawMessage(`{}`),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ds.status != "" {
+			if err := datasources.UpdateStatus(ctx, created.ID, ds.status, nil); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name      string
+		filter    model.DataSourceFilter
+		wantNames []string
+	}{
+		{"plugin", model.DataSourceFilter{Plugin: "source-postgres"}, []string{"pg-down", "pg-live", "pg-new"}},
+		{"plugin and status", model.DataSourceFilter{Plugin: "source-postgres", Status: "active"}, []string{"pg-live"}},
+		{"plugin and type", model.DataSourceFilter{Plugin: "source-postgres", Type: "database"}, []string{"pg-down", "pg-live", "pg-new"}},
+		{"plugin, type and status", model.DataSourceFilter{Plugin: "source-postgres", Type: "database", Status: "inactive"}, []string{"pg-new"}},
+		{"type and status", model.DataSourceFilter{Type: "database", Status: "active"}, []string{"ch-live", "pg-live"}},
+		{"plugin of another type", model.DataSourceFilter{Plugin: "source-postgres", Type: "api"}, nil},
+		{"unused plugin", model.DataSourceFilter{Plugin: "source-wind"}, nil},
+		{"no filter", model.DataSourceFilter{}, []string{"ch-live", "csv-new", "pg-down", "pg-live", "pg-new", "tushare-live"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, total, err := datasources.List(ctx, tt.filter, api.Sort{Field: "name"}, 1, 100, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, ds := range list {
+				names = append(names, ds.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) || total != len(tt.wantNames) {
+				t.Errorf("List = %v of %d, want %v", names, total, tt.wantNames)
+			}
+		})
+	}
+}
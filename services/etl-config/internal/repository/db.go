@@ -3,16 +3,23 @@ package repository
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // DB holds the database connection pool
 var DB *pgxpool.Pool
 
+// ReplicaDB holds the optional read-replica pool; nil when not configured
+var ReplicaDB *pgxpool.Pool
+
 func init() {
 	// Load .env file if exists
 	loadEnvFile(".env")
@@ -43,44 +50,216 @@ func loadEnvFile(filename string) {
 	}
 }
 
-// InitDB initializes the database connection pool
-func InitDB() error {
-	host := getEnv("DB_HOST", "localhost")
-	port := getEnv("DB_PORT", "5432")
-	user := getEnv("DB_USER", "postgres")
-	password := getEnv("DB_PASSWORD", "")
-	dbname := getEnv("DB_NAME", "mellivora")
-	sslmode := getEnv("DB_SSLMODE", "require")
+// PoolSettings sizes the connection pool and bounds connection lifetimes
+type PoolSettings struct {
+	MaxConns          int32
+	MinConns          int32
+	MaxConnLifetime   time.Duration
+	HealthCheckPeriod time.Duration
+	// AcquireTimeout bounds how long establishing a new pooled connection
+	// may take; pgxpool has no separate wait limit for idle connections
+	AcquireTimeout time.Duration
+}
+
+// LoadPoolSettings reads the pool settings from DB_MAX_CONNS, DB_MIN_CONNS,
+// DB_MAX_CONN_LIFETIME, DB_ACQUIRE_TIMEOUT and DB_HEALTH_CHECK_PERIOD.
+// Durations use time.ParseDuration syntax, e.g. "30m" or "5s".
+func LoadPoolSettings() (PoolSettings, error) {
+	var (
+		s   PoolSettings
+		err error
+	)
+	if s.MaxConns, err = getEnvInt32("DB_MAX_CONNS", 20); err != nil {
+		return s, err
+	}
+	if s.MinConns, err = getEnvInt32("DB_MIN_CONNS", 5); err != nil {
+		return s, err
+	}
+	if s.MaxConnLifetime, err = getEnvDuration("DB_MAX_CONN_LIFETIME", time.Hour); err != nil {
+		return s, err
+	}
+	if s.AcquireTimeout, err = getEnvDuration("DB_ACQUIRE_TIMEOUT", 5*time.Second); err != nil {
+		return s, err
+	}
+	if s.HealthCheckPeriod, err = getEnvDuration("DB_HEALTH_CHECK_PERIOD", time.Minute); err != nil {
+		return s, err
+	}
 
+	if s.MaxConns < 1 {
+		return s, fmt.Errorf("DB_MAX_CONNS must be at least 1, got %d", s.MaxConns)
+	}
+	if s.MinConns < 0 || s.MinConns > s.MaxConns {
+		return s, fmt.Errorf("DB_MIN_CONNS must be between 0 and DB_MAX_CONNS (%d), got %d", s.MaxConns, s.MinConns)
+	}
+	if s.MaxConnLifetime <= 0 || s.AcquireTimeout <= 0 || s.HealthCheckPeriod <= 0 {
+		return s, fmt.Errorf("DB_MAX_CONN_LIFETIME, DB_ACQUIRE_TIMEOUT and DB_HEALTH_CHECK_PERIOD must be positive")
+	}
+	return s, nil
+}
+
+// InitDB initializes the primary database connection pool and, when
+// DB_REPLICA_HOST is set, a read-replica pool. Replica connection settings
+// default to the primary's.
+func InitDB(pool PoolSettings) error {
+	primary := connSettings{
+		host:     getEnv("DB_HOST", "localhost"),
+		port:     getEnv("DB_PORT", "5432"),
+		user:     getEnv("DB_USER", "postgres"),
+		password: getEnv("DB_PASSWORD", ""),
+		dbname:   getEnv("DB_NAME", "mellivora"),
+		sslmode:  getEnv("DB_SSLMODE", "require"),
+	}
+
+	db, err := newPool(primary, pool)
+	if err != nil {
+		return err
+	}
+	DB = db
+
+	if host := os.Getenv("DB_REPLICA_HOST"); host != "" {
+		replica := connSettings{
+			host:     host,
+			port:     getEnv("DB_REPLICA_PORT", primary.port),
+			user:     getEnv("DB_REPLICA_USER", primary.user),
+			password: getEnv("DB_REPLICA_PASSWORD", primary.password),
+			dbname:   getEnv("DB_REPLICA_NAME", primary.dbname),
+			sslmode:  getEnv("DB_REPLICA_SSLMODE", primary.sslmode),
+		}
+		rdb, err := newPool(replica, pool)
+		if err != nil {
+			return fmt.Errorf("replica: %w", err)
+		}
+		ReplicaDB = rdb
+	}
+	return nil
+}
+
+// connSettings identifies a Postgres server and database
+type connSettings struct {
+	host, port, user, password, dbname, sslmode string
+}
+
+// newPool opens and pings a connection pool
+func newPool(conn connSettings, pool PoolSettings) (*pgxpool.Pool, error) {
 	dsn := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		host, port, user, password, dbname, sslmode,
+		conn.host, conn.port, conn.user, conn.password, conn.dbname, conn.sslmode,
 	)
 
 	config, err := pgxpool.ParseConfig(dsn)
 	if err != nil {
-		return fmt.Errorf("failed to parse db config: %w", err)
+		return nil, fmt.Errorf("failed to parse db config: %w", err)
 	}
 
-	config.MaxConns = 20
-	config.MinConns = 5
+	config.MaxConns = pool.MaxConns
+	config.MinConns = pool.MinConns
+	config.MaxConnLifetime = pool.MaxConnLifetime
+	config.HealthCheckPeriod = pool.HealthCheckPeriod
+	config.ConnConfig.ConnectTimeout = pool.AcquireTimeout
 
-	pool, err := pgxpool.NewWithConfig(context.Background(), config)
+	db, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
-		return fmt.Errorf("failed to create db pool: %w", err)
+		return nil, fmt.Errorf("failed to create db pool: %w", err)
 	}
 
 	// Test connection
-	if err := pool.Ping(context.Background()); err != nil {
-		return fmt.Errorf("failed to ping db: %w", err)
+	if err := db.Ping(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping db: %w", err)
 	}
 
-	DB = pool
+	return db, nil
+}
+
+// primaryContextKey marks contexts whose reads must see the latest writes
+type primaryContextKey struct{}
+
+// WithPrimary returns a context whose repository reads go to the primary
+// even when a replica is configured. Use it for read-modify-write flows that
+// must not act on replica lag.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+// readDB returns the pool for read-only queries: the replica when one is
+// configured and the context does not require the primary
+func readDB(ctx context.Context) *pgxpool.Pool {
+	if ReplicaDB == nil || ctx.Value(primaryContextKey{}) != nil {
+		return DB
+	}
+	return ReplicaDB
+}
+
+// DefaultTenant owns the rows of requests that carry no tenant
+const DefaultTenant = "default"
+
+// tenantContextKey carries the tenant a request acts for
+type tenantContextKey struct{}
+
+// allTenantsContextKey marks contexts whose queries span every tenant
+type allTenantsContextKey struct{}
+
+// WithTenant returns a context whose repository queries only see and write
+// rows of the given tenant
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// WithAllTenants returns a context whose repository queries see the rows of
+// every tenant. New rows still belong to the context's tenant. Only admin
+// requests may use it.
+func WithAllTenants(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allTenantsContextKey{}, true)
+}
+
+// tenantOf returns the tenant new rows are created for
+func tenantOf(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantContextKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return DefaultTenant
+}
+
+// tenantFilter returns the tenant queries are scoped to, or nil for a
+// cross-tenant view. Pass it as a text parameter compared with
+// ($n::text IS NULL OR tenant_id = $n).
+func tenantFilter(ctx context.Context) *string {
+	if ctx.Value(allTenantsContextKey{}) != nil {
+		return nil
+	}
+	tenantID := tenantOf(ctx)
+	return &tenantID
+}
+
+// actorContextKey carries the user a request acts as
+type actorContextKey struct{}
+
+// WithActor returns a context whose repository writes record userID as the
+// user that created or last modified a resource
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, userID)
+}
+
+// actorOf returns the acting user, or nil for anonymous requests
+func actorOf(ctx context.Context) *string {
+	if userID, ok := ctx.Value(actorContextKey{}).(string); ok && userID != "" {
+		return &userID
+	}
 	return nil
 }
 
-// CloseDB closes the database connection pool
+// IsUniqueViolation reports whether err is a unique constraint violation,
+// e.g. a second resource with a name already taken in the tenant
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// CloseDB closes the database connection pools
 func CloseDB() {
+	if ReplicaDB != nil {
+		ReplicaDB.Close()
+	}
 	if DB != nil {
 		DB.Close()
 	}
@@ -92,3 +271,27 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt32(key string, defaultValue int32) (int32, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return int32(n), nil
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return d, nil
+}
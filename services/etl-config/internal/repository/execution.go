@@ -4,9 +4,14 @@ import (
 	"context"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 )
 
+// executionColumns is the column list read by scanExecution
+const executionColumns = `id, tenant_id, schedule_id, schedule_name, pipeline_id, pipeline_name, status, trigger, params,
+		       started_at, finished_at, duration, error_message, created_at`
+
 // ExecutionRepository handles execution database operations
 type ExecutionRepository struct{}
 
@@ -16,16 +21,18 @@ func NewExecutionRepository() *ExecutionRepository {
 }
 
 // List returns paginated executions
-func (r *ExecutionRepository) List(ctx context.Context, scheduleID, pipelineID, status string, page, pageSize int) ([]model.Execution, int, error) {
+func (r *ExecutionRepository) List(ctx context.Context, filter model.ExecutionFilter, sort api.Sort, page, pageSize int, withTotal bool) ([]model.Execution, int, error) {
 	query := `
-		SELECT id, schedule_id, schedule_name, pipeline_id, pipeline_name, status, trigger, params,
-		       started_at, finished_at, duration, error_message, created_at
+		SELECT ` + executionColumns + `
 		FROM etl_executions
 		WHERE ($1 = '' OR schedule_id::text = $1)
 		  AND ($2 = '' OR pipeline_id::text = $2)
 		  AND ($3 = '' OR status = $3::execution_status)
-		ORDER BY created_at DESC
-		LIMIT $4 OFFSET $5
+		  AND ($4::timestamptz IS NULL OR started_at >= $4)
+		  AND ($5::timestamptz IS NULL OR started_at <= $5)
+		  AND ($6::text IS NULL OR tenant_id = $6)
+		ORDER BY ` + ExecutionSortColumns.OrderBy(sort, "created_at DESC") + `
+		LIMIT $7 OFFSET $8
 	`
 
 	countQuery := `
@@ -33,11 +40,19 @@ func (r *ExecutionRepository) List(ctx context.Context, scheduleID, pipelineID,
 		WHERE ($1 = '' OR schedule_id::text = $1)
 		  AND ($2 = '' OR pipeline_id::text = $2)
 		  AND ($3 = '' OR status = $3::execution_status)
+		  AND ($4::timestamptz IS NULL OR started_at >= $4)
+		  AND ($5::timestamptz IS NULL OR started_at <= $5)
+		  AND ($6::text IS NULL OR tenant_id = $6)
 	`
 
+	args := []interface{}{
+		filter.ScheduleID, filter.PipelineID, filter.Status, filter.StartedAfter, filter.StartedBefore,
+		tenantFilter(ctx),
+	}
+
 	offset := (page - 1) * pageSize
 
-	rows, err := DB.Query(ctx, query, scheduleID, pipelineID, status, pageSize, offset)
+	rows, err := readDB(ctx).Query(ctx, query, append(args, api.PageLimit(pageSize, withTotal), offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -45,12 +60,7 @@ func (r *ExecutionRepository) List(ctx context.Context, scheduleID, pipelineID,
 
 	var executions []model.Execution
 	for rows.Next() {
-		var e model.Execution
-		err := rows.Scan(
-			&e.ID, &e.ScheduleID, &e.ScheduleName, &e.PipelineID, &e.PipelineName,
-			&e.Status, &e.Trigger, &e.Params,
-			&e.StartedAt, &e.FinishedAt, &e.Duration, &e.ErrorMessage, &e.CreatedAt,
-		)
+		e, err := scanExecution(rows)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -62,11 +72,15 @@ func (r *ExecutionRepository) List(ctx context.Context, scheduleID, pipelineID,
 		}
 		e.Tasks = tasks
 
-		executions = append(executions, e)
+		executions = append(executions, *e)
+	}
+
+	if !withTotal {
+		return executions, api.UnknownTotal, nil
 	}
 
 	var total int
-	err = DB.QueryRow(ctx, countQuery, scheduleID, pipelineID, status).Scan(&total)
+	err = readDB(ctx).QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -77,18 +91,12 @@ func (r *ExecutionRepository) List(ctx context.Context, scheduleID, pipelineID,
 // GetByID returns an execution by ID
 func (r *ExecutionRepository) GetByID(ctx context.Context, id string) (*model.Execution, error) {
 	query := `
-		SELECT id, schedule_id, schedule_name, pipeline_id, pipeline_name, status, trigger, params,
-		       started_at, finished_at, duration, error_message, created_at
+		SELECT ` + executionColumns + `
 		FROM etl_executions
-		WHERE id = $1
+		WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)
 	`
 
-	var e model.Execution
-	err := DB.QueryRow(ctx, query, id).Scan(
-		&e.ID, &e.ScheduleID, &e.ScheduleName, &e.PipelineID, &e.PipelineName,
-		&e.Status, &e.Trigger, &e.Params,
-		&e.StartedAt, &e.FinishedAt, &e.Duration, &e.ErrorMessage, &e.CreatedAt,
-	)
+	e, err := scanExecution(readDB(ctx).QueryRow(ctx, query, id, tenantFilter(ctx)))
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -103,7 +111,39 @@ func (r *ExecutionRepository) GetByID(ctx context.Context, id string) (*model.Ex
 	}
 	e.Tasks = tasks
 
-	return &e, nil
+	return e, nil
+}
+
+// CreateBatch queues a pending execution per seed in one transaction,
+// naming each after its schedule and pipeline. It returns the ID of each
+// seed's execution, or "" for a seed whose schedule or pipeline does not
+// exist in the tenant.
+func (r *ExecutionRepository) CreateBatch(ctx context.Context, seeds []model.ExecutionSeed) ([]string, error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO etl_executions (schedule_id, schedule_name, pipeline_id, pipeline_name, trigger, params, tenant_id)
+		SELECT s.id, s.name, p.id, p.name, $3::execution_trigger, $4, $5
+		FROM (SELECT $1::uuid AS schedule_id, $2::uuid AS pipeline_id) seed
+		LEFT JOIN etl_schedules s ON s.id = seed.schedule_id AND s.tenant_id = $5
+		LEFT JOIN etl_pipelines p ON p.id = seed.pipeline_id AND p.tenant_id = $5
+		WHERE (seed.schedule_id IS NULL OR s.id IS NOT NULL)
+		  AND (seed.pipeline_id IS NULL OR p.id IS NOT NULL)
+		RETURNING id
+	`
+
+	ids := make([]string, len(seeds))
+	for i, seed := range seeds {
+		err := tx.QueryRow(ctx, query, seed.ScheduleID, seed.PipelineID, seed.Trigger, seed.Params, tenantOf(ctx)).Scan(&ids[i])
+		if err != nil && err != pgx.ErrNoRows {
+			return nil, err
+		}
+	}
+	return ids, tx.Commit(ctx)
 }
 
 // GetTasks returns tasks for an execution
@@ -115,7 +155,7 @@ func (r *ExecutionRepository) GetTasks(ctx context.Context, executionID string)
 		ORDER BY created_at
 	`
 
-	rows, err := DB.Query(ctx, query, executionID)
+	rows, err := readDB(ctx).Query(ctx, query, executionID)
 	if err != nil {
 		return nil, err
 	}
@@ -137,31 +177,90 @@ func (r *ExecutionRepository) GetTasks(ctx context.Context, executionID string)
 	return tasks, nil
 }
 
-// GetLogs returns logs for an execution
-func (r *ExecutionRepository) GetLogs(ctx context.Context, executionID string, taskID, level string) ([]string, error) {
+// GetLogs returns logs for an execution in the order they were written.
+// A line repeated with the same time, level and task, as written by an
+// executor retrying a log write, is returned once. The structured fields
+// are only read when withFields is set.
+func (r *ExecutionRepository) GetLogs(ctx context.Context, executionID string, taskID, level string, withFields bool) ([]model.ExecutionLog, error) {
 	query := `
-		SELECT message FROM etl_execution_logs
-		WHERE execution_id = $1
-		  AND ($2 = '' OR task_id::text = $2)
-		  AND ($3 = '' OR level = $3)
-		ORDER BY created_at
+		SELECT l.id, l.created_at, l.level, l.task_id::text, l.message,
+		       CASE WHEN $5 THEN l.metadata END
+		FROM etl_execution_logs l
+		JOIN etl_executions e ON e.id = l.execution_id
+		WHERE l.execution_id = $1
+		  AND ($2 = '' OR l.task_id::text = $2)
+		  AND ($3 = '' OR l.level = $3)
+		  AND ($4::text IS NULL OR e.tenant_id = $4)
+		ORDER BY l.created_at, l.id
 		LIMIT 1000
 	`
 
-	rows, err := DB.Query(ctx, query, executionID, taskID, level)
+	rows, err := readDB(ctx).Query(ctx, query, executionID, taskID, level, tenantFilter(ctx), withFields)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var logs []string
+	var logs []model.ExecutionLog
 	for rows.Next() {
-		var msg string
-		if err := rows.Scan(&msg); err != nil {
+		var l model.ExecutionLog
+		if err := rows.Scan(&l.ID, &l.Timestamp, &l.Level, &l.TaskID, &l.Message, &l.Fields); err != nil {
 			return nil, err
 		}
-		logs = append(logs, msg)
+		if n := len(logs); n > 0 && sameLogLine(&logs[n-1], &l) {
+			continue
+		}
+		logs = append(logs, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
 	return logs, nil
 }
+
+// sameLogLine reports whether two log rows are copies of the same line
+func sameLogLine(a, b *model.ExecutionLog) bool {
+	return a.Timestamp.Equal(b.Timestamp) && a.Level == b.Level && a.Message == b.Message &&
+		(a.TaskID == nil) == (b.TaskID == nil) && (a.TaskID == nil || *a.TaskID == *b.TaskID)
+}
+
+// scanExecution scans a row selected with executionColumns
+func scanExecution(row pgx.Row) (*model.Execution, error) {
+	var e model.Execution
+	err := row.Scan(
+		&e.ID, &e.TenantID, &e.ScheduleID, &e.ScheduleName, &e.PipelineID, &e.PipelineName,
+		&e.Status, &e.Trigger, &e.Params,
+		&e.StartedAt, &e.FinishedAt, &e.Duration, &e.ErrorMessage, &e.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// QueueStats counts pending and running executions and those that failed
+// in the last hour (by creation time), and measures the age of the oldest
+// pending and the duration of the longest running execution
+func (r *ExecutionRepository) QueueStats(ctx context.Context) (*model.ExecutionQueueStats, error) {
+	query := `
+		SELECT COUNT(*) FILTER (WHERE status = 'pending'),
+		       COUNT(*) FILTER (WHERE status = 'running'),
+		       COUNT(*) FILTER (WHERE status = 'failed'),
+		       (EXTRACT(EPOCH FROM NOW() - MIN(created_at) FILTER (WHERE status = 'pending')) * 1000)::bigint,
+		       (EXTRACT(EPOCH FROM NOW() - MIN(COALESCE(started_at, created_at)) FILTER (WHERE status = 'running')) * 1000)::bigint
+		FROM etl_executions
+		WHERE (status IN ('pending', 'running')
+		       OR (status = 'failed' AND created_at >= NOW() - INTERVAL '1 hour'))
+		  AND ($1::text IS NULL OR tenant_id = $1)
+	`
+
+	var s model.ExecutionQueueStats
+	err := readDB(ctx).QueryRow(ctx, query, tenantFilter(ctx)).Scan(
+		&s.Pending, &s.Running, &s.FailedLastHour, &s.OldestPendingAgeMs, &s.LongestRunningMs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
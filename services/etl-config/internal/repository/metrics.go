@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// FinishedExecution is an execution that reached a final status
+type FinishedExecution struct {
+	ID         string
+	Status     string
+	Duration   *int64 // milliseconds
+	FinishedAt time.Time
+}
+
+// MetricsRepository reads the state behind the domain metrics. Its queries
+// span every tenant.
+type MetricsRepository struct{}
+
+// NewMetricsRepository creates a new MetricsRepository
+func NewMetricsRepository() *MetricsRepository {
+	return &MetricsRepository{}
+}
+
+// Counts returns the number of enabled schedules that are not archived and
+// of data sources in the error state
+func (r *MetricsRepository) Counts(ctx context.Context) (enabledSchedules, erroredDataSources int, err error) {
+	query := `
+		SELECT (SELECT COUNT(*) FROM etl_schedules WHERE enabled AND archived_at IS NULL),
+		       (SELECT COUNT(*) FROM etl_datasources WHERE status = 'error')
+	`
+	err = readDB(ctx).QueryRow(ctx, query).Scan(&enabledSchedules, &erroredDataSources)
+	return enabledSchedules, erroredDataSources, err
+}
+
+// ListFinishedAfter returns up to limit executions that finished after the
+// cursor (finishedAt, id), oldest first, so a caller can page through them
+// as they finish
+func (r *MetricsRepository) ListFinishedAfter(ctx context.Context, finishedAt time.Time, id string, limit int) ([]FinishedExecution, error) {
+	query := `
+		SELECT id, status::text, duration, finished_at
+		FROM etl_executions
+		WHERE finished_at IS NOT NULL AND (finished_at, id::text) > ($1, $2)
+		ORDER BY finished_at, id
+		LIMIT $3
+	`
+
+	rows, err := readDB(ctx).Query(ctx, query, finishedAt, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var executions []FinishedExecution
+	for rows.Next() {
+		var e FinishedExecution
+		if err := rows.Scan(&e.ID, &e.Status, &e.Duration, &e.FinishedAt); err != nil {
+			return nil, err
+		}
+		executions = append(executions, e)
+	}
+	return executions, rows.Err()
+}
@@ -2,11 +2,24 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 )
 
+// pipelineColumns is the column list read by scanPipeline
+const pipelineColumns = `id, tenant_id, name, version, description, trigger, parameters, steps, tags, status,
+		       created_by, updated_by, created_at, updated_at`
+
+// pipelineSummaryColumns is pipelineColumns with the parameters and steps
+// selected as NULL, followed by their sizes in bytes; read by
+// scanPipelineSummary
+const pipelineSummaryColumns = `id, tenant_id, name, version, description, trigger, NULL::jsonb, NULL::jsonb, tags, status,
+		       created_by, updated_by, created_at, updated_at,
+		       COALESCE(octet_length(parameters::text), 0), octet_length(steps::text)`
+
 // PipelineRepository handles pipeline database operations
 type PipelineRepository struct{}
 
@@ -16,23 +29,34 @@ func NewPipelineRepository() *PipelineRepository {
 }
 
 // List returns paginated pipelines
-func (r *PipelineRepository) List(ctx context.Context, status string, page, pageSize int) ([]model.Pipeline, int, error) {
+func (r *PipelineRepository) List(ctx context.Context, filter model.PipelineFilter, sort api.Sort, page, pageSize int, withTotal bool) ([]model.Pipeline, int, error) {
+	columns, scan := pipelineColumns, scanPipeline
+	if filter.Summary {
+		columns, scan = pipelineSummaryColumns, scanPipelineSummary
+	}
+
 	query := `
-		SELECT id, name, version, description, trigger, parameters, steps, status, created_at, updated_at
+		SELECT ` + columns + `
 		FROM etl_pipelines
 		WHERE ($1 = '' OR status = $1::pipeline_status)
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		  AND ($2::text IS NULL OR tenant_id = $2)
+		  AND ($3 = '' OR created_by = $3)
+		ORDER BY ` + PipelineSortColumns.OrderBy(sort, "created_at DESC") + `
+		LIMIT $4 OFFSET $5
 	`
 
 	countQuery := `
 		SELECT COUNT(*) FROM etl_pipelines
 		WHERE ($1 = '' OR status = $1::pipeline_status)
+		  AND ($2::text IS NULL OR tenant_id = $2)
+		  AND ($3 = '' OR created_by = $3)
 	`
 
+	args := []interface{}{filter.Status, tenantFilter(ctx), filter.CreatedBy}
+
 	offset := (page - 1) * pageSize
 
-	rows, err := DB.Query(ctx, query, status, pageSize, offset)
+	rows, err := readDB(ctx).Query(ctx, query, append(args, api.PageLimit(pageSize, withTotal), offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -40,20 +64,19 @@ func (r *PipelineRepository) List(ctx context.Context, status string, page, page
 
 	var pipelines []model.Pipeline
 	for rows.Next() {
-		var p model.Pipeline
-		err := rows.Scan(
-			&p.ID, &p.Name, &p.Version, &p.Description,
-			&p.Trigger, &p.Parameters, &p.Steps, &p.Status,
-			&p.CreatedAt, &p.UpdatedAt,
-		)
+		p, err := scan(rows)
 		if err != nil {
 			return nil, 0, err
 		}
-		pipelines = append(pipelines, p)
+		pipelines = append(pipelines, *p)
+	}
+
+	if !withTotal {
+		return pipelines, api.UnknownTotal, nil
 	}
 
 	var total int
-	err = DB.QueryRow(ctx, countQuery, status).Scan(&total)
+	err = readDB(ctx).QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -64,17 +87,12 @@ func (r *PipelineRepository) List(ctx context.Context, status string, page, page
 // GetByID returns a pipeline by ID
 func (r *PipelineRepository) GetByID(ctx context.Context, id string) (*model.Pipeline, error) {
 	query := `
-		SELECT id, name, version, description, trigger, parameters, steps, status, created_at, updated_at
+		SELECT ` + pipelineColumns + `
 		FROM etl_pipelines
-		WHERE id = $1
+		WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)
 	`
 
-	var p model.Pipeline
-	err := DB.QueryRow(ctx, query, id).Scan(
-		&p.ID, &p.Name, &p.Version, &p.Description,
-		&p.Trigger, &p.Parameters, &p.Steps, &p.Status,
-		&p.CreatedAt, &p.UpdatedAt,
-	)
+	p, err := scanPipeline(readDB(ctx).QueryRow(ctx, query, id, tenantFilter(ctx)))
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -82,64 +100,206 @@ func (r *PipelineRepository) GetByID(ctx context.Context, id string) (*model.Pip
 		return nil, err
 	}
 
-	return &p, nil
+	return p, nil
 }
 
-// Create creates a new pipeline
-func (r *PipelineRepository) Create(ctx context.Context, p *model.Pipeline) (*model.Pipeline, error) {
+// ListMentioning returns the pipelines whose trigger or steps contain the
+// text s anywhere, e.g. the ID of a data source. It is a coarse prefilter:
+// callers parse the definitions to find the actual references.
+func (r *PipelineRepository) ListMentioning(ctx context.Context, s string) ([]model.Pipeline, error) {
 	query := `
-		INSERT INTO etl_pipelines (name, description, trigger, parameters, steps, status)
-		VALUES ($1, $2, $3, $4, $5, $6::pipeline_status)
-		RETURNING id, name, version, description, trigger, parameters, steps, status, created_at, updated_at
+		SELECT ` + pipelineColumns + `
+		FROM etl_pipelines
+		WHERE ($2::text IS NULL OR tenant_id = $2)
+		  AND (strpos(steps::text, $1) > 0 OR strpos(trigger::text, $1) > 0)
+		ORDER BY name
 	`
 
-	status := p.Status
-	if status == "" {
-		status = "draft"
-	}
-
-	var result model.Pipeline
-	err := DB.QueryRow(ctx, query,
-		p.Name, p.Description, p.Trigger, p.Parameters, p.Steps, status,
-	).Scan(
-		&result.ID, &result.Name, &result.Version, &result.Description,
-		&result.Trigger, &result.Parameters, &result.Steps, &result.Status,
-		&result.CreatedAt, &result.UpdatedAt,
-	)
+	rows, err := readDB(ctx).Query(ctx, query, s, tenantFilter(ctx))
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &result, nil
+	var pipelines []model.Pipeline
+	for rows.Next() {
+		p, err := scanPipeline(rows)
+		if err != nil {
+			return nil, err
+		}
+		pipelines = append(pipelines, *p)
+	}
+	return pipelines, rows.Err()
+}
+
+// Create creates a new pipeline
+func (r *PipelineRepository) Create(ctx context.Context, form *model.PipelineForm) (*model.Pipeline, error) {
+	query := `
+		INSERT INTO etl_pipelines (name, description, trigger, parameters, steps, tags, tenant_id, created_by, updated_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING ` + pipelineColumns
+
+	trigger, parameters, steps := pipelineFormJSON(form)
+
+	var p *model.Pipeline
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		p, err = scanPipeline(tx.QueryRow(ctx, query,
+			form.Name, form.Description, trigger, parameters, steps, pipelineTags(form), tenantOf(ctx), actorOf(ctx),
+		))
+		return err
+	})
+	return p, err
+}
+
+// Update updates a pipeline and bumps its version. It returns nil when the
+// pipeline does not exist.
+func (r *PipelineRepository) Update(ctx context.Context, id string, form *model.PipelineForm) (*model.Pipeline, error) {
+	return r.update(ctx, id, nil, form)
+}
+
+// UpdateAtVersion updates a pipeline only if it is still at version, and
+// bumps its version. It returns nil when the pipeline does not exist or has
+// moved on to another version.
+func (r *PipelineRepository) UpdateAtVersion(ctx context.Context, id string, version int, form *model.PipelineForm) (*model.Pipeline, error) {
+	return r.update(ctx, id, &version, form)
+}
+
+func (r *PipelineRepository) update(ctx context.Context, id string, version *int, form *model.PipelineForm) (*model.Pipeline, error) {
+	query := `
+		UPDATE etl_pipelines
+		SET description = $2, trigger = $3, parameters = $4, steps = $5, tags = $8, version = version + 1,
+		    updated_by = $7
+		WHERE id = $1 AND ($6::text IS NULL OR tenant_id = $6)
+		  AND ($9::int IS NULL OR version = $9)
+		RETURNING ` + pipelineColumns
+
+	trigger, parameters, steps := pipelineFormJSON(form)
+
+	var p *model.Pipeline
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		p, err = scanPipeline(tx.QueryRow(ctx, query,
+			id, form.Description, trigger, parameters, steps, tenantFilter(ctx), actorOf(ctx), pipelineTags(form), version,
+		))
+		return err
+	})
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
 }
 
-// Update updates a pipeline
-func (r *PipelineRepository) Update(ctx context.Context, id string, p *model.Pipeline) (*model.Pipeline, error) {
+// SetStatus moves a pipeline from status from to status to. It returns nil
+// when the pipeline does not exist or is no longer in status from.
+func (r *PipelineRepository) SetStatus(ctx context.Context, id, from, to string) (*model.Pipeline, error) {
 	query := `
 		UPDATE etl_pipelines
-		SET description = $2, trigger = $3, parameters = $4, steps = $5, status = $6::pipeline_status
-		WHERE id = $1
-		RETURNING id, name, version, description, trigger, parameters, steps, status, created_at, updated_at
+		SET status = $3::pipeline_status, updated_by = $5
+		WHERE id = $1 AND ($4::text IS NULL OR tenant_id = $4)
+		  AND status = $2::pipeline_status
+		RETURNING ` + pipelineColumns
+
+	var p *model.Pipeline
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		p, err = scanPipeline(tx.QueryRow(ctx, query, id, from, to, tenantFilter(ctx), actorOf(ctx)))
+		return err
+	})
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// pipelineFormJSON returns the form's JSON fields with column defaults applied
+func pipelineFormJSON(form *model.PipelineForm) (trigger, parameters, steps json.RawMessage) {
+	trigger = form.Trigger
+	if trigger == nil {
+		trigger = json.RawMessage(`{"type": "manual"}`)
+	}
+	parameters = form.Parameters
+	if parameters == nil {
+		parameters = json.RawMessage(`[]`)
+	}
+	steps = form.Steps
+	if steps == nil {
+		steps = json.RawMessage(`[]`)
+	}
+	return trigger, parameters, steps
+}
+
+// pipelineTags returns the form's tags, never nil so the column stays an
+// empty array
+func pipelineTags(form *model.PipelineForm) []string {
+	if form.Tags == nil {
+		return []string{}
+	}
+	return form.Tags
+}
+
+// ListTagged returns the pipelines among ids that carry tag
+func (r *PipelineRepository) ListTagged(ctx context.Context, ids []string, tag string) ([]model.Pipeline, error) {
+	query := `
+		SELECT ` + pipelineColumns + `
+		FROM etl_pipelines
+		WHERE id = ANY($1) AND $2 = ANY(tags) AND ($3::text IS NULL OR tenant_id = $3)
+		ORDER BY name
 	`
 
-	var result model.Pipeline
-	err := DB.QueryRow(ctx, query,
-		id, p.Description, p.Trigger, p.Parameters, p.Steps, p.Status,
-	).Scan(
-		&result.ID, &result.Name, &result.Version, &result.Description,
-		&result.Trigger, &result.Parameters, &result.Steps, &result.Status,
-		&result.CreatedAt, &result.UpdatedAt,
-	)
+	rows, err := readDB(ctx).Query(ctx, query, ids, tag, tenantFilter(ctx))
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return &result, nil
+	var pipelines []model.Pipeline
+	for rows.Next() {
+		p, err := scanPipeline(rows)
+		if err != nil {
+			return nil, err
+		}
+		pipelines = append(pipelines, *p)
+	}
+	return pipelines, rows.Err()
+}
+
+// PipelineDefaults returns a copy of form with the defaults applied on
+// save, so it compares equal to the pipeline it would store
+func PipelineDefaults(form *model.PipelineForm) model.PipelineForm {
+	out := *form
+	out.Trigger, out.Parameters, out.Steps = pipelineFormJSON(form)
+	out.Tags = pipelineTags(form)
+	return out
 }
 
 // Delete deletes a pipeline
 func (r *PipelineRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM etl_pipelines WHERE id = $1`
-	_, err := DB.Exec(ctx, query, id)
-	return err
+	query := `DELETE FROM etl_pipelines WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)`
+	return audited(ctx, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, query, id, tenantFilter(ctx))
+		return err
+	})
+}
+
+// scanPipeline scans a row selected with pipelineColumns
+func scanPipeline(row pgx.Row) (*model.Pipeline, error) {
+	var p model.Pipeline
+	err := row.Scan(
+		&p.ID, &p.TenantID, &p.Name, &p.Version, &p.Description,
+		&p.Trigger, &p.Parameters, &p.Steps, &p.Tags, &p.Status,
+		&p.CreatedBy, &p.UpdatedBy, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// scanPipelineSummary scans a row selected with pipelineSummaryColumns
+func scanPipelineSummary(row pgx.Row) (*model.Pipeline, error) {
+	var parametersSize, stepsSize int
+	p, err := scanPipeline(extraColumns{row, []interface{}{&parametersSize, &stepsSize}})
+	if err != nil {
+		return nil, err
+	}
+	p.Sizes = map[string]int{"parameters": parametersSize, "steps": stepsSize}
+	return p, nil
 }
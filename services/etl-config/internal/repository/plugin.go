@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 )
 
@@ -24,7 +25,7 @@ func (r *PluginRepository) List(ctx context.Context, pluginType string) ([]model
 		ORDER BY type, display_name
 	`
 
-	rows, err := DB.Query(ctx, query, pluginType)
+	rows, err := readDB(ctx).Query(ctx, query, pluginType)
 	if err != nil {
 		return nil, err
 	}
@@ -55,10 +56,13 @@ func (r *PluginRepository) GetByName(ctx context.Context, name string) (*model.P
 	`
 
 	var p model.Plugin
-	err := DB.QueryRow(ctx, query, name).Scan(
+	err := readDB(ctx).QueryRow(ctx, query, name).Scan(
 		&p.ID, &p.Name, &p.Type, &p.DisplayName, &p.Description,
 		&p.Version, &p.ConfigSchema, &p.Capabilities, &p.Enabled,
 	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
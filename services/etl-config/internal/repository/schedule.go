@@ -2,11 +2,20 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/cron"
 	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
 )
 
+// scheduleColumns is the column list read by scanSchedule
+const scheduleColumns = `id, tenant_id, name, description, cron_expr, timezone, enabled, active_from, active_until,
+		       allow_frequent, dag,
+		       last_run_at, next_run_at, archived_at, created_at, updated_at`
+
 // ScheduleRepository handles schedule database operations
 type ScheduleRepository struct{}
 
@@ -16,23 +25,35 @@ func NewScheduleRepository() *ScheduleRepository {
 }
 
 // List returns paginated schedules
-func (r *ScheduleRepository) List(ctx context.Context, enabled *bool, page, pageSize int) ([]model.Schedule, int, error) {
+func (r *ScheduleRepository) List(ctx context.Context, filter model.ScheduleFilter, sort api.Sort, page, pageSize int, withTotal bool) ([]model.Schedule, int, error) {
 	query := `
-		SELECT id, name, description, cron_expr, timezone, enabled, dag, last_run_at, next_run_at, created_at, updated_at
+		SELECT ` + scheduleColumns + `
 		FROM etl_schedules
 		WHERE ($1::boolean IS NULL OR enabled = $1)
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		  AND ($2::text IS NULL OR tenant_id = $2)
+		  AND ($3 OR archived_at IS NULL)
+		  AND (NOT $4 OR (enabled AND archived_at IS NULL
+		       AND (active_from IS NULL OR active_from <= NOW())
+		       AND (active_until IS NULL OR active_until > NOW())))
+		ORDER BY ` + ScheduleSortColumns.OrderBy(sort, "created_at DESC") + `
+		LIMIT $5 OFFSET $6
 	`
 
 	countQuery := `
 		SELECT COUNT(*) FROM etl_schedules
 		WHERE ($1::boolean IS NULL OR enabled = $1)
+		  AND ($2::text IS NULL OR tenant_id = $2)
+		  AND ($3 OR archived_at IS NULL)
+		  AND (NOT $4 OR (enabled AND archived_at IS NULL
+		       AND (active_from IS NULL OR active_from <= NOW())
+		       AND (active_until IS NULL OR active_until > NOW())))
 	`
 
+	args := []interface{}{filter.Enabled, tenantFilter(ctx), filter.IncludeArchived, filter.ActiveNow}
+
 	offset := (page - 1) * pageSize
 
-	rows, err := DB.Query(ctx, query, enabled, pageSize, offset)
+	rows, err := readDB(ctx).Query(ctx, query, append(args, api.PageLimit(pageSize, withTotal), offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -40,20 +61,19 @@ func (r *ScheduleRepository) List(ctx context.Context, enabled *bool, page, page
 
 	var schedules []model.Schedule
 	for rows.Next() {
-		var s model.Schedule
-		err := rows.Scan(
-			&s.ID, &s.Name, &s.Description, &s.CronExpr, &s.Timezone,
-			&s.Enabled, &s.DAG, &s.LastRunAt, &s.NextRunAt,
-			&s.CreatedAt, &s.UpdatedAt,
-		)
+		s, err := scanSchedule(rows)
 		if err != nil {
 			return nil, 0, err
 		}
-		schedules = append(schedules, s)
+		schedules = append(schedules, *s)
+	}
+
+	if !withTotal {
+		return schedules, api.UnknownTotal, nil
 	}
 
 	var total int
-	err = DB.QueryRow(ctx, countQuery, enabled).Scan(&total)
+	err = readDB(ctx).QueryRow(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -64,17 +84,12 @@ func (r *ScheduleRepository) List(ctx context.Context, enabled *bool, page, page
 // GetByID returns a schedule by ID
 func (r *ScheduleRepository) GetByID(ctx context.Context, id string) (*model.Schedule, error) {
 	query := `
-		SELECT id, name, description, cron_expr, timezone, enabled, dag, last_run_at, next_run_at, created_at, updated_at
+		SELECT ` + scheduleColumns + `
 		FROM etl_schedules
-		WHERE id = $1
+		WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)
 	`
 
-	var s model.Schedule
-	err := DB.QueryRow(ctx, query, id).Scan(
-		&s.ID, &s.Name, &s.Description, &s.CronExpr, &s.Timezone,
-		&s.Enabled, &s.DAG, &s.LastRunAt, &s.NextRunAt,
-		&s.CreatedAt, &s.UpdatedAt,
-	)
+	s, err := scanSchedule(readDB(ctx).QueryRow(ctx, query, id, tenantFilter(ctx)))
 	if err == pgx.ErrNoRows {
 		return nil, nil
 	}
@@ -82,80 +97,270 @@ func (r *ScheduleRepository) GetByID(ctx context.Context, id string) (*model.Sch
 		return nil, err
 	}
 
-	return &s, nil
+	return s, nil
 }
 
-// Create creates a new schedule
-func (r *ScheduleRepository) Create(ctx context.Context, s *model.Schedule) (*model.Schedule, error) {
+// NextRunAt returns the first fire time of a schedule from now on within
+// its active window, or nil when it never fires again or its cron
+// expression or timezone cannot be read
+func NextRunAt(cronExpr, timezone string, activeFrom, activeUntil *time.Time) *time.Time {
+	expr, err := cron.Parse(cronExpr)
+	if err != nil {
+		return nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil
+	}
+	runs := expr.Upcoming(time.Now().In(loc), 1, activeFrom, activeUntil)
+	if len(runs) == 0 {
+		return nil
+	}
+	return &runs[0]
+}
+
+// formNextRunAt returns the next run of a schedule form, or nil when the
+// form disables it
+func formNextRunAt(form *model.ScheduleForm) *time.Time {
+	if !form.Enabled {
+		return nil
+	}
+	return NextRunAt(form.CronExpr, form.Timezone, form.ActiveFrom, form.ActiveUntil)
+}
+
+// Create creates a new schedule, with its next run when enabled
+func (r *ScheduleRepository) Create(ctx context.Context, form *model.ScheduleForm) (*model.Schedule, error) {
 	query := `
-		INSERT INTO etl_schedules (name, description, cron_expr, timezone, enabled, dag)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, name, description, cron_expr, timezone, enabled, dag, last_run_at, next_run_at, created_at, updated_at
-	`
+		INSERT INTO etl_schedules (name, description, cron_expr, timezone, enabled, active_from, active_until, dag, tenant_id,
+		                           allow_frequent, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE($10, false), $11)
+		RETURNING ` + scheduleColumns
 
-	var result model.Schedule
-	err := DB.QueryRow(ctx, query,
-		s.Name, s.Description, s.CronExpr, s.Timezone, s.Enabled, s.DAG,
-	).Scan(
-		&result.ID, &result.Name, &result.Description, &result.CronExpr, &result.Timezone,
-		&result.Enabled, &result.DAG, &result.LastRunAt, &result.NextRunAt,
-		&result.CreatedAt, &result.UpdatedAt,
-	)
+	dagJSON := form.DAG
+	if dagJSON == nil {
+		dagJSON = json.RawMessage(`[]`)
+	}
+
+	var s *model.Schedule
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		s, err = scanSchedule(tx.QueryRow(ctx, query,
+			form.Name, form.Description, form.CronExpr, form.Timezone, form.Enabled, form.ActiveFrom, form.ActiveUntil,
+			dagJSON, tenantOf(ctx), form.AllowFrequent, formNextRunAt(form),
+		))
+		return err
+	})
+	return s, err
+}
+
+// Upsert creates a schedule, or updates the schedule of the same name in
+// the tenant. A schedule whose definition already matches the form is left
+// untouched. It reports whether the schedule was created.
+func (r *ScheduleRepository) Upsert(ctx context.Context, form *model.ScheduleForm) (*model.Schedule, bool, error) {
+	query := `
+		INSERT INTO etl_schedules (name, description, cron_expr, timezone, enabled, active_from, active_until, dag, tenant_id,
+		                           allow_frequent, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, COALESCE($10, false), $11)
+		ON CONFLICT (tenant_id, name) DO UPDATE
+		SET description = EXCLUDED.description, cron_expr = EXCLUDED.cron_expr, timezone = EXCLUDED.timezone,
+		    enabled = (EXCLUDED.enabled AND etl_schedules.archived_at IS NULL),
+		    active_from = EXCLUDED.active_from, active_until = EXCLUDED.active_until, dag = EXCLUDED.dag,
+		    allow_frequent = COALESCE($10, etl_schedules.allow_frequent),
+		    next_run_at = CASE WHEN etl_schedules.archived_at IS NULL THEN EXCLUDED.next_run_at END
+		WHERE (etl_schedules.description, etl_schedules.cron_expr, etl_schedules.timezone,
+		       etl_schedules.enabled, etl_schedules.active_from, etl_schedules.active_until, etl_schedules.dag,
+		       etl_schedules.allow_frequent)
+		      IS DISTINCT FROM (EXCLUDED.description, EXCLUDED.cron_expr, EXCLUDED.timezone,
+		       EXCLUDED.enabled AND etl_schedules.archived_at IS NULL, EXCLUDED.active_from, EXCLUDED.active_until,
+		       EXCLUDED.dag, COALESCE($10, etl_schedules.allow_frequent))
+		RETURNING ` + scheduleColumns + `, xmax = 0`
+
+	dagJSON := form.DAG
+	if dagJSON == nil {
+		dagJSON = json.RawMessage(`[]`)
+	}
+
+	var s *model.Schedule
+	var created bool
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		row := tx.QueryRow(ctx, query,
+			form.Name, form.Description, form.CronExpr, form.Timezone, form.Enabled, form.ActiveFrom, form.ActiveUntil,
+			dagJSON, tenantOf(ctx), form.AllowFrequent, formNextRunAt(form),
+		)
+		s, err = scanSchedule(extraColumns{row, []interface{}{&created}})
+		return err
+	})
+	if err == pgx.ErrNoRows {
+		// The conflict update was skipped: the definition is unchanged
+		s, err = r.GetByName(ctx, form.Name)
+		return s, false, err
+	}
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
+	return s, created, nil
+}
 
-	return &result, nil
+// GetByName returns the schedule of a name in the context's own tenant, or
+// nil if there is none
+func (r *ScheduleRepository) GetByName(ctx context.Context, name string) (*model.Schedule, error) {
+	query := `
+		SELECT ` + scheduleColumns + `
+		FROM etl_schedules
+		WHERE name = $1 AND tenant_id = $2
+	`
+	s, err := scanSchedule(DB.QueryRow(ctx, query, name, tenantOf(ctx)))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
 }
 
-// Update updates a schedule
-func (r *ScheduleRepository) Update(ctx context.Context, id string, s *model.Schedule) (*model.Schedule, error) {
+// extraColumns scans columns selected after the ones a scan helper reads
+type extraColumns struct {
+	pgx.Row
+	dest []interface{}
+}
+
+func (r extraColumns) Scan(dest ...interface{}) error {
+	return r.Row.Scan(append(dest, r.dest...)...)
+}
+
+// Update updates a schedule and recomputes its next run; archived schedules
+// stay disabled. It returns nil when the schedule does not exist.
+func (r *ScheduleRepository) Update(ctx context.Context, id string, form *model.ScheduleForm) (*model.Schedule, error) {
 	query := `
 		UPDATE etl_schedules
-		SET name = $2, description = $3, cron_expr = $4, timezone = $5, enabled = $6, dag = $7
-		WHERE id = $1
-		RETURNING id, name, description, cron_expr, timezone, enabled, dag, last_run_at, next_run_at, created_at, updated_at
-	`
+		SET name = $2, description = $3, cron_expr = $4, timezone = $5, enabled = ($6 AND archived_at IS NULL),
+		    active_from = $7, active_until = $8, dag = $9, allow_frequent = COALESCE($11, allow_frequent),
+		    next_run_at = CASE WHEN archived_at IS NULL THEN $12::timestamptz END
+		WHERE id = $1 AND ($10::text IS NULL OR tenant_id = $10)
+		RETURNING ` + scheduleColumns
 
-	var result model.Schedule
-	err := DB.QueryRow(ctx, query,
-		id, s.Name, s.Description, s.CronExpr, s.Timezone, s.Enabled, s.DAG,
-	).Scan(
-		&result.ID, &result.Name, &result.Description, &result.CronExpr, &result.Timezone,
-		&result.Enabled, &result.DAG, &result.LastRunAt, &result.NextRunAt,
-		&result.CreatedAt, &result.UpdatedAt,
-	)
-	if err != nil {
-		return nil, err
+	dagJSON := form.DAG
+	if dagJSON == nil {
+		dagJSON = json.RawMessage(`[]`)
 	}
 
-	return &result, nil
+	var s *model.Schedule
+	err := audited(ctx, func(tx pgx.Tx) (err error) {
+		s, err = scanSchedule(tx.QueryRow(ctx, query,
+			id, form.Name, form.Description, form.CronExpr, form.Timezone, form.Enabled, form.ActiveFrom, form.ActiveUntil,
+			dagJSON, tenantFilter(ctx), form.AllowFrequent, formNextRunAt(form),
+		))
+		return err
+	})
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	return s, err
 }
 
-// Delete deletes a schedule
-func (r *ScheduleRepository) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM etl_schedules WHERE id = $1`
-	_, err := DB.Exec(ctx, query, id)
-	return err
+// Delete removes a schedule. A schedule that executions reference is
+// archived instead: it is disabled and never runs again, but its history
+// stays attached. With force the schedule and its executions are deleted
+// regardless. It reports whether the schedule was archived.
+func (r *ScheduleRepository) Delete(ctx context.Context, id string, force bool) (archived bool, err error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+	if err := setAuditActor(ctx, tx); err != nil {
+		return false, err
+	}
+
+	tenant := tenantFilter(ctx)
+
+	// Lock the schedule so no execution is recorded against it meanwhile
+	lockQuery := `SELECT 1 FROM etl_schedules WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2) FOR UPDATE`
+	var found int
+	if err := tx.QueryRow(ctx, lockQuery, id, tenant).Scan(&found); err != nil {
+		if err == pgx.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if force {
+		if _, err := tx.Exec(ctx, `DELETE FROM etl_executions WHERE schedule_id = $1`, id); err != nil {
+			return false, err
+		}
+	} else {
+		var hasHistory bool
+		historyQuery := `SELECT EXISTS (SELECT 1 FROM etl_executions WHERE schedule_id = $1)`
+		if err := tx.QueryRow(ctx, historyQuery, id).Scan(&hasHistory); err != nil {
+			return false, err
+		}
+		if hasHistory {
+			archiveQuery := `
+				UPDATE etl_schedules
+				SET archived_at = COALESCE(archived_at, NOW()), enabled = false, next_run_at = NULL
+				WHERE id = $1
+			`
+			if _, err := tx.Exec(ctx, archiveQuery, id); err != nil {
+				return false, err
+			}
+			return true, tx.Commit(ctx)
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM etl_schedules WHERE id = $1`, id); err != nil {
+		return false, err
+	}
+	return false, tx.Commit(ctx)
 }
 
-// SetEnabled enables or disables a schedule
+// SetEnabled enables or disables a schedule, computing its next run when
+// enabled and clearing it when disabled. It returns nil when the schedule
+// does not exist.
 func (r *ScheduleRepository) SetEnabled(ctx context.Context, id string, enabled bool) (*model.Schedule, error) {
+	tx, err := DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+	if err := setAuditActor(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	lockQuery := `
+		SELECT ` + scheduleColumns + `
+		FROM etl_schedules
+		WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)
+		FOR UPDATE
+	`
+	s, err := scanSchedule(tx.QueryRow(ctx, lockQuery, id, tenantFilter(ctx)))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var next *time.Time
+	if enabled {
+		next = NextRunAt(s.CronExpr, s.Timezone, s.ActiveFrom, s.ActiveUntil)
+	}
 	query := `
-		UPDATE etl_schedules SET enabled = $2
+		UPDATE etl_schedules SET enabled = $2, next_run_at = $3
 		WHERE id = $1
-		RETURNING id, name, description, cron_expr, timezone, enabled, dag, last_run_at, next_run_at, created_at, updated_at
-	`
+		RETURNING ` + scheduleColumns
+	if s, err = scanSchedule(tx.QueryRow(ctx, query, id, enabled, next)); err != nil {
+		return nil, err
+	}
+	return s, tx.Commit(ctx)
+}
 
-	var result model.Schedule
-	err := DB.QueryRow(ctx, query, id, enabled).Scan(
-		&result.ID, &result.Name, &result.Description, &result.CronExpr, &result.Timezone,
-		&result.Enabled, &result.DAG, &result.LastRunAt, &result.NextRunAt,
-		&result.CreatedAt, &result.UpdatedAt,
+// scanSchedule scans a row selected with scheduleColumns
+func scanSchedule(row pgx.Row) (*model.Schedule, error) {
+	var s model.Schedule
+	err := row.Scan(
+		&s.ID, &s.TenantID, &s.Name, &s.Description, &s.CronExpr, &s.Timezone,
+		&s.Enabled, &s.ActiveFrom, &s.ActiveUntil, &s.AllowFrequent, &s.DAG, &s.LastRunAt, &s.NextRunAt, &s.ArchivedAt,
+		&s.CreatedAt, &s.UpdatedAt,
 	)
 	if err != nil {
 		return nil, err
 	}
-
-	return &result, nil
+	return &s, nil
 }
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNextRunAt(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(48 * time.Hour)
+
+	tests := []struct {
+		name                    string
+		expr, tz                string
+		activeFrom, activeUntil *time.Time
+		wantNil                 bool
+	}{
+		{"daily", "0 9 * * *", "America/New_York", nil, nil, false},
+		{"with seconds", "30 0 9 * * *", "Asia/Shanghai", nil, nil, false},
+		{"window ended", "0 9 * * *", "UTC", nil, &past, true},
+		{"window ahead", "0 9 * * *", "UTC", &future, nil, false},
+		{"never fires", "0 0 31 2 *", "UTC", nil, nil, true},
+		{"bad expression", "not cron", "UTC", nil, nil, true},
+		{"bad timezone", "0 9 * * *", "Mars/Olympus", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NextRunAt(tt.expr, tt.tz, tt.activeFrom, tt.activeUntil)
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("NextRunAt = %v, want nil %v", got, tt.wantNil)
+			}
+			if got == nil {
+				return
+			}
+			if !got.After(time.Now()) {
+				t.Errorf("NextRunAt = %v, want a future time", got)
+			}
+			if tt.activeFrom != nil && !got.After(*tt.activeFrom) {
+				t.Errorf("NextRunAt = %v, want after activeFrom %v", got, tt.activeFrom)
+			}
+			if loc := got.Location().String(); loc != tt.tz {
+				t.Errorf("NextRunAt location = %s, want %s", loc, tt.tz)
+			}
+		})
+	}
+}
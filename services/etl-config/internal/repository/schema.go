@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+)
+
+// ExpectedEnumTypes are the Postgres enum types the repositories cast to.
+// Keep in sync with migrations/postgres when adding casts or migrations.
+var ExpectedEnumTypes = []string{
+	"datasource_type",
+	"datasource_status",
+	"storage_type",
+	"field_type",
+	"dataset_status",
+	"dataset_role",
+	"step_type",
+	"error_handling",
+	"pipeline_status",
+	"trigger_type",
+	"execution_status",
+	"execution_trigger",
+	"plugin_type",
+	"webhook_delivery_status",
+}
+
+// ExpectedTables are the tables the repositories read and write
+var ExpectedTables = []string{
+	"etl_plugins",
+	"etl_datasources",
+	"etl_datasets",
+	"etl_dataset_versions",
+	"etl_dataset_favorites",
+	"etl_dataset_grants",
+	"etl_pipelines",
+	"etl_schedules",
+	"etl_executions",
+	"etl_execution_tasks",
+	"etl_execution_logs",
+	"etl_webhooks",
+	"etl_webhook_deliveries",
+}
+
+// SchemaStatus reports which expected database objects are missing
+type SchemaStatus struct {
+	MissingTypes  []string `json:"missingTypes,omitempty"`
+	MissingTables []string `json:"missingTables,omitempty"`
+}
+
+// OK reports whether every expected type and table exists
+func (s SchemaStatus) OK() bool {
+	return len(s.MissingTypes) == 0 && len(s.MissingTables) == 0
+}
+
+// CheckSchema verifies that the expected enum types and tables exist in the
+// current search path. Ping alone succeeds against an unmigrated database,
+// while every query casting to a missing enum fails.
+func CheckSchema(ctx context.Context) (SchemaStatus, error) {
+	var status SchemaStatus
+
+	typesQuery := `
+		SELECT name FROM unnest($1::text[]) AS name
+		WHERE NOT EXISTS (
+			SELECT 1 FROM pg_type t
+			JOIN pg_namespace n ON n.oid = t.typnamespace
+			WHERE t.typname = name AND t.typtype = 'e'
+			  AND n.nspname = ANY (current_schemas(false))
+		)
+		ORDER BY name
+	`
+	missing, err := queryNames(ctx, typesQuery, ExpectedEnumTypes)
+	if err != nil {
+		return status, err
+	}
+	status.MissingTypes = missing
+
+	tablesQuery := `
+		SELECT name FROM unnest($1::text[]) AS name
+		WHERE NOT EXISTS (
+			SELECT 1 FROM information_schema.tables t
+			WHERE t.table_name = name AND t.table_schema = ANY (current_schemas(false))
+		)
+		ORDER BY name
+	`
+	missing, err = queryNames(ctx, tablesQuery, ExpectedTables)
+	if err != nil {
+		return status, err
+	}
+	status.MissingTables = missing
+
+	return status, nil
+}
+
+// queryNames runs a query taking a text array and returning a name column
+func queryNames(ctx context.Context, query string, names []string) ([]string, error) {
+	rows, err := DB.Query(ctx, query, names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		result = append(result, name)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+)
+
+// SortColumns maps the sort fields accepted by a list endpoint to SQL
+// columns. Only whitelisted columns ever reach an ORDER BY clause.
+type SortColumns map[string]string
+
+// Sortable columns per entity
+var (
+	DataSourceSortColumns = SortColumns{
+		"name":       "name",
+		"type":       "type",
+		"plugin":     "plugin",
+		"status":     "status",
+		"lastSyncAt": "last_sync_at",
+		"createdAt":  "created_at",
+		"updatedAt":  "updated_at",
+	}
+	DataSetSortColumns = SortColumns{
+		"name":      "name",
+		"category":  "category",
+		"version":   "version",
+		"status":    "status",
+		"createdAt": "created_at",
+		"updatedAt": "updated_at",
+	}
+	PipelineSortColumns = SortColumns{
+		"name":      "name",
+		"version":   "version",
+		"status":    "status",
+		"createdAt": "created_at",
+		"updatedAt": "updated_at",
+	}
+	ScheduleSortColumns = SortColumns{
+		"name":      "name",
+		"enabled":   "enabled",
+		"lastRunAt": "last_run_at",
+		"nextRunAt": "next_run_at",
+		"createdAt": "created_at",
+		"updatedAt": "updated_at",
+	}
+	ExecutionSortColumns = SortColumns{
+		"status":     "status",
+		"startedAt":  "started_at",
+		"finishedAt": "finished_at",
+		"duration":   "duration",
+		"createdAt":  "created_at",
+	}
+)
+
+// Fields returns the accepted sort fields in alphabetical order
+func (s SortColumns) Fields() []string {
+	fields := make([]string, 0, len(s))
+	for field := range s {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// OrderBy returns the ORDER BY expression for a sort, or fallback when no
+// sort field was requested. NULLs sort last and id breaks ties so pages
+// stay stable.
+func (s SortColumns) OrderBy(by api.Sort, fallback string) string {
+	column, ok := s[by.Field]
+	if !ok {
+		return fallback
+	}
+	if by.Desc {
+		return column + " DESC NULLS LAST, id"
+	}
+	return column + " ASC NULLS LAST, id"
+}
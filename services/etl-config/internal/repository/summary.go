@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// SummaryWindow is how far back recent executions are counted
+const SummaryWindow = 24 * time.Hour
+
+// SummaryRepository computes dashboard counts
+type SummaryRepository struct{}
+
+// NewSummaryRepository creates a new SummaryRepository
+func NewSummaryRepository() *SummaryRepository {
+	return &SummaryRepository{}
+}
+
+// Get counts datasources and pipelines by status, schedules by enabled
+// flag, and the executions created within SummaryWindow by status
+func (r *SummaryRepository) Get(ctx context.Context) (*model.Summary, error) {
+	tenant := tenantFilter(ctx)
+	summary := &model.Summary{GeneratedAt: time.Now()}
+
+	var err error
+	summary.DataSources, err = countByStatus(ctx, `
+		SELECT status::text, COUNT(*) FROM etl_datasources
+		WHERE ($1::text IS NULL OR tenant_id = $1)
+		GROUP BY status
+	`, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.Pipelines, err = countByStatus(ctx, `
+		SELECT status::text, COUNT(*) FROM etl_pipelines
+		WHERE ($1::text IS NULL OR tenant_id = $1)
+		GROUP BY status
+	`, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	summary.RecentExecutions, err = countByStatus(ctx, `
+		SELECT status::text, COUNT(*) FROM etl_executions
+		WHERE ($1::text IS NULL OR tenant_id = $1)
+		  AND created_at >= NOW() - make_interval(secs => $2)
+		GROUP BY status
+	`, tenant, SummaryWindow.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT COUNT(*) FILTER (WHERE enabled), COUNT(*) FILTER (WHERE NOT enabled)
+		FROM etl_schedules
+		WHERE ($1::text IS NULL OR tenant_id = $1) AND archived_at IS NULL
+	`
+	err = readDB(ctx).QueryRow(ctx, query, tenant).Scan(&summary.Schedules.Enabled, &summary.Schedules.Disabled)
+	if err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// countByStatus runs a query selecting (status, count) rows
+func countByStatus(ctx context.Context, query string, args ...interface{}) (map[string]int, error) {
+	rows, err := readDB(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
+}
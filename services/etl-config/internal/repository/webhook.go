@@ -0,0 +1,245 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/mellivora-tech/mellivora-mind-studio/pkg/api"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// webhookColumns is the column list read by scanWebhook
+const webhookColumns = `id, tenant_id, url, secret, events, enabled, created_by, created_at, updated_at`
+
+// webhookDeliveryColumns is the column list read by scanWebhookDelivery
+const webhookDeliveryColumns = `id, webhook_id, execution_id, event, payload, status, attempts, next_attempt_at,
+		       response_status, last_error, created_at, delivered_at`
+
+// WebhookRepository handles webhook and delivery database operations
+type WebhookRepository struct{}
+
+// NewWebhookRepository creates a new WebhookRepository
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{}
+}
+
+// List returns the webhooks of the tenant, oldest first
+func (r *WebhookRepository) List(ctx context.Context) ([]model.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM etl_webhooks
+		WHERE ($1::text IS NULL OR tenant_id = $1)
+		ORDER BY created_at
+	`
+
+	rows, err := readDB(ctx).Query(ctx, query, tenantFilter(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []model.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, *w)
+	}
+	return webhooks, rows.Err()
+}
+
+// GetByID returns a webhook by ID
+func (r *WebhookRepository) GetByID(ctx context.Context, id string) (*model.Webhook, error) {
+	query := `
+		SELECT ` + webhookColumns + `
+		FROM etl_webhooks
+		WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)
+	`
+
+	w, err := scanWebhook(readDB(ctx).QueryRow(ctx, query, id, tenantFilter(ctx)))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Create registers a webhook
+func (r *WebhookRepository) Create(ctx context.Context, form *model.WebhookForm) (*model.Webhook, error) {
+	query := `
+		INSERT INTO etl_webhooks (url, secret, events, enabled, tenant_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + webhookColumns
+
+	events := form.Events
+	if events == nil {
+		events = []string{}
+	}
+	enabled := form.Enabled == nil || *form.Enabled
+
+	return scanWebhook(DB.QueryRow(ctx, query,
+		form.URL, form.Secret, events, enabled, tenantOf(ctx), actorOf(ctx),
+	))
+}
+
+// Delete deletes a webhook together with its deliveries
+func (r *WebhookRepository) Delete(ctx context.Context, id string) error {
+	query := `DELETE FROM etl_webhooks WHERE id = $1 AND ($2::text IS NULL OR tenant_id = $2)`
+	_, err := DB.Exec(ctx, query, id, tenantFilter(ctx))
+	return err
+}
+
+// ListDeliveries returns the deliveries of a webhook, newest first. An empty
+// status matches every delivery.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID, status string, page, pageSize int, withTotal bool) ([]model.WebhookDelivery, int, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM etl_webhook_deliveries
+		WHERE webhook_id = $1
+		  AND ($2 = '' OR status = $2::webhook_delivery_status)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	countQuery := `
+		SELECT COUNT(*) FROM etl_webhook_deliveries
+		WHERE webhook_id = $1
+		  AND ($2 = '' OR status = $2::webhook_delivery_status)
+	`
+
+	args := []interface{}{webhookID, status}
+
+	offset := (page - 1) * pageSize
+
+	rows, err := readDB(ctx).Query(ctx, query, append(args, api.PageLimit(pageSize, withTotal), offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var deliveries []model.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if !withTotal {
+		return deliveries, api.UnknownTotal, nil
+	}
+
+	var total int
+	err = readDB(ctx).QueryRow(ctx, countQuery, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// DueDelivery is a claimed delivery with the webhook it is sent to
+type DueDelivery struct {
+	model.WebhookDelivery
+	URL    string
+	Secret string
+}
+
+// ClaimDueDeliveries claims up to limit pending deliveries whose next
+// attempt is due, across all tenants. Claimed deliveries are pushed back by
+// lease, so they are retried if the worker dies before recording the
+// outcome, and concurrent workers never claim the same delivery.
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DueDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT id FROM etl_webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE etl_webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM due, etl_webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id
+		RETURNING d.id, d.webhook_id, d.execution_id, d.event, d.payload, d.status, d.attempts, d.next_attempt_at,
+		          d.response_status, d.last_error, d.created_at, d.delivered_at, w.url, w.secret
+	`
+
+	rows, err := DB.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []DueDelivery
+	for rows.Next() {
+		var d DueDelivery
+		delivery, err := scanWebhookDelivery(extraColumns{rows, []interface{}{&d.URL, &d.Secret}})
+		if err != nil {
+			return nil, err
+		}
+		d.WebhookDelivery = *delivery
+		due = append(due, d)
+	}
+	return due, rows.Err()
+}
+
+// MarkDelivered records a successful attempt
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, id int64, responseStatus int) error {
+	query := `
+		UPDATE etl_webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, response_status = $2, last_error = NULL,
+		    delivered_at = NOW()
+		WHERE id = $1
+	`
+	_, err := DB.Exec(ctx, query, id, responseStatus)
+	return err
+}
+
+// MarkFailed records a failed attempt. The delivery is retried at
+// nextAttemptAt, or given up on as dead when dead is set.
+func (r *WebhookRepository) MarkFailed(ctx context.Context, id int64, responseStatus *int, lastError string, nextAttemptAt time.Time, dead bool) error {
+	query := `
+		UPDATE etl_webhook_deliveries
+		SET status = CASE WHEN $5 THEN 'dead' ELSE 'pending' END::webhook_delivery_status,
+		    attempts = attempts + 1, response_status = $2, last_error = $3, next_attempt_at = $4
+		WHERE id = $1
+	`
+	_, err := DB.Exec(ctx, query, id, responseStatus, lastError, nextAttemptAt, dead)
+	return err
+}
+
+// scanWebhook scans a row selected with webhookColumns
+func scanWebhook(row pgx.Row) (*model.Webhook, error) {
+	var w model.Webhook
+	err := row.Scan(
+		&w.ID, &w.TenantID, &w.URL, &w.Secret, &w.Events, &w.Enabled, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// scanWebhookDelivery scans a row selected with webhookDeliveryColumns
+func scanWebhookDelivery(row pgx.Row) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	err := row.Scan(
+		&d.ID, &d.WebhookID, &d.ExecutionID, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,227 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// Defaults used for decimal fields that don't declare precision/scale
+const (
+	defaultDecimalPrecision = 38
+	defaultDecimalScale     = 10
+)
+
+// ToAvro translates a dataset schema into an Avro record schema
+func ToAvro(ds *model.DataSet, s *model.DataSetSchema) (map[string]interface{}, error) {
+	fields := make([]map[string]interface{}, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		typ, err := avroType(f)
+		if err != nil {
+			return nil, err
+		}
+
+		field := map[string]interface{}{
+			"name": identifier(f.Name),
+			"type": typ,
+		}
+		if f.Nullable {
+			field["type"] = []interface{}{"null", typ}
+			field["default"] = nil
+		}
+		if f.Description != nil {
+			field["doc"] = *f.Description
+		}
+		fields = append(fields, field)
+	}
+
+	record := map[string]interface{}{
+		"type":      "record",
+		"name":      messageName(ds.Name),
+		"namespace": namespace(ds.Category),
+		"fields":    fields,
+	}
+	if ds.Description != nil {
+		record["doc"] = *ds.Description
+	}
+	return record, nil
+}
+
+func avroType(f model.FieldDefinition) (interface{}, error) {
+	switch f.Type {
+	case model.FieldTypeString, model.FieldTypeJSON:
+		return "string", nil
+	case model.FieldTypeInt, model.FieldTypeBigint:
+		return "long", nil
+	case model.FieldTypeFloat:
+		return "float", nil
+	case model.FieldTypeDouble:
+		return "double", nil
+	case model.FieldTypeBool:
+		return "boolean", nil
+	case model.FieldTypeDecimal:
+		precision, scale := decimalSpec(f)
+		return map[string]interface{}{
+			"type":        "bytes",
+			"logicalType": "decimal",
+			"precision":   precision,
+			"scale":       scale,
+		}, nil
+	case model.FieldTypeDate:
+		return map[string]interface{}{"type": "int", "logicalType": "date"}, nil
+	case model.FieldTypeDatetime:
+		return map[string]interface{}{"type": "long", "logicalType": "timestamp-millis"}, nil
+	case model.FieldTypeEnum:
+		if len(f.EnumValues) == 0 {
+			return "string", nil
+		}
+		symbols := make([]string, len(f.EnumValues))
+		for i, v := range f.EnumValues {
+			symbols[i] = identifier(v)
+		}
+		return map[string]interface{}{
+			"type":    "enum",
+			"name":    messageName(f.Name),
+			"symbols": symbols,
+		}, nil
+	default:
+		return nil, fmt.Errorf("field %q has unsupported type %q", f.Name, f.Type)
+	}
+}
+
+// ToProtobuf translates a dataset schema into a proto3 message definition
+func ToProtobuf(ds *model.DataSet, s *model.DataSetSchema) (string, error) {
+	var (
+		body       strings.Builder
+		enums      strings.Builder
+		needsTime  bool
+		fieldCount int
+	)
+
+	for _, f := range s.Fields {
+		fieldCount++
+		name := identifier(f.Name)
+
+		var typ string
+		switch f.Type {
+		case model.FieldTypeString, model.FieldTypeJSON, model.FieldTypeDate:
+			typ = "string"
+		case model.FieldTypeDecimal:
+			// Decimals travel as strings, matching mellivora.common.Decimal
+			typ = "string"
+		case model.FieldTypeInt, model.FieldTypeBigint:
+			typ = "int64"
+		case model.FieldTypeFloat:
+			typ = "float"
+		case model.FieldTypeDouble:
+			typ = "double"
+		case model.FieldTypeBool:
+			typ = "bool"
+		case model.FieldTypeDatetime:
+			typ = "google.protobuf.Timestamp"
+			needsTime = true
+		case model.FieldTypeEnum:
+			if len(f.EnumValues) == 0 {
+				typ = "string"
+				break
+			}
+			typ = messageName(f.Name)
+			writeProtoEnum(&enums, typ, f.EnumValues)
+		default:
+			return "", fmt.Errorf("field %q has unsupported type %q", f.Name, f.Type)
+		}
+
+		// Message types already carry presence; scalars need "optional"
+		label := ""
+		if f.Nullable && typ != "google.protobuf.Timestamp" {
+			label = "optional "
+		}
+		if f.Description != nil {
+			fmt.Fprintf(&body, "  // %s\n", singleLine(*f.Description))
+		}
+		fmt.Fprintf(&body, "  %s%s %s = %d;\n", label, typ, name, fieldCount)
+	}
+
+	var out strings.Builder
+	out.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&out, "package %s;\n", namespace(ds.Category))
+	if needsTime {
+		out.WriteString("\nimport \"google/protobuf/timestamp.proto\";\n")
+	}
+	out.WriteString("\n")
+	if ds.Description != nil {
+		fmt.Fprintf(&out, "// %s\n", singleLine(*ds.Description))
+	}
+	fmt.Fprintf(&out, "message %s {\n", messageName(ds.Name))
+	out.WriteString(enums.String())
+	out.WriteString(body.String())
+	out.WriteString("}\n")
+
+	return out.String(), nil
+}
+
+func writeProtoEnum(b *strings.Builder, name string, values []string) {
+	prefix := strings.ToUpper(identifier(name))
+	fmt.Fprintf(b, "  enum %s {\n", name)
+	fmt.Fprintf(b, "    %s_UNSPECIFIED = 0;\n", prefix)
+	for i, v := range values {
+		fmt.Fprintf(b, "    %s_%s = %d;\n", prefix, strings.ToUpper(identifier(v)), i+1)
+	}
+	b.WriteString("  }\n\n")
+}
+
+func decimalSpec(f model.FieldDefinition) (int, int) {
+	precision, scale := defaultDecimalPrecision, defaultDecimalScale
+	if f.Precision != nil {
+		precision = *f.Precision
+	}
+	if f.Scale != nil {
+		scale = *f.Scale
+	}
+	return precision, scale
+}
+
+// identifier converts a name into a valid Avro/proto identifier
+func identifier(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	id := b.String()
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "_" + id
+	}
+	return id
+}
+
+// messageName converts a dataset or field name into PascalCase
+func messageName(name string) string {
+	parts := strings.FieldsFunc(identifier(name), func(r rune) bool { return r == '_' })
+	var b strings.Builder
+	for _, p := range parts {
+		b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	if b.Len() == 0 || unicode.IsDigit(rune(b.String()[0])) {
+		return "Dataset" + b.String()
+	}
+	return b.String()
+}
+
+// namespace returns the package/namespace for a dataset category
+func namespace(category string) string {
+	ns := "mellivora.etl"
+	if category == "" {
+		return ns
+	}
+	return ns + "." + strings.ToLower(identifier(category))
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
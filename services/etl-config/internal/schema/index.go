@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// ValidateIndexes checks that every index covers at least one column, only
+// references columns present in s, and that index names are unique
+func ValidateIndexes(raw json.RawMessage, s *model.DataSetSchema) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+
+	var indexes []model.IndexDefinition
+	if err := json.Unmarshal(raw, &indexes); err != nil {
+		return fmt.Errorf("invalid indexes: %w", err)
+	}
+
+	names := make(map[string]bool, len(indexes))
+	for i, idx := range indexes {
+		label := fmt.Sprintf("indexes[%d]", i)
+		if idx.Name != "" {
+			if names[idx.Name] {
+				return fmt.Errorf("duplicate index name %q", idx.Name)
+			}
+			names[idx.Name] = true
+			label = fmt.Sprintf("index %q", idx.Name)
+		}
+
+		if len(idx.Fields) == 0 {
+			return fmt.Errorf("%s must reference at least one column", label)
+		}
+		if err := checkColumns(label, idx.Fields, s); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
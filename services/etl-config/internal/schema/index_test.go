@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+func TestValidateIndexes(t *testing.T) {
+	s := &model.DataSetSchema{Fields: []model.FieldDefinition{{Name: "code"}, {Name: "trade_date"}, {Name: "close"}}}
+
+	tests := []struct {
+		name    string
+		indexes string
+		schema  *model.DataSetSchema
+		wantErr string // substring of the error, "" when valid
+	}{
+		{"valid", `[
+			{"name": "pk", "fields": ["code", "trade_date"], "unique": true},
+			{"name": "by_date", "fields": ["trade_date"]},
+			{"fields": ["close"]}
+		]`, s, ""},
+		{"unnamed indexes on the same columns", `[{"fields": ["code"]}, {"fields": ["code"]}]`, s, ""},
+		{"empty", ``, s, ""},
+		{"null", `null`, s, ""},
+		{"no indexes", `[]`, s, ""},
+		{"missing column", `[{"name": "by_open", "fields": ["code", "open"]}]`, s,
+			`index "by_open" references unknown column "open"`},
+		{"missing column in unnamed index", `[{"fields": ["code"]}, {"fields": ["volume"]}]`, s,
+			`indexes[1] references unknown column "volume"`},
+		{"duplicate name", `[{"name": "pk", "fields": ["code"]}, {"name": "pk", "fields": ["trade_date"]}]`, s,
+			`duplicate index name "pk"`},
+		{"no columns", `[{"name": "empty", "fields": []}]`, s, `index "empty" must reference at least one column`},
+		{"columns unchecked without a schema", `[{"name": "by_open", "fields": ["open"]}]`, nil, ""},
+		{"not JSON", `[{"fields": `, s, "invalid indexes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIndexes([]byte(tt.indexes), tt.schema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateIndexes: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateIndexes = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
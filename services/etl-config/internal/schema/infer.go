@@ -0,0 +1,362 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// MaxInferRows caps how many sample rows are inspected during inference
+const MaxInferRows = 10000
+
+// Layouts recognised as dates and datetimes when inferring string values
+var (
+	dateLayouts = []string{"2006-01-02", "2006/01/02"}
+
+	datetimeLayouts = []string{
+		time.RFC3339Nano,
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02 15:04:05.000",
+		"2006/01/02 15:04:05",
+	}
+)
+
+// InferredField is a schema field plus hints about how it was inferred
+type InferredField struct {
+	model.FieldDefinition
+	Confidence    float64        `json:"confidence"`
+	MixedTypes    bool           `json:"mixedTypes"`
+	ObservedTypes map[string]int `json:"observedTypes"`
+	PresentIn     int            `json:"presentIn"`
+}
+
+// InferResult is the outcome of inferring a schema from a sample payload
+type InferResult struct {
+	Fields    []InferredField `json:"fields"`
+	Rows      int             `json:"rows"`
+	Truncated bool            `json:"truncated"`
+}
+
+// column accumulates observations for a single column
+type column struct {
+	name     string
+	observed map[string]int
+	nulls    int
+	present  int
+}
+
+// inferrer collects columns in first-seen order so results are deterministic
+type inferrer struct {
+	columns []*column
+	index   map[string]*column
+	rows    int
+}
+
+func newInferrer() *inferrer {
+	return &inferrer{index: make(map[string]*column)}
+}
+
+func (in *inferrer) column(name string) *column {
+	col, ok := in.index[name]
+	if !ok {
+		col = &column{name: name, observed: make(map[string]int)}
+		in.index[name] = col
+		in.columns = append(in.columns, col)
+	}
+	return col
+}
+
+// observe records a value of the given type; an empty type means null
+func (col *column) observe(typ string) {
+	col.present++
+	if typ == "" {
+		col.nulls++
+		return
+	}
+	col.observed[typ]++
+}
+
+// InferJSON infers a schema from a JSON array of objects
+func InferJSON(r io.Reader) (*InferResult, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON sample: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("sample must be a JSON array of objects")
+	}
+
+	in := newInferrer()
+	truncated := false
+	for dec.More() {
+		if in.rows >= MaxInferRows {
+			truncated = true
+			break
+		}
+		if err := in.readObject(dec); err != nil {
+			return nil, fmt.Errorf("row %d: %w", in.rows, err)
+		}
+		in.rows++
+	}
+
+	return in.result(truncated), nil
+}
+
+// readObject reads one JSON object, preserving key order
+func (in *inferrer) readObject(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return errors.New("expected a JSON object")
+	}
+
+	for dec.More() {
+		keyTok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := keyTok.(string)
+		if !ok {
+			return errors.New("expected an object key")
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		in.column(key).observe(jsonValueType(raw))
+	}
+
+	// Consume the closing brace
+	_, err = dec.Token()
+	return err
+}
+
+// InferCSV infers a schema from CSV data whose first row is a header. Each
+// value is read into the column of its position, so a header naming a
+// column twice is rejected.
+func InferCSV(r io.Reader) (*InferResult, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("CSV sample is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV sample: %w", err)
+	}
+
+	in := newInferrer()
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if _, ok := in.index[name]; ok {
+			return nil, fmt.Errorf("invalid CSV sample: header column %d repeats the column name %q", i+1, name)
+		}
+		in.column(name)
+	}
+
+	truncated := false
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV sample: %w", err)
+		}
+		if in.rows >= MaxInferRows {
+			truncated = true
+			break
+		}
+		for i, col := range in.columns {
+			if i >= len(record) {
+				break
+			}
+			col.observe(stringValueType(record[i], true))
+		}
+		in.rows++
+	}
+
+	return in.result(truncated), nil
+}
+
+// jsonValueType classifies a raw JSON value
+func jsonValueType(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+
+	switch raw[0] {
+	case '{', '[':
+		return model.FieldTypeJSON
+	case 't', 'f':
+		return model.FieldTypeBool
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return model.FieldTypeString
+		}
+		return stringValueType(s, false)
+	default:
+		return numberType(string(raw))
+	}
+}
+
+// stringValueType classifies a string value. When untyped is set (CSV),
+// booleans and numbers are also detected from the text.
+func stringValueType(s string, untyped bool) string {
+	s = strings.TrimSpace(s)
+	if untyped {
+		if s == "" {
+			return ""
+		}
+		switch strings.ToLower(s) {
+		case "true", "false":
+			return model.FieldTypeBool
+		}
+		if t := numberType(s); t != "" && t != model.FieldTypeString {
+			return t
+		}
+	}
+
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return model.FieldTypeDate
+		}
+	}
+	for _, layout := range datetimeLayouts {
+		if _, err := time.Parse(layout, s); err == nil {
+			return model.FieldTypeDatetime
+		}
+	}
+
+	return model.FieldTypeString
+}
+
+// numberType classifies a numeric literal as int, bigint, or double
+func numberType(s string) string {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if i >= math.MinInt32 && i <= math.MaxInt32 {
+			return model.FieldTypeInt
+		}
+		return model.FieldTypeBigint
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return model.FieldTypeDouble
+	}
+	return model.FieldTypeString
+}
+
+// typeFamily groups types that can be widened into one another
+func typeFamily(typ string) string {
+	switch typ {
+	case model.FieldTypeInt, model.FieldTypeBigint, model.FieldTypeDouble:
+		return "numeric"
+	case model.FieldTypeDate, model.FieldTypeDatetime:
+		return "temporal"
+	default:
+		return typ
+	}
+}
+
+// typeRank orders types within a family from narrowest to widest
+var typeRank = map[string]int{
+	model.FieldTypeInt:      0,
+	model.FieldTypeBigint:   1,
+	model.FieldTypeDouble:   2,
+	model.FieldTypeDate:     0,
+	model.FieldTypeDatetime: 1,
+}
+
+// resolve picks the column type and reports whether incompatible types were seen
+func (col *column) resolve() (typ string, mixed bool, confidence float64) {
+	nonNull := col.present - col.nulls
+	if nonNull == 0 {
+		return model.FieldTypeString, false, 0
+	}
+
+	// Sort observed types so ties resolve identically on every run
+	types := make([]string, 0, len(col.observed))
+	for t := range col.observed {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	families := make(map[string]int)
+	for _, t := range types {
+		families[typeFamily(t)] += col.observed[t]
+	}
+
+	if len(families) == 1 {
+		widest := types[0]
+		for _, t := range types[1:] {
+			if typeRank[t] > typeRank[widest] {
+				widest = t
+			}
+		}
+		return widest, false, 1
+	}
+
+	// Incompatible families: fall back to the most general representation
+	typ = model.FieldTypeString
+	if _, ok := families[model.FieldTypeJSON]; ok {
+		typ = model.FieldTypeJSON
+	}
+
+	dominant := 0
+	for _, count := range families {
+		if count > dominant {
+			dominant = count
+		}
+	}
+	return typ, true, round(float64(dominant) / float64(nonNull))
+}
+
+func (in *inferrer) result(truncated bool) *InferResult {
+	res := &InferResult{
+		Fields:    make([]InferredField, 0, len(in.columns)),
+		Rows:      in.rows,
+		Truncated: truncated,
+	}
+
+	for _, col := range in.columns {
+		typ, mixed, confidence := col.resolve()
+		res.Fields = append(res.Fields, InferredField{
+			FieldDefinition: model.FieldDefinition{
+				Name:     col.name,
+				Type:     typ,
+				Nullable: col.nulls > 0 || col.present < in.rows,
+			},
+			Confidence:    confidence,
+			MixedTypes:    mixed,
+			ObservedTypes: col.observed,
+			PresentIn:     col.present,
+		})
+	}
+
+	return res
+}
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
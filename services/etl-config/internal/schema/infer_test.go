@@ -0,0 +1,39 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+func TestInferCSV(t *testing.T) {
+	result, err := InferCSV(strings.NewReader("code, price,listed\n600519.SH,1700.5,true\n000001.SZ,11.2,false\n"))
+	if err != nil {
+		t.Fatalf("InferCSV: %v", err)
+	}
+	want := []struct{ name, typ string }{
+		{"code", model.FieldTypeString},
+		{"price", model.FieldTypeDouble},
+		{"listed", model.FieldTypeBool},
+	}
+	if len(result.Fields) != len(want) || result.Rows != 2 {
+		t.Fatalf("InferCSV = %d fields over %d rows, want %d over 2", len(result.Fields), result.Rows, len(want))
+	}
+	for i, w := range want {
+		if f := result.Fields[i]; f.Name != w.name || f.Type != w.typ {
+			t.Errorf("field %d = %s %s, want %s %s", i, f.Name, f.Type, w.name, w.typ)
+		}
+	}
+}
+
+func TestInferCSVDuplicateHeader(t *testing.T) {
+	for _, sample := range []string{
+		"price,code,price\n1.5,600519.SH,abc\n",
+		"price, price\n1,2\n",
+	} {
+		if result, err := InferCSV(strings.NewReader(sample)); err == nil {
+			t.Errorf("InferCSV(%q) = %+v, want an error", sample, result.Fields)
+		}
+	}
+}
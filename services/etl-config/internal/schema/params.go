@@ -0,0 +1,128 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// ParameterTypes are the types a pipeline parameter may declare
+var ParameterTypes = []string{"string", "number", "integer", "boolean", "date", "datetime", "object", "array"}
+
+// CheckParameterValue checks that value is a JSON value of the declared
+// parameter type. Dates are "YYYY-MM-DD" strings and datetimes RFC3339
+// strings.
+func CheckParameterValue(typ string, value json.RawMessage) error {
+	var v interface{}
+	d := json.NewDecoder(bytes.NewReader(value))
+	d.UseNumber()
+	if err := d.Decode(&v); err != nil {
+		return fmt.Errorf("must be valid JSON: %w", err)
+	}
+
+	switch typ {
+	case "string":
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("must be a string")
+		}
+	case "number":
+		if _, ok := v.(json.Number); !ok {
+			return fmt.Errorf("must be a number")
+		}
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return fmt.Errorf("must be an integer")
+		}
+		if _, err := n.Int64(); err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("must be a boolean")
+		}
+	case "date":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("must be a date string (YYYY-MM-DD)")
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return fmt.Errorf("must be a date string (YYYY-MM-DD)")
+		}
+	case "datetime":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("must be an RFC3339 timestamp")
+		}
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return fmt.Errorf("must be an RFC3339 timestamp")
+		}
+	case "object":
+		if _, ok := v.(map[string]interface{}); !ok {
+			return fmt.Errorf("must be an object")
+		}
+	case "array":
+		if _, ok := v.([]interface{}); !ok {
+			return fmt.Errorf("must be an array")
+		}
+	default:
+		return fmt.Errorf("has unknown type %q", typ)
+	}
+	return nil
+}
+
+// ResolveParameters checks the parameters supplied to a pipeline run
+// against the pipeline's declarations and fills in declared defaults.
+// Unknown, mistyped and missing required parameters are errors; the
+// returned error lists every problem.
+func ResolveParameters(declared []model.PipelineParameter, supplied map[string]json.RawMessage) (map[string]json.RawMessage, error) {
+	resolved := make(map[string]json.RawMessage, len(declared))
+	known := make(map[string]bool, len(declared))
+	var problems []string
+
+	for _, p := range declared {
+		known[p.Name] = true
+		value, ok := supplied[p.Name]
+		if ok && isJSONNull(value) {
+			ok = false
+		}
+		switch {
+		case ok:
+			if err := CheckParameterValue(p.Type, value); err != nil {
+				problems = append(problems, fmt.Sprintf("parameter %q %v", p.Name, err))
+				continue
+			}
+			resolved[p.Name] = value
+		case len(p.Default) > 0 && !isJSONNull(p.Default):
+			resolved[p.Name] = p.Default
+		case p.Required:
+			problems = append(problems, fmt.Sprintf("parameter %q is required", p.Name))
+		}
+	}
+
+	var unknown []string
+	for name := range supplied {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fmt.Sprintf("parameter %q is not declared by the pipeline", name))
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid parameters: %s", strings.Join(problems, "; "))
+	}
+	return resolved, nil
+}
+
+// isJSONNull reports whether raw is the JSON null literal
+func isJSONNull(raw json.RawMessage) bool {
+	return strings.TrimSpace(string(raw)) == "null"
+}
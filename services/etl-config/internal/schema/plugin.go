@@ -0,0 +1,302 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// JSON Schema dialect of resolved plugin config schemas
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// PluginConfigSchema returns a plugin's config schema as a self-contained
+// JSON Schema. A stored field list (the seeded format) is translated; a
+// stored JSON Schema object has every local $ref ("#/$defs/...") inlined, so
+// form generators never need to resolve references. Unresolvable, remote and
+// circular references are errors.
+func PluginConfigSchema(raw json.RawMessage) (map[string]interface{}, error) {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" || trimmed == "null" {
+		return fieldsSchema(nil)
+	}
+
+	if strings.HasPrefix(trimmed, "[") {
+		var fields []model.PluginConfigField
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil, fmt.Errorf("invalid config field list: %w", err)
+		}
+		return fieldsSchema(fields)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("config schema must be a field list or a JSON Schema object: %w", err)
+	}
+
+	resolved, err := derefNode(doc, doc, nil)
+	if err != nil {
+		return nil, err
+	}
+	out := resolved.(map[string]interface{})
+	delete(out, "$defs")
+	delete(out, "definitions")
+	if _, ok := out["$schema"]; !ok {
+		out["$schema"] = jsonSchemaDialect
+	}
+	return out, nil
+}
+
+// fieldsSchema translates a plugin config field list into a JSON Schema object
+func fieldsSchema(fields []model.PluginConfigField) (map[string]interface{}, error) {
+	properties := make(map[string]interface{}, len(fields))
+	required := []string{}
+	for _, f := range fields {
+		if f.Name == "" {
+			return nil, fmt.Errorf("config field without a name")
+		}
+		prop, err := fieldProperty(f)
+		if err != nil {
+			return nil, fmt.Errorf("config field %s: %w", f.Name, err)
+		}
+		properties[f.Name] = prop
+		if f.Required {
+			required = append(required, f.Name)
+		}
+	}
+
+	return map[string]interface{}{
+		"$schema":    jsonSchemaDialect,
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}, nil
+}
+
+// fieldProperty translates one config field into a JSON Schema property
+func fieldProperty(f model.PluginConfigField) (map[string]interface{}, error) {
+	prop := map[string]interface{}{}
+	switch f.Type {
+	case "string":
+		prop["type"] = "string"
+	case "number":
+		prop["type"] = "number"
+	case "boolean":
+		prop["type"] = "boolean"
+	case "secret":
+		prop["type"] = "string"
+		prop["format"] = "password"
+		prop["writeOnly"] = true
+	case "json":
+		// any JSON value
+	case "select":
+		var options []struct {
+			Label string      `json:"label"`
+			Value interface{} `json:"value"`
+		}
+		if len(f.Options) > 0 {
+			if err := json.Unmarshal(f.Options, &options); err != nil {
+				return nil, fmt.Errorf("invalid options: %w", err)
+			}
+		}
+		oneOf := make([]interface{}, 0, len(options))
+		for _, o := range options {
+			oneOf = append(oneOf, map[string]interface{}{"const": o.Value, "title": o.Label})
+		}
+		prop["oneOf"] = oneOf
+	default:
+		return nil, fmt.Errorf("unsupported field type %q", f.Type)
+	}
+
+	if f.Label != "" {
+		prop["title"] = f.Label
+	}
+	if f.Description != nil {
+		prop["description"] = *f.Description
+	}
+	if f.Default != nil {
+		prop["default"] = f.Default
+	}
+	return prop, nil
+}
+
+// derefNode returns a copy of node with every $ref replaced by its target
+// within root. Keywords next to a $ref override those of the target. stack
+// holds the references being expanded, to reject cycles.
+func derefNode(root map[string]interface{}, node interface{}, stack []string) (interface{}, error) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		if ref, ok := v["$ref"]; ok {
+			refStr, ok := ref.(string)
+			if !ok {
+				return nil, fmt.Errorf("$ref must be a string")
+			}
+			for _, seen := range stack {
+				if seen == refStr {
+					return nil, fmt.Errorf("circular $ref %s", refStr)
+				}
+			}
+			target, err := resolvePointer(root, refStr)
+			if err != nil {
+				return nil, err
+			}
+			resolved, err := derefNode(root, target, append(stack, refStr))
+			if err != nil {
+				return nil, err
+			}
+			if m, ok := resolved.(map[string]interface{}); ok {
+				for key, value := range m {
+					out[key] = value
+				}
+			} else if len(v) == 1 {
+				return resolved, nil
+			}
+		}
+		for key, value := range v {
+			if key == "$ref" {
+				continue
+			}
+			resolved, err := derefNode(root, value, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := derefNode(root, item, stack)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// resolvePointer resolves a local "#/..." JSON pointer reference against root
+func resolvePointer(root map[string]interface{}, ref string) (interface{}, error) {
+	pointer, ok := strings.CutPrefix(ref, "#")
+	if !ok {
+		return nil, fmt.Errorf("unsupported $ref %s: only local references are allowed", ref)
+	}
+	if pointer == "" {
+		return root, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("unsupported $ref %s: anchors are not allowed", ref)
+	}
+
+	var node interface{} = root
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := node.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("unresolvable $ref %s", ref)
+			}
+			node = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("unresolvable $ref %s", ref)
+			}
+			node = v[i]
+		default:
+			return nil, fmt.Errorf("unresolvable $ref %s", ref)
+		}
+	}
+	return node, nil
+}
+
+// CheckConfigOverride checks a partial plugin config, such as an environment
+// override, against a schema returned by PluginConfigSchema. It must be an
+// object; a schema that declares properties rejects other keys unless it
+// allows additional properties, and each value must be of its property's
+// type and one of its options. Required properties may be left out, and a
+// null unsets a property.
+func CheckConfigOverride(doc map[string]interface{}, override json.RawMessage) error {
+	var values map[string]interface{}
+	if err := json.Unmarshal(override, &values); err != nil || values == nil {
+		return fmt.Errorf("must be a config object")
+	}
+
+	properties, _ := doc["properties"].(map[string]interface{})
+	additional := len(properties) == 0 || doc["additionalProperties"] == true
+	if _, ok := doc["additionalProperties"].(map[string]interface{}); ok {
+		additional = true
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		prop, ok := properties[key].(map[string]interface{})
+		if !ok {
+			if !additional {
+				return fmt.Errorf("%s: not a config field of the plugin", key)
+			}
+			continue
+		}
+		if values[key] == nil {
+			continue
+		}
+		if err := checkPropertyValue(prop, values[key]); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// checkPropertyValue checks a value against the type and oneOf options of a
+// schema property
+func checkPropertyValue(prop map[string]interface{}, v interface{}) error {
+	if typ, ok := prop["type"].(string); ok {
+		valid := true
+		switch typ {
+		case "string":
+			_, valid = v.(string)
+		case "number":
+			_, valid = v.(float64)
+		case "integer":
+			n, ok := v.(float64)
+			valid = ok && n == math.Trunc(n)
+		case "boolean":
+			_, valid = v.(bool)
+		case "object":
+			_, valid = v.(map[string]interface{})
+		case "array":
+			_, valid = v.([]interface{})
+		}
+		if !valid {
+			return fmt.Errorf("must be of type %s", typ)
+		}
+	}
+
+	options, _ := prop["oneOf"].([]interface{})
+	if len(options) == 0 {
+		return nil
+	}
+	for _, o := range options {
+		option, _ := o.(map[string]interface{})
+		c, ok := option["const"]
+		if !ok || reflect.DeepEqual(c, v) {
+			// Options without a const are not enumerations
+			return nil
+		}
+	}
+	return fmt.Errorf("must be one of the plugin's options")
+}
@@ -0,0 +1,24 @@
+// Package schema works with typed dataset schema documents: parsing,
+// validation, inference from sample data, and translation to external
+// formats. It also resolves plugin config schemas into self-contained JSON
+// Schema.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// Parse decodes a stored dataset schema document
+func Parse(raw json.RawMessage) (*model.DataSetSchema, error) {
+	var s model.DataSetSchema
+	if len(raw) == 0 {
+		return &s, nil
+	}
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema document: %w", err)
+	}
+	return &s, nil
+}
@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+)
+
+// ValidateStorage checks a dataset storage config against the typed config
+// of its declared backend. Columns referenced by orderBy must exist in s.
+func ValidateStorage(raw json.RawMessage, s *model.DataSetSchema) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return fmt.Errorf("storage is required")
+	}
+
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return fmt.Errorf("invalid storage config: %w", err)
+	}
+
+	switch header.Type {
+	case model.StoragePostgres:
+		var cfg model.PostgresStorage
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return fmt.Errorf("invalid postgres storage config: %w", err)
+		}
+		return requireTable(cfg.Table)
+	case model.StorageClickHouse:
+		var cfg model.ClickHouseStorage
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return fmt.Errorf("invalid clickhouse storage config: %w", err)
+		}
+		if err := requireTable(cfg.Table); err != nil {
+			return err
+		}
+		if err := checkTTL(cfg.TTLDays); err != nil {
+			return err
+		}
+		return checkColumns("storage.orderBy", cfg.OrderBy, s)
+	case model.StorageRedis:
+		var cfg model.RedisStorage
+		if err := json.Unmarshal(raw, &cfg); err != nil {
+			return fmt.Errorf("invalid redis storage config: %w", err)
+		}
+		if err := requireTable(cfg.Table); err != nil {
+			return err
+		}
+		return checkTTL(cfg.TTLDays)
+	case "":
+		return fmt.Errorf("storage.type is required")
+	default:
+		return fmt.Errorf("unsupported storage type %q (expected %s, %s or %s)",
+			header.Type, model.StoragePostgres, model.StorageClickHouse, model.StorageRedis)
+	}
+}
+
+func requireTable(table string) error {
+	if table == "" {
+		return fmt.Errorf("storage.table is required")
+	}
+	return nil
+}
+
+func checkTTL(days *int) error {
+	if days != nil && *days < 0 {
+		return fmt.Errorf("storage.ttlDays must not be negative")
+	}
+	return nil
+}
+
+// checkColumns verifies every column exists in the schema
+func checkColumns(path string, columns []string, s *model.DataSetSchema) error {
+	if s == nil {
+		return nil
+	}
+	known := make(map[string]bool, len(s.Fields))
+	for _, f := range s.Fields {
+		known[f.Name] = true
+	}
+	for _, col := range columns {
+		if !known[col] {
+			return fmt.Errorf("%s references unknown column %q", path, col)
+		}
+	}
+	return nil
+}
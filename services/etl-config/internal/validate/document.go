@@ -0,0 +1,91 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/model"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/schema"
+)
+
+// FieldTypes are the types a dataset schema field may declare
+var FieldTypes = []string{
+	model.FieldTypeString, model.FieldTypeInt, model.FieldTypeBigint, model.FieldTypeDecimal,
+	model.FieldTypeFloat, model.FieldTypeDouble, model.FieldTypeBool, model.FieldTypeDate,
+	model.FieldTypeDatetime, model.FieldTypeJSON, model.FieldTypeEnum,
+}
+
+// documentSchema is the JSON Schema of a dataset schema document. Fields may
+// carry keys beyond the declared ones, such as the hints of an inferred
+// schema.
+var documentSchema = map[string]interface{}{
+	"$schema":  dialect,
+	"type":     "object",
+	"required": []interface{}{"fields"},
+	"properties": map[string]interface{}{
+		"fields": map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":     "object",
+				"required": []interface{}{"name", "type"},
+				"properties": map[string]interface{}{
+					"name":        map[string]interface{}{"type": "string", "minLength": 1},
+					"type":        map[string]interface{}{"type": "string", "enum": stringValues(FieldTypes)},
+					"precision":   map[string]interface{}{"type": "integer", "minimum": 1},
+					"scale":       map[string]interface{}{"type": "integer", "minimum": 0},
+					"enumValues":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+					"primary":     map[string]interface{}{"type": "boolean"},
+					"nullable":    map[string]interface{}{"type": "boolean"},
+					"description": map[string]interface{}{"type": []interface{}{"string", "null"}},
+				},
+			},
+		},
+	},
+}
+
+// Document checks a dataset schema document against documentSchema,
+// then rejects duplicate field names, enum fields without values and a
+// decimal scale larger than its precision. Errors name the offending path,
+// e.g. schema.fields[2].type. An empty document declares no fields.
+func Document(raw json.RawMessage) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return fmt.Errorf("invalid schema document: %w", err)
+	}
+	if err := Check(documentSchema, doc, "schema"); err != nil {
+		return err
+	}
+
+	s, err := schema.Parse(raw)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]int, len(s.Fields))
+	for i, f := range s.Fields {
+		path := fmt.Sprintf("schema.fields[%d]", i)
+		if j, ok := seen[f.Name]; ok {
+			return fmt.Errorf("%s.name: duplicates the name %q of schema.fields[%d]", path, f.Name, j)
+		}
+		seen[f.Name] = i
+
+		if f.Type == model.FieldTypeEnum && len(f.EnumValues) == 0 {
+			return fmt.Errorf("%s.enumValues: required for enum fields", path)
+		}
+		if f.Precision != nil && f.Scale != nil && *f.Scale > *f.Precision {
+			return fmt.Errorf("%s.scale: must not exceed precision %d", path, *f.Precision)
+		}
+	}
+	return nil
+}
+
+// stringValues converts strings to the []interface{} of a decoded JSON array
+func stringValues(values []string) []interface{} {
+	out := make([]interface{}, len(values))
+	for i, v := range values {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,51 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr string // substring of the error, "" when valid
+	}{
+		{"valid", `{"fields": [
+			{"name": "code", "type": "string", "nullable": false, "primary": true},
+			{"name": "price", "type": "decimal", "precision": 18, "scale": 4, "nullable": true},
+			{"name": "board", "type": "enum", "enumValues": ["main", "star"]},
+			{"name": "listed", "type": "bool", "sample": "true"}
+		]}`, ""},
+		{"empty", ``, ""},
+		{"no fields", `{"fields": []}`, ""},
+		{"duplicate field", `{"fields": [{"name": "code", "type": "string"}, {"name": "code", "type": "int"}]}`,
+			`schema.fields[1].name: duplicates the name "code" of schema.fields[0]`},
+		{"unknown type", `{"fields": [{"name": "code", "type": "string"}, {"name": "price", "type": "money"}]}`,
+			"schema.fields[1].type: must be one of"},
+		{"missing type", `{"fields": [{"name": "code"}]}`, "schema.fields[0].type: is required"},
+		{"empty name", `{"fields": [{"name": "", "type": "string"}]}`, "schema.fields[0].name: must not be empty"},
+		{"nullable not boolean", `{"fields": [{"name": "code", "type": "string", "nullable": "yes"}]}`,
+			"schema.fields[0].nullable: must be of type boolean"},
+		{"fields not an array", `{"fields": {"code": "string"}}`, "schema.fields: must be of type array"},
+		{"no fields key", `{}`, "schema.fields: is required"},
+		{"enum without values", `{"fields": [{"name": "board", "type": "enum"}]}`, "schema.fields[0].enumValues"},
+		{"scale over precision", `{"fields": [{"name": "price", "type": "decimal", "precision": 4, "scale": 6}]}`,
+			"schema.fields[0].scale"},
+		{"not JSON", `{"fields": [`, "invalid schema document"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Document([]byte(tt.doc))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Document: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Document = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
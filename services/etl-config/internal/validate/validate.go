@@ -0,0 +1,132 @@
+// Package validate checks decoded JSON values against JSON Schema documents,
+// so request bodies with free-form JSON are rejected with the path of the
+// offending value rather than persisted.
+package validate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// dialect is the JSON Schema version of the schemas in this package
+const dialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Check checks a decoded JSON value against a JSON Schema node, supporting
+// the type, enum, minimum, minLength, required, properties and items
+// keywords. The error of the first violation names its path below path,
+// e.g. "schema.fields[2].type: is required".
+func Check(node map[string]interface{}, v interface{}, path string) error {
+	if typ, ok := node["type"]; ok && !matchesType(typ, v) {
+		return fmt.Errorf("%s: must be of type %s", path, typeNames(typ))
+	}
+	if options, ok := node["enum"].([]interface{}); ok && !containsValue(options, v) {
+		return fmt.Errorf("%s: must be one of: %s", path, typeNames(options))
+	}
+	if min, ok := node["minimum"].(int); ok {
+		if n, isNum := v.(float64); isNum && n < float64(min) {
+			return fmt.Errorf("%s: must be at least %d", path, min)
+		}
+	}
+	if min, ok := node["minLength"].(int); ok {
+		if s, isStr := v.(string); isStr && len(s) < min {
+			return fmt.Errorf("%s: must not be empty", path)
+		}
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		required, _ := node["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := v[key.(string)]; !ok {
+				return fmt.Errorf("%s.%s: is required", path, key)
+			}
+		}
+		properties, _ := node["properties"].(map[string]interface{})
+		keys := make([]string, 0, len(properties))
+		for key := range properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := v[key]
+			if !ok {
+				continue
+			}
+			if err := Check(properties[key].(map[string]interface{}), value, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		items, ok := node["items"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range v {
+			if err := Check(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// matchesType reports whether v is of a JSON Schema type, or one of a list
+// of types
+func matchesType(typ interface{}, v interface{}) bool {
+	if types, ok := typ.([]interface{}); ok {
+		for _, t := range types {
+			if matchesType(t, v) {
+				return true
+			}
+		}
+		return false
+	}
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+// typeNames formats a type or a list of values for an error message
+func typeNames(v interface{}) string {
+	list, ok := v.([]interface{})
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	names := make([]string, len(list))
+	for i, item := range list {
+		names[i] = fmt.Sprint(item)
+	}
+	return strings.Join(names, ", ")
+}
+
+// containsValue reports whether options holds v
+func containsValue(options []interface{}, v interface{}) bool {
+	for _, o := range options {
+		if o == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package validate
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	node := map[string]interface{}{
+		"type":     "object",
+		"required": []interface{}{"name"},
+		"properties": map[string]interface{}{
+			"name":  map[string]interface{}{"type": "string", "minLength": 1},
+			"size":  map[string]interface{}{"type": "integer", "minimum": 1},
+			"mode":  map[string]interface{}{"enum": []interface{}{"append", "replace"}},
+			"note":  map[string]interface{}{"type": []interface{}{"string", "null"}},
+			"parts": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "number"}},
+		},
+	}
+	tests := []struct {
+		value string
+		want  string // the error, "" when valid
+	}{
+		{`{"name": "bars", "size": 3, "mode": "append", "note": null, "parts": [1, 2.5]}`, ""},
+		{`{"name": "bars", "extra": true}`, ""},
+		{`[]`, "doc: must be of type object"},
+		{`{}`, "doc.name: is required"},
+		{`{"name": ""}`, "doc.name: must not be empty"},
+		{`{"name": "bars", "size": 1.5}`, "doc.size: must be of type integer"},
+		{`{"name": "bars", "size": 0}`, "doc.size: must be at least 1"},
+		{`{"name": "bars", "mode": "merge"}`, "doc.mode: must be one of: append, replace"},
+		{`{"name": "bars", "note": 1}`, "doc.note: must be of type string, null"},
+		{`{"name": "bars", "parts": [1, "2"]}`, "doc.parts[1]: must be of type number"},
+	}
+	for _, tt := range tests {
+		var v interface{}
+		if err := json.Unmarshal([]byte(tt.value), &v); err != nil {
+			t.Fatal(err)
+		}
+		err := Check(node, v, "doc")
+		if got := errString(err); got != tt.want {
+			t.Errorf("Check(%s) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
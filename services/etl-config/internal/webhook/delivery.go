@@ -0,0 +1,168 @@
+// Package webhook delivers execution status changes to registered outbound
+// webhooks.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/config"
+	"github.com/mellivora-tech/mellivora-mind-studio/services/etl-config/internal/repository"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, keyed by
+// the webhook secret and prefixed with "sha256="
+const SignatureHeader = "X-Webhook-Signature"
+
+const (
+	// claimBatch is the most deliveries claimed per poll
+	claimBatch = 50
+
+	// baseBackoff is the wait after the first failed attempt; it doubles
+	// with every further failure up to maxBackoff
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Worker sends due webhook deliveries and records their outcome
+type Worker struct {
+	repo   *repository.WebhookRepository
+	client *http.Client
+	cfg    config.WebhookConfig
+	logger *zap.Logger
+}
+
+// NewWorker creates a new Worker
+func NewWorker(cfg config.WebhookConfig, logger *zap.Logger) *Worker {
+	return &Worker{
+		repo:   repository.NewWebhookRepository(),
+		client: &http.Client{Timeout: cfg.Timeout},
+		cfg:    cfg,
+		logger: logger,
+	}
+}
+
+// Run delivers due deliveries every poll interval until ctx is done
+func (w *Worker) Run(ctx context.Context) {
+	poll := time.NewTicker(w.cfg.PollInterval)
+	defer poll.Stop()
+
+	for {
+		w.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+		}
+	}
+}
+
+// deliverDue claims and sends one batch of due deliveries. Claims are leased
+// for longer than a delivery can take, so a delivery still in flight is
+// never claimed twice.
+func (w *Worker) deliverDue(ctx context.Context) {
+	due, err := w.repo.ClaimDueDeliveries(ctx, claimBatch, 2*w.cfg.Timeout+time.Minute)
+	if err != nil {
+		if ctx.Err() == nil {
+			w.logger.Error("failed to claim webhook deliveries", zap.Error(err))
+		}
+		return
+	}
+
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		w.deliver(ctx, d)
+	}
+}
+
+// deliver sends one delivery and records the attempt
+func (w *Worker) deliver(ctx context.Context, d repository.DueDelivery) {
+	status, err := w.send(ctx, d)
+	if ctx.Err() != nil {
+		// Shutting down; the lease expires and the delivery is retried
+		return
+	}
+	if err == nil {
+		if err := w.repo.MarkDelivered(ctx, d.ID, status); err != nil {
+			w.logger.Error("failed to record webhook delivery", zap.Int64("delivery_id", d.ID), zap.Error(err))
+		}
+		return
+	}
+
+	var responseStatus *int
+	if status != 0 {
+		responseStatus = &status
+	}
+	attempts := d.Attempts + 1
+	dead := attempts >= w.cfg.MaxAttempts
+	if dead {
+		w.logger.Warn("webhook delivery is dead",
+			zap.Int64("delivery_id", d.ID),
+			zap.String("webhook_id", d.WebhookID),
+			zap.Int("attempts", attempts),
+			zap.Error(err),
+		)
+	}
+
+	if err := w.repo.MarkFailed(ctx, d.ID, responseStatus, err.Error(), time.Now().Add(backoff(attempts)), dead); err != nil {
+		w.logger.Error("failed to record webhook delivery", zap.Int64("delivery_id", d.ID), zap.Error(err))
+	}
+}
+
+// send posts the signed payload. It returns the response status, or 0 when
+// no response was received, and an error unless the status is 2xx.
+func (w *Worker) send(ctx context.Context, d repository.DueDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", "execution."+d.Event)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set(SignatureHeader, Sign(d.Secret, d.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value of body for secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns how long to wait before retrying after the given number of
+// failed attempts
+func backoff(attempts int) time.Duration {
+	d := baseBackoff
+	for i := 1; i < attempts && d < maxBackoff; i++ {
+		d *= 2
+	}
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d
+}
@@ -1,4 +1,4 @@
-module github.com/mellivora-mind/mellivora-mind-studio/services/schedule
+module github.com/mellivora-tech/mellivora-mind-studio/services/schedule
 
 go 1.22
 